@@ -0,0 +1,132 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package id3
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	v1 "github.com/lion187chen/id3-go/v1"
+	v2 "github.com/lion187chen/id3-go/v2"
+)
+
+// Whether a tag holds nothing but SEEK frames
+func onlySeek(tag *v2.Tag) bool {
+	for _, f := range tag.AllFrames() {
+		if f.Id() != "SEEK" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Parses the ID3v2.4 tag appended to the file, which comes before any ID3v1
+// tag, nil when there is none
+func parseAppendedTag(file storage, opts v2.ParseOptions) (*v2.Tag, error) {
+	size, err := file.Size()
+	if err != nil {
+		return nil, err
+	}
+
+	end := size
+	if data := readAt(file, end-v1.TagSize, 3); data != nil && string(data) == "TAG" {
+		end -= v1.TagSize
+	}
+
+	tag, _, err := v2.ParseAppendedTag(file, end, opts)
+	return tag, err
+}
+
+// Saves the tag appended to the file, after the audio and before any ID3v1
+// tag, so that saving never moves the audio
+// Tags at the start of the file are replaced by one of the same size that
+// only holds a SEEK frame pointing to the appended tag. Once saved this way,
+// Save and Close keep appending, and reopening the file reads the appended
+// tag. Only ID3v2.4 tags can be appended, others give ErrUnsupportedVersion.
+func (f *File) SaveAppended() error {
+	if f.readOnly {
+		return ErrReadOnly
+	}
+
+	tag, ok := f.Tagger.(*v2.Tag)
+	if !ok || tag.Version()[2] != '4' {
+		return fmt.Errorf("SaveAppended: %w", ErrUnsupportedVersion)
+	}
+
+	// The whole tag is built first, so that a frame failing to encode leaves
+	// the file as it was
+	var buf bytes.Buffer
+	if _, err := tag.WriteAppendedTo(&buf); err != nil {
+		return err
+	}
+
+	size, err := f.file.Size()
+	if err != nil {
+		return err
+	}
+
+	leading, err := leadingTagsSize(f.file)
+	if err != nil {
+		return err
+	}
+
+	// The tag replaces an appended tag, or goes before an ID3v1 tag
+	at, rest := size, size
+	for _, region := range findTrailingTags(f.file, size) {
+		if region.Offset < leading {
+			continue
+		}
+		if region.Kind == "ID3v2" {
+			at, rest = region.Offset, region.Offset+region.Size
+			break
+		}
+		if region.Kind == "ID3v1" || region.Kind == "ID3v1+" {
+			at, rest = region.Offset, region.Offset
+			break
+		}
+	}
+
+	tail := make([]byte, size-rest)
+	if _, err := f.file.ReadAt(tail, rest); err != nil && err != io.EOF {
+		return err
+	}
+
+	if _, err := f.file.WriteAt(append(buf.Bytes(), tail...), at); err != nil {
+		return err
+	}
+	if err := f.file.Truncate(at + int64(buf.Len()+len(tail))); err != nil {
+		return err
+	}
+
+	if leading > 0 {
+		if _, err := f.file.WriteAt(seekTag(leading, at-leading), 0); err != nil {
+			return err
+		}
+	}
+
+	f.appended = true
+	f.chained = nil
+
+	if f.verify {
+		return f.verifyWrite()
+	}
+
+	return nil
+}
+
+// ID3v2.4 tag taking size bytes whose SEEK frame points offset bytes past it
+// The frame is left out when it does not fit, leaving only padding.
+func seekTag(size, offset int64) []byte {
+	tag := v2.NewTag(4)
+
+	body := size - v2.HeaderSize
+	if body >= v2.FrameHeaderSize+4 && offset <= 1<<32-1 {
+		tag.AddFrames(v2.NewSeekFrame(uint32(offset)))
+	}
+	tag.SetPadding(uint(body) - uint(tag.Size()))
+
+	return tag.Bytes()
+}
@@ -0,0 +1,176 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Options shared by all commands that process files
+type batchOptions struct {
+	recursive bool
+	dryRun    bool
+	jobs      int
+	// Print a header before the output of each file
+	headers bool
+}
+
+func addBatchFlags(fs *flag.FlagSet) *batchOptions {
+	opts := new(batchOptions)
+	fs.BoolVar(&opts.recursive, "r", false, "process the MP3 files in directories recursively")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "report the files that would change without writing them")
+	fs.IntVar(&opts.jobs, "jobs", runtime.NumCPU(), "process `N` files in parallel")
+
+	return opts
+}
+
+// Operation on a single file, writing any output to w
+// Reports whether the file was (or with a dry run would be) changed.
+type fileOp func(name string, w io.Writer, dryRun bool) (changed bool, err error)
+
+type result struct {
+	output  bytes.Buffer
+	changed bool
+	err     error
+	done    chan struct{}
+}
+
+// Applies op to every file, printing output in argument order
+// Errors do not stop the batch; they are reported on stderr and counted in
+// the summary printed for multiple files.
+func runBatch(args []string, opts *batchOptions, op fileOp, stdout, stderr io.Writer) error {
+	files, err := expandFiles(args, opts.recursive)
+	if err != nil {
+		return err
+	}
+
+	jobs := opts.jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	results := make([]*result, len(files))
+	for i := range results {
+		results[i] = &result{done: make(chan struct{})}
+	}
+
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for j := 0; j < jobs; j++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				r := results[i]
+				r.changed, r.err = op(files[i], &r.output, opts.dryRun)
+				close(r.done)
+			}
+		}()
+	}
+
+	go func() {
+		for i := range files {
+			queue <- i
+		}
+		close(queue)
+	}()
+
+	var changed, failed int
+	for i, r := range results {
+		<-r.done
+
+		if opts.headers && len(files) > 1 {
+			if i > 0 {
+				fmt.Fprintln(stdout)
+			}
+			fmt.Fprintf(stdout, "==> %s <==\n", files[i])
+		}
+		stdout.Write(r.output.Bytes())
+
+		if r.err != nil {
+			failed++
+			fmt.Fprintf(stderr, "id3go: %s: %v\n", files[i], r.err)
+		} else if r.changed {
+			changed++
+			if opts.dryRun {
+				fmt.Fprintf(stdout, "would change %s\n", files[i])
+			}
+		}
+	}
+	wg.Wait()
+
+	if len(files) > 1 || opts.recursive {
+		verb := "changed"
+		if opts.dryRun {
+			verb = "would change"
+		}
+		fmt.Fprintf(stderr, "%d files, %d %s, %d errors\n", len(files), changed, verb, failed)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed", failed, len(files))
+	}
+
+	return nil
+}
+
+// Files named by the arguments
+// Glob patterns are expanded, and directories are walked for MP3 files when
+// recursive is set.
+func expandFiles(args []string, recursive bool) ([]string, error) {
+	var files []string
+
+	for _, arg := range args {
+		matches := []string{arg}
+		if strings.ContainsAny(arg, "*?[") {
+			var err error
+			if matches, err = filepath.Glob(arg); err != nil {
+				return nil, err
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("%s: no matching files", arg)
+			}
+		}
+
+		for _, name := range matches {
+			fi, err := os.Stat(name)
+			if err != nil {
+				return nil, err
+			}
+
+			if !fi.IsDir() {
+				files = append(files, name)
+				continue
+			}
+
+			if !recursive {
+				return nil, fmt.Errorf("%s: is a directory (use -r)", name)
+			}
+
+			err = filepath.WalkDir(name, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if !d.IsDir() && strings.EqualFold(filepath.Ext(path), ".mp3") {
+					files = append(files, path)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return files, nil
+}
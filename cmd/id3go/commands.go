@@ -0,0 +1,328 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	id3 "github.com/lion187chen/id3-go"
+	v1 "github.com/lion187chen/id3-go/v1"
+	v2 "github.com/lion187chen/id3-go/v2"
+)
+
+func runGet(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("get", "[-frame ID] file...", stderr)
+	frameId := fs.String("frame", "", "print the frames with this `ID` instead of the common fields")
+	opts := addBatchFlags(fs)
+	opts.headers = true
+
+	files, err := parseFiles(fs, args)
+	if err != nil {
+		return err
+	}
+
+	return runBatch(files, opts, func(name string, w io.Writer, dryRun bool) (bool, error) {
+		return false, get(name, *frameId, w)
+	}, stdout, stderr)
+}
+
+func get(name, frameId string, w io.Writer) error {
+	file, closeFile, err := openFile(name, false)
+	if err != nil {
+		return err
+	}
+	defer closeFile()
+
+	printTag(file, frameId, w)
+
+	return nil
+}
+
+// Prints the common fields of a tag, or the frames with an ID
+func printTag(file *id3.File, frameId string, w io.Writer) {
+	if frameId != "" {
+		for _, f := range file.Frames(frameId) {
+			fmt.Fprintln(w, f)
+		}
+		return
+	}
+
+	fields := []struct {
+		name  string
+		value string
+	}{
+		{"Title", file.Title()},
+		{"Artist", file.Artist()},
+		{"Album", file.Album()},
+		{"Year", file.Year()},
+		{"Genre", file.Genre()},
+	}
+	for _, c := range file.Comments() {
+		fields = append(fields, struct {
+			name  string
+			value string
+		}{"Comment", c})
+	}
+
+	for _, field := range fields {
+		if value := strings.TrimRight(field.value, "\x00 "); value != "" {
+			fmt.Fprintf(w, "%s: %s\n", field.name, value)
+		}
+	}
+}
+
+// Changes requested on the set command line, nil when not given
+type edits struct {
+	title, artist, album, year, genre, comment *string
+	picture                                    string
+}
+
+func runSet(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("set", "[flags] file...", stderr)
+	title := fs.String("title", "", "set the title")
+	artist := fs.String("artist", "", "set the artist")
+	album := fs.String("album", "", "set the album")
+	year := fs.String("year", "", "set the year")
+	genre := fs.String("genre", "", "set the genre")
+	comment := fs.String("comment", "", "set the comment")
+	picture := fs.String("picture", "", "attach the image `file` as the front cover")
+	opts := addBatchFlags(fs)
+
+	files, err := parseFiles(fs, args)
+	if err != nil {
+		return err
+	}
+
+	e := edits{picture: *picture}
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "title":
+			e.title = title
+		case "artist":
+			e.artist = artist
+		case "album":
+			e.album = album
+		case "year":
+			e.year = year
+		case "genre":
+			e.genre = genre
+		case "comment":
+			e.comment = comment
+		}
+	})
+
+	var pictureData []byte
+	if e.picture != "" {
+		if pictureData, err = os.ReadFile(e.picture); err != nil {
+			return err
+		}
+	}
+
+	return runBatch(files, opts, func(name string, w io.Writer, dryRun bool) (bool, error) {
+		return set(name, e, pictureData, dryRun)
+	}, stdout, stderr)
+}
+
+func set(name string, e edits, pictureData []byte, dryRun bool) (changed bool, err error) {
+	file, closeFile, err := openFile(name, !dryRun)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		changed = file.Dirty()
+		if closeErr := closeFile(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	setters := []struct {
+		value *string
+		set   func(string)
+	}{
+		{e.title, file.SetTitle},
+		{e.artist, file.SetArtist},
+		{e.album, file.SetAlbum},
+		{e.year, file.SetYear},
+		{e.genre, file.SetGenre},
+	}
+	for _, s := range setters {
+		if s.value != nil {
+			s.set(*s.value)
+		}
+	}
+
+	if e.comment == nil && pictureData == nil {
+		return false, nil
+	}
+
+	tag, ok := file.Tagger.(*v2.Tag)
+	if !ok {
+		return false, errors.New("comments and pictures need an ID3v2 tag")
+	}
+
+	if e.comment != nil {
+		if err := setComment(tag, *e.comment); err != nil {
+			return false, err
+		}
+	}
+
+	if pictureData != nil {
+		if err := setFrontCover(tag, pictureData); err != nil {
+			return false, err
+		}
+	}
+
+	return false, nil
+}
+
+// Replaces the comment without a description
+func setComment(tag *v2.Tag, text string) error {
+	ft, _ := frameType(tag, "COMM")
+	for _, f := range tag.Frames(ft.Id()) {
+		if uf, ok := f.(*v2.UnsynchTextFrame); ok && strings.TrimRight(uf.Description(), "\x00") == "" {
+			tag.DeleteFrame(f)
+		}
+	}
+
+	if text == "" {
+		return nil
+	}
+
+	f := v2.NewUnsynchTextFrame(ft, "", text)
+	if err := f.SetEncoding(tag.EncodingFor(text)); err != nil {
+		return err
+	}
+	if err := f.SetLanguage(tag.CommentLanguage()); err != nil {
+		return err
+	}
+	tag.AddFrames(f)
+
+	return nil
+}
+
+// Replaces the front cover
+func setFrontCover(tag *v2.Tag, data []byte) error {
+	if tagVersion(tag) == 2 {
+		return errors.New("pictures cannot be written to ID3v2.2 tags")
+	}
+
+	if err := tag.SetFrontCover(data, ""); err != nil {
+		return fmt.Errorf("picture is %s: %w", http.DetectContentType(data), err)
+	}
+
+	return nil
+}
+
+func runDeleteFrame(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("delete-frame", "ID file...", stderr)
+	opts := addBatchFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return errUsage
+	}
+
+	id := fs.Arg(0)
+	return runBatch(fs.Args()[1:], opts, func(name string, w io.Writer, dryRun bool) (bool, error) {
+		return deleteFrame(name, id, dryRun)
+	}, stdout, stderr)
+}
+
+func deleteFrame(name, id string, dryRun bool) (bool, error) {
+	file, closeFile, err := openFile(name, !dryRun)
+	if err != nil {
+		return false, err
+	}
+
+	changed := len(file.DeleteFrames(id)) > 0
+
+	return changed, closeFile()
+}
+
+func runStrip(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("strip", "file...", stderr)
+	opts := addBatchFlags(fs)
+
+	files, err := parseFiles(fs, args)
+	if err != nil {
+		return err
+	}
+
+	return runBatch(files, opts, strip, stdout, stderr)
+}
+
+func strip(name string, w io.Writer, dryRun bool) (bool, error) {
+	osFile, err := os.Open(name)
+	if err != nil {
+		return false, err
+	}
+
+	tagged := v2.ParseHeader(osFile) != nil || v1.ParseTag(osFile) != nil
+	osFile.Close()
+
+	if !tagged || dryRun {
+		return tagged, nil
+	}
+
+	return true, id3.Strip(name)
+}
+
+// Opens a file for reading, or for editing when write is set
+// Edits are saved by the returned close function only when writing.
+func openFile(name string, write bool) (*id3.File, func() error, error) {
+	if write {
+		file, err := id3.Open(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		return file, file.Close, nil
+	}
+
+	osFile, err := os.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := id3.Parse(osFile)
+	if err != nil {
+		osFile.Close()
+		return nil, nil, err
+	}
+
+	return file, osFile.Close, nil
+}
+
+// Major version of a tag
+func tagVersion(tag *v2.Tag) byte {
+	return tag.Version()[2] - '0'
+}
+
+// Frame type for an ID3v2.3 frame ID in the version of the tag
+func frameType(tag *v2.Tag, id string) (v2.FrameType, bool) {
+	switch tagVersion(tag) {
+	case 2:
+		for v22Id, v23Id := range v2.V23DeprecatedTypeMap {
+			if v23Id == id {
+				ft, ok := v2.V22FrameTypeMap[v22Id]
+				return ft, ok
+			}
+		}
+		return v2.FrameType{}, false
+	case 4:
+		ft, ok := v2.V24FrameTypeMap[id]
+		return ft, ok
+	}
+
+	ft, ok := v2.V23FrameTypeMap[id]
+	return ft, ok
+}
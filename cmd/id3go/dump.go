@@ -0,0 +1,104 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	id3 "github.com/lion187chen/id3-go"
+	v2 "github.com/lion187chen/id3-go/v2"
+)
+
+const (
+	// Bytes of binary frames shown in the table
+	hexDumpSize = 64
+	// Characters of text frames shown in the table
+	textSize = 100
+)
+
+func runDump(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("dump", "[-json] [-full] file...", stderr)
+	asJSON := fs.Bool("json", false, "print the tags as JSON")
+	full := fs.Bool("full", false, "print long text and binary payloads in full")
+	opts := addBatchFlags(fs)
+
+	files, err := parseFiles(fs, args)
+	if err != nil {
+		return err
+	}
+	opts.headers = !*asJSON
+
+	return runBatch(files, opts, func(name string, w io.Writer, dryRun bool) (bool, error) {
+		return false, dump(name, w, *asJSON, *full)
+	}, stdout, stderr)
+}
+
+func dump(name string, w io.Writer, asJSON, full bool) error {
+	file, closeFile, err := openFile(name, false)
+	if err != nil {
+		return err
+	}
+	defer closeFile()
+
+	if asJSON {
+		return id3.DumpJSON(w, file, v2.JSONOptions{OmitBinary: !full, Indent: true})
+	}
+
+	tag, ok := file.Tagger.(*v2.Tag)
+	if !ok {
+		fmt.Fprintf(w, "ID3v%s\n", file.Version())
+		printTag(file, "", w)
+		return nil
+	}
+
+	frames := tag.AllFrames()
+	fmt.Fprintf(w, "ID3v%s, %d bytes, %d bytes padding, %d frames\n", tag.Version(), tag.Size(), tag.Padding(), len(frames))
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSIZE\tFLAGS\tCONTENT")
+	for _, f := range frames {
+		lines := frameContent(f, full)
+		fmt.Fprintf(tw, "%s\t%d\t%02x %02x\t%s\n", f.Id(), f.Size(), f.StatusFlags(), f.FormatFlags(), lines[0])
+		for _, line := range lines[1:] {
+			fmt.Fprintf(tw, "\t\t\t%s\n", line)
+		}
+	}
+
+	return tw.Flush()
+}
+
+// Lines describing the content of a frame, with a hexdump for binary data
+func frameContent(f v2.Framer, full bool) []string {
+	var data []byte
+	switch f := f.(type) {
+	case *v2.ImageFrame:
+		return []string{f.String()}
+	case *v2.DataFrame:
+		data = f.Data()
+	case v2.TextFramer, *v2.ChapterFrame, *v2.TOCFrame, *v2.IdFrame:
+		text := []rune(strings.ReplaceAll(f.String(), "\n", " "))
+		if !full && len(text) > textSize {
+			text = append(text[:textSize], '…')
+		}
+		return []string{string(text)}
+	default:
+		data = f.Bytes()
+	}
+
+	lines := []string{fmt.Sprintf("<binary data, %d bytes>", len(data))}
+	if !full && len(data) > hexDumpSize {
+		data = data[:hexDumpSize]
+	}
+
+	dump := strings.TrimRight(hex.Dump(data), "\n")
+	if dump != "" {
+		lines = append(lines, strings.Split(dump, "\n")...)
+	}
+
+	return lines
+}
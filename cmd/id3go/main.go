@@ -0,0 +1,101 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Command id3go reads and edits the ID3 tags of MP3 files
+//
+// Usage:
+//
+//	id3go get [-frame ID] file...
+//	id3go set [-title T] [-artist A] [-album A] [-year Y] [-genre G] [-comment C] [-picture cover.jpg] file...
+//	id3go delete-frame ID file...
+//	id3go strip file...
+//	id3go dump [-json] [-full] file...
+//
+// Every command also accepts -r to process the MP3 files in directories,
+// -dry-run to report changes without writing them and -jobs N to process
+// files in parallel.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `usage: id3go <command> [flags] file...
+
+commands:
+  get           print the common fields or the frames with -frame ID
+  set           change fields and attach a front cover picture
+  delete-frame  remove all frames with an ID
+  strip         remove all ID3 tags
+  dump          print every frame as a table or as JSON
+
+all commands accept:
+  -r            process the MP3 files in directories recursively
+  -dry-run      report the files that would change without writing them
+  -jobs N       process N files in parallel
+`
+
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if err != errUsage {
+			fmt.Fprintf(os.Stderr, "id3go: %v\n", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	if len(args) < 1 {
+		fmt.Fprint(stderr, usage)
+		return errUsage
+	}
+
+	commands := map[string]func([]string, io.Writer, io.Writer) error{
+		"get":          runGet,
+		"set":          runSet,
+		"delete-frame": runDeleteFrame,
+		"strip":        runStrip,
+		"dump":         runDump,
+	}
+
+	command, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "id3go: unknown command %q\n\n%s", args[0], usage)
+		return errUsage
+	}
+
+	return command(args[1:], stdout, stderr)
+}
+
+// Flag set for a subcommand that reports errors instead of exiting
+func newFlagSet(name, args string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: id3go %s %s\n", name, args)
+		fs.PrintDefaults()
+	}
+
+	return fs
+}
+
+// Parses subcommand flags, requiring at least one file
+func parseFiles(fs *flag.FlagSet, args []string) ([]string, error) {
+	if err := fs.Parse(args); err != nil {
+		return nil, errUsage
+	}
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return nil, errUsage
+	}
+
+	return fs.Args(), nil
+}
@@ -0,0 +1,159 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Copies the test file to a temporary directory
+func tempMp3(t *testing.T) string {
+	data, err := os.ReadFile("../../test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := filepath.Join(t.TempDir(), "test.mp3")
+	if err := os.WriteFile(name, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	return name
+}
+
+func runCommand(t *testing.T, args ...string) string {
+	var stdout, stderr bytes.Buffer
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("id3go %s: %v\n%s", strings.Join(args, " "), err, stderr.String())
+	}
+
+	return stdout.String()
+}
+
+func TestGetSet(t *testing.T) {
+	name := tempMp3(t)
+
+	if out := runCommand(t, "get", name); !strings.Contains(out, "Artist: Paloalto\n") {
+		t.Errorf("get printed %q", out)
+	}
+
+	cover := filepath.Join(t.TempDir(), "cover.png")
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	if err := os.WriteFile(cover, png, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	runCommand(t, "set", "-title", "Chief Life", "-comment", "Über", "-picture", cover, name)
+
+	out := runCommand(t, "get", name)
+	if !strings.Contains(out, "Title: Chief Life\n") || !strings.Contains(out, "Comment: [eng] Über") {
+		t.Errorf("get after set printed %q", out)
+	}
+
+	if out := runCommand(t, "get", "-frame", "APIC", name); !strings.HasPrefix(out, "image/png, picture type 3, 16 bytes") {
+		t.Errorf("get -frame APIC printed %q", out)
+	}
+
+	runCommand(t, "delete-frame", "APIC", name)
+	if out := runCommand(t, "get", "-frame", "APIC", name); out != "" {
+		t.Errorf("get -frame APIC after delete printed %q", out)
+	}
+}
+
+func TestStrip(t *testing.T) {
+	name := tempMp3(t)
+	runCommand(t, "strip", name)
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.HasPrefix(data, []byte("ID3")) {
+		t.Errorf("strip left the ID3v2 tag")
+	}
+}
+
+func TestUsage(t *testing.T) {
+	for _, args := range [][]string{nil, {"bogus"}, {"get"}, {"delete-frame", "TIT2"}} {
+		var stdout, stderr bytes.Buffer
+		if err := run(args, &stdout, &stderr); err != errUsage || stderr.Len() == 0 {
+			t.Errorf("id3go %v returned %v with usage %q", args, err, stderr.String())
+		}
+	}
+}
+
+func TestDump(t *testing.T) {
+	name := tempMp3(t)
+
+	out := runCommand(t, "dump", name)
+	if !strings.HasPrefix(out, "ID3v2.3.0, 81909 bytes") || !strings.Contains(out, "TPE1  10    00 00  Paloalto\n") {
+		t.Errorf("dump printed %q", out)
+	}
+
+	out = runCommand(t, "dump", "-json", name)
+	if !strings.Contains(out, `"text": "Paloalto"`) {
+		t.Errorf("dump -json printed %q", out)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	dir := t.TempDir()
+	data, err := os.ReadFile("../../test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := []string{
+		filepath.Join(dir, "a.mp3"),
+		filepath.Join(dir, "sub", "b.MP3"),
+		filepath.Join(dir, "sub", "notes.txt"),
+	}
+	for _, name := range names {
+		os.MkdirAll(filepath.Dir(name), 0777)
+		if err := os.WriteFile(name, data, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"set", "-title", "Chief Life", dir}, &stdout, &stderr); err == nil {
+		t.Errorf("set on a directory without -r succeeded")
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if err := run([]string{"set", "-r", "-dry-run", "-jobs", "2", "-title", "Chief Life", dir}, &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := strings.Count(stdout.String(), "would change"); n != 2 {
+		t.Errorf("dry run reported %d changes:\n%s", n, stdout.String())
+	}
+
+	if !strings.Contains(stderr.String(), "2 files, 2 would change, 0 errors") {
+		t.Errorf("dry run summary %q", stderr.String())
+	}
+
+	if out := runCommand(t, "get", names[0]); !strings.Contains(out, "Title: Nice Life") {
+		t.Errorf("dry run changed the file: %q", out)
+	}
+
+	stderr.Reset()
+	if err := run([]string{"strip", "-r", dir}, &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(stderr.String(), "2 files, 2 changed, 0 errors") {
+		t.Errorf("strip summary %q", stderr.String())
+	}
+
+	if out, _ := os.ReadFile(names[2]); !bytes.Equal(out, data) {
+		t.Errorf("strip changed a file that is not an MP3")
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package id3
+
+import (
+	"errors"
+	"fmt"
+
+	v2 "github.com/lion187chen/id3-go/v2"
+)
+
+var errEditDone = errors.New("edit: session already committed or rolled back")
+
+// Edit is a session of changes staged on a copy of the tag
+// The file's own tag is left untouched until Commit.
+type Edit struct {
+	*v2.Tag
+	file *File
+	done bool
+}
+
+// Starts an edit session on a copy of the ID3v2 tag
+// Changes made to the file's tag after Begin are replaced on Commit.
+func (f *File) Begin() (*Edit, error) {
+	tag, ok := f.Tagger.(*v2.Tag)
+	if !ok {
+		return nil, fmt.Errorf("Begin: %w", ErrNoTag)
+	}
+
+	return &Edit{Tag: tag.Clone(), file: f}, nil
+}
+
+// Replaces the file's tag with the staged copy and saves it
+// When the save fails the file keeps its previous tag and the session stays
+// open, so that Commit can be retried or the changes rolled back.
+func (e *Edit) Commit() error {
+	if e.done {
+		return errEditDone
+	}
+
+	previous := e.file.Tagger
+	e.file.Tagger = e.Tag
+	if err := e.file.Save(); err != nil {
+		e.file.Tagger = previous
+		return err
+	}
+
+	e.done = true
+	return nil
+}
+
+// Discards the staged changes
+func (e *Edit) Rollback() error {
+	if e.done {
+		return errEditDone
+	}
+	e.done = true
+
+	return nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package encodedbytes
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+const latin1Index = 0
+
+// What happens to characters that ISO-8859-1 cannot represent
+type Latin1Policy int
+
+const (
+	// Encoding fails, the default
+	Latin1Error Latin1Policy = iota
+	// Each character is replaced with '?'
+	Latin1Replace
+	// Characters are spelled with similar ones, such as ő as o and œ as oe,
+	// and replaced with '?' when there is none
+	Latin1Transliterate
+)
+
+// Sets what happens to characters that ISO-8859-1 cannot represent
+// The setting applies to every tag, so change it before writing any.
+func SetLatin1Policy(policy Latin1Policy) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+
+	latin1Policy = policy
+}
+
+// Policy for characters that ISO-8859-1 cannot represent
+func CurrentLatin1Policy() Latin1Policy {
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+
+	return latin1Policy
+}
+
+// ISO-8859-1 encoder following the policy
+func latin1Encoder(policy Latin1Policy) *encoding.Encoder {
+	switch policy {
+	case Latin1Replace:
+		return &encoding.Encoder{Transformer: latin1Fallback{transliterate: false}}
+	case Latin1Transliterate:
+		return &encoding.Encoder{Transformer: latin1Fallback{transliterate: true}}
+	}
+
+	return charmap.ISO8859_1.NewEncoder()
+}
+
+// Spellings that decomposition does not give
+var transliterations = map[rune]string{
+	'Œ': "OE", 'œ': "oe", 'Ł': "L", 'ł': "l", 'Đ': "D", 'đ': "d",
+	'ı': "i", 'ẞ': "SS", '‘': "'", '’': "'", '‚': ",", '“': "\"",
+	'”': "\"", '„': "\"", '–': "-", '—': "-", '…': "...", '€': "EUR",
+	'™': "TM", '•': "*",
+}
+
+// Spells a character outside ISO-8859-1 with ones inside it
+func transliterate(r rune) string {
+	if s, ok := transliterations[r]; ok {
+		return s
+	}
+
+	// Compatibility decomposition without the combining marks
+	var s []rune
+	for _, d := range norm.NFKD.String(string(r)) {
+		switch {
+		case unicode.Is(unicode.Mn, d):
+		case d > 0xFF:
+			return "?"
+		default:
+			s = append(s, d)
+		}
+	}
+
+	if len(s) == 0 {
+		return "?"
+	}
+	return string(s)
+}
+
+// ISO-8859-1 encoder that does not fail on other characters
+type latin1Fallback struct {
+	transform.NopResetter
+	transliterate bool
+}
+
+func (f latin1Fallback) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for nSrc < len(src) {
+		r, size := utf8.DecodeRune(src[nSrc:])
+		if r == utf8.RuneError && !atEOF && !utf8.FullRune(src[nSrc:]) {
+			return nDst, nSrc, transform.ErrShortSrc
+		}
+
+		s := "?"
+		switch {
+		case r <= 0xFF:
+			s = string(r)
+		case f.transliterate:
+			s = transliterate(r)
+		}
+
+		// Every rune of s is in ISO-8859-1
+		if nDst+utf8.RuneCountInString(s) > len(dst) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+		for _, c := range s {
+			dst[nDst] = byte(c)
+			nDst++
+		}
+		nSrc += size
+	}
+
+	return nDst, nSrc, nil
+}
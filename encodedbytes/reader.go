@@ -50,6 +50,20 @@ func (r *Reader) ReadNumBytes(n int) ([]byte, error) {
 	return b, err
 }
 
+// Like ReadNumBytes but returns a view of the data instead of a copy
+func (r *Reader) view(n int) ([]byte, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	if r.index+n > len(r.data) {
+		return nil, io.EOF
+	}
+
+	b := r.data[r.index : r.index+n]
+	r.index += n
+	return b, nil
+}
+
 // Read a number of bytes and cast to a string
 func (r *Reader) ReadNumBytesString(n int) (string, error) {
 	b, err := r.ReadNumBytes(n)
@@ -61,27 +75,40 @@ func (r *Reader) ReadRest() ([]byte, error) {
 	return r.ReadNumBytes(len(r.data) - r.index)
 }
 
+// Read until the end of the data, returning a view of it instead of a copy
+func (r *Reader) ReadRestView() ([]byte, error) {
+	b, err := r.view(len(r.data) - r.index)
+	if b == nil {
+		b = []byte{}
+	}
+	return b, err
+}
+
 // Read until the end of the data and cast to a string
 func (r *Reader) ReadRestString(encoding byte) (string, error) {
-	b, err := r.ReadRest()
+	b, err := r.view(len(r.data) - r.index)
 	if err != nil {
 		return "", err
 	}
 
 	if int(encoding) >= len(Decoders) || Decoders[encoding] == nil {
-		return "", fmt.Errorf("invalid encoding: %d", encoding)
+		return "", fmt.Errorf("%w: %d", ErrBadEncoding, encoding)
 	}
-	return Decoders[encoding].String(string(b))
+	return decode(b, encoding)
 }
 
 // Read a null terminated string of specified encoding
 func (r *Reader) ReadNullTermString(encoding byte) (string, error) {
+	if int(encoding) >= len(Decoders) || Decoders[encoding] == nil {
+		return "", fmt.Errorf("%w: %d", ErrBadEncoding, encoding)
+	}
+
 	atIndex, afterIndex := nullIndex(r.data[r.index:], encoding)
 	if atIndex == -1 {
 		return r.ReadRestString(encoding)
 	}
 
-	b, err := r.ReadNumBytes(afterIndex)
+	b, err := r.view(afterIndex)
 	if err != nil {
 		return "", err
 	}
@@ -89,10 +116,7 @@ func (r *Reader) ReadNullTermString(encoding byte) (string, error) {
 	if atIndex == -1 {
 		return "", errors.New("could not read null terminated string")
 	}
-	if int(encoding) >= len(Decoders) || Decoders[encoding] == nil {
-		return "", fmt.Errorf("invalid encoding: %d", encoding)
-	}
-	return Decoders[encoding].String(string(b[:atIndex]))
+	return decode(b[:atIndex], encoding)
 }
 
 func NewReader(b []byte) *Reader { return &Reader{b, 0} }
@@ -0,0 +1,154 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package encodedbytes
+
+import (
+	"encoding/binary"
+	"strings"
+	stdunicode "unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
+)
+
+const (
+	utf16Index   = 1
+	utf16BEIndex = 2
+)
+
+// Sets the byte order of text written in the UTF-16 encoding
+// Text is written big-endian by default; either way a BOM is written. The
+// setting applies to every tag, so change it before writing any.
+func SetUTF16LittleEndian(littleEndian bool) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+
+	utf16LittleEndian = littleEndian
+}
+
+// UTF-16 encoder in the byte order set with SetUTF16LittleEndian
+func utf16Encoder(littleEndian bool) *encoding.Encoder {
+	endianness := unicode.BigEndian
+	if littleEndian {
+		endianness = unicode.LittleEndian
+	}
+
+	return unicode.UTF16(endianness, unicode.UseBOM).NewEncoder()
+}
+
+// Decodes text in an encoding
+func decode(b []byte, encoding byte) (string, error) {
+	if encoding == utf16Index {
+		return decodeUTF16(b), nil
+	}
+
+	if encoding == latin1Index && isASCII(b) || encoding == utf8Index && utf8.Valid(b) {
+		// Text that decodes to itself needs no transform
+		return string(b), nil
+	}
+
+	return decoderFor(encoding).String(string(b))
+}
+
+// Decodes UTF-16 text holding null separated strings
+// Each string may start with its own BOM, which sets the byte order of the
+// string and those without a BOM after it. The byte order of text that
+// starts without a BOM is guessed.
+func decodeUTF16(b []byte) string {
+	var order binary.ByteOrder
+	var sb strings.Builder
+
+	for start := 0; start+1 < len(b); {
+		switch {
+		case b[start] == 0xFE && b[start+1] == 0xFF:
+			order = binary.BigEndian
+			start += 2
+		case b[start] == 0xFF && b[start+1] == 0xFE:
+			order = binary.LittleEndian
+			start += 2
+		case order == nil:
+			order = binary.BigEndian
+			if GuessUTF16LittleEndian(b[start:]) {
+				order = binary.LittleEndian
+			}
+		}
+
+		end := start
+		for end+1 < len(b) && (b[end] != 0 || b[end+1] != 0) {
+			end += 2
+		}
+
+		units := make([]uint16, 0, (end-start)/2)
+		for i := start; i < end; i += 2 {
+			units = append(units, order.Uint16(b[i:]))
+		}
+		sb.WriteString(string(utf16.Decode(units)))
+
+		if end+1 >= len(b) {
+			break
+		}
+
+		sb.WriteByte(0)
+		start = end + 2
+	}
+
+	return sb.String()
+}
+
+// Whether UTF-16 text is little-endian, judged from BOM or content
+// Without a BOM, ASCII characters give away the byte order through the
+// position of their zero bytes. Failing that, the byte order that decodes
+// into more plausible characters wins, with big-endian preferred on a tie.
+func GuessUTF16LittleEndian(b []byte) bool {
+	if len(b) >= 2 {
+		switch {
+		case b[0] == 0xFE && b[1] == 0xFF:
+			return false
+		case b[0] == 0xFF && b[1] == 0xFE:
+			return true
+		}
+	}
+
+	var highZero, lowZero int
+	for i := 0; i+1 < len(b); i += 2 {
+		switch {
+		case b[i] == 0 && b[i+1] != 0:
+			highZero++
+		case b[i] != 0 && b[i+1] == 0:
+			lowZero++
+		}
+	}
+	if highZero != lowZero {
+		return lowZero > highZero
+	}
+
+	return utf16Score(b, binary.LittleEndian) > utf16Score(b, binary.BigEndian)
+}
+
+// Whether UTF-16 text lacks the BOM the ID3 UTF-16 encoding requires
+func MissingUTF16BOM(b []byte) bool {
+	return len(b) >= 2 && !(b[0] == 0xFE && b[1] == 0xFF) && !(b[0] == 0xFF && b[1] == 0xFE) && (b[0] != 0 || b[1] != 0)
+}
+
+// Plausibility of text decoded in a byte order
+func utf16Score(b []byte, order binary.ByteOrder) int {
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		units = append(units, order.Uint16(b[i:]))
+	}
+
+	score := 0
+	for _, r := range utf16.Decode(units) {
+		switch {
+		case r == stdunicode.ReplacementChar, stdunicode.Is(stdunicode.Co, r), !stdunicode.IsPrint(r) && r != 0:
+			score -= 2
+		case stdunicode.IsGraphic(r):
+			score++
+		}
+	}
+
+	return score
+}
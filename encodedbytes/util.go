@@ -4,14 +4,22 @@
 package encodedbytes
 
 import (
-	"bytes"
 	"errors"
+	"fmt"
+	"sync"
+	"unicode/utf8"
 
 	"golang.org/x/text/encoding"
 	"golang.org/x/text/encoding/charmap"
 	"golang.org/x/text/encoding/unicode"
 )
 
+const utf8Index = 3
+
+// Returned for encoding indexes that are not defined and text that cannot be
+// represented in an encoding
+var ErrBadEncoding = errors.New("encoding: invalid encoding")
+
 const (
 	BytesPerInt     = 4
 	SynchByteLength = 7
@@ -34,10 +42,53 @@ var (
 		{Name: "UTF-16BE", NullLength: 2},
 		{Name: "UTF-8", NullLength: 1},
 	}
+	// Decoders and encoders with the default settings, the package creates
+	// its own for each string so that tags can be read and written
+	// concurrently
 	Decoders = make([]*encoding.Decoder, len(EncodingMap))
 	Encoders = make([]*encoding.Encoder, len(EncodingMap))
 )
 
+// Settings that apply to every tag
+var (
+	settingsMu        sync.RWMutex
+	latin1Policy      = Latin1Error
+	utf16LittleEndian bool
+)
+
+// Decoder for an encoding index, created for each string as decoders keep
+// state between calls
+func decoderFor(encoding byte) *encoding.Decoder {
+	switch encoding {
+	case latin1Index:
+		return charmap.ISO8859_1.NewDecoder()
+	case utf16Index:
+		return unicode.UTF16(unicode.BigEndian, unicode.UseBOM).NewDecoder()
+	case utf16BEIndex:
+		return unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM).NewDecoder()
+	}
+
+	return unicode.UTF8.NewDecoder()
+}
+
+// Encoder for an encoding index following the settings
+func encoderFor(encoding byte) *encoding.Encoder {
+	settingsMu.RLock()
+	policy, littleEndian := latin1Policy, utf16LittleEndian
+	settingsMu.RUnlock()
+
+	switch encoding {
+	case latin1Index:
+		return latin1Encoder(policy)
+	case utf16Index:
+		return utf16Encoder(littleEndian)
+	case utf16BEIndex:
+		return unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM).NewEncoder()
+	}
+
+	return unicode.UTF8.NewEncoder()
+}
+
 func init() {
 	Decoders[0] = charmap.ISO8859_1.NewDecoder()
 	Encoders[0] = charmap.ISO8859_1.NewEncoder()
@@ -108,7 +159,7 @@ func NormBytes(n uint32) []byte {
 
 func EncodingForIndex(b byte) string {
 	encodingIndex := int(b)
-	if encodingIndex < 0 || encodingIndex > len(EncodingMap) {
+	if encodingIndex < 0 || encodingIndex >= len(EncodingMap) {
 		encodingIndex = 0
 	}
 
@@ -117,7 +168,7 @@ func EncodingForIndex(b byte) string {
 
 func EncodingNullLengthForIndex(b byte) int {
 	encodingIndex := int(b)
-	if encodingIndex < 0 || encodingIndex > len(EncodingMap) {
+	if encodingIndex < 0 || encodingIndex >= len(EncodingMap) {
 		encodingIndex = 0
 	}
 
@@ -137,13 +188,12 @@ func IndexForEncoding(e string) byte {
 func nullIndex(data []byte, encoding byte) (atIndex, afterIndex int) {
 	byteCount := EncodingNullLengthForIndex(encoding)
 	limit := len(data)
-	null := bytes.Repeat([]byte{0x0}, byteCount)
 
-	for i, _ := range data[:limit/byteCount] {
+	for i := range data[:limit/byteCount] {
 		atIndex = byteCount * i
 		afterIndex = atIndex + byteCount
 
-		if bytes.Equal(data[atIndex:afterIndex], null) {
+		if isZero(data[atIndex:afterIndex]) {
 			return
 		}
 	}
@@ -154,28 +204,53 @@ func nullIndex(data []byte, encoding byte) (atIndex, afterIndex int) {
 }
 
 func EncodedDiff(newEncoding byte, newString string, oldEncoding byte, oldString string) (int, error) {
-	newEncodedString, err := Encoders[newEncoding].String(newString)
+	newLen, err := EncodedLen(newString, newEncoding)
 	if err != nil {
 		return 0, err
 	}
 
-	oldEncodedString, err := Encoders[oldEncoding].String(oldString)
+	oldLen, err := EncodedLen(oldString, oldEncoding)
 	if err != nil {
 		return 0, err
 	}
 
-	return len(newEncodedString) - len(oldEncodedString), nil
+	return newLen - oldLen, nil
 }
 
 func EncodedStringBytes(s string, encoding byte) ([]byte, error) {
-	encodedString, err := Encoders[encoding].String(s)
+	if encoding == latin1Index && isASCII(s) || encoding == utf8Index && utf8.ValidString(s) {
+		// Text that encodes to itself needs no transform
+		return []byte(s), nil
+	}
+
+	if int(encoding) >= len(EncodingMap) {
+		return nil, ErrBadEncoding
+	}
+
+	encodedString, err := encoderFor(encoding).String(s)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %v", ErrBadEncoding, err)
 	}
 
 	return []byte(encodedString), nil
 }
 
+// Length of the encoded string, without copying text that encodes to itself
+func EncodedLen(s string, encoding byte) (int, error) {
+	if encoding == latin1Index && isASCII(s) || encoding == utf8Index && utf8.ValidString(s) {
+		return len(s), nil
+	}
+
+	b, err := EncodedStringBytes(s, encoding)
+	return len(b), err
+}
+
+// Length of the encoded string with its null terminator
+func EncodedNullTermLen(s string, encoding byte) (int, error) {
+	n, err := EncodedLen(s, encoding)
+	return n + EncodingNullLengthForIndex(encoding), err
+}
+
 func EncodedNullTermStringBytes(s string, encoding byte) ([]byte, error) {
 	encodedBytes, err := EncodedStringBytes(s, encoding)
 	if err != nil {
@@ -190,3 +265,23 @@ func EncodedNullTermStringBytes(s string, encoding byte) ([]byte, error) {
 	return encodedBytes, nil
 
 }
+
+func isZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isASCII[T string | []byte](s T) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -5,6 +5,7 @@ package encodedbytes
 
 import (
 	"bytes"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -65,3 +66,140 @@ func TestEncodeDecode(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, string(sampleISO_8859_1), encoded)
 }
+
+func TestDecodeUTF16(t *testing.T) {
+	tests := []struct {
+		data     string
+		expected string
+	}{
+		{"\xfe\xff\x00H\x00i", "Hi"},
+		{"\xff\xfeH\x00i\x00", "Hi"},
+		{"\x00H\x00i", "Hi"},
+		{"\xff\xfeH\x00i\x00\x00\x00", "Hi\x00"},
+		{"\xff\xfeA\x00\x00\x00\xfe\xff\x00B\x00\x00\x00C", "A\x00B\x00C"},
+		{"\xff\xfe\x3d\xd8\x00\xde", "\U0001F600"},
+	}
+
+	for _, test := range tests {
+		s, err := NewReader([]byte(test.data)).ReadRestString(1)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, s)
+	}
+
+	s, err := NewReader([]byte("\xff\xfeH\x00i\x00\x00\x00rest")).ReadNullTermString(1)
+	require.NoError(t, err)
+	assert.Equal(t, "Hi", s)
+}
+
+func TestUTF16LittleEndian(t *testing.T) {
+	SetUTF16LittleEndian(true)
+	defer SetUTF16LittleEndian(false)
+
+	b, err := EncodedStringBytes("Hi", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("\xff\xfeH\x00i\x00"), b)
+
+	s, err := NewReader(b).ReadRestString(1)
+	require.NoError(t, err)
+	assert.Equal(t, "Hi", s)
+}
+
+func TestGuessUTF16LittleEndian(t *testing.T) {
+	tests := []struct {
+		data         string
+		littleEndian bool
+	}{
+		{"H\x00i\x00", true},
+		{"\x00H\x00i", false},
+		{"\xfe\xffH\x00", false},
+		{"\xff\xfe\x00H", true},
+		{"\x13\x27", false}, // both plausible, big-endian preferred
+		{"\xdb\xff", true},  // an unpaired surrogate when big-endian
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.littleEndian, GuessUTF16LittleEndian([]byte(test.data)), "%q", test.data)
+	}
+
+	s, err := NewReader([]byte("H\x00i\x00")).ReadRestString(1)
+	require.NoError(t, err)
+	assert.Equal(t, "Hi", s)
+}
+
+func TestLatin1Policy(t *testing.T) {
+	defer SetLatin1Policy(Latin1Error)
+
+	_, err := EncodedStringBytes("Dvořák", 0)
+	assert.Error(t, err)
+
+	tests := []struct {
+		policy   Latin1Policy
+		text     string
+		expected string
+	}{
+		{Latin1Replace, "Dvořák", "Dvo?\xe1k"},
+		{Latin1Replace, "七里香", "???"},
+		{Latin1Transliterate, "Dvořák", "Dvor\xe1k"},
+		{Latin1Transliterate, "Œuvre – Straße", "OEuvre - Stra\xdfe"},
+		{Latin1Transliterate, "Ｈｉ ﬁ", "Hi fi"},
+		{Latin1Transliterate, "七里香", "???"},
+	}
+
+	for _, test := range tests {
+		SetLatin1Policy(test.policy)
+		b, err := EncodedStringBytes(test.text, 0)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, string(b), "%q", test.text)
+
+		diff, err := EncodedDiff(0, test.text, 0, "")
+		require.NoError(t, err)
+		assert.Equal(t, len(test.expected), diff)
+	}
+}
+
+func TestSettingsConcurrency(t *testing.T) {
+	defer SetLatin1Policy(Latin1Error)
+	defer SetUTF16LittleEndian(false)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				SetLatin1Policy(Latin1Policy(j % 3))
+				SetUTF16LittleEndian(j%2 == 0)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				EncodedStringBytes("Dvořák", 0)
+				b, err := EncodedStringBytes("Hi", 1)
+				assert.NoError(t, err)
+				assert.Len(t, b, 6)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestEncodedLen(t *testing.T) {
+	for _, text := range []string{"", "plain", "café", "日本語"} {
+		for encoding := byte(0); encoding < byte(len(EncodingMap)); encoding++ {
+			b, err := EncodedNullTermStringBytes(text, encoding)
+			n, lenErr := EncodedNullTermLen(text, encoding)
+			if (err == nil) != (lenErr == nil) || err == nil && n != len(b) {
+				t.Errorf("EncodedNullTermLen(%q, %d) = %d, %v, want %d, %v", text, encoding, n, lenErr, len(b), err)
+			}
+		}
+	}
+}
+
+func BenchmarkDecodeLatin1(b *testing.B) {
+	data := []byte("An ordinary ASCII title\x00")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		NewReader(data).ReadNullTermString(0)
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package id3
+
+import (
+	"errors"
+
+	v2 "github.com/lion187chen/id3-go/v2"
+)
+
+var (
+	// The operation needs an ID3v2 tag and the file has an ID3v1 tag
+	ErrNoTag = errors.New("tag: no ID3v2 tag")
+	// The file was opened with OpenReadOnly
+	ErrReadOnly = errors.New("file: opened read-only")
+	// SaveInPlace would have to move the audio
+	ErrInsufficientPadding = errors.New("file: tag does not fit its space")
+	// A Format template has unbalanced braces or an unknown format
+	ErrBadTemplate = errors.New("template: malformed template")
+	// ParsePattern found no fields where the template puts them
+	ErrPatternMismatch = errors.New("template: path does not match template")
+	// The tag format cannot store the value, such as a length in ID3v1
+	ErrUnsupported = errors.New("tag: not supported by this tag format")
+
+	ErrUnsupportedVersion = v2.ErrUnsupportedVersion
+	ErrFrameTooLarge      = v2.ErrFrameTooLarge
+	ErrBadEncoding        = v2.ErrBadEncoding
+	ErrBadValue           = v2.ErrBadValue
+	ErrFrameNotAllowed    = v2.ErrFrameNotAllowed
+)
@@ -0,0 +1,292 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package id3
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	v2 "github.com/lion187chen/id3-go/v2"
+)
+
+// FormatOption configures Format
+type FormatOption func(*formatConfig)
+
+type formatConfig struct {
+	replacement string
+	missing     string
+}
+
+// Replaces characters that are invalid in file names, "_" by default
+func WithReplacement(s string) FormatOption {
+	return func(c *formatConfig) { c.replacement = s }
+}
+
+// Text of fields the tag does not have, "" by default
+func WithMissing(s string) FormatOption {
+	return func(c *formatConfig) { c.missing = s }
+}
+
+// Builds a file path from a template of fields such as
+// "{artist}/{album}/{track:02d} - {title}"
+// Fields are named as Tag.SetAll accepts them. A format after a colon is a
+// fmt verb without the percent sign; "d" verbs format the number a value
+// starts with, so track "3/10" becomes "03". Slashes in the template separate
+// directories, while characters invalid in file names are replaced within
+// field values. "{{" and "}}" stand for literal braces. Multiple values are
+// joined with ", ".
+func Format(t Tagger, template string, opts ...FormatOption) (string, error) {
+	c := formatConfig{replacement: "_"}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	parts, err := parseTemplate(template)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, part := range parts {
+		if part.name == "" {
+			b.WriteString(part.literal)
+			continue
+		}
+
+		b.WriteString(sanitizeName(formatField(t, part, c), c.replacement))
+	}
+
+	return filepath.FromSlash(b.String()), nil
+}
+
+// Literal text or a field of a template
+type templatePart struct {
+	literal string
+	name    string
+	verb    string
+}
+
+// Splits a template into literal text and fields
+func parseTemplate(template string) ([]templatePart, error) {
+	var parts []templatePart
+	var literal strings.Builder
+	flush := func() {
+		if literal.Len() > 0 {
+			parts = append(parts, templatePart{literal: literal.String()})
+			literal.Reset()
+		}
+	}
+
+	for i := 0; i < len(template); i++ {
+		switch ch := template[i]; {
+		case strings.HasPrefix(template[i:], "{{"), strings.HasPrefix(template[i:], "}}"):
+			literal.WriteByte(ch)
+			i++
+		case ch == '}':
+			return nil, fmt.Errorf("%w: unmatched } at %d", ErrBadTemplate, i)
+		case ch == '{':
+			end := strings.IndexByte(template[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unclosed { at %d", ErrBadTemplate, i)
+			}
+
+			field := template[i+1 : i+end]
+			name, verb, hasVerb := strings.Cut(field, ":")
+			switch {
+			case name == "" || (hasVerb && verb == ""):
+				return nil, fmt.Errorf("%w: empty field in {%s}", ErrBadTemplate, field)
+			case hasVerb && !strings.HasSuffix(verb, "d") && !strings.HasSuffix(verb, "s"):
+				return nil, fmt.Errorf("%w: unsupported format %q", ErrBadTemplate, verb)
+			}
+
+			flush()
+			parts = append(parts, templatePart{name: name, verb: verb})
+			i += end
+		default:
+			literal.WriteByte(ch)
+		}
+	}
+	flush()
+
+	return parts, nil
+}
+
+// Formats a single field
+func formatField(t Tagger, part templatePart, c formatConfig) string {
+	values := fieldValues(t, part.name)
+	if len(values) == 0 || values[0] == "" {
+		return c.missing
+	}
+	value := strings.Join(values, ", ")
+
+	switch {
+	case part.verb == "":
+		return value
+	case strings.HasSuffix(part.verb, "d"):
+		digits := strings.TrimSpace(value)
+		if end := strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }); end >= 0 {
+			digits = digits[:end]
+		}
+		n, err := strconv.Atoi(digits)
+		if err != nil {
+			return c.missing
+		}
+		return fmt.Sprintf("%"+part.verb, n)
+	}
+
+	return fmt.Sprintf("%"+part.verb, value)
+}
+
+// Values of a field of any tag
+// ID3v1 tags only have the title, artist, album, year, genre and comment.
+func fieldValues(t Tagger, name string) []string {
+	t = innerTagger(t)
+
+	if tag, ok := t.(*v2.Tag); ok {
+		return tag.Values(name)
+	}
+
+	switch strings.ToLower(name) {
+	case "title":
+		return []string{t.Title()}
+	case "artist":
+		return []string{t.Artist()}
+	case "album":
+		return []string{t.Album()}
+	case "year", "date":
+		return []string{t.Year()}
+	case "genre":
+		return []string{t.Genre()}
+	case "comment", "comments":
+		return t.Comments()
+	}
+
+	return nil
+}
+
+// Tag of a file, or the tag itself
+func innerTagger(t Tagger) Tagger {
+	switch f := t.(type) {
+	case *File:
+		return f.Tagger
+	case *Mp3Bytes:
+		return f.Tagger
+	}
+
+	return t
+}
+
+// Replaces the characters Windows, macOS and Linux do not allow in file
+// names, and names that refer to directories
+func sanitizeName(s, replacement string) string {
+	var b strings.Builder
+	for _, r := range strings.TrimSpace(s) {
+		if r < 0x20 || strings.ContainsRune(`/\:*?"<>|`, r) {
+			b.WriteString(replacement)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	s = b.String()
+
+	// Windows drops trailing dots and spaces
+	if trimmed := strings.TrimRight(s, ". "); trimmed != s {
+		s = trimmed + strings.Repeat(replacement, len(s)-len(trimmed))
+	}
+
+	return s
+}
+
+// Extracts fields from a file name or path with a template as for Format
+// The template matches the end of the path, so "{album}/{track:d} {title}"
+// reads the album from the directory. The extension is ignored unless the
+// template has one. Fields with a "d" format match only digits, which are
+// returned without leading zeros.
+func ParsePattern(template, path string) (map[string]string, error) {
+	parts, err := parseTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+
+	path = filepath.ToSlash(path)
+	if filepath.Ext(template) == "" {
+		path = strings.TrimSuffix(path, filepath.Ext(path))
+	}
+
+	var expr strings.Builder
+	var fieldParts []templatePart
+	expr.WriteString(`(?:^|/)`)
+	for _, part := range parts {
+		switch {
+		case part.name == "":
+			expr.WriteString(regexp.QuoteMeta(part.literal))
+		case strings.HasSuffix(part.verb, "d"):
+			expr.WriteString(`\s*(\d+)`)
+		default:
+			expr.WriteString(`([^/]+?)`)
+		}
+		if part.name != "" {
+			fieldParts = append(fieldParts, part)
+		}
+	}
+	expr.WriteString(`$`)
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadTemplate, err)
+	}
+
+	match := re.FindStringSubmatch(path)
+	if match == nil {
+		return nil, fmt.Errorf("%w: %q", ErrPatternMismatch, path)
+	}
+
+	fields := make(map[string]string, len(fieldParts))
+	for i, part := range fieldParts {
+		value := strings.TrimSpace(match[i+1])
+		if n, err := strconv.Atoi(value); err == nil && strings.HasSuffix(part.verb, "d") {
+			value = strconv.Itoa(n)
+		}
+		if value != "" {
+			fields[part.name] = value
+		}
+	}
+
+	return fields, nil
+}
+
+// Sets the fields ParsePattern extracts from the path on a tag
+// ID3v1 tags only take the title, artist, album, year and genre.
+func ApplyPattern(t Tagger, template, path string) error {
+	fields, err := ParsePattern(template, path)
+	if err != nil {
+		return err
+	}
+
+	t = innerTagger(t)
+
+	if tag, ok := t.(*v2.Tag); ok {
+		return tag.SetAll(fields)
+	}
+
+	for name, value := range fields {
+		switch strings.ToLower(name) {
+		case "title":
+			t.SetTitle(value)
+		case "artist":
+			t.SetArtist(value)
+		case "album":
+			t.SetAlbum(value)
+		case "year", "date":
+			t.SetYear(value)
+		case "genre":
+			t.SetGenre(value)
+		}
+	}
+
+	return nil
+}
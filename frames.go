@@ -0,0 +1,37 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package id3
+
+import (
+	v2 "github.com/lion187chen/id3-go/v2"
+)
+
+// First frame with the id that is of type T
+// Frames of other types with the same id are skipped.
+//
+//	if tf, ok := id3.GetFrame[*v2.TextFrame](file, "TIT2"); ok {
+//		fmt.Println(tf.Text())
+//	}
+func GetFrame[T v2.Framer](t Tagger, id string) (T, bool) {
+	for _, f := range t.Frames(id) {
+		if frame, ok := f.(T); ok {
+			return frame, true
+		}
+	}
+
+	var zero T
+	return zero, false
+}
+
+// All frames with the id that are of type T
+func GetFrames[T v2.Framer](t Tagger, id string) []T {
+	var frames []T
+	for _, f := range t.Frames(id) {
+		if frame, ok := f.(T); ok {
+			frames = append(frames, frame)
+		}
+	}
+
+	return frames
+}
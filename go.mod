@@ -1,6 +1,6 @@
 module github.com/lion187chen/id3-go
 
-go 1.20
+go 1.23
 
 require (
 	github.com/ghenry22/id3-go v0.1.1
@@ -5,9 +5,16 @@ package id3
 
 import (
 	"bytes"
-	"errors"
+	"crypto/md5"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
 	"os"
+	"sync"
+	"time"
 
+	"github.com/lion187chen/id3-go/mpeg"
 	v1 "github.com/lion187chen/id3-go/v1"
 	v2 "github.com/lion187chen/id3-go/v2"
 )
@@ -48,22 +55,85 @@ type Tagger interface {
 type File struct {
 	Tagger
 	originalSize int
-	file         *os.File
+	chained      []*v2.Tag
+	file         storage
+	opts         v2.ParseOptions
+	verify       bool
+	progress     ProgressFunc
+	// Audio the last save moved or copied, see LastMove
+	moved    int64
+	moveTime time.Duration
+	readOnly bool
+	// Whether the tag is appended to the file, see SaveAppended
+	appended bool
+	// Whether Save pads the tag over junk following it, see SetRemoveJunk
+	removeJunk bool
+	// Padding of a tag that outgrew its space, see SetPaddingPolicy
+	paddingPolicy PaddingPolicy
+
+	audioOnce sync.Once
+	audio     *mpeg.Properties
+	audioErr  error
 }
 
 type Mp3Bytes struct {
 	Tagger
 	originalSize int
+	chained      []*v2.Tag
 	blob         []byte
+	// Whether the data starts with the ID3v2 tag
+	tagged bool
 }
 
 // Parses an open file
 func Parse(file *os.File) (*File, error) {
-	res := &File{file: file}
+	return ParseWithOptions(file, v2.ParseOptions{})
+}
+
+// Parses an open file, enforcing the given v2 parse options
+func ParseWithOptions(file *os.File, opts v2.ParseOptions) (*File, error) {
+	return parseStorage(osFile{file}, opts)
+}
+
+// Parses tagged data of the given size from any seekable value that can
+// also be written, such as an encrypted container or an in-memory file
+// Reading starts at offset zero. Saving writes rws, truncating it only if it
+// has a Truncate method, and Close closes it if it is an io.Closer.
+func ParseRW(rws io.ReadWriteSeeker, size int64) (*File, error) {
+	return ParseRWWithOptions(rws, size, v2.ParseOptions{})
+}
+
+// Parses tagged data as ParseRW, enforcing the given v2 parse options
+func ParseRWWithOptions(rws io.ReadWriteSeeker, size int64, opts v2.ParseOptions) (*File, error) {
+	if file, ok := rws.(*os.File); ok {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return parseStorage(osFile{file}, opts)
+	}
+
+	file := &rwsFile{rws: rws, size: size}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
 
-	if v2Tag := v2.ParseTag(file); v2Tag != nil {
+	return parseStorage(file, opts)
+}
+
+func parseStorage(file storage, opts v2.ParseOptions) (*File, error) {
+	res := &File{file: file, opts: opts}
+
+	v2Tag, err := v2.ParseTagWithOptions(file, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if v2Tag != nil {
 		res.Tagger = v2Tag
-		res.originalSize = v2Tag.Size()
+		res.originalSize = storedSize(v2Tag)
+		if res.chained, err = parseChainedTags(file, opts); err != nil {
+			return nil, err
+		}
 	} else if v1Tag := v1.ParseTag(file); v1Tag != nil {
 		res.Tagger = v1Tag
 	} else {
@@ -71,16 +141,43 @@ func Parse(file *os.File) (*File, error) {
 		res.Tagger = v2.NewTag(LatestVersion)
 	}
 
+	// A tag at the start holding only a SEEK frame points to an appended tag
+	if v2Tag == nil || onlySeek(v2Tag) {
+		appended, err := parseAppendedTag(file, opts)
+		if err != nil {
+			return nil, err
+		}
+		if appended != nil {
+			res.Tagger = appended
+			res.appended = true
+		}
+	}
+
 	return res, nil
 }
 
 // NewMp3Bytes should match Parse above but for in memory mp3 data not on disk files
 func NewMp3Bytes(blob []byte) (*Mp3Bytes, error) {
+	return NewMp3BytesWithOptions(blob, v2.ParseOptions{})
+}
+
+// NewMp3BytesWithOptions should match ParseWithOptions above but for in memory mp3 data
+func NewMp3BytesWithOptions(blob []byte, opts v2.ParseOptions) (*Mp3Bytes, error) {
 	res := &Mp3Bytes{blob: blob}
 
-	if v2Tag := v2.ParseTag(bytes.NewReader(blob)); v2Tag != nil {
+	reader := bytes.NewReader(blob)
+	v2Tag, err := v2.ParseTagWithOptions(reader, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if v2Tag != nil {
 		res.Tagger = v2Tag
-		res.originalSize = v2Tag.Size()
+		res.originalSize = storedSize(v2Tag)
+		res.tagged = true
+		if res.chained, err = parseChainedTags(reader, opts); err != nil {
+			return nil, err
+		}
 	} else if v1Tag := v1.ParseTag(bytes.NewReader(blob)); v1Tag != nil {
 		res.Tagger = v1Tag
 	} else {
@@ -91,90 +188,871 @@ func NewMp3Bytes(blob []byte) (*Mp3Bytes, error) {
 	return res, nil
 }
 
+// Parses any further ID3v2 tags directly following the one just read
+func parseChainedTags(readSeeker io.ReadSeeker, opts v2.ParseOptions) ([]*v2.Tag, error) {
+	var tags []*v2.Tag
+
+	for {
+		tag, err := v2.ParseTagWithOptions(readSeeker, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		if tag == nil {
+			return tags, nil
+		}
+
+		tags = append(tags, tag)
+	}
+}
+
+// Bytes a parsed ID3v2 tag takes in the file after its header, including
+// its footer
+func storedSize(tag *v2.Tag) int {
+	size := tag.StoredSize()
+	if tag.Footer() {
+		size += v2.HeaderSize
+	}
+
+	return size
+}
+
+// Merges chained tags into the primary tag, which is grown to cover the
+// space all of them occupied. Returns the new original size.
+func collapseTags(primary *v2.Tag, originalSize int, chained []*v2.Tag) int {
+	for _, tag := range chained {
+		originalSize += v2.HeaderSize + storedSize(tag)
+		primary.Merge(tag)
+	}
+
+	padding := int(primary.Padding())
+	if d := originalSize - primary.Size(); d > 0 {
+		padding += d
+	}
+	primary.SetPadding(uint(padding))
+
+	return originalSize
+}
+
 // Opens a new tagged file
 func Open(name string) (*File, error) {
+	return OpenWithOptions(name, v2.ParseOptions{})
+}
+
+// Opens a new tagged file, enforcing the given v2 parse options
+func OpenWithOptions(name string, opts v2.ParseOptions) (*File, error) {
 	fi, err := os.OpenFile(name, os.O_RDWR, 0666)
 	if err != nil {
 		return nil, err
 	}
 
+	file, err := ParseWithOptions(fi, opts)
+	if err != nil {
+		fi.Close()
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// Opens a tagged file for reading only
+// Saving edits fails with ErrReadOnly.
+func OpenReadOnly(name string) (*File, error) {
+	fi, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
 	file, err := Parse(fi)
 	if err != nil {
+		fi.Close()
 		return nil, err
 	}
+	file.readOnly = true
 
 	return file, nil
 }
 
+// Removes all ID3v1 and ID3v2 tags from the named file, including an
+// enhanced TAG+ block and an ID3v2 tag appended before the ID3v1 tag
+func Strip(name string) error {
+	file, err := os.OpenFile(name, os.O_RDWR, 0666)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	size, err := osFile{file}.Size()
+	if err != nil {
+		return err
+	}
+
+	// APE tags and Lyrics3 blocks before an ID3v1 tag are kept
+	start, err := leadingTagsSize(file)
+	if err != nil {
+		return err
+	}
+
+	end := size
+	if v1.ParseTag(file) != nil {
+		end -= v1.TagSize
+		if enhancedV1(file, end) {
+			end -= v1EnhancedSize
+		}
+	}
+	if tag, ok := appendedTag(file, end); ok {
+		end = tag.Offset
+	}
+
+	if start > end {
+		start = end
+	}
+	if start == 0 && end == size {
+		return nil
+	}
+
+	if err := moveBytes(osFile{file}, start, end); err != nil {
+		return err
+	}
+
+	return file.Truncate(end - start)
+}
+
+// Removes the ID3v1 tag, and the enhanced TAG+ block before it, by
+// truncating the file
+// Lyrics3 blocks before the ID3v1 tag are removed too, as readers only look
+// for them there. APE tags and an appended ID3v2 tag are kept, ending the
+// file instead. A file whose only tag was the ID3v1 tag gets a new ID3v2
+// tag, as an untagged file does when opened.
+func (f *File) StripV1() error {
+	if f.readOnly {
+		return ErrReadOnly
+	}
+
+	size, err := f.file.Size()
+	if err != nil {
+		return err
+	}
+	leading, err := leadingTagsSize(f.file)
+	if err != nil {
+		return err
+	}
+
+	tags := findTrailingTags(f.file, size)
+	if len(tags) == 0 || tags[len(tags)-1].Kind != "ID3v1" {
+		return nil
+	}
+
+	end := size
+	for i := len(tags) - 1; i >= 0 && tags[i].Offset >= leading; i-- {
+		switch tags[i].Kind {
+		case "ID3v1", "ID3v1+", "Lyrics3v1", "Lyrics3v2":
+			end = tags[i].Offset
+			continue
+		}
+		break
+	}
+
+	if err := f.file.Truncate(end); err != nil {
+		return err
+	}
+	f.resetAudio()
+
+	if _, ok := f.Tagger.(*v1.Tag); ok {
+		f.Tagger = v2.NewTag(LatestVersion)
+		f.originalSize = 0
+	}
+
+	return nil
+}
+
+// Size of the leading ID3v2 tags, including any chained after the first
+func leadingTagsSize(readSeeker io.ReadSeeker) (int64, error) {
+	var size int64
+	for {
+		if _, err := readSeeker.Seek(size, os.SEEK_SET); err != nil {
+			return 0, err
+		}
+
+		header := v2.ParseHeader(readSeeker)
+		if header == nil {
+			return size, nil
+		}
+		size += int64(v2.HeaderSize + header.Size())
+		if header.Footer() {
+			size += v2.HeaderSize
+		}
+	}
+}
+
+type readSeekerAt interface {
+	io.ReadSeeker
+	io.ReaderAt
+}
+
+// Offsets of the audio between the leading ID3v2 tags and any trailing tags
+func audioRegion(r readSeekerAt, size int64) (start, end int64, err error) {
+	if start, err = leadingTagsSize(r); err != nil {
+		return 0, 0, err
+	}
+
+	end = size
+	if tags := findTrailingTags(r, size); len(tags) > 0 {
+		end = tags[0].Offset
+	}
+
+	if start > end {
+		start = end
+	}
+
+	return start, end, nil
+}
+
+// Reads the audio as stored in the file, without the leading ID3v2 tags and
+// the trailing ID3v1, APE and Lyrics3 tags
+// The region is found when called, so call it again after saving.
+func (f *File) AudioReader() (*io.SectionReader, error) {
+	size, err := f.file.Size()
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := audioRegion(f.file, size)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NewSectionReader(f.file, start, end-start), nil
+}
+
+// MD5 checksum of the audio, leaving out all tags
+// Files that differ only in their tags have the same checksum.
+func (f *File) AudioMD5() ([md5.Size]byte, error) {
+	var sum [md5.Size]byte
+	err := f.hashAudio(md5.New(), sum[:])
+	return sum, err
+}
+
+// SHA-256 checksum of the audio, leaving out all tags
+func (f *File) AudioSHA256() ([sha256.Size]byte, error) {
+	var sum [sha256.Size]byte
+	err := f.hashAudio(sha256.New(), sum[:])
+	return sum, err
+}
+
+func (f *File) hashAudio(h hash.Hash, sum []byte) error {
+	r, err := f.AudioReader()
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(h, r); err != nil {
+		return err
+	}
+
+	h.Sum(sum[:0])
+	return nil
+}
+
+// Properties of the MPEG audio following the tag
+// The audio is read on the first call; later calls return the same result.
+func (f *File) AudioProperties() (*mpeg.Properties, error) {
+	f.audioOnce.Do(func() {
+		size, err := f.file.Size()
+		if err != nil {
+			f.audioErr = err
+			return
+		}
+
+		start, end, err := audioRegion(f.file, size)
+		if err != nil {
+			f.audioErr = err
+			return
+		}
+
+		f.audio, f.audioErr = mpeg.ReadProperties(f.file, start, end)
+	})
+
+	return f.audio, f.audioErr
+}
+
+// Makes the next AudioProperties call read the audio again, after the file
+// has changed around it
+func (f *File) resetAudio() {
+	f.audioOnce = sync.Once{}
+	f.audio, f.audioErr = nil, nil
+}
+
+// Playing time of the audio, zero if it cannot be read
+func (f *File) Duration() time.Duration {
+	if p, err := f.AudioProperties(); err == nil {
+		return p.Duration
+	}
+
+	return 0
+}
+
+// Bitrate of the audio in kbit/s, averaged when VBR
+func (f *File) Bitrate() int {
+	if p, err := f.AudioProperties(); err == nil {
+		return p.Bitrate
+	}
+
+	return 0
+}
+
+// Sample rate of the audio in Hz
+func (f *File) SampleRate() int {
+	if p, err := f.AudioProperties(); err == nil {
+		return p.SampleRate
+	}
+
+	return 0
+}
+
+// Channel mode of the audio
+func (f *File) ChannelMode() mpeg.ChannelMode {
+	if p, err := f.AudioProperties(); err == nil {
+		return p.ChannelMode
+	}
+
+	return mpeg.Stereo
+}
+
+// Whether the audio has a variable bitrate
+func (f *File) VBR() bool {
+	if p, err := f.AudioProperties(); err == nil {
+		return p.VBR
+	}
+
+	return false
+}
+
+// Measures the audio and stores its duration in milliseconds in the TLEN
+// frame
+func (f *File) UpdateLength() error {
+	p, err := f.AudioProperties()
+	if err != nil {
+		return err
+	}
+
+	return setLength(f.Tagger, p)
+}
+
+func setLength(tagger Tagger, p *mpeg.Properties) error {
+	if _, ok := tagger.(*v2.Tag); !ok {
+		return fmt.Errorf("UpdateLength: %w", ErrNoTag)
+	}
+
+	length := int(p.Duration / time.Millisecond)
+	if tagger.Length() != length {
+		tagger.SetLength(length)
+	}
+
+	return nil
+}
+
+// Statistics of parsing the ID3v2 tag, zero for ID3v1 and new tags
+func (f *File) ParseStats() v2.ParseStats {
+	if tag, ok := f.Tagger.(*v2.Tag); ok {
+		return tag.ParseStats()
+	}
+
+	return v2.ParseStats{}
+}
+
+// MusicBrainz identifiers of the ID3v2 tag, empty for ID3v1 tags
+func (f *File) MusicBrainzIDs() v2.MusicBrainzIDs {
+	if tag, ok := f.Tagger.(*v2.Tag); ok {
+		return tag.MusicBrainzIDs()
+	}
+
+	return v2.MusicBrainzIDs{}
+}
+
+// Replaces the MusicBrainz identifiers, which need an ID3v2 tag
+func (f *File) SetMusicBrainzIDs(ids v2.MusicBrainzIDs) error {
+	tag, ok := f.Tagger.(*v2.Tag)
+	if !ok {
+		return fmt.Errorf("SetMusicBrainzIDs: %w", ErrNoTag)
+	}
+
+	tag.SetMusicBrainzIDs(ids)
+	return nil
+}
+
+// Additional ID3v2 tags found directly after the first one
+// Some broken taggers prepend a new tag instead of updating the existing one
+func (f *File) ChainedTags() []*v2.Tag {
+	return append([]*v2.Tag(nil), f.chained...)
+}
+
+// Merges the chained tags into the first tag, so that the next save
+// replaces all of them with a single tag
+// Frames in the first tag take precedence over those of later tags.
+func (f *File) CollapseTags() {
+	if primary, ok := f.Tagger.(*v2.Tag); ok && len(f.chained) > 0 {
+		f.originalSize = collapseTags(primary, f.originalSize, f.chained)
+		f.chained = nil
+	}
+}
+
 // Saves any edits to the tagged file
 func (f *File) Close() error {
 	defer f.file.Close()
 
+	return f.Save()
+}
+
+// Saves any edits to the tagged file, leaving it open
+func (f *File) Save() error {
+	if f.removeJunk && !f.readOnly {
+		if err := f.absorbJunk(); err != nil {
+			return err
+		}
+	}
+
 	if !f.Dirty() {
 		return nil
 	}
+	if f.readOnly {
+		return ErrReadOnly
+	}
+	if f.appended {
+		return f.SaveAppended()
+	}
 
-	switch f.Tagger.(type) {
+	var data []byte
+	switch tag := f.Tagger.(type) {
 	case (*v1.Tag):
 		if _, err := f.file.Seek(-v1.TagSize, os.SEEK_END); err != nil {
 			return err
 		}
+		var err error
+		if data, err = encodeTag(tag); err != nil {
+			return err
+		}
 	case (*v2.Tag):
-		if f.Size() > f.originalSize {
-			start := int64(f.originalSize + v2.HeaderSize)
-			offset := int64(f.Tagger.Size() - f.originalSize)
+		// A tag that shrank or lost its footer is padded to fill its space
+		if d := f.originalSize - f.Size(); d > 0 {
+			tag.SetPadding(tag.Padding() + uint(d))
+		}
+		if padding, ok := f.policyPadding(tag); ok {
+			tag.SetPadding(padding)
+		}
+
+		// Encoding first reports frames that fail to encode before anything
+		// in the file is moved or overwritten
+		var buf bytes.Buffer
+		if _, err := tag.WriteTo(&buf); err != nil {
+			return err
+		}
+		data = buf.Bytes()
 
-			if err := shiftBytesBack(f.file, start, offset); err != nil {
+		if start, offset, ok := f.shift(); ok {
+			began := time.Now()
+			if err := shiftBytesBack(f.file, start, offset, f.progress); err != nil {
+				return err
+			}
+			size, err := f.file.Size()
+			if err != nil {
 				return err
 			}
+			f.moved, f.moveTime = size-offset-start, time.Since(began)
 		}
 
 		if _, err := f.file.Seek(0, os.SEEK_SET); err != nil {
 			return err
 		}
 	default:
-		return errors.New("Close: unknown tag version")
+		return fmt.Errorf("Save: %w", ErrUnsupportedVersion)
 	}
 
-	if _, err := f.file.Write(f.Tagger.Bytes()); err != nil {
+	if _, err := f.file.Write(data); err != nil {
 		return err
 	}
 
+	// The tag now fills the space it was written to
+	if _, ok := f.Tagger.(*v2.Tag); ok {
+		f.originalSize = f.Size()
+	}
+
+	if f.verify {
+		return f.verifyWrite()
+	}
+
 	return nil
 }
 
-// UpdateEditsIntoBytes is like Close above but for in memory mp3 data not on disk
-func (b *Mp3Bytes) UpdateEditsIntoBytes() (*[]byte, error) {
-	if !b.Dirty() {
-		return &b.blob, nil
+// Encodes the tag, failing on text it cannot represent where Bytes would
+// replace it
+func encodeTag(t Tagger) ([]byte, error) {
+	wt, ok := t.(io.WriterTo)
+	if !ok {
+		return t.Bytes(), nil
+	}
+
+	var buf bytes.Buffer
+	if _, err := wt.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Sets a function called as Save moves the audio to make room for a grown
+// tag and as SaveAs copies it, which can cancel the save by returning an
+// error
+// The file is left unchanged when a save is cancelled.
+func (f *File) SetProgress(progress ProgressFunc) {
+	f.progress = progress
+}
+
+// Bytes of audio the last save moved or copied and how long that took, from
+// which its throughput follows
+// Both are zero when no save has moved the audio.
+func (f *File) LastMove() (n int64, elapsed time.Duration) {
+	return f.moved, f.moveTime
+}
+
+// Writes the file with any edits to a new file, leaving the open file and
+// its tag as they were
+// The audio is copied to the new file after the tag, reporting progress to
+// the function set with SetProgress, and a cancelled copy removes the new
+// file. Files saved with SaveAppended give ErrUnsupported.
+func (f *File) SaveAs(name string) error {
+	if f.appended {
+		return fmt.Errorf("SaveAs: %w", ErrUnsupported)
+	}
+	if file, ok := f.file.(osFile); ok {
+		if this, err := file.Stat(); err == nil {
+			if other, err := os.Stat(name); err == nil && os.SameFile(this, other) {
+				return fmt.Errorf("SaveAs: %s is the open file, use Save", name)
+			}
+		}
+	}
+
+	size, err := f.file.Size()
+	if err != nil {
+		return err
 	}
-	start := int64(0)
-	offset := int64(0)
 
-	switch b.Tagger.(type) {
+	// The tag goes before the audio, or replaces the ID3v1 tag ending it
+	var head, tail []byte
+	start, end := int64(0), size
+	switch tag := f.Tagger.(type) {
 	case (*v1.Tag):
-		//unless I am much mistaken in v1 the tags are at the end of the file
-		offset = int64(len(b.blob)) - v1.TagSize
+		if tail, err = encodeTag(tag); err != nil {
+			return err
+		}
+		end -= v1.TagSize
+	case (*v2.Tag):
+		var buf bytes.Buffer
+		if _, err := tag.WriteTo(&buf); err != nil {
+			return err
+		}
+		head = buf.Bytes()
 
+		// The tag replaces the one the file starts with, if any
+		leading, err := leadingTagsSize(f.file)
+		if err != nil {
+			return err
+		}
+		if leading > 0 {
+			start = int64(f.originalSize + v2.HeaderSize)
+		}
+	default:
+		return fmt.Errorf("SaveAs: %w", ErrUnsupportedVersion)
+	}
+	if start > end {
+		start = end
+	}
+
+	out, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+
+	began := time.Now()
+	_, err = out.Write(head)
+	if err == nil {
+		err = streamBytes(out, f.file, start, end-start, f.progress)
+	}
+	if err == nil {
+		_, err = out.Write(tail)
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(name)
+		return err
+	}
+
+	f.moved, f.moveTime = end-start, time.Since(began)
+	return nil
+}
+
+// Saves edits only if the tag fits the space it already occupies, returning
+// ErrInsufficientPadding rather than moving the audio
+func (f *File) SaveInPlace() error {
+	if _, _, ok := f.shift(); ok && f.Dirty() {
+		if _, isV2 := f.Tagger.(*v2.Tag); isV2 {
+			return ErrInsufficientPadding
+		}
+	}
+
+	return f.Save()
+}
+
+// Where the audio starts and how far it must move for the tag to fit
+func (f *File) shift() (start, offset int64, ok bool) {
+	return f.shiftFor(f.Size())
+}
+
+// Where the audio starts and how far it must move for a tag of the size
+func (f *File) shiftFor(size int) (start, offset int64, ok bool) {
+	if f.appended || size <= f.originalSize {
+		return 0, 0, false
+	}
+
+	return int64(f.originalSize + v2.HeaderSize), int64(size - f.originalSize), true
+}
+
+// SavePreview describes what Save would write
+type SavePreview struct {
+	// Whether there are unsaved changes, Save writes nothing otherwise
+	Dirty bool
+	// The tag as it would be written
+	Bytes []byte
+	// Whether the tag outgrew its space, so the rest of the file is rewritten
+	// to move the audio back
+	Shift bool
+	// Bytes the audio moves by
+	ShiftBy int64
+	// Bytes of the file that would be moved
+	ShiftSize int64
+}
+
+// Reports what Save would write without modifying the file
+// Tools can use it to warn before a save rewrites the whole file.
+func (f *File) PreviewSave() (*SavePreview, error) {
+	p := &SavePreview{Dirty: f.Dirty()}
+	if !p.Dirty {
+		return p, nil
+	}
+
+	switch f.Tagger.(type) {
+	case (*v1.Tag):
+		var err error
+		if p.Bytes, err = encodeTag(f.Tagger); err != nil {
+			return nil, err
+		}
+		return p, nil
 	case (*v2.Tag):
-		if b.Size() > b.originalSize {
-			start = int64(b.originalSize + v2.HeaderSize)
-			offset = int64(b.Tagger.Size() - b.originalSize)
-			b.blob = shiftBytesBackInMem(b.blob, start, offset)
+	default:
+		return nil, fmt.Errorf("PreviewSave: %w", ErrUnsupportedVersion)
+	}
+
+	// The padding policy is applied to a copy, leaving the tag unchanged
+	tag := f.Tagger.(*v2.Tag)
+	if padding, ok := f.policyPadding(tag); ok {
+		tag = tag.Clone()
+		tag.SetPadding(padding)
+	}
+
+	var buf bytes.Buffer
+	if _, err := tag.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	p.Bytes = buf.Bytes()
+
+	if start, offset, ok := f.shiftFor(tag.Size()); ok {
+		size, err := f.file.Size()
+		if err != nil {
+			return nil, err
 		}
 
+		p.Shift, p.ShiftBy = true, offset
+		if size > start {
+			p.ShiftSize = size - start
+		}
+	}
+
+	return p, nil
+}
+
+// ChainedTags is like File.ChainedTags above but for in memory mp3 data
+func (b *Mp3Bytes) ChainedTags() []*v2.Tag {
+	return append([]*v2.Tag(nil), b.chained...)
+}
+
+// AudioProperties is like File.AudioProperties above but for in memory mp3 data
+func (b *Mp3Bytes) AudioProperties() (*mpeg.Properties, error) {
+	reader := bytes.NewReader(b.blob)
+	start, end, err := audioRegion(reader, reader.Size())
+	if err != nil {
+		return nil, err
+	}
+
+	return mpeg.ReadProperties(reader, start, end)
+}
+
+// AudioBytes is like File.AudioReader above but for in memory mp3 data
+// The returned slice shares the data.
+func (b *Mp3Bytes) AudioBytes() []byte {
+	audio, _ := b.audioBytes()
+	return audio
+}
+
+func (b *Mp3Bytes) audioBytes() ([]byte, error) {
+	start, end, err := audioRegion(bytes.NewReader(b.blob), int64(len(b.blob)))
+	if err != nil {
+		return nil, err
+	}
+
+	return b.blob[start:end], nil
+}
+
+// AudioMD5 is like File.AudioMD5 above but for in memory mp3 data
+func (b *Mp3Bytes) AudioMD5() ([md5.Size]byte, error) {
+	audio, err := b.audioBytes()
+	if err != nil {
+		return [md5.Size]byte{}, err
+	}
+	return md5.Sum(audio), nil
+}
+
+// AudioSHA256 is like File.AudioSHA256 above but for in memory mp3 data
+func (b *Mp3Bytes) AudioSHA256() ([sha256.Size]byte, error) {
+	audio, err := b.audioBytes()
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(audio), nil
+}
+
+// UpdateLength is like File.UpdateLength above but for in memory mp3 data
+func (b *Mp3Bytes) UpdateLength() error {
+	p, err := b.AudioProperties()
+	if err != nil {
+		return err
+	}
+
+	return setLength(b.Tagger, p)
+}
+
+// CollapseTags is like File.CollapseTags above but for in memory mp3 data
+func (b *Mp3Bytes) CollapseTags() {
+	if primary, ok := b.Tagger.(*v2.Tag); ok && len(b.chained) > 0 {
+		b.originalSize = collapseTags(primary, b.originalSize, b.chained)
+		b.chained = nil
+	}
+}
+
+// Applies the edits to the data and returns it
+// A tag that fits the space of the old one, padding included, is written
+// over it without reallocating, so the result shares the data passed to
+// NewMp3Bytes. Only a grown tag copies the audio.
+func (b *Mp3Bytes) Update() ([]byte, error) {
+	if !b.Dirty() {
+		return b.blob, nil
+	}
+
+	switch tag := b.Tagger.(type) {
+	case *v1.Tag:
+		// The v1 tag is the last 128 bytes, after any APE or Lyrics3 tags
+		data, err := encodeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		copy(b.blob[len(b.blob)-v1.TagSize:], data)
+	case *v2.Tag:
+		// Encode first so a failure leaves the data unchanged
+		var buf bytes.Buffer
+		buf.Grow(tag.Size() + v2.HeaderSize)
+		if _, err := tag.WriteTo(&buf); err != nil {
+			return nil, err
+		}
+
+		end := b.tagEnd()
+		size := buf.Len()
+		switch {
+		case size > end:
+			blob := make([]byte, len(b.blob)-end+size)
+			copy(blob[size:], b.blob[end:])
+			b.blob = blob
+		case size < end:
+			n := copy(b.blob[size:], b.blob[end:])
+			b.blob = b.blob[:size+n]
+		}
+		copy(b.blob, buf.Bytes())
+
+		b.originalSize = size - v2.HeaderSize
+		b.tagged = true
 	default:
-		return nil, errors.New("Close: unknown tag version")
+		return nil, fmt.Errorf("Update: %w", ErrUnsupportedVersion)
+	}
+
+	return b.blob, nil
+}
+
+// UpdateEditsIntoBytes is like Close above but for in memory mp3 data not on disk
+// Deprecated: Use Update, which returns the data itself.
+func (b *Mp3Bytes) UpdateEditsIntoBytes() (*[]byte, error) {
+	if _, err := b.Update(); err != nil {
+		return nil, err
 	}
 
-	insert := b.Tagger.Bytes()
-	copy(b.blob[0:start+offset], insert)
 	return &b.blob, nil
 }
 
-func shiftBytesBackInMem(blob []byte, start, offset int64) []byte {
-	out := make([]byte, int64(len(blob))+offset)
-	copy(out, blob[:start])
-	copy(out[start+offset:], blob[start:])
-	return out
+// Streams the data with the edits applied to w, without changing the data
+// Returns the number of bytes written.
+func (b *Mp3Bytes) WriteTo(w io.Writer) (int64, error) {
+	if !b.Dirty() {
+		n, err := w.Write(b.blob)
+		return int64(n), err
+	}
+
+	var written int64
+	switch tag := b.Tagger.(type) {
+	case *v1.Tag:
+		data, err := encodeTag(tag)
+		if err != nil {
+			return 0, err
+		}
+
+		n, err := w.Write(b.blob[:len(b.blob)-v1.TagSize])
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+
+		n, err = w.Write(data)
+		return written + int64(n), err
+	case *v2.Tag:
+		n, err := tag.WriteTo(w)
+		written += n
+		if err != nil {
+			return written, err
+		}
+
+		m, err := w.Write(b.blob[b.tagEnd():])
+		return written + int64(m), err
+	}
+
+	return 0, fmt.Errorf("WriteTo: %w", ErrUnsupportedVersion)
+}
+
+// End of the ID3v2 tag in the data
+// A tag that claims more bytes than the data holds ends with the data.
+func (b *Mp3Bytes) tagEnd() int {
+	if !b.tagged {
+		return 0
+	}
+
+	if end := b.originalSize + v2.HeaderSize; end < len(b.blob) {
+		return end
+	}
+	return len(b.blob)
 }
@@ -5,11 +5,26 @@ package id3
 
 import (
 	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/lion187chen/id3-go/encodedbytes"
+	"github.com/lion187chen/id3-go/mpeg"
+	v1 "github.com/lion187chen/id3-go/v1"
 	v2 "github.com/lion187chen/id3-go/v2"
 )
 
@@ -33,7 +48,7 @@ func TestParse(t *testing.T) {
 		t.Errorf("Parse: incorrect tagger type")
 	}
 
-	if s := tag.Artist(); s != "Paloalto\x00" {
+	if s := tag.Artist(); s != "Paloalto" {
 		t.Errorf("Parse: incorrect artist, %v", s)
 	}
 
@@ -76,7 +91,7 @@ func TestOpen(t *testing.T) {
 		t.Errorf("Open: incorrect tagger type")
 	}
 
-	if s := tag.Artist(); s != "Paloalto\x00" {
+	if s := tag.Artist(); s != "Paloalto" {
 		t.Errorf("Open: incorrect artist, %v", s)
 	}
 
@@ -276,3 +291,1553 @@ func TestUTF16CommPanic(t *testing.T) {
 		file.Close()
 	}
 }
+
+func TestChainedTags(t *testing.T) {
+	newer := v2.NewTag(3)
+	newer.SetTitle("Nice Life")
+
+	older := v2.NewTag(3)
+	older.SetTitle("Old Title")
+	older.SetArtist("Paloalto")
+
+	audio := []byte{0xFF, 0xFB, 0x90, 0x64, 1, 2, 3, 4}
+
+	tempfile, err := ioutil.TempFile("", "chained")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tempfile.Name())
+
+	tempfile.Write(newer.Bytes())
+	tempfile.Write(older.Bytes())
+	tempfile.Write(audio)
+	tempfile.Close()
+
+	file, err := Open(tempfile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(file.ChainedTags()); n != 1 {
+		t.Fatalf("ChainedTags found %d tags, want 1", n)
+	}
+
+	file.CollapseTags()
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err = Open(tempfile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if n := len(file.ChainedTags()); n != 0 {
+		t.Errorf("ChainedTags after collapse found %d tags, want 0", n)
+	}
+
+	if s := file.Title(); s != "Nice Life" {
+		t.Errorf("collapsed tag title = %q, want the newer tag's title", s)
+	}
+
+	if s := file.Artist(); s != "Paloalto" {
+		t.Errorf("collapsed tag artist = %q, want the older tag's artist", s)
+	}
+
+	after, err := ioutil.ReadFile(tempfile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.HasSuffix(after, audio) || len(after) != v2.HeaderSize+file.Size()+len(audio) {
+		t.Errorf("collapsed file does not end with the audio data directly after the tag")
+	}
+}
+
+func TestDumpJSON(t *testing.T) {
+	file, err := Open(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var buf bytes.Buffer
+	if err := DumpJSON(&buf, file, v2.JSONOptions{OmitBinary: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Version string
+		Frames  []struct {
+			Id   string
+			Text string
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.Version != "2.3.0" || len(doc.Frames) != 10 {
+		t.Errorf("DumpJSON: version %s with %d frames", doc.Version, len(doc.Frames))
+	}
+
+	for _, f := range doc.Frames {
+		if f.Id == "TPE1" && f.Text != "Paloalto" {
+			t.Errorf("DumpJSON: incorrect artist, %q", f.Text)
+		}
+	}
+}
+
+func TestStrip(t *testing.T) {
+	before, err := ioutil.ReadFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v1Tag := make([]byte, 128)
+	copy(v1Tag, "TAGNice Life")
+	data := append(append([]byte(nil), before...), v1Tag...)
+
+	tempfile, err := ioutil.TempFile("", "id3strip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tempfile.Name())
+
+	_, err = tempfile.Write(data)
+	tempfile.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Strip(tempfile.Name()); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := ioutil.ReadFile(tempfile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tagSize := v2.HeaderSize + v2.ParseTag(bytes.NewReader(before)).Size()
+	if !bytes.Equal(after, before[tagSize:]) {
+		t.Errorf("Strip: file has %d bytes, want %d bytes of audio", len(after), len(before)-tagSize)
+	}
+}
+
+func TestWalk(t *testing.T) {
+	data, err := ioutil.ReadFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	for _, name := range []string{"a.mp3", "b.mp3", "c/d.mp3", "c/broken.mp3", "c/notes.txt"} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0777)
+
+		contents := data
+		if name == "c/broken.mp3" {
+			// Header declaring a tag larger than the parse limit
+			contents = []byte{'I', 'D', '3', 3, 0, 0, 0x7f, 0x7f, 0x7f, 0x7f}
+		}
+		if err := ioutil.WriteFile(path, contents, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var mu sync.Mutex
+	var seen []string
+	err = Walk(dir, func(path string, f *File) error {
+		mu.Lock()
+		seen = append(seen, filepath.Base(path))
+		mu.Unlock()
+
+		f.SetTitle("Chief Life")
+		return nil
+	}, WithJobs(2), WithWriteBack())
+
+	var walkErr *WalkError
+	if !errors.As(err, &walkErr) || len(walkErr.Errors) != 1 || !strings.HasSuffix(walkErr.Errors[0].Path, "broken.mp3") {
+		t.Fatalf("Walk returned %v, want error for broken.mp3", err)
+	}
+
+	if !errors.Is(err, v2.ErrTagTooLarge) {
+		t.Errorf("Walk error %v does not wrap the parse error", err)
+	}
+
+	sort.Strings(seen)
+	if strings.Join(seen, " ") != "a.mp3 b.mp3 d.mp3" {
+		t.Errorf("Walk visited %v", seen)
+	}
+
+	// Edits are only saved with WithWriteBack
+	err = Walk(dir, func(path string, f *File) error {
+		if s := f.Title(); s != "Chief Life" {
+			t.Errorf("Walk: %s has title %q", path, s)
+		}
+		f.SetTitle("Nice Life")
+		return nil
+	}, WithMatch(func(path string) bool { return filepath.Base(path) == "a.mp3" }))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := Open(filepath.Join(dir, "a.mp3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if s := file.Title(); s != "Chief Life" {
+		t.Errorf("Walk without write back saved title %q", s)
+	}
+}
+
+func TestAudioProperties(t *testing.T) {
+	file, err := Open(testFile)
+	if err != nil {
+		t.Fatalf("AudioProperties: unable to open file")
+	}
+	defer file.Close()
+
+	p, err := file.AudioProperties()
+	if err != nil {
+		t.Fatalf("AudioProperties: %v", err)
+	}
+
+	if p.Version != mpeg.Version1 || p.Layer != mpeg.Layer3 || p.Offset != 81919 {
+		t.Errorf("AudioProperties: unexpected stream %+v", p)
+	}
+
+	if file.Bitrate() != 320 || file.SampleRate() != 44100 || file.ChannelMode() != mpeg.JointStereo || file.VBR() {
+		t.Errorf("AudioProperties: expected 320kbit/s 44.1kHz joint stereo CBR, got %+v", p)
+	}
+
+	if expected := 1264 * 8 * time.Millisecond / 320; file.Duration() != expected {
+		t.Errorf("Duration: expected %v, got %v", expected, file.Duration())
+	}
+
+	data, err := ioutil.ReadFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mp3, err := NewMp3Bytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bp, err := mp3.AudioProperties(); err != nil || *bp != *p {
+		t.Errorf("Mp3Bytes.AudioProperties: expected %+v, got %+v, %v", p, bp, err)
+	}
+}
+
+func TestUpdateLength(t *testing.T) {
+	data, err := ioutil.ReadFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mp3, err := NewMp3Bytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if l := mp3.Length(); l != -1 {
+		t.Errorf("UpdateLength: expected no length before update, got %d", l)
+	}
+
+	if err := mp3.UpdateLength(); err != nil {
+		t.Fatalf("UpdateLength: %v", err)
+	}
+
+	if l := mp3.Length(); l != 31 {
+		t.Errorf("UpdateLength: expected 31ms, got %d", l)
+	}
+	if f := mp3.Frame("TLEN"); f == nil || !mp3.Dirty() {
+		t.Errorf("UpdateLength: expected a new TLEN frame")
+	}
+}
+
+func TestTrailingTags(t *testing.T) {
+	before, err := ioutil.ReadFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	audio := before[81919:]
+
+	lyrics := "LYRICSBEGININD00002" + "10" + "LYR00005hello"
+	lyrics += fmt.Sprintf("%06d", len(lyrics)) + "LYRICS200"
+
+	ape := make([]byte, 32+10+32)
+	copy(ape, "APETAGEX")
+	binary.LittleEndian.PutUint32(ape[8:], 2000)
+	binary.LittleEndian.PutUint32(ape[12:], 10+32)
+	binary.LittleEndian.PutUint32(ape[20:], 1<<31|1<<29)
+	copy(ape[42:], ape[:32])
+	binary.LittleEndian.PutUint32(ape[42+20:], 1<<31)
+
+	v1Tag := make([]byte, 128)
+	copy(v1Tag, "TAGNice Life")
+
+	data := append(append(append(append([]byte(nil), audio...), lyrics...), ape...), v1Tag...)
+
+	mp3, err := NewMp3Bytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lyricsEnd := int64(len(audio) + len(lyrics))
+	expected := []TrailingTag{
+		{"Lyrics3v2", int64(len(audio)), int64(len(lyrics))},
+		{"APEv2", lyricsEnd, int64(len(ape))},
+		{"ID3v1", lyricsEnd + int64(len(ape)), 128},
+	}
+	tags := mp3.TrailingTags()
+	if len(tags) != len(expected) {
+		t.Fatalf("TrailingTags: expected %v, got %v", expected, tags)
+	}
+	for i := range tags {
+		if tags[i] != expected[i] {
+			t.Errorf("TrailingTags: expected %v, got %v", expected[i], tags[i])
+		}
+	}
+
+	if p, err := mp3.AudioProperties(); err != nil || p.Size != int64(len(audio)) {
+		t.Errorf("AudioProperties: expected %d bytes of audio, got %+v, %v", len(audio), p, err)
+	}
+
+	mp3.SetTitle("Nicer Life")
+	blob, err := mp3.UpdateEditsIntoBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal((*blob)[:tags[2].Offset], data[:tags[2].Offset]) {
+		t.Errorf("UpdateEditsIntoBytes: ID3v1 write changed the preceding tags")
+	}
+	if !bytes.HasPrefix((*blob)[tags[2].Offset:], []byte("TAGNicer Life")) {
+		t.Errorf("UpdateEditsIntoBytes: ID3v1 tag not written at the end")
+	}
+}
+
+func TestEdit(t *testing.T) {
+	data, err := ioutil.ReadFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(t.TempDir(), "edit.mp3")
+	if err := ioutil.WriteFile(name, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	title := file.Title()
+
+	edit, err := file.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	edit.SetTitle("Discarded")
+	if file.Title() != title {
+		t.Errorf("Begin: expected the file's tag left untouched")
+	}
+	if err := edit.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if err := edit.Commit(); err == nil {
+		t.Errorf("Commit: expected an error after Rollback")
+	}
+
+	// A failed save keeps the session open and the file's tag as it was
+	errCancel := errors.New("cancelled")
+	file.SetProgress(func(int64, int64) error {
+		return errCancel
+	})
+	edit, _ = file.Begin()
+	edit.SetTitle(strings.Repeat("Cancelled ", 10000))
+	if err := edit.Commit(); err != errCancel {
+		t.Fatalf("Commit: expected the cancel error, got %v", err)
+	}
+	if file.Title() != title {
+		t.Errorf("Commit: expected the file's tag kept after a failed save, got %q", file.Title())
+	}
+	file.SetProgress(nil)
+	edit.SetTitle("A title long enough to grow the tag beyond its padding")
+	if err := edit.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if err := edit.Rollback(); err == nil {
+		t.Errorf("Rollback: expected an error after Commit")
+	}
+
+	reopened, err := Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if reopened.Title() != "A title long enough to grow the tag beyond its padding" {
+		t.Errorf("Commit: expected the title saved, got %q", reopened.Title())
+	}
+	if artist := reopened.Artist(); artist != file.Artist() {
+		t.Errorf("Commit: expected the other frames kept, got artist %q", artist)
+	}
+}
+
+func TestSaveEncodeError(t *testing.T) {
+	_, audio := taggedFixture(t)
+	tag := v2.NewTag(2)
+	tag.SetTitle("Nice Life")
+	data := append(tag.Bytes(), audio...)
+
+	name := filepath.Join(t.TempDir(), "invalid.mp3")
+	if err := ioutil.WriteFile(name, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	// A grown tag with a frame the version does not allow
+	file.SetArtist(strings.Repeat("Paloalto ", 1000))
+	file.AddFrames(v2.NewTextFrame(v2.V23FrameTypeMap["TIT2"], "Nice Life", "ISO-8859-1"))
+	if err := file.Save(); !errors.Is(err, v2.ErrFrameNotAllowed) {
+		t.Errorf("Save: expected ErrFrameNotAllowed, got %v", err)
+	}
+	if after, _ := ioutil.ReadFile(name); !bytes.Equal(after, data) {
+		t.Errorf("Save: expected the file unchanged when the tag fails to encode")
+	}
+}
+
+func TestPreviewSave(t *testing.T) {
+	data, err := ioutil.ReadFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(t.TempDir(), "preview.mp3")
+	if err := ioutil.WriteFile(name, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if p, err := file.PreviewSave(); err != nil || p.Dirty {
+		t.Errorf("PreviewSave: expected nothing to save, got %+v, %v", p, err)
+	}
+
+	file.SetTitle(strings.Repeat("Long title ", 10000))
+	p, err := file.PreviewSave()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Shift || p.ShiftBy <= 0 || p.ShiftSize <= 0 {
+		t.Errorf("PreviewSave: expected the audio to move, got %v by %d", p.Shift, p.ShiftBy)
+	}
+
+	after, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, after) {
+		t.Errorf("PreviewSave: file modified")
+	}
+
+	if err := file.Save(); err != nil {
+		t.Fatal(err)
+	}
+	after, err = ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(after[:len(p.Bytes)], p.Bytes) {
+		t.Errorf("PreviewSave: expected the bytes Save wrote")
+	}
+	if int64(len(after)) != int64(len(data))+p.ShiftBy {
+		t.Errorf("PreviewSave: expected the file to grow by %d, got %d", p.ShiftBy, len(after)-len(data))
+	}
+}
+
+func TestVerifyWrites(t *testing.T) {
+	data, err := ioutil.ReadFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(t.TempDir(), "verify.mp3")
+	if err := ioutil.WriteFile(name, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	file.SetVerifyWrites(true)
+	file.SetTitle(strings.Repeat("Verified ", 1000))
+	if err := file.Save(); err != nil {
+		t.Errorf("Save: expected the written tag to verify, got %v", err)
+	}
+
+	frames := file.AllFrames()
+	other := v2.NewTextFrame(v2.V23FrameTypeMap["TIT3"], "Other", "ISO-8859-1")
+	changed := append([]v2.Framer{other}, frames[1:]...)
+	if err := compareFrames(frames, changed); !errors.Is(err, ErrVerify) {
+		t.Errorf("compareFrames: expected ErrVerify for a changed frame, got %v", err)
+	}
+	if err := compareFrames(frames, frames[1:]); !errors.Is(err, ErrVerify) {
+		t.Errorf("compareFrames: expected ErrVerify for a missing frame, got %v", err)
+	}
+}
+
+func TestShiftBytesBack(t *testing.T) {
+	data := make([]byte, copyBufferSize+copyBufferSize/2)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	name := filepath.Join(t.TempDir(), "shift.bin")
+	if err := ioutil.WriteFile(name, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.OpenFile(name, os.O_RDWR, 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	const start, offset = 100, 3
+	if err := shiftBytesBack(osFile{file}, start, offset, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after) != len(data)+offset {
+		t.Fatalf("shiftBytesBack: expected %d bytes, got %d", len(data)+offset, len(after))
+	}
+	if !bytes.Equal(after[:start], data[:start]) || !bytes.Equal(after[start+offset:], data[start:]) {
+		t.Errorf("shiftBytesBack: data not moved intact")
+	}
+}
+
+func BenchmarkShiftBytesBack(b *testing.B) {
+	const size = 64 << 20
+	name := filepath.Join(b.TempDir(), "shift.bin")
+	if err := ioutil.WriteFile(name, make([]byte, size), 0666); err != nil {
+		b.Fatal(err)
+	}
+
+	file, err := os.OpenFile(name, os.O_RDWR, 0666)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer file.Close()
+
+	b.SetBytes(size)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := shiftBytesBack(osFile{file}, 0, 4096, nil); err != nil {
+			b.Fatal(err)
+		}
+		b.StopTimer()
+		if err := file.Truncate(size); err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+	}
+}
+
+func TestSaveProgress(t *testing.T) {
+	data, err := ioutil.ReadFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Audio longer than one copy buffer, so the move takes several steps
+	data = append(data, make([]byte, copyBufferSize)...)
+	name := filepath.Join(t.TempDir(), "progress.mp3")
+	if err := ioutil.WriteFile(name, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	errCancel := errors.New("cancelled")
+	file.SetProgress(func(written, total int64) error {
+		return errCancel
+	})
+	file.SetTitle(strings.Repeat("Progress ", 10000))
+	if err := file.Save(); err != errCancel {
+		t.Errorf("Save: expected the cancel error, got %v", err)
+	}
+	after, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, after) {
+		t.Errorf("Save: expected a cancelled save to leave the file unchanged")
+	}
+
+	var calls int
+	var written, total int64
+	file.SetProgress(func(w, tot int64) error {
+		calls++
+		written, total = w, tot
+		return nil
+	})
+	if err := file.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if calls < 2 || written != total || total == 0 {
+		t.Errorf("Save: expected progress up to the total, got %d calls, %d of %d", calls, written, total)
+	}
+	if moved, elapsed := file.LastMove(); moved != total || elapsed <= 0 {
+		t.Errorf("LastMove: expected %d bytes moved in some time, got %d in %v", total, moved, elapsed)
+	}
+}
+
+func TestSaveAs(t *testing.T) {
+	data, err := ioutil.ReadFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Audio longer than one copy buffer, so the copy takes several steps
+	data = append(data, make([]byte, copyBufferSize)...)
+	dir := t.TempDir()
+	name := filepath.Join(dir, "original.mp3")
+	if err := ioutil.WriteFile(name, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	file.SetTitle(strings.Repeat("Copied ", 10000))
+
+	if err := file.SaveAs(name); err == nil {
+		t.Errorf("SaveAs: expected an error saving over the open file")
+	}
+
+	copied := filepath.Join(dir, "copied.mp3")
+	errCancel := errors.New("cancelled")
+	file.SetProgress(func(written, total int64) error {
+		return errCancel
+	})
+	if err := file.SaveAs(copied); err != errCancel {
+		t.Errorf("SaveAs: expected the cancel error, got %v", err)
+	}
+	if _, err := os.Stat(copied); !os.IsNotExist(err) {
+		t.Errorf("SaveAs: expected a cancelled copy removed, got %v", err)
+	}
+
+	var calls int
+	var written, total int64
+	file.SetProgress(func(w, tot int64) error {
+		calls++
+		written, total = w, tot
+		return nil
+	})
+	if err := file.SaveAs(copied); err != nil {
+		t.Fatal(err)
+	}
+	if calls < 2 || written != total || total == 0 {
+		t.Errorf("SaveAs: expected progress up to the total, got %d calls, %d of %d", calls, written, total)
+	}
+
+	after, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, after) {
+		t.Errorf("SaveAs: expected the open file unchanged")
+	}
+
+	saved, err := Open(copied)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer saved.Close()
+	if saved.Title() != file.Title() {
+		t.Errorf("SaveAs: expected the edited title in the copy")
+	}
+	want, err := file.AudioMD5()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := saved.AudioMD5(); err != nil || got != want {
+		t.Errorf("SaveAs: expected the audio copied unchanged, got %v", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	data, err := ioutil.ReadFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	name := filepath.Join(dir, "errors.mp3")
+	if err := ioutil.WriteFile(name, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	readOnly, err := OpenReadOnly(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readOnly.SetTitle("Changed")
+	if err := readOnly.Close(); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Close: expected ErrReadOnly, got %v", err)
+	}
+
+	file, err := Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	file.SetTitle(strings.Repeat("Too long ", 10000))
+	if err := file.SaveInPlace(); !errors.Is(err, ErrInsufficientPadding) {
+		t.Errorf("SaveInPlace: expected ErrInsufficientPadding, got %v", err)
+	}
+
+	v1Name := filepath.Join(dir, "v1.mp3")
+	v1Tag := append(make([]byte, 256), "TAG"...)
+	if err := ioutil.WriteFile(v1Name, append(v1Tag, make([]byte, 125)...), 0666); err != nil {
+		t.Fatal(err)
+	}
+	v1File, err := Open(v1Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer v1File.Close()
+	if _, err := v1File.Begin(); !errors.Is(err, ErrNoTag) {
+		t.Errorf("Begin: expected ErrNoTag for an ID3v1 tag, got %v", err)
+	}
+	if err := v1File.SetMusicBrainzIDs(v2.MusicBrainzIDs{}); !errors.Is(err, ErrNoTag) {
+		t.Errorf("SetMusicBrainzIDs: expected ErrNoTag for an ID3v1 tag, got %v", err)
+	}
+}
+
+// The test file with an APE and an ID3v1 tag after the audio
+func taggedFixture(t *testing.T) (data, audio []byte) {
+	before, err := ioutil.ReadFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	audio = before[81919:]
+
+	ape := make([]byte, 32+10)
+	copy(ape[10:], "APETAGEX")
+	binary.LittleEndian.PutUint32(ape[18:], 2000)
+	binary.LittleEndian.PutUint32(ape[22:], 10+32)
+
+	v1Tag := make([]byte, 128)
+	copy(v1Tag, "TAGNice Life")
+
+	data = append(append(append([]byte(nil), before...), ape...), v1Tag...)
+	return data, audio
+}
+
+func TestAudioReader(t *testing.T) {
+	data, audio := taggedFixture(t)
+	name := filepath.Join(t.TempDir(), "audio.mp3")
+	if err := ioutil.WriteFile(name, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	r, err := file.AudioReader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, err := io.ReadAll(r)
+	if err != nil || !bytes.Equal(read, audio) {
+		t.Errorf("AudioReader: expected %d bytes of audio, got %d, %v", len(audio), len(read), err)
+	}
+
+	mp3, err := NewMp3Bytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(mp3.AudioBytes(), audio) {
+		t.Errorf("AudioBytes: expected only the audio")
+	}
+}
+
+func TestTagRegions(t *testing.T) {
+	data, audio := taggedFixture(t)
+	tagEnd := int64(len(data) - len(audio) - 42 - 128)
+
+	mp3, err := NewMp3Bytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	audioEnd := tagEnd + int64(len(audio))
+	expected := []TagRegion{
+		{"ID3v2", 0, tagEnd},
+		{"APEv2", audioEnd, 42},
+		{"ID3v1", audioEnd + 42, 128},
+	}
+	regions := mp3.TagRegions()
+	if len(regions) != len(expected) {
+		t.Fatalf("TagRegions: expected %v, got %v", expected, regions)
+	}
+	for i := range regions {
+		if regions[i] != expected[i] {
+			t.Errorf("TagRegions: expected %v, got %v", expected[i], regions[i])
+		}
+	}
+}
+
+func TestAudioChecksums(t *testing.T) {
+	data, audio := taggedFixture(t)
+	name := filepath.Join(t.TempDir(), "audio.mp3")
+	if err := ioutil.WriteFile(name, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if sum, err := file.AudioMD5(); err != nil || sum != md5.Sum(audio) {
+		t.Errorf("AudioMD5: expected %x, got %x, %v", md5.Sum(audio), sum, err)
+	}
+	if sum, err := file.AudioSHA256(); err != nil || sum != sha256.Sum256(audio) {
+		t.Errorf("AudioSHA256: expected %x, got %x, %v", sha256.Sum256(audio), sum, err)
+	}
+
+	// A copy without the tags has the same checksums
+	mp3, err := NewMp3Bytes(audio)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum, err := mp3.AudioSHA256(); err != nil || sum != sha256.Sum256(audio) {
+		t.Errorf("AudioSHA256: expected the checksum of the untagged audio, %v", err)
+	}
+	if sum, err := mp3.AudioMD5(); err != nil || sum != md5.Sum(audio) {
+		t.Errorf("AudioMD5: expected the checksum of the untagged audio, %v", err)
+	}
+}
+
+func TestGetFrame(t *testing.T) {
+	file, err := Open(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	tf, ok := GetFrame[*v2.TextFrame](file, "TIT2")
+	if !ok || tf.Text() != file.Title() {
+		t.Errorf("GetFrame: expected title %q, got %v", file.Title(), tf)
+	}
+	if _, ok := GetFrame[*v2.ImageFrame](file, "TIT2"); ok {
+		t.Errorf("GetFrame: expected no image frame")
+	}
+	if _, ok := GetFrame[*v2.TextFrame](file, "TXXX"); ok {
+		t.Errorf("GetFrame: expected no frame for a missing id")
+	}
+
+	comments := GetFrames[*v2.UnsynchTextFrame](file, "COMM")
+	if len(comments) != len(file.Comments()) {
+		t.Errorf("GetFrames: expected %d comments, got %d", len(file.Comments()), len(comments))
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tag := v2.NewTag(3)
+	if err := tag.SetAll(map[string]string{
+		"artist": "AC/DC",
+		"album":  "Back in Black",
+		"track":  "3/10",
+		"title":  "What Do You Do for Money Honey?",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		template string
+		opts     []FormatOption
+		expected string
+	}{
+		{"{artist}/{album}/{track:02d} - {title}", nil, "AC_DC/Back in Black/03 - What Do You Do for Money Honey_"},
+		{"{Artist} - {title}", []FormatOption{WithReplacement("")}, "ACDC - What Do You Do for Money Honey"},
+		{"{{{genre}}} {year}", []FormatOption{WithMissing("Unknown")}, "{Unknown} Unknown"},
+		{"{disc:d}{title:.4s}", nil, "What"},
+	}
+	for _, test := range tests {
+		got, err := Format(tag, test.template, test.opts...)
+		if err != nil {
+			t.Errorf("Format(%q): %v", test.template, err)
+		} else if got != filepath.FromSlash(test.expected) {
+			t.Errorf("Format(%q): expected %q, got %q", test.template, test.expected, got)
+		}
+	}
+
+	for _, template := range []string{"{artist", "artist}", "{}", "{track:x}"} {
+		if _, err := Format(tag, template); !errors.Is(err, ErrBadTemplate) {
+			t.Errorf("Format(%q): expected ErrBadTemplate, got %v", template, err)
+		}
+	}
+
+	if got, _ := Format(tag, "{title:.4s}.."); got != "What.." {
+		t.Errorf("Format: expected dots in the template to stay, got %q", got)
+	}
+	if got := sanitizeName("..", "_"); got != "__" {
+		t.Errorf("sanitizeName: expected %q, got %q", "__", got)
+	}
+}
+
+func TestParsePattern(t *testing.T) {
+	tests := []struct {
+		template, path string
+		expected       map[string]string
+	}{
+		{"{artist} - {title}", "Music/Paloalto - Nice Life.mp3", map[string]string{"artist": "Paloalto", "title": "Nice Life"}},
+		{"{album}/{track:02d} {title}", "/music/Chief Life/03 Nice Life.mp3", map[string]string{"album": "Chief Life", "track": "3", "title": "Nice Life"}},
+		{"{artist} - {title}.mp3", "Paloalto - Nice Life - Live.mp3", map[string]string{"artist": "Paloalto", "title": "Nice Life - Live"}},
+		{"{{{year}}} {title}", "{2013} Nice Life", map[string]string{"year": "2013", "title": "Nice Life"}},
+	}
+	for _, test := range tests {
+		fields, err := ParsePattern(test.template, filepath.FromSlash(test.path))
+		if err != nil {
+			t.Errorf("ParsePattern(%q): %v", test.template, err)
+		} else if !reflect.DeepEqual(fields, test.expected) {
+			t.Errorf("ParsePattern(%q): expected %v, got %v", test.template, test.expected, fields)
+		}
+	}
+
+	if _, err := ParsePattern("{track:d} {title}", "Nice Life.mp3"); !errors.Is(err, ErrPatternMismatch) {
+		t.Errorf("ParsePattern: expected ErrPatternMismatch, got %v", err)
+	}
+
+	tag := v2.NewTag(3)
+	if err := ApplyPattern(tag, "{artist}/{track:d} - {title}", "Paloalto/3 - Nice Life.mp3"); err != nil {
+		t.Fatal(err)
+	}
+	if tag.Artist() != "Paloalto" || tag.Title() != "Nice Life" || tag.Frame("TRCK") == nil {
+		t.Errorf("ApplyPattern: expected artist, title and track, got %v", tag.AllFrames())
+	}
+}
+
+func TestChecked(t *testing.T) {
+	tag := v2.NewTag(4)
+	checked := Checked(&Mp3Bytes{Tagger: tag})
+	if err := checked.SetTitle("Nice Life"); err != nil || tag.Title() != "Nice Life" {
+		t.Errorf("Checked: expected the title set, got %q, %v", tag.Title(), err)
+	}
+	if err := checked.SetYear("2013"); err != nil || tag.Frame("TDRC") == nil {
+		t.Errorf("Checked: expected the year as TDRC, %v", err)
+	}
+	if err := checked.SetYear("2013/11"); !errors.Is(err, v2.ErrBadDate) {
+		t.Errorf("Checked: expected ErrBadDate, got %v", err)
+	}
+	if err := checked.SetLength(-1); !errors.Is(err, ErrBadValue) {
+		t.Errorf("Checked: expected ErrBadValue for a negative length, got %v", err)
+	}
+
+	tyer := v2.NewTextFrame(v2.V23FrameTypeMap["TYER"], "2013", "ISO-8859-1")
+	if err := checked.AddFrames(tyer); !errors.Is(err, ErrFrameNotAllowed) {
+		t.Errorf("Checked: expected ErrFrameNotAllowed for TYER, got %v", err)
+	}
+	if n := len(tag.AllFrames()); n != 2 {
+		t.Errorf("Checked: expected TYER not added, got %d frames", n)
+	}
+
+	data, err := checked.Bytes()
+	if err != nil || !bytes.Equal(data, tag.Bytes()) {
+		t.Errorf("Checked: expected the bytes of the tag, %v", err)
+	}
+
+	v23 := v2.NewTag(3)
+	if err := Checked(&Mp3Bytes{Tagger: v23}).SetYear("2013"); err != nil || v23.Frame("TYER") == nil {
+		t.Errorf("Checked: expected the ID3v2.3 year as TYER, %v", err)
+	}
+
+	v1Tag := &v1.Tag{}
+	checked = Checked(v1Tag)
+	if err := checked.SetArtist("Yang Lin"); err != nil || v1Tag.Artist() != "Yang Lin" {
+		t.Errorf("Checked: expected the ID3v1 artist set, got %q, %v", v1Tag.Artist(), err)
+	}
+	if err := checked.SetTitle(strings.Repeat("x", 31)); !errors.Is(err, ErrBadValue) {
+		t.Errorf("Checked: expected ErrBadValue for a long title, got %v", err)
+	}
+	if err := checked.SetGenre("Zydeco"); !errors.Is(err, ErrBadValue) {
+		t.Errorf("Checked: expected ErrBadValue for an unknown genre, got %v", err)
+	}
+	if err := checked.SetLength(1000); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Checked: expected ErrUnsupported for the length, got %v", err)
+	}
+	if err := checked.AddFrames(v2.NewTextFrame(v2.V23CommonFrame["Title"], "x", "ISO-8859-1")); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Checked: expected ErrUnsupported for frames, got %v", err)
+	}
+	if v1Tag.Title() != "" {
+		t.Errorf("Checked: expected the title unchanged, got %q", v1Tag.Title())
+	}
+}
+
+func TestMp3BytesMissingFooter(t *testing.T) {
+	tag := v2.NewTag(4)
+	tag.SetTitle("Nice Life")
+	data := tag.Bytes()
+	// The flag promises a footer that the data does not hold
+	data[5] |= v2.FlagFooter
+
+	mp3, err := NewMp3Bytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mp3.SetArtist("Paloalto")
+
+	var buf bytes.Buffer
+	if _, err := mp3.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	blob, err := mp3.Update()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, written := range [][]byte{buf.Bytes(), blob} {
+		if parsed, err := NewMp3Bytes(written); err != nil || parsed.Artist() != "Paloalto" || parsed.Title() != "Nice Life" {
+			t.Errorf("Update: expected the edited tag, %v", err)
+		}
+	}
+}
+
+func TestMp3BytesUpdate(t *testing.T) {
+	data, audio := taggedFixture(t)
+	in := append([]byte(nil), data...)
+	mp3, err := NewMp3Bytes(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mp3.SetTitle("Nicer Life")
+	var streamed bytes.Buffer
+	if _, err := mp3.WriteTo(&streamed); err != nil {
+		t.Fatal(err)
+	}
+	blob, err := mp3.Update()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blob) != len(data) || &blob[0] != &in[0] {
+		t.Errorf("Update: expected a tag that fits to be written in place")
+	}
+	if !bytes.Equal(streamed.Bytes(), blob) {
+		t.Errorf("WriteTo: expected the same data as Update")
+	}
+	if parsed, err := NewMp3Bytes(blob); err != nil || parsed.Title() != "Nicer Life" {
+		t.Errorf("Update: expected the new title, %v", err)
+	}
+
+	mp3.AddFrames(v2.NewDataFrame(v2.V23FrameTypeMap["PRIV"], make([]byte, 100000)))
+	streamed.Reset()
+	if _, err := mp3.WriteTo(&streamed); err != nil {
+		t.Fatal(err)
+	}
+	if blob, err = mp3.Update(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(streamed.Bytes(), blob) {
+		t.Errorf("WriteTo: expected the same data as Update for a grown tag")
+	}
+	parsed, err := NewMp3Bytes(blob)
+	if err != nil || parsed.Title() != "Nicer Life" || parsed.Frame("PRIV") == nil {
+		t.Fatalf("Update: expected the grown tag, %v", err)
+	}
+	if !bytes.HasPrefix(parsed.AudioBytes(), audio) {
+		t.Errorf("Update: expected the audio moved intact")
+	}
+
+	// Untagged data gets a tag in front of the audio
+	mp3, err = NewMp3Bytes(append([]byte(nil), audio...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mp3.SetTitle("Nice Life")
+	if blob, err = mp3.Update(); err != nil {
+		t.Fatal(err)
+	}
+	if parsed, err := NewMp3Bytes(blob); err != nil || parsed.Title() != "Nice Life" || !bytes.Equal(parsed.AudioBytes(), audio) {
+		t.Errorf("Update: expected a new tag before the audio, %v", err)
+	}
+}
+
+// In-memory io.ReadWriteSeeker, without ReadAt or Truncate
+type memFile struct {
+	data []byte
+	pos  int64
+}
+
+func (m *memFile) Read(p []byte) (int, error) {
+	if m.pos >= int64(len(m.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, m.data[m.pos:])
+	m.pos += int64(n)
+	return n, nil
+}
+
+func (m *memFile) Write(p []byte) (int, error) {
+	if end := m.pos + int64(len(p)); end > int64(len(m.data)) {
+		m.data = append(m.data, make([]byte, end-int64(len(m.data)))...)
+	}
+	n := copy(m.data[m.pos:], p)
+	m.pos += int64(n)
+	return n, nil
+}
+
+func (m *memFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += m.pos
+	case io.SeekEnd:
+		offset += int64(len(m.data))
+	}
+	if offset < 0 {
+		return 0, errors.New("memFile: negative position")
+	}
+	m.pos = offset
+	return offset, nil
+}
+
+func TestParseRW(t *testing.T) {
+	data, audio := taggedFixture(t)
+	mem := &memFile{data: append([]byte(nil), data...)}
+
+	file, err := ParseRW(mem, int64(len(mem.data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title := "Nice Life (Feat. Basick)"; file.Title() != title {
+		t.Errorf("ParseRW: expected title %q, got %q", title, file.Title())
+	}
+	if tags, err := file.TrailingTags(); err != nil || len(tags) != 2 {
+		t.Errorf("TrailingTags: expected APE and ID3v1 tags, got %v, %v", tags, err)
+	}
+
+	file.SetTitle("Nicer Life")
+	file.AddFrames(v2.NewDataFrame(v2.V23FrameTypeMap["PRIV"], make([]byte, 100000)))
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	mp3, err := NewMp3Bytes(mem.data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mp3.Title() != "Nicer Life" || mp3.Frame("PRIV") == nil {
+		t.Errorf("ParseRW: expected the edits saved")
+	}
+	if !bytes.Equal(mp3.AudioBytes(), audio) {
+		t.Errorf("ParseRW: expected the audio moved intact")
+	}
+
+	// ReadAt and WriteAt leave the offset where it was
+	rws := &rwsFile{rws: &memFile{data: []byte("0123456789")}, size: 10}
+	if _, err := rws.Seek(2, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 3)
+	if n, err := rws.ReadAt(buf, 6); n != 3 || err != nil || string(buf) != "678" {
+		t.Errorf("ReadAt: got %q, %d, %v", buf, n, err)
+	}
+	if _, err := rws.WriteAt([]byte("ab"), 8); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := rws.Read(buf); n != 3 || err != nil || string(buf) != "234" {
+		t.Errorf("ReadAt: expected the offset kept, read %q, %d, %v", buf, n, err)
+	}
+}
+
+func TestSaveAppended(t *testing.T) {
+	data, audio := taggedFixture(t)
+	mem := &memFile{data: append([]byte(nil), data...)}
+
+	file, err := ParseRW(mem, int64(len(mem.data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := file.SaveAppended(); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("SaveAppended: expected ErrUnsupportedVersion for ID3v2.3, got %v", err)
+	}
+
+	tag, _ := file.Tagger.(*v2.Tag).Convert(4)
+	tag.SetTitle("Appended")
+	file.Tagger = tag
+	if err := file.SaveAppended(); err != nil {
+		t.Fatal(err)
+	}
+
+	leading := len(data) - len(audio) - 42 - 128
+	if !bytes.Equal(mem.data[leading:leading+len(audio)], audio) {
+		t.Errorf("SaveAppended: expected the audio left in place")
+	}
+	if !bytes.HasPrefix(mem.data[len(mem.data)-128:], []byte("TAGNice Life")) {
+		t.Errorf("SaveAppended: expected the ID3v1 tag kept last")
+	}
+
+	regions, err := file.TagRegions()
+	if err != nil || len(regions) != 4 || regions[2].Kind != "ID3v2" {
+		t.Fatalf("TagRegions: expected the appended tag before the ID3v1 tag, got %v, %v", regions, err)
+	}
+	stub := v2.ParseTag(bytes.NewReader(mem.data))
+	if offset, ok := stub.SeekOffset(); !ok || int64(offset) != regions[2].Offset-int64(leading) {
+		t.Errorf("SeekOffset: expected %d, got %d, %v", regions[2].Offset-int64(leading), offset, ok)
+	}
+
+	reopened, err := ParseRW(mem, int64(len(mem.data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.Title() != "Appended" {
+		t.Errorf("ParseRW: expected the appended title, got %q", reopened.Title())
+	}
+
+	reopened.SetArtist("Later")
+	reopened.AddFrames(v2.NewDataFrame(v2.V24FrameTypeMap["PRIV"], make([]byte, 100000)))
+	if err := reopened.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	mp3, err := NewMp3Bytes(mem.data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(mp3.AudioBytes(), audio) {
+		t.Errorf("Save: expected the audio left in place")
+	}
+	if tags := mp3.TrailingTags(); len(tags) != 3 || tags[1].Kind != "ID3v2" {
+		t.Errorf("Save: expected a single appended tag, got %v", tags)
+	}
+
+	last, err := ParseRW(mem, int64(len(mem.data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.Title() != "Appended" || last.Artist() != "Later" || last.Frame("PRIV") == nil {
+		t.Errorf("Save: expected the edits appended")
+	}
+}
+
+func TestJunk(t *testing.T) {
+	data, audio := taggedFixture(t)
+	leading := int64(len(data) - len(audio) - 42 - 128)
+
+	mp3, err := NewMp3Bytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset, size, err := mp3.Junk(); err != nil || offset != leading || size != 0 {
+		t.Errorf("Junk: expected none at %d, got %d bytes at %d, %v", leading, size, offset, err)
+	}
+
+	junk := bytes.Repeat([]byte("junk"), 75)
+	data = append(append(append([]byte(nil), data[:leading]...), junk...), data[leading:]...)
+	name := filepath.Join(t.TempDir(), "junk.mp3")
+	if err := ioutil.WriteFile(name, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset, size, err := file.Junk(); err != nil || offset != leading || size != int64(len(junk)) {
+		t.Errorf("Junk: expected %d bytes at %d, got %d at %d, %v", len(junk), leading, size, offset, err)
+	}
+
+	file.SetRemoveJunk(true)
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after) != len(data) {
+		t.Errorf("SetRemoveJunk: expected the file size kept, got %d bytes, want %d", len(after), len(data))
+	}
+
+	mp3, err = NewMp3Bytes(after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, size, err := mp3.Junk(); err != nil || size != 0 {
+		t.Errorf("SetRemoveJunk: expected no junk left, got %d bytes, %v", size, err)
+	}
+	if !bytes.Equal(mp3.AudioBytes(), audio) || mp3.Title() != "Nice Life (Feat. Basick)" {
+		t.Errorf("SetRemoveJunk: expected the tag and audio intact")
+	}
+}
+
+func TestV1Genre(t *testing.T) {
+	data := make([]byte, v1.TagSize)
+	copy(data, "TAGTitle")
+	data[127] = 189
+
+	tag := v1.ParseTag(bytes.NewReader(data))
+	if genre := tag.Genre(); genre != "Dubstep" {
+		t.Errorf("Genre: expected the Winamp genre Dubstep, got %q", genre)
+	}
+
+	for text, want := range map[string]string{
+		"hip hop":       "Hip-Hop",
+		"Drum and Bass": "Drum & Bass",
+		"(17)":          "Rock",
+		"Psychedelic":   "Psychadelic",
+		"Zydeco":        "Other",
+	} {
+		tag.SetGenre(text)
+		if tag.Genre() != want {
+			t.Errorf("SetGenre(%q): expected %q, got %q", text, want, tag.Genre())
+		}
+	}
+
+	tag.SetGenre("")
+	if b := tag.Bytes(); b[127] != v1.NoGenre || tag.Genre() != "" {
+		t.Errorf("SetGenre: expected no genre, got byte %d", b[127])
+	}
+}
+
+func TestV1Latin1Error(t *testing.T) {
+	_, audio := taggedFixture(t)
+	data := make([]byte, v1.TagSize)
+	copy(data, "TAGNice Life")
+	data = append(append([]byte(nil), audio...), data...)
+
+	mp3, err := NewMp3Bytes(append([]byte(nil), data...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mp3.SetArtist("팔로알토")
+	if b := mp3.Tagger.Bytes(); string(b[33:37]) != "????" {
+		t.Errorf("Bytes: expected the artist replaced with '?', got %q", b[33:63])
+	}
+	if _, err := mp3.Update(); !errors.Is(err, encodedbytes.ErrBadEncoding) {
+		t.Errorf("Update: expected ErrBadEncoding under Latin1Error, got %v", err)
+	}
+	if _, err := mp3.WriteTo(io.Discard); !errors.Is(err, encodedbytes.ErrBadEncoding) {
+		t.Errorf("WriteTo: expected ErrBadEncoding under Latin1Error, got %v", err)
+	}
+
+	encodedbytes.SetLatin1Policy(encodedbytes.Latin1Replace)
+	defer encodedbytes.SetLatin1Policy(encodedbytes.Latin1Error)
+	if updated, err := mp3.Update(); err != nil || string(updated[len(updated)-v1.TagSize+33:][:4]) != "????" {
+		t.Errorf("Update: expected the artist replaced under Latin1Replace, got %v", err)
+	}
+}
+
+func TestV1ToV2(t *testing.T) {
+	data := make([]byte, v1.TagSize)
+	copy(data, "TAGNice Life")
+	copy(data[33:], "Paloalto")
+	copy(data[93:], "2010")
+	copy(data[97:], "Comment")
+	data[127] = 7
+
+	tag, err := v1.ParseTag(bytes.NewReader(data)).ToV2(4, "kor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag.Title() != "Nice Life" || tag.Artist() != "Paloalto" || tag.Year() != "2010" || tag.Genre() != "Hip-Hop" {
+		t.Errorf("ToV2: unexpected fields %q %q %q %q", tag.Title(), tag.Artist(), tag.Year(), tag.Genre())
+	}
+	if f, ok := tag.Frame("COMM").(*v2.UnsynchTextFrame); !ok || f.Text() != "Comment" || f.Language() != "kor" {
+		t.Errorf("ToV2: expected the comment in Korean")
+	}
+	if _, err := v1.ParseTag(bytes.NewReader(data)).ToV2(3, "ko"); !errors.Is(err, v2.ErrBadLanguage) {
+		t.Errorf("ToV2: expected ErrBadLanguage, got %v", err)
+	}
+}
+
+func TestPaddingPolicy(t *testing.T) {
+	if p := PadToMultiple(4096)(5000); p != 8192-5000-v2.HeaderSize {
+		t.Errorf("PadToMultiple: expected %d, got %d", 8192-5000-v2.HeaderSize, p)
+	}
+	if p := PadBy(1024)(5000); p != 1024 {
+		t.Errorf("PadBy: expected 1024, got %d", p)
+	}
+
+	data, err := ioutil.ReadFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(t.TempDir(), "padding.mp3")
+	if err := ioutil.WriteFile(name, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	file.SetPaddingPolicy(PadToMultiple(4096))
+
+	file.SetTitle(strings.Repeat("Long title ", 10000))
+	p, err := file.PreviewSave()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Bytes)%4096 != 0 {
+		t.Errorf("PreviewSave: expected a multiple of 4096 bytes, got %d", len(p.Bytes))
+	}
+	if err := file.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(after[:len(p.Bytes)], p.Bytes) || int64(len(after)) != int64(len(data))+p.ShiftBy {
+		t.Errorf("Save: expected the bytes and shift PreviewSave reported")
+	}
+	if file.Padding() == 0 {
+		t.Errorf("Save: expected padding left for later edits")
+	}
+
+	// The padding takes a small edit without moving the audio again
+	file.SetArtist("Someone")
+	if err := file.SaveInPlace(); err != nil {
+		t.Errorf("SaveInPlace: %v", err)
+	}
+}
+
+func TestStripV1(t *testing.T) {
+	before, err := ioutil.ReadFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	audio := before[81919:]
+
+	ape := make([]byte, 32+10)
+	copy(ape[10:], "APETAGEX")
+	binary.LittleEndian.PutUint32(ape[18:], 2000)
+	binary.LittleEndian.PutUint32(ape[22:], 10+32)
+
+	lyrics := "LYRICSBEGININD0000210"
+	lyrics += fmt.Sprintf("%06d", len(lyrics)) + "LYRICS200"
+
+	enhanced := make([]byte, 227)
+	copy(enhanced, "TAG+Nice Life")
+	v1Tag := make([]byte, 128)
+	copy(v1Tag, "TAGNice Life")
+
+	for _, test := range []struct {
+		name     string
+		data     []byte
+		expected []byte
+	}{
+		{"v2", concat(before, ape, []byte(lyrics), enhanced, v1Tag), concat(before, ape)},
+		{"v1", concat(audio, v1Tag), audio},
+		{"none", concat(before, ape), concat(before, ape)},
+	} {
+		name := filepath.Join(t.TempDir(), "strip.mp3")
+		if err := ioutil.WriteFile(name, test.data, 0666); err != nil {
+			t.Fatal(err)
+		}
+
+		file, err := Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cached, _ := file.AudioProperties()
+		if err := file.StripV1(); err != nil {
+			t.Errorf("%s: StripV1: %v", test.name, err)
+		}
+		if _, ok := file.Tagger.(*v2.Tag); !ok {
+			t.Errorf("%s: expected an ID3v2 tag, got %T", test.name, file.Tagger)
+		}
+		props, err := file.AudioProperties()
+		if stripped := len(test.data) != len(test.expected); err != nil || stripped && props == cached {
+			t.Errorf("%s: AudioProperties: expected the audio read again after stripping, %v", test.name, err)
+		}
+		if err := file.Close(); err != nil {
+			t.Errorf("%s: Close: %v", test.name, err)
+		}
+
+		reopened, err := Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fresh, err := reopened.AudioProperties()
+		reopened.Close()
+		if err != nil || props == nil || props.Offset != fresh.Offset || props.Size != fresh.Size || props.Duration != fresh.Duration {
+			t.Errorf("%s: AudioProperties: expected %+v after stripping, got %+v, %v", test.name, fresh, props, err)
+		}
+
+		after, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(after, test.expected) {
+			t.Errorf("%s: expected %d bytes, got %d", test.name, len(test.expected), len(after))
+		}
+	}
+}
+
+func concat(parts ...[]byte) []byte {
+	var data []byte
+	for _, p := range parts {
+		data = append(data, p...)
+	}
+	return data
+}
+
+func TestLyrics3BadSize(t *testing.T) {
+	v1Tag := make([]byte, 128)
+	copy(v1Tag, "TAG")
+
+	lyrics := "LYRICSBEGININD0000210"
+	lyrics += fmt.Sprintf("%06d", len(lyrics)) + "LYRICS200"
+
+	// The bad trailer sits right before a valid block, so a size of zero
+	// would find that block's LYRICSBEGIN again and again
+	for _, size := range []string{"-00015", "000000", "+00015", "00 015"} {
+		data := concat([]byte("audio"), []byte(size+"LYRICS200"), []byte(lyrics), v1Tag)
+
+		done := make(chan []TrailingTag)
+		go func() {
+			b := &Mp3Bytes{blob: data}
+			done <- b.TrailingTags()
+		}()
+
+		select {
+		case tags := <-done:
+			if len(tags) != 2 || tags[0].Kind != "Lyrics3v2" || tags[1].Kind != "ID3v1" {
+				t.Errorf("%q: expected the valid Lyrics3 block and the ID3v1 tag, got %v", size, tags)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%q: TrailingTags did not return", size)
+		}
+	}
+}
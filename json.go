@@ -0,0 +1,65 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package id3
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	v2 "github.com/lion187chen/id3-go/v2"
+)
+
+// JSON document for tags without frames
+type jsonTagger struct {
+	Version  string   `json:"version"`
+	Title    string   `json:"title,omitempty"`
+	Artist   string   `json:"artist,omitempty"`
+	Album    string   `json:"album,omitempty"`
+	Year     string   `json:"year,omitempty"`
+	Genre    string   `json:"genre,omitempty"`
+	Comments []string `json:"comments,omitempty"`
+}
+
+// Writes the tag of a file as JSON
+// ID3v2 tags are written with all frames, other tags with the common fields
+func DumpJSON(w io.Writer, file *File, opts v2.JSONOptions) error {
+	var data []byte
+	var err error
+
+	if tag, ok := file.Tagger.(*v2.Tag); ok {
+		data, err = tag.JSON(opts)
+	} else {
+		doc := jsonTagger{
+			Version: file.Version(),
+			Title:   trimField(file.Title()),
+			Artist:  trimField(file.Artist()),
+			Album:   trimField(file.Album()),
+			Year:    trimField(file.Year()),
+			Genre:   trimField(file.Genre()),
+		}
+		for _, c := range file.Comments() {
+			if c = trimField(c); c != "" {
+				doc.Comments = append(doc.Comments, c)
+			}
+		}
+
+		if opts.Indent {
+			data, err = json.MarshalIndent(doc, "", "  ")
+		} else {
+			data, err = json.Marshal(doc)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// ID3v1 fields are padded with nulls or spaces
+func trimField(s string) string {
+	return strings.TrimRight(s, "\x00 ")
+}
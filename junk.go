@@ -0,0 +1,72 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package id3
+
+import (
+	"bytes"
+
+	"github.com/lion187chen/id3-go/mpeg"
+	v2 "github.com/lion187chen/id3-go/v2"
+)
+
+// Bytes between the leading ID3v2 tags and the first MPEG audio frame, which
+// some encoders leave behind, such as a stale partial tag
+// Returns the offset and size of the gap, a zero size when the audio follows
+// the tags directly. Without MPEG audio it fails with mpeg.ErrNoFrame.
+func (f *File) Junk() (offset, size int64, err error) {
+	fileSize, err := f.file.Size()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return findJunk(f.file, fileSize)
+}
+
+// Junk is like File.Junk above but for in memory mp3 data
+func (b *Mp3Bytes) Junk() (offset, size int64, err error) {
+	return findJunk(bytes.NewReader(b.blob), int64(len(b.blob)))
+}
+
+func findJunk(r readSeekerAt, size int64) (int64, int64, error) {
+	start, end, err := audioRegion(r, size)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	first, _, err := mpeg.FindFrame(r, start, end)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return start, first - start, nil
+}
+
+// Sets whether Save removes junk between the tag and the audio
+// The junk becomes padding of the tag, so the tag ends where the first audio
+// frame starts and the audio is not moved. Saving then writes the tag even
+// without edits. Junk after chained tags is kept, CollapseTags first.
+func (f *File) SetRemoveJunk(remove bool) {
+	f.removeJunk = remove
+}
+
+// Grows the space of the tag over the junk following it, marking the tag
+// dirty so that it is written padded over it
+func (f *File) absorbJunk() error {
+	tag, ok := f.Tagger.(*v2.Tag)
+	if !ok || f.appended {
+		return nil
+	}
+
+	offset, size, err := f.Junk()
+	if err == mpeg.ErrNoFrame {
+		return nil
+	}
+	if err != nil || size == 0 || offset != int64(v2.HeaderSize+f.originalSize) {
+		return err
+	}
+
+	f.originalSize += int(size)
+	tag.MarkDirty()
+	return nil
+}
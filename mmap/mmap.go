@@ -0,0 +1,77 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package mmap reads ID3v2 tags through memory-mapped files
+// Only the tag region is mapped, and frames are views into the mapping, so
+// scanning large libraries does not copy picture data that is never looked at.
+// On systems without mmap the tag region is read into memory instead.
+package mmap
+
+import (
+	"os"
+
+	v2 "github.com/lion187chen/id3-go/v2"
+)
+
+// Tag is an ID3v2 tag whose frames refer to the mapped file
+// Pages written to are copied privately, the file itself is never changed.
+// Neither the tag nor its frames may be used after Close, and neither may the
+// slices they return, such as the Data of a picture, which point into the
+// mapping: touching them after Close crashes the program with SIGSEGV or
+// SIGBUS. Clone gives a copy that outlives the mapping, and bytes.Clone does
+// the same for a single slice.
+type Tag struct {
+	*v2.Tag
+	data []byte
+}
+
+// Maps the ID3v2 tag at the start of the named file
+// A nil tag and nil error are returned when no tag is present.
+func Open(name string, opts v2.ParseOptions) (*Tag, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	header := v2.ParseHeader(file)
+	if header == nil {
+		return nil, nil
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	size := int64(v2.HeaderSize + header.Size())
+	if size > stat.Size() {
+		size = stat.Size()
+	}
+
+	data, err := mapRegion(file, int(size))
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := v2.ParseTagData(data, opts)
+	if err != nil || tag == nil {
+		unmapRegion(data)
+		return nil, err
+	}
+
+	return &Tag{Tag: tag, data: data}, nil
+}
+
+// Releases the mapping
+// Slices obtained from the tag's frames are invalid afterwards.
+func (t *Tag) Close() error {
+	if t.data == nil {
+		return nil
+	}
+
+	data := t.data
+	t.data = nil
+	return unmapRegion(data)
+}
@@ -0,0 +1,26 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build !unix
+
+package mmap
+
+import (
+	"io"
+	"os"
+)
+
+// Reads the first size bytes of the file where mmap is not available
+func mapRegion(file *os.File, size int) ([]byte, error) {
+	data := make([]byte, size)
+	if _, err := file.ReadAt(data, 0); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func unmapRegion(data []byte) error {
+	return nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package mmap
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v2 "github.com/lion187chen/id3-go/v2"
+)
+
+const testFile = "../test.mp3"
+
+func TestOpen(t *testing.T) {
+	file, err := os.Open(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	want := v2.ParseTag(file)
+
+	tag, err := Open(testFile, v2.ParseOptions{})
+	if err != nil || tag == nil {
+		t.Fatalf("Open: expected a tag, got %v", err)
+	}
+
+	if tag.Title() != want.Title() || len(tag.AllFrames()) != len(want.AllFrames()) {
+		t.Errorf("Open: expected the tag ParseTag reads")
+	}
+	if !bytes.Equal(tag.Bytes(), want.Bytes()) {
+		t.Errorf("Open: expected the same bytes as ParseTag")
+	}
+
+	clone := tag.Clone()
+	if err := tag.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(clone.Bytes(), want.Bytes()) {
+		t.Errorf("Clone: expected a copy usable after Close")
+	}
+	for _, f := range clone.AllFrames() {
+		f.RawBytes()
+	}
+}
+
+func TestOpenNoTag(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "notag.mp3")
+	if err := os.WriteFile(name, make([]byte, 1024), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	if tag, err := Open(name, v2.ParseOptions{}); tag != nil || err != nil {
+		t.Errorf("Open: expected no tag, got %v, %v", tag, err)
+	}
+}
@@ -0,0 +1,21 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build unix
+
+package mmap
+
+import (
+	"os"
+	"syscall"
+)
+
+// Maps the first size bytes of the file as private, writable pages
+func mapRegion(file *os.File, size int) ([]byte, error) {
+	return syscall.Mmap(int(file.Fd()), 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE)
+}
+
+func unmapRegion(data []byte) error {
+	return syscall.Munmap(data)
+}
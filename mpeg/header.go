@@ -0,0 +1,181 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package mpeg reads the properties of MPEG audio streams
+package mpeg
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	HeaderSize = 4
+)
+
+// Version of the MPEG audio standard
+type Version byte
+
+const (
+	Version25 Version = iota
+	versionReserved
+	Version2
+	Version1
+)
+
+func (v Version) String() string {
+	switch v {
+	case Version1:
+		return "MPEG-1"
+	case Version2:
+		return "MPEG-2"
+	case Version25:
+		return "MPEG-2.5"
+	}
+
+	return fmt.Sprintf("version(%d)", byte(v))
+}
+
+// Layer of the MPEG audio standard
+type Layer byte
+
+const (
+	layerReserved Layer = iota
+	Layer3
+	Layer2
+	Layer1
+)
+
+func (l Layer) String() string {
+	switch l {
+	case Layer1:
+		return "Layer I"
+	case Layer2:
+		return "Layer II"
+	case Layer3:
+		return "Layer III"
+	}
+
+	return fmt.Sprintf("layer(%d)", byte(l))
+}
+
+// ChannelMode of an MPEG audio frame
+type ChannelMode byte
+
+const (
+	Stereo ChannelMode = iota
+	JointStereo
+	DualChannel
+	Mono
+)
+
+func (m ChannelMode) String() string {
+	switch m {
+	case Stereo:
+		return "Stereo"
+	case JointStereo:
+		return "Joint stereo"
+	case DualChannel:
+		return "Dual channel"
+	case Mono:
+		return "Mono"
+	}
+
+	return fmt.Sprintf("mode(%d)", byte(m))
+}
+
+var (
+	ErrBadHeader = errors.New("mpeg: invalid frame header")
+	ErrNoFrame   = errors.New("mpeg: no audio frame found")
+)
+
+var (
+	// Bitrates in kbit/s by MPEG-1 layer
+	v1Bitrates = map[Layer][15]int{
+		Layer1: {0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+		Layer2: {0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+		Layer3: {0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	}
+
+	// Bitrates in kbit/s by MPEG-2 and MPEG-2.5 layer
+	v2Bitrates = map[Layer][15]int{
+		Layer1: {0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+		Layer2: {0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		Layer3: {0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	}
+
+	sampleRates = map[Version][3]int{
+		Version1:  {44100, 48000, 32000},
+		Version2:  {22050, 24000, 16000},
+		Version25: {11025, 12000, 8000},
+	}
+)
+
+// Header of a single MPEG audio frame
+type Header struct {
+	Version     Version
+	Layer       Layer
+	Protected   bool
+	Bitrate     int // kbit/s
+	SampleRate  int // Hz
+	Padding     bool
+	ChannelMode ChannelMode
+}
+
+// Parses the four byte header at the start of data
+// Free format streams, which do not declare a bitrate, are not supported.
+func ParseHeader(data []byte) (Header, error) {
+	if len(data) < HeaderSize || data[0] != 0xFF || data[1]&0xE0 != 0xE0 {
+		return Header{}, ErrBadHeader
+	}
+
+	h := Header{
+		Version:     Version(data[1] >> 3 & 3),
+		Layer:       Layer(data[1] >> 1 & 3),
+		Protected:   data[1]&1 == 0,
+		Padding:     data[2]>>1&1 == 1,
+		ChannelMode: ChannelMode(data[3] >> 6),
+	}
+
+	bitrateIndex := data[2] >> 4
+	sampleRateIndex := data[2] >> 2 & 3
+	if h.Version == versionReserved || h.Layer == layerReserved || bitrateIndex == 0 || bitrateIndex == 15 || sampleRateIndex == 3 {
+		return Header{}, ErrBadHeader
+	}
+
+	if h.Version == Version1 {
+		h.Bitrate = v1Bitrates[h.Layer][bitrateIndex]
+	} else {
+		h.Bitrate = v2Bitrates[h.Layer][bitrateIndex]
+	}
+	h.SampleRate = sampleRates[h.Version][sampleRateIndex]
+
+	return h, nil
+}
+
+// Audio samples per channel in the frame
+func (h Header) Samples() int {
+	switch {
+	case h.Layer == Layer1:
+		return 384
+	case h.Layer == Layer3 && h.Version != Version1:
+		return 576
+	}
+
+	return 1152
+}
+
+// Size of the frame in bytes, including the header
+func (h Header) FrameSize() int {
+	padding := 0
+	if h.Padding {
+		padding = 1
+	}
+
+	if h.Layer == Layer1 {
+		return (12*h.Bitrate*1000/h.SampleRate + padding) * 4
+	}
+
+	return h.Samples()/8*h.Bitrate*1000/h.SampleRate + padding
+}
@@ -0,0 +1,146 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package mpeg
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// MPEG-1 Layer III frame with the given bitrate index at 44.1kHz
+func testFrame(bitrateIndex byte, mode ChannelMode) []byte {
+	head := []byte{0xFF, 0xFB, bitrateIndex<<4 | 0<<2, byte(mode) << 6}
+	h, err := ParseHeader(head)
+	if err != nil {
+		panic(err)
+	}
+
+	frame := make([]byte, h.FrameSize())
+	copy(frame, head)
+	return frame
+}
+
+func TestParseHeader(t *testing.T) {
+	h, err := ParseHeader([]byte{0xFF, 0xFB, 0x90, 0x64})
+	if err != nil {
+		t.Fatalf("ParseHeader: %v", err)
+	}
+
+	if h.Version != Version1 || h.Layer != Layer3 || h.Bitrate != 128 || h.SampleRate != 44100 || h.ChannelMode != JointStereo {
+		t.Errorf("ParseHeader: unexpected header %+v", h)
+	}
+	if s := h.FrameSize(); s != 417 {
+		t.Errorf("FrameSize: expected 417, got %d", s)
+	}
+
+	// MPEG-2 Layer III, 64kbit/s, 22.05kHz, padded
+	h, err = ParseHeader([]byte{0xFF, 0xF3, 0x82, 0xC0})
+	if err != nil {
+		t.Fatalf("ParseHeader: %v", err)
+	}
+	if h.Version != Version2 || h.Samples() != 576 || h.FrameSize() != 209 || h.ChannelMode != Mono {
+		t.Errorf("ParseHeader: unexpected MPEG-2 header %+v", h)
+	}
+
+	for _, head := range [][]byte{
+		{0xFF, 0xFB, 0xF0, 0x00}, // bad bitrate
+		{0xFF, 0xFB, 0x0C, 0x00}, // free format, reserved sample rate
+		{0xFF, 0xE9, 0x90, 0x00}, // reserved version
+		{0xFF, 0xF9, 0x90, 0x00}, // reserved layer
+		{0xFE, 0xFB, 0x90, 0x00},
+	} {
+		if _, err := ParseHeader(head); err != ErrBadHeader {
+			t.Errorf("ParseHeader: expected error for % x", head)
+		}
+	}
+}
+
+func TestReadProperties(t *testing.T) {
+	var cbr bytes.Buffer
+	cbr.WriteString("junk")
+	for i := 0; i < 100; i++ {
+		cbr.Write(testFrame(9, Stereo))
+	}
+
+	p, err := ReadProperties(bytes.NewReader(cbr.Bytes()), 0, int64(cbr.Len()))
+	if err != nil {
+		t.Fatalf("ReadProperties: %v", err)
+	}
+	if p.Offset != 4 || p.VBR || p.Bitrate != 128 || p.SampleRate != 44100 || p.ChannelMode != Stereo {
+		t.Errorf("ReadProperties: unexpected CBR properties %+v", p)
+	}
+	if expected := time.Duration(417*100*8) * time.Millisecond / 128; p.Duration != expected {
+		t.Errorf("ReadProperties: expected CBR duration %v, got %v", expected, p.Duration)
+	}
+
+	var vbr bytes.Buffer
+	for i := 0; i < 100; i++ {
+		index := byte(9)
+		if i%2 == 1 {
+			index = 11
+		}
+		vbr.Write(testFrame(index, Mono))
+	}
+
+	p, err = ReadProperties(bytes.NewReader(vbr.Bytes()), 0, int64(vbr.Len()))
+	if err != nil {
+		t.Fatalf("ReadProperties: %v", err)
+	}
+	if !p.VBR || p.Bitrate < 128 || p.Bitrate > 192 || p.ChannelMode != Mono {
+		t.Errorf("ReadProperties: unexpected VBR properties %+v", p)
+	}
+	if expected := 100 * 1152 * time.Second / 44100; p.Duration != expected {
+		t.Errorf("ReadProperties: expected VBR duration %v, got %v", expected, p.Duration)
+	}
+
+	if _, err := ReadProperties(bytes.NewReader(make([]byte, 1000)), 0, 1000); err != ErrNoFrame {
+		t.Errorf("ReadProperties: expected ErrNoFrame, got %v", err)
+	}
+}
+
+func TestVBRHeader(t *testing.T) {
+	// Stereo MPEG-1 Layer III: the Xing header follows 32 bytes of side info
+	xing := testFrame(9, Stereo)
+	copy(xing[36:], "Xing\x00\x00\x00\x03\x00\x00\x03\xe8\x00\x06\x5d\x58")
+	h, _ := ParseHeader(xing)
+
+	v := ParseVBRHeader(xing, h)
+	if v == nil || v.Id != "Xing" || v.Frames != 1000 || v.Bytes != 417112 || !v.VBR() {
+		t.Fatalf("ParseVBRHeader: unexpected Xing header %+v", v)
+	}
+
+	var stream bytes.Buffer
+	stream.Write(xing)
+	for i := 0; i < 10; i++ {
+		stream.Write(testFrame(11, Stereo))
+	}
+
+	p, err := ReadProperties(bytes.NewReader(stream.Bytes()), 0, int64(stream.Len()))
+	if err != nil {
+		t.Fatalf("ReadProperties: %v", err)
+	}
+	if expected := 1000 * 1152 * time.Second / 44100; p.Duration != expected {
+		t.Errorf("ReadProperties: expected Xing duration %v, got %v", expected, p.Duration)
+	}
+	if !p.VBR || p.VBRHeader == nil || p.Offset != 417 || p.Size != 417112-417 {
+		t.Errorf("ReadProperties: unexpected Xing properties %+v", p)
+	}
+
+	info := testFrame(9, Mono)
+	copy(info[21:], "Info\x00\x00\x00\x01\x00\x00\x00\x64")
+	if v := ParseVBRHeader(info, h); v != nil {
+		t.Errorf("ParseVBRHeader: found header at stereo offset in mono frame")
+	}
+	h, _ = ParseHeader(info)
+	if v := ParseVBRHeader(info, h); v == nil || v.Frames != 100 || v.Bytes != 0 || v.VBR() {
+		t.Errorf("ParseVBRHeader: unexpected Info header %+v", v)
+	}
+
+	vbri := testFrame(9, Stereo)
+	copy(vbri[36:], "VBRI\x00\x01\x00\x00\x00\x50\x00\x01\x00\x00\x00\x00\x01\xf4")
+	if v := ParseVBRHeader(vbri, h); v == nil || v.Id != "VBRI" || v.Frames != 500 || v.Bytes != 65536 {
+		t.Errorf("ParseVBRHeader: unexpected VBRI header %+v", v)
+	}
+}
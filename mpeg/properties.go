@@ -0,0 +1,173 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package mpeg
+
+import (
+	"bufio"
+	"io"
+	"time"
+)
+
+const (
+	// Bytes searched for the first frame, to skip junk after a tag
+	maxSyncSearch = 64 * 1024
+	// Frames with the same bitrate that mark a stream as CBR
+	cbrFrames = 32
+)
+
+// Properties of an MPEG audio stream
+type Properties struct {
+	Version     Version
+	Layer       Layer
+	SampleRate  int // Hz
+	ChannelMode ChannelMode
+	Bitrate     int  // kbit/s, averaged over the stream when VBR
+	VBR         bool // Frames differ in bitrate
+	Duration    time.Duration
+	// Offset and size in bytes of the audio frames
+	Offset int64
+	Size   int64
+	// Xing, Info or VBRI header of the stream, nil when absent
+	VBRHeader *VBRHeader
+}
+
+// Reads the properties of the audio stream between start and end
+// The first frame is the first valid header whose successor, if any, is
+// valid too. A Xing, Info or VBRI header in that frame gives the length of
+// the stream. Without one, the stream is taken as CBR when the leading
+// frames share a bitrate and its duration computed from its size;
+// otherwise every frame is read.
+func ReadProperties(r io.ReaderAt, start, end int64) (*Properties, error) {
+	first, h, err := FindFrame(r, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Properties{
+		Version:     h.Version,
+		Layer:       h.Layer,
+		SampleRate:  h.SampleRate,
+		ChannelMode: h.ChannelMode,
+		Offset:      first,
+		Size:        end - first,
+	}
+
+	frame := make([]byte, h.FrameSize())
+	n, _ := r.ReadAt(frame, first)
+	if v := ParseVBRHeader(frame[:n], h); v != nil && v.Frames > 0 {
+		p.VBRHeader = v
+		p.applyVBRHeader(h, end)
+		return p, nil
+	}
+
+	br := bufio.NewReader(io.NewSectionReader(r, first, end-first))
+	buf := make([]byte, HeaderSize)
+
+	var frames, samples, size int64
+	for {
+		if _, err := io.ReadFull(br, buf); err != nil {
+			break
+		}
+
+		fh, err := ParseHeader(buf)
+		if err != nil || fh.Version != h.Version || fh.Layer != h.Layer {
+			break
+		}
+
+		frames++
+		samples += int64(fh.Samples())
+		size += int64(fh.FrameSize())
+		if fh.Bitrate != h.Bitrate {
+			p.VBR = true
+		}
+
+		if !p.VBR && frames == cbrFrames {
+			break
+		}
+
+		if _, err := br.Discard(fh.FrameSize() - HeaderSize); err != nil {
+			break
+		}
+	}
+
+	if !p.VBR {
+		p.Bitrate = h.Bitrate
+		p.Duration = time.Duration(p.Size * 8 * int64(time.Millisecond) / int64(h.Bitrate))
+		return p, nil
+	}
+
+	p.Size = size
+	p.Duration = time.Duration(samples * int64(time.Second) / int64(h.SampleRate))
+	p.Bitrate = int(size * 8 * int64(h.SampleRate) / samples / 1000)
+
+	return p, nil
+}
+
+// Sets the length of the stream from the counts in its VBR header
+func (p *Properties) applyVBRHeader(h Header, end int64) {
+	v := p.VBRHeader
+
+	// The header frame holds no audio
+	p.Offset += int64(h.FrameSize())
+	p.Size = end - p.Offset
+	if v.Bytes > 0 {
+		// The byte count includes the header frame
+		p.Size = int64(v.Bytes) - int64(h.FrameSize())
+	}
+	if p.Size < 0 {
+		p.Size = 0
+	}
+
+	samples := int64(v.Frames) * int64(h.Samples())
+	p.VBR = v.VBR()
+	p.Duration = time.Duration(samples * int64(time.Second) / int64(h.SampleRate))
+	p.Bitrate = int(p.Size * 8 * int64(h.SampleRate) / samples / 1000)
+}
+
+// Offset and header of the first frame between start and end
+// Up to 64 KiB of junk before the frame are skipped. A header only counts
+// when the header following its frame, if any, is valid too.
+func FindFrame(r io.ReaderAt, start, end int64) (int64, Header, error) {
+	size := end - start
+	if size > maxSyncSearch+HeaderSize {
+		size = maxSyncSearch + HeaderSize
+	}
+	if size < HeaderSize {
+		return 0, Header{}, ErrNoFrame
+	}
+
+	buf := make([]byte, size)
+	n, err := r.ReadAt(buf, start)
+	if err != nil && err != io.EOF {
+		return 0, Header{}, err
+	}
+	buf = buf[:n]
+
+	next := make([]byte, HeaderSize)
+	for i := 0; i+HeaderSize <= len(buf); i++ {
+		if buf[i] != 0xFF {
+			continue
+		}
+
+		h, err := ParseHeader(buf[i:])
+		if err != nil {
+			continue
+		}
+
+		offset := start + int64(i)
+		nextOffset := offset + int64(h.FrameSize())
+		if nextOffset+HeaderSize > end {
+			return offset, h, nil
+		}
+
+		if _, err := r.ReadAt(next, nextOffset); err != nil {
+			continue
+		}
+		if nh, err := ParseHeader(next); err == nil && nh.Version == h.Version && nh.Layer == h.Layer && nh.SampleRate == h.SampleRate {
+			return offset, h, nil
+		}
+	}
+
+	return 0, Header{}, ErrNoFrame
+}
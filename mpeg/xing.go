@@ -0,0 +1,86 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package mpeg
+
+import (
+	"encoding/binary"
+)
+
+const (
+	xingFrames = 1 << iota
+	xingBytes
+
+	// Offset of the VBRI header from the start of the frame
+	vbriOffset = HeaderSize + 32
+)
+
+// VBRHeader summarizes the stream in the Xing, Info or VBRI header stored
+// in place of the audio of its first frame
+type VBRHeader struct {
+	// "Xing", "Info" or "VBRI"
+	Id string
+	// Audio frames and bytes following the header frame, zero when unknown
+	Frames uint32
+	Bytes  uint32
+}
+
+// Whether the header marks a variable bitrate stream
+// LAME writes "Info" instead of "Xing" for CBR streams.
+func (v VBRHeader) VBR() bool {
+	return v.Id != "Info"
+}
+
+// Parses the Xing, Info or VBRI header in a frame, nil when absent
+func ParseVBRHeader(frame []byte, h Header) *VBRHeader {
+	offset := HeaderSize + sideInfoSize(h)
+	if h.Protected {
+		offset += 2
+	}
+
+	if len(frame) >= offset+8 {
+		id := string(frame[offset : offset+4])
+		if id == "Xing" || id == "Info" {
+			v := &VBRHeader{Id: id}
+			flags := binary.BigEndian.Uint32(frame[offset+4:])
+			data := frame[offset+8:]
+
+			if flags&xingFrames != 0 && len(data) >= 4 {
+				v.Frames = binary.BigEndian.Uint32(data)
+				data = data[4:]
+			}
+			if flags&xingBytes != 0 && len(data) >= 4 {
+				v.Bytes = binary.BigEndian.Uint32(data)
+			}
+
+			return v
+		}
+	}
+
+	if len(frame) >= vbriOffset+18 && string(frame[vbriOffset:vbriOffset+4]) == "VBRI" {
+		data := frame[vbriOffset:]
+		return &VBRHeader{
+			Id:     "VBRI",
+			Bytes:  binary.BigEndian.Uint32(data[10:]),
+			Frames: binary.BigEndian.Uint32(data[14:]),
+		}
+	}
+
+	return nil
+}
+
+// Size of the Layer III side information following the header
+func sideInfoSize(h Header) int {
+	mono := h.ChannelMode == Mono
+
+	switch {
+	case h.Version == Version1 && mono:
+		return 17
+	case h.Version == Version1:
+		return 32
+	case mono:
+		return 9
+	}
+
+	return 17
+}
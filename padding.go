@@ -0,0 +1,53 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package id3
+
+import v2 "github.com/lion187chen/id3-go/v2"
+
+// PaddingPolicy gives the padding of an ID3v2 tag that outgrew its space,
+// from the size of its frames
+type PaddingPolicy func(size int) int
+
+// Pads a grown tag so that it ends on a multiple of n bytes, header included
+func PadToMultiple(n int) PaddingPolicy {
+	return func(size int) int {
+		if n <= 0 {
+			return 0
+		}
+		total := size + v2.HeaderSize
+		return (total+n-1)/n*n - total
+	}
+}
+
+// Pads a grown tag with n bytes
+func PadBy(n int) PaddingPolicy {
+	return func(int) int {
+		return n
+	}
+}
+
+// Sets the padding Save gives an ID3v2 tag that outgrew its space
+// By default the audio moves by only the bytes the tag lacks, so the next
+// edit that adds to the tag moves it again. A policy leaves room for later
+// edits, such as PadToMultiple(4096). Nil restores the default.
+func (f *File) SetPaddingPolicy(policy PaddingPolicy) {
+	f.paddingPolicy = policy
+}
+
+// Padding the policy gives the tag, when it outgrew its space
+// The tag still fills the space of the old one, so a policy giving less
+// padding than it lacks leaves the audio in place.
+func (f *File) policyPadding(tag *v2.Tag) (uint, bool) {
+	if f.paddingPolicy == nil || f.appended || tag.Size() <= f.originalSize {
+		return 0, false
+	}
+
+	size := tag.RealSize()
+	padding := max(f.paddingPolicy(size), 0)
+	if size+padding < f.originalSize {
+		padding = f.originalSize - size
+	}
+
+	return uint(padding), true
+}
@@ -0,0 +1,167 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package id3
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Storage a File reads its tags from and saves them to
+type storage interface {
+	io.ReadWriteSeeker
+	io.ReaderAt
+	io.WriterAt
+	io.Closer
+	Size() (int64, error)
+	Truncate(size int64) error
+}
+
+// An *os.File as storage
+type osFile struct {
+	*os.File
+}
+
+func (f osFile) Size() (int64, error) {
+	stat, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	return stat.Size(), nil
+}
+
+// Any io.ReadWriteSeeker as storage
+// Reads and writes at an offset seek there and back, so every call takes the
+// lock, and the size is tracked as the data is written.
+type rwsFile struct {
+	rws  io.ReadWriteSeeker
+	mu   sync.Mutex
+	pos  int64
+	size int64
+}
+
+func (f *rwsFile) Read(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.read(p)
+}
+
+func (f *rwsFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.write(p)
+}
+
+func (f *rwsFile) Seek(offset int64, whence int) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.seek(offset, whence)
+}
+
+func (f *rwsFile) read(p []byte) (int, error) {
+	n, err := f.rws.Read(p)
+	f.pos += int64(n)
+	return n, err
+}
+
+func (f *rwsFile) write(p []byte) (int, error) {
+	n, err := f.rws.Write(p)
+	f.pos += int64(n)
+	if f.pos > f.size {
+		f.size = f.pos
+	}
+	return n, err
+}
+
+func (f *rwsFile) seek(offset int64, whence int) (int64, error) {
+	pos, err := f.rws.Seek(offset, whence)
+	if err == nil {
+		f.pos = pos
+	}
+	return pos, err
+}
+
+// Calls fn with the data positioned at off, then returns to the offset it
+// had before, as ReadAt and WriteAt must not move it
+func (f *rwsFile) at(off int64, fn func() (int, error)) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	pos := f.pos
+	if _, err := f.seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	n, err := fn()
+	if _, serr := f.seek(pos, io.SeekStart); serr != nil && err == nil {
+		err = serr
+	}
+	return n, err
+}
+
+func (f *rwsFile) ReadAt(p []byte, off int64) (int, error) {
+	return f.at(off, func() (int, error) {
+		n, err := io.ReadFull(readerFunc(f.read), p)
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		return n, err
+	})
+}
+
+func (f *rwsFile) WriteAt(p []byte, off int64) (int, error) {
+	return f.at(off, func() (int, error) {
+		return f.write(p)
+	})
+}
+
+// Function as an io.Reader
+type readerFunc func(p []byte) (int, error)
+
+func (r readerFunc) Read(p []byte) (int, error) {
+	return r(p)
+}
+
+func (f *rwsFile) Size() (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.size, nil
+}
+
+// Shrinks the data if the underlying value has a Truncate method
+func (f *rwsFile) Truncate(size int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if size >= f.size {
+		return nil
+	}
+
+	t, ok := f.rws.(interface{ Truncate(int64) error })
+	if !ok {
+		return fmt.Errorf("file: %T cannot be truncated", f.rws)
+	}
+	if err := t.Truncate(size); err != nil {
+		return err
+	}
+
+	f.size = size
+	return nil
+}
+
+// Closes the underlying value if it is an io.Closer
+func (f *rwsFile) Close() error {
+	if c, ok := f.rws.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
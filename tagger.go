@@ -0,0 +1,188 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package id3
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/lion187chen/id3-go/encodedbytes"
+	v1 "github.com/lion187chen/id3-go/v1"
+	v2 "github.com/lion187chen/id3-go/v2"
+)
+
+// TaggerV2 is Tagger with mutating operations and serialization that report
+// what a tag cannot store instead of dropping or mangling it
+type TaggerV2 interface {
+	Title() string
+	Artist() string
+	Album() string
+	Year() string
+	Genre() string
+	Length() int
+	Comments() []string
+	SetTitle(string) error
+	SetArtist(string) error
+	SetAlbum(string) error
+	SetYear(string) error
+	SetGenre(string) error
+	SetLength(int) error
+	AllFrames() []v2.Framer
+	Frames(string) []v2.Framer
+	Frame(string) v2.Framer
+	DeleteFrames(string) []v2.Framer
+	DeleteFrame(v2.Framer) []v2.Framer
+	AddFrames(...v2.Framer) error
+	Bytes() ([]byte, error)
+	Dirty() bool
+	Padding() uint
+	Size() int
+	Version() string
+}
+
+// Checked returns a TaggerV2 that changes the same tag as t
+// Files and Mp3Bytes are unwrapped to their tag. ID3v2 setters fail as
+// Tag.SetFrameText does, ID3v1 setters on text that does not fit its field
+// or is not ISO-8859-1. Taggers of other types never fail.
+func Checked(t Tagger) TaggerV2 {
+	switch tag := innerTagger(t).(type) {
+	case *v2.Tag:
+		return checkedV2{checkedTagger{tag}, tag}
+	case *v1.Tag:
+		return checkedV1{checkedTagger{tag}, tag}
+	}
+
+	return checkedTagger{innerTagger(t)}
+}
+
+// Wraps a tagger whose operations cannot fail
+type checkedTagger struct {
+	Tagger
+}
+
+func (t checkedTagger) SetTitle(s string) error  { t.Tagger.SetTitle(s); return nil }
+func (t checkedTagger) SetArtist(s string) error { t.Tagger.SetArtist(s); return nil }
+func (t checkedTagger) SetAlbum(s string) error  { t.Tagger.SetAlbum(s); return nil }
+func (t checkedTagger) SetYear(s string) error   { t.Tagger.SetYear(s); return nil }
+func (t checkedTagger) SetGenre(s string) error  { t.Tagger.SetGenre(s); return nil }
+func (t checkedTagger) SetLength(n int) error    { t.Tagger.SetLength(n); return nil }
+
+func (t checkedTagger) AddFrames(frames ...v2.Framer) error {
+	t.Tagger.AddFrames(frames...)
+	return nil
+}
+
+func (t checkedTagger) Bytes() ([]byte, error) {
+	return encodeTag(t.Tagger)
+}
+
+type checkedV2 struct {
+	checkedTagger
+	tag *v2.Tag
+}
+
+func (t checkedV2) SetTitle(s string) error  { return t.tag.SetFrameText("TIT2", s) }
+func (t checkedV2) SetArtist(s string) error { return t.tag.SetFrameText("TPE1", s) }
+func (t checkedV2) SetAlbum(s string) error  { return t.tag.SetFrameText("TALB", s) }
+func (t checkedV2) SetGenre(s string) error  { return t.tag.SetFrameText("TCON", s) }
+
+// Sets the year in TYER, or in TDRC from ID3v2.4 on
+func (t checkedV2) SetYear(s string) error {
+	if t.tag.Version()[2] >= '4' {
+		return t.tag.SetFrameText("TDRC", s)
+	}
+	return t.tag.SetFrameText("TYER", s)
+}
+
+func (t checkedV2) SetLength(n int) error {
+	if n < 0 {
+		return fmt.Errorf("%w: negative length %d", ErrBadValue, n)
+	}
+	return t.tag.SetFrameText("TLEN", strconv.Itoa(n))
+}
+
+// Adds the frames if the version allows all of them
+func (t checkedV2) AddFrames(frames ...v2.Framer) error {
+	version := t.tag.Version()[2] - '0'
+	for _, f := range frames {
+		if !v2.ValidFrameId(version, f.Id()) {
+			return fmt.Errorf("%w: %s", ErrFrameNotAllowed, f.Id())
+		}
+	}
+
+	t.tag.AddFrames(frames...)
+	return nil
+}
+
+type checkedV1 struct {
+	checkedTagger
+	tag *v1.Tag
+}
+
+// Checks text for an ID3v1 field of the size
+func checkV1Field(name, s string, size int) error {
+	b, err := encodedbytes.EncodedStringBytes(s, 0)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, ErrBadEncoding)
+	}
+	if len(b) > size {
+		return fmt.Errorf("%w: %s longer than %d bytes", ErrBadValue, name, size)
+	}
+
+	return nil
+}
+
+func (t checkedV1) SetTitle(s string) error {
+	if err := checkV1Field("title", s, 30); err != nil {
+		return err
+	}
+	t.tag.SetTitle(s)
+	return nil
+}
+
+func (t checkedV1) SetArtist(s string) error {
+	if err := checkV1Field("artist", s, 30); err != nil {
+		return err
+	}
+	t.tag.SetArtist(s)
+	return nil
+}
+
+func (t checkedV1) SetAlbum(s string) error {
+	if err := checkV1Field("album", s, 30); err != nil {
+		return err
+	}
+	t.tag.SetAlbum(s)
+	return nil
+}
+
+func (t checkedV1) SetYear(s string) error {
+	if err := checkV1Field("year", s, 4); err != nil {
+		return err
+	}
+	t.tag.SetYear(s)
+	return nil
+}
+
+// Genres must match one of v1.Genres, rather than the nearest being taken
+func (t checkedV1) SetGenre(s string) error {
+	if _, exact := v1.GenreIndex(s); !exact {
+		return fmt.Errorf("%w: unknown ID3v1 genre %q", ErrBadValue, s)
+	}
+
+	t.tag.SetGenre(s)
+	return nil
+}
+
+func (t checkedV1) SetLength(int) error {
+	return fmt.Errorf("length: %w", ErrUnsupported)
+}
+
+func (t checkedV1) AddFrames(frames ...v2.Framer) error {
+	if len(frames) > 0 {
+		return fmt.Errorf("frames: %w", ErrUnsupported)
+	}
+
+	return nil
+}
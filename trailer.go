@@ -0,0 +1,257 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package id3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	v1 "github.com/lion187chen/id3-go/v1"
+	v2 "github.com/lion187chen/id3-go/v2"
+)
+
+const (
+	apeFooterSize    = 32
+	apeHasHeader     = 1 << 31
+	lyrics3Begin     = "LYRICSBEGIN"
+	lyrics3v1End     = "LYRICSEND"
+	lyrics3v2End     = "LYRICS200"
+	lyrics3v2SizeLen = 6
+	// Longest Lyrics3v1 block, lyrics of up to 5100 bytes and the markers
+	lyrics3v1MaxSize = 5100 + len(lyrics3Begin) + len(lyrics3v1End)
+	// Enhanced ID3v1 block starting with "TAG+" before the ID3v1 tag
+	v1EnhancedSize = 227
+)
+
+// TagRegion is the range of bytes a tag occupies in a file
+type TagRegion struct {
+	// "ID3v2", "ID3v1", "ID3v1+" for an enhanced TAG+ block, "APEv1",
+	// "APEv2", "Lyrics3v1" or "Lyrics3v2"
+	Kind   string
+	Offset int64
+	Size   int64
+}
+
+// TrailingTag is a tag stored after the audio
+type TrailingTag = TagRegion
+
+// Tags following the audio, ordered by offset
+// APE tags, Lyrics3 blocks and an appended ID3v2 tag may sit between the
+// audio and an ID3v1 tag.
+func (f *File) TrailingTags() ([]TrailingTag, error) {
+	size, err := f.file.Size()
+	if err != nil {
+		return nil, err
+	}
+
+	return findTrailingTags(f.file, size), nil
+}
+
+// TrailingTags is like File.TrailingTags above but for in memory mp3 data
+func (b *Mp3Bytes) TrailingTags() []TrailingTag {
+	return findTrailingTags(bytes.NewReader(b.blob), int64(len(b.blob)))
+}
+
+// Regions of all tags in the file, ordered by offset
+// The ID3v2 tags at the start, including any chained after the first, come
+// before those following the audio.
+func (f *File) TagRegions() ([]TagRegion, error) {
+	size, err := f.file.Size()
+	if err != nil {
+		return nil, err
+	}
+
+	return tagRegions(f.file, size)
+}
+
+// TagRegions is like File.TagRegions above but for in memory mp3 data
+func (b *Mp3Bytes) TagRegions() []TagRegion {
+	regions, _ := tagRegions(bytes.NewReader(b.blob), int64(len(b.blob)))
+	return regions
+}
+
+func tagRegions(r readSeekerAt, size int64) ([]TagRegion, error) {
+	var regions []TagRegion
+
+	var offset int64
+	for {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+
+		header := v2.ParseHeader(r)
+		if header == nil {
+			break
+		}
+
+		tagSize := int64(v2.HeaderSize + header.Size())
+		if header.Footer() {
+			tagSize += v2.HeaderSize
+		}
+		regions = append(regions, TagRegion{Kind: "ID3v2", Offset: offset, Size: tagSize})
+		offset += tagSize
+	}
+
+	for _, tag := range findTrailingTags(r, size) {
+		// A tag claiming the whole file must not be counted twice
+		if tag.Offset >= offset {
+			regions = append(regions, tag)
+		}
+	}
+
+	return regions, nil
+}
+
+func findTrailingTags(r io.ReaderAt, size int64) []TrailingTag {
+	var tags []TrailingTag
+
+	end := size
+	if data := readAt(r, end-v1.TagSize, v1.TagSize); data != nil && string(data[:3]) == "TAG" {
+		end -= v1.TagSize
+		tags = append(tags, TrailingTag{Kind: "ID3v1", Offset: end, Size: v1.TagSize})
+
+		if enhancedV1(r, end) {
+			end -= v1EnhancedSize
+			tags = append(tags, TrailingTag{Kind: "ID3v1+", Offset: end, Size: v1EnhancedSize})
+		}
+	}
+
+	for {
+		tag, ok := apeTag(r, end)
+		if !ok {
+			tag, ok = lyrics3Tag(r, end)
+		}
+		if !ok {
+			tag, ok = appendedTag(r, end)
+		}
+		// Each tag must move the end back, or a damaged size loops forever
+		if !ok || tag.Offset >= end || tag.Offset < 0 {
+			break
+		}
+
+		tags = append(tags, tag)
+		end = tag.Offset
+	}
+
+	for i, j := 0, len(tags)-1; i < j; i, j = i+1, j-1 {
+		tags[i], tags[j] = tags[j], tags[i]
+	}
+
+	return tags
+}
+
+// Reports whether an enhanced TAG+ block ends at end
+func enhancedV1(r io.ReaderAt, end int64) bool {
+	data := readAt(r, end-v1EnhancedSize, 4)
+	return data != nil && string(data) == "TAG+"
+}
+
+// APE tag ending at end
+func apeTag(r io.ReaderAt, end int64) (TrailingTag, bool) {
+	footer := readAt(r, end-apeFooterSize, apeFooterSize)
+	if footer == nil || string(footer[:8]) != "APETAGEX" {
+		return TrailingTag{}, false
+	}
+
+	version := binary.LittleEndian.Uint32(footer[8:])
+	size := int64(binary.LittleEndian.Uint32(footer[12:]))
+	flags := binary.LittleEndian.Uint32(footer[20:])
+	if flags&apeHasHeader != 0 {
+		size += apeFooterSize
+	}
+	if size < apeFooterSize || size > end {
+		return TrailingTag{}, false
+	}
+
+	kind := "APEv2"
+	if version < 2000 {
+		kind = "APEv1"
+	}
+
+	return TrailingTag{Kind: kind, Offset: end - size, Size: size}, true
+}
+
+// Lyrics3 block ending at end
+func lyrics3Tag(r io.ReaderAt, end int64) (TrailingTag, bool) {
+	trailerSize := int64(lyrics3v2SizeLen + len(lyrics3v2End))
+	if trailer := readAt(r, end-trailerSize, int(trailerSize)); trailer != nil && string(trailer[lyrics3v2SizeLen:]) == lyrics3v2End {
+		size, ok := lyrics3Size(trailer[:lyrics3v2SizeLen])
+		if !ok {
+			return TrailingTag{}, false
+		}
+
+		size += trailerSize
+		if begin := readAt(r, end-size, len(lyrics3Begin)); begin == nil || string(begin) != lyrics3Begin {
+			return TrailingTag{}, false
+		}
+
+		return TrailingTag{Kind: "Lyrics3v2", Offset: end - size, Size: size}, true
+	}
+
+	if trailer := readAt(r, end-int64(len(lyrics3v1End)), len(lyrics3v1End)); trailer == nil || string(trailer) != lyrics3v1End {
+		return TrailingTag{}, false
+	}
+
+	window := int64(lyrics3v1MaxSize)
+	if window > end {
+		window = end
+	}
+
+	data := readAt(r, end-window, int(window))
+	i := bytes.LastIndex(data, []byte(lyrics3Begin))
+	if i < 0 {
+		return TrailingTag{}, false
+	}
+
+	size := window - int64(i)
+	return TrailingTag{Kind: "Lyrics3v1", Offset: end - size, Size: size}, true
+}
+
+// Size of a Lyrics3v2 block, which must be six ASCII digits and not zero
+func lyrics3Size(field []byte) (int64, bool) {
+	var size int64
+	for _, c := range field {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		size = size*10 + int64(c-'0')
+	}
+
+	return size, size > 0
+}
+
+// ID3v2.4 tag with a footer ending at end
+func appendedTag(r io.ReaderAt, end int64) (TrailingTag, bool) {
+	data := readAt(r, end-v2.HeaderSize, v2.HeaderSize)
+	if data == nil {
+		return TrailingTag{}, false
+	}
+
+	footer := v2.ParseFooter(bytes.NewReader(data))
+	if footer == nil {
+		return TrailingTag{}, false
+	}
+
+	size := int64(2*v2.HeaderSize + footer.Size())
+	if header := readAt(r, end-size, 3); header == nil || string(header) != "ID3" {
+		return TrailingTag{}, false
+	}
+
+	return TrailingTag{Kind: "ID3v2", Offset: end - size, Size: size}, true
+}
+
+// Reads n bytes at offset, nil when they are not all available
+func readAt(r io.ReaderAt, offset int64, n int) []byte {
+	if offset < 0 {
+		return nil
+	}
+
+	data := make([]byte, n)
+	if read, _ := r.ReadAt(data, offset); read < n {
+		return nil
+	}
+
+	return data
+}
@@ -5,54 +5,129 @@ package id3
 
 import (
 	"io"
-	"os"
+	"sync"
 )
 
-func shiftBytesBack(file *os.File, start, offset int64) error {
-	stat, err := file.Stat()
+// Size of the buffers used to move audio, large enough that rewriting big
+// files is limited by the disk rather than by the number of calls
+const copyBufferSize = 4 << 20
+
+var copyBuffers = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, copyBufferSize)
+		return &buf
+	},
+}
+
+// Reports the progress of a long save, in bytes of audio written so far out
+// of the total to write
+// Returning an error cancels the save, which then returns that error.
+type ProgressFunc func(written, total int64) error
+
+// Moves the bytes from start to the end of the file offset bytes further
+// Windows are copied starting at the end, so that nothing is overwritten
+// before it has been read. When progress cancels the move, the file is
+// restored to how it was.
+func shiftBytesBack(file storage, start, offset int64, progress ProgressFunc) error {
+	end, err := file.Size()
 	if err != nil {
 		return err
 	}
-	end := stat.Size()
 
-	wrBuf := make([]byte, offset)
-	rdBuf := make([]byte, offset)
+	bufp := copyBuffers.Get().(*[]byte)
+	defer copyBuffers.Put(bufp)
+	buf := *bufp
+
+	for pos := end; pos > start; {
+		n := int64(len(buf))
+		if pos-start < n {
+			n = pos - start
+		}
+		pos -= n
+
+		if _, err := file.ReadAt(buf[:n], pos); err != nil {
+			return err
+		}
 
-	wrOffset := offset
-	rdOffset := start
+		if _, err := file.WriteAt(buf[:n], pos+offset); err != nil {
+			return err
+		}
 
-	rn, err := file.ReadAt(wrBuf, rdOffset)
-	if err != nil && err != io.EOF {
-		panic(err)
+		if progress == nil {
+			continue
+		}
+		if cancel := progress(end-pos, end-start); cancel != nil {
+			if err := copyBytes(file, pos+offset, pos, end-pos); err != nil {
+				return err
+			}
+			if err := file.Truncate(end); err != nil {
+				return err
+			}
+			return cancel
+		}
 	}
-	rdOffset += int64(rn)
 
-	for {
-		if rdOffset >= end {
-			break
+	return nil
+}
+
+// Moves the bytes between start and end to the beginning of the file
+func moveBytes(file storage, start, end int64) error {
+	return copyBytes(file, start, 0, end-start)
+}
+
+// Copies n bytes from src to dst, which must not be after src
+func copyBytes(file storage, src, dst, n int64) error {
+	bufp := copyBuffers.Get().(*[]byte)
+	defer copyBuffers.Put(bufp)
+	buf := *bufp
+
+	for done := int64(0); done < n; {
+		size := int64(len(buf))
+		if n-done < size {
+			size = n - done
 		}
 
-		n, err := file.ReadAt(rdBuf, rdOffset)
-		if err != nil && err != io.EOF {
+		if _, err := file.ReadAt(buf[:size], src+done); err != nil {
 			return err
 		}
 
-		if rdOffset+int64(n) > end {
-			n = int(end - rdOffset)
+		if _, err := file.WriteAt(buf[:size], dst+done); err != nil {
+			return err
 		}
 
-		if _, err := file.WriteAt(wrBuf[:rn], wrOffset); err != nil {
+		done += size
+	}
+
+	return nil
+}
+
+// Writes n bytes of the file from start to w
+func streamBytes(w io.Writer, file storage, start, n int64, progress ProgressFunc) error {
+	bufp := copyBuffers.Get().(*[]byte)
+	defer copyBuffers.Put(bufp)
+	buf := *bufp
+
+	for done := int64(0); done < n; {
+		size := int64(len(buf))
+		if n-done < size {
+			size = n - done
+		}
+
+		if _, err := file.ReadAt(buf[:size], start+done); err != nil {
 			return err
 		}
 
-		rdOffset += int64(n)
-		wrOffset += int64(rn)
-		copy(wrBuf, rdBuf)
-		rn = n
-	}
+		if _, err := w.Write(buf[:size]); err != nil {
+			return err
+		}
 
-	if _, err := file.WriteAt(wrBuf[:rn], wrOffset); err != nil {
-		return err
+		done += size
+		if progress == nil {
+			continue
+		}
+		if err := progress(done, n); err != nil {
+			return err
+		}
 	}
 
 	return nil
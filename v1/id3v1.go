@@ -4,10 +4,17 @@
 package v1
 
 import (
+	"bytes"
+	"fmt"
 	"io"
 	"os"
+	"strconv"
+	"strings"
+	"unicode"
 
+	"github.com/lion187chen/id3-go/encodedbytes"
 	v2 "github.com/lion187chen/id3-go/v2"
+	"golang.org/x/text/encoding/charmap"
 )
 
 const (
@@ -36,9 +43,41 @@ var (
 		"Rave", "Showtunes", "Trailer", "Lo-Fi",
 		"Tribal", "Acid Punk", "Acid Jazz", "Polka",
 		"Retro", "Musical", "Rock & Roll", "Hard Rock",
+		// Winamp extensions
+		"Folk", "Folk-Rock", "National Folk", "Swing",
+		"Fast Fusion", "Bebob", "Latin", "Revival",
+		"Celtic", "Bluegrass", "Avantgarde", "Gothic Rock",
+		"Progressive Rock", "Psychedelic Rock", "Symphonic Rock", "Slow Rock",
+		"Big Band", "Chorus", "Easy Listening", "Acoustic",
+		"Humour", "Speech", "Chanson", "Opera",
+		"Chamber Music", "Sonata", "Symphony", "Booty Bass",
+		"Primus", "Porn Groove", "Satire", "Slow Jam",
+		"Club", "Tango", "Samba", "Folklore",
+		"Ballad", "Power Ballad", "Rhythmic Soul", "Freestyle",
+		"Duet", "Punk Rock", "Drum Solo", "A capella",
+		"Euro-House", "Dance Hall", "Goa", "Drum & Bass",
+		"Club-House", "Hardcore", "Terror", "Indie",
+		"BritPop", "Afro-Punk", "Polsk Punk", "Beat",
+		"Christian Gangsta Rap", "Heavy Metal", "Black Metal", "Crossover",
+		"Contemporary Christian", "Christian Rock", "Merengue", "Salsa",
+		"Thrash Metal", "Anime", "JPop", "Synthpop",
+		"Abstract", "Art Rock", "Baroque", "Bhangra",
+		"Big Beat", "Breakbeat", "Chillout", "Downtempo",
+		"Dub", "EBM", "Eclectic", "Electro",
+		"Electroclash", "Emo", "Experimental", "Garage",
+		"Global", "IDM", "Illbient", "Industro-Goth",
+		"Jam Band", "Krautrock", "Leftfield", "Lounge",
+		"Math Rock", "New Romantic", "Nu-Breakz", "Post-Punk",
+		"Post-Rock", "Psytrance", "Shoegaze", "Space Rock",
+		"Trop Rock", "World Music", "Neoclassical", "Audiobook",
+		"Audio Theatre", "Neue Deutsche Welle", "Podcast", "Indie Rock",
+		"G-Funk", "Dubstep", "Garage Rock", "Psybient",
 	}
 )
 
+// Genre byte of a tag without a genre
+const NoGenre = 255
+
 // Tag represents an ID3v1 tag
 type Tag struct {
 	title, artist, album, year, comment string
@@ -56,11 +95,11 @@ func ParseTag(readSeeker io.ReadSeeker) *Tag {
 	}
 
 	return &Tag{
-		title:   string(data[3:33]),
-		artist:  string(data[33:63]),
-		album:   string(data[63:93]),
-		year:    string(data[93:97]),
-		comment: string(data[97:127]),
+		title:   decodeField(data[3:33]),
+		artist:  decodeField(data[33:63]),
+		album:   decodeField(data[63:93]),
+		year:    decodeField(data[93:97]),
+		comment: decodeField(data[97:127]),
 		genre:   data[127],
 		dirty:   false,
 	}
@@ -75,6 +114,8 @@ func (t Tag) Artist() string { return t.artist }
 func (t Tag) Album() string  { return t.album }
 func (t Tag) Year() string   { return t.year }
 
+// Name of the genre the tag's genre byte stands for, "" when the byte is
+// NoGenre or not a known genre
 func (t Tag) Genre() string {
 	if int(t.genre) < len(Genres) {
 		return Genres[t.genre]
@@ -111,13 +152,12 @@ func (t *Tag) SetYear(text string) {
 	t.dirty = true
 }
 
+// Sets the genre nearest to text, as GenreIndex finds it
+// Empty text clears the genre.
 func (t *Tag) SetGenre(text string) {
-	t.genre = 255
-	for i, genre := range Genres {
-		if text == genre {
-			t.genre = byte(i)
-			break
-		}
+	t.genre = NoGenre
+	if text != "" {
+		t.genre, _ = GenreIndex(text)
 	}
 	t.dirty = true
 }
@@ -126,18 +166,102 @@ func (t *Tag) SetLength(length int) {
 	// do nothing
 }
 
+// Text ISO-8859-1 cannot represent is replaced with '?', see WriteTo.
 func (t Tag) Bytes() []byte {
-	data := make([]byte, TagSize)
+	data, _ := t.encode(false)
+	return data
+}
+
+// Writes the tag to w
+// Unlike Bytes, text ISO-8859-1 cannot represent fails with the error of
+// encodedbytes.EncodedStringBytes when encodedbytes.SetLatin1Policy is
+// Latin1Error. Returns the number of bytes written.
+func (t Tag) WriteTo(w io.Writer) (int64, error) {
+	data, err := t.encode(true)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(data)
+	return int64(n), err
+}
 
+// Encodes the tag, failing on text that cannot be encoded when strict
+func (t Tag) encode(strict bool) ([]byte, error) {
+	data := make([]byte, TagSize)
 	copy(data[:3], []byte("TAG"))
-	copy(data[3:33], []byte(t.title))
-	copy(data[33:63], []byte(t.artist))
-	copy(data[63:93], []byte(t.album))
-	copy(data[93:97], []byte(t.year))
-	copy(data[97:127], []byte(t.comment))
+
+	fields := []struct {
+		name, text string
+		dst        []byte
+	}{
+		{"title", t.title, data[3:33]},
+		{"artist", t.artist, data[33:63]},
+		{"album", t.album, data[63:93]},
+		{"year", t.year, data[93:97]},
+		{"comment", t.comment, data[97:127]},
+	}
+	for _, field := range fields {
+		b, err := encodeField(field.text, strict)
+		if err != nil {
+			return nil, fmt.Errorf("v1: %s: %w", field.name, err)
+		}
+		copy(field.dst, b)
+	}
 	data[127] = t.genre
 
-	return data
+	return data, nil
+}
+
+// Converts the tag to a new ID3v2 tag of the given version
+// The comment is written in the given ISO 639-2 language, "" for
+// v2.DefaultLanguage, which also stays the language of the new tag.
+func (t Tag) ToV2(version byte, language string) (*v2.Tag, error) {
+	tag := v2.NewTag(version)
+	if err := tag.SetCommentLanguage(language); err != nil {
+		return nil, err
+	}
+
+	err := tag.SetAll(map[string]string{
+		"title":   t.title,
+		"artist":  t.artist,
+		"album":   t.album,
+		"year":    t.year,
+		"genre":   t.Genre(),
+		"comment": t.comment,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tag, nil
+}
+
+// Fields are ISO-8859-1 text padded with nulls
+func decodeField(data []byte) string {
+	data = bytes.TrimRight(data, "\x00")
+	s, err := charmap.ISO8859_1.NewDecoder().Bytes(data)
+	if err != nil {
+		return string(data)
+	}
+
+	return string(s)
+}
+
+// Encodes a field following encodedbytes.SetLatin1Policy
+// Unless strict, characters are replaced with '?' when the policy is to fail.
+func encodeField(s string, strict bool) ([]byte, error) {
+	b, err := encodedbytes.EncodedStringBytes(s, 0)
+	if err == nil || strict {
+		return b, err
+	}
+
+	return encodedbytes.EncodedStringBytes(strings.Map(func(r rune) rune {
+		if r > 0xFF {
+			return '?'
+		}
+		return r
+	}, s), 0)
 }
 
 func (t Tag) Size() int {
@@ -156,3 +280,66 @@ func (t Tag) Frames(id string) []v2.Framer        { return []v2.Framer{} }
 func (t Tag) DeleteFrames(id string) []v2.Framer  { return []v2.Framer{} }
 func (t Tag) DeleteFrame(f v2.Framer) []v2.Framer { return []v2.Framer{} }
 func (t Tag) AddFrames(f ...v2.Framer)            {}
+
+// Index of the genre nearest to name, and whether it matched exactly
+// Names match regardless of case, spaces and punctuation, and "17" or "(17)"
+// give the index itself. Otherwise the genre fewest edits away is taken, or
+// "Other" when none is close.
+func GenreIndex(name string) (byte, bool) {
+	if n, err := strconv.Atoi(strings.Trim(name, "()")); err == nil && n >= 0 && n < len(Genres) {
+		return byte(n), true
+	}
+
+	key := genreKey(name)
+	best, bestDistance := -1, len(key)/3+1
+	for i, genre := range Genres {
+		d := editDistance(key, genreKey(genre))
+		if d == 0 {
+			return byte(i), true
+		}
+		if d < bestDistance {
+			best, bestDistance = i, d
+		}
+	}
+
+	if best < 0 {
+		return 12, false // Other
+	}
+	return byte(best), false
+}
+
+// Lower case letters and digits of a genre name, with "&" read as "and"
+func genreKey(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.ReplaceAll(name, "&", "and")) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// Number of single character edits turning a into b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	row := make([]int, len(rb)+1)
+	for j := range row {
+		row[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		prev := row[0]
+		row[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur := min(row[j]+1, row[j-1]+1, prev+cost)
+			prev, row[j] = row[j], cur
+		}
+	}
+
+	return row[len(rb)]
+}
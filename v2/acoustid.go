@@ -0,0 +1,30 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+// Descriptions of the user defined text frames written by AcoustID clients
+const (
+	acoustIDDesc            = "Acoustid Id"
+	acoustIDFingerprintDesc = "Acoustid Fingerprint"
+)
+
+// AcoustID track identifier, "" when there is none
+func (t *Tag) AcoustID() string {
+	return t.userText(acoustIDDesc)
+}
+
+// Sets the AcoustID track identifier, an empty id removes it
+func (t *Tag) SetAcoustID(id string) {
+	t.setUserText(acoustIDDesc, id)
+}
+
+// Chromaprint fingerprint of the audio, "" when there is none
+func (t *Tag) AcoustIDFingerprint() string {
+	return t.userText(acoustIDFingerprintDesc)
+}
+
+// Sets the Chromaprint fingerprint, an empty fingerprint removes it
+func (t *Tag) SetAcoustIDFingerprint(fingerprint string) {
+	t.setUserText(acoustIDFingerprintDesc, fingerprint)
+}
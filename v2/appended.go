@@ -0,0 +1,85 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Identifier of the footer, which repeats the header at the end of a tag
+const footerId = "3DI"
+
+// Whether the ID3v2.4 tag ends with a footer, as tags appended to a file do
+func (h Header) Footer() bool {
+	return h.version == 4 && h.flags&FlagFooter != 0
+}
+
+// Parses the footer of an ID3v2.4 tag
+// The footer repeats the header, so the result describes the tag it ends.
+func ParseFooter(reader io.Reader) *Header {
+	header := parseHeader(reader, footerId)
+	if header == nil || !header.Footer() {
+		return nil
+	}
+
+	return header
+}
+
+// Parses the tag appended to data ending at end, found through its footer
+// Returns the tag and its offset. A nil tag and nil error are returned when
+// no footer ends the data.
+func ParseAppendedTag(r io.ReaderAt, end int64, opts ParseOptions) (*Tag, int64, error) {
+	if end < 2*HeaderSize {
+		return nil, 0, nil
+	}
+
+	footer := ParseFooter(io.NewSectionReader(r, end-HeaderSize, HeaderSize))
+	if footer == nil {
+		return nil, 0, nil
+	}
+
+	start := end - 2*HeaderSize - int64(footer.size)
+	if start < 0 {
+		return nil, 0, nil
+	}
+
+	t, err := ParseTagWithOptions(io.NewSectionReader(r, start, end-start), opts)
+	if err != nil || t == nil {
+		return nil, 0, err
+	}
+
+	return t, start, nil
+}
+
+// Streams the tag to w as a tag appended to a file, without padding and
+// followed by a footer so that readers find it from the end
+// Only ID3v2.4 tags have footers, others give ErrFlagNotAllowed. Errors are
+// reported as with WriteTo.
+func (t *Tag) WriteAppendedTo(w io.Writer) (int64, error) {
+	if t.version != 4 {
+		return 0, ErrFlagNotAllowed
+	}
+
+	return t.writeTo(w, true)
+}
+
+// Creates a SEEK frame, pointing to a tag offset bytes after the end of the
+// tag holding the frame
+func NewSeekFrame(offset uint32) *DataFrame {
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint32(data, offset)
+
+	return NewDataFrame(V24FrameTypeMap["SEEK"], data)
+}
+
+// Offset from the end of the tag to the next tag, given by its SEEK frame
+func (t *Tag) SeekOffset() (uint32, bool) {
+	f, ok := t.Frame("SEEK").(*DataFrame)
+	if !ok || len(f.Data()) != 4 {
+		return 0, false
+	}
+
+	return binary.BigEndian.Uint32(f.Data()), true
+}
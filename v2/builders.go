@@ -0,0 +1,200 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/lion187chen/id3-go/encodedbytes"
+)
+
+// The builders below create ID3v2.3 frames, which are also valid ID3v2.4
+// frames. Text is stored as ISO-8859-1 when it can be, UTF-16 otherwise.
+
+// Checks a field that is stored null terminated
+func checkField(name, s string) error {
+	if strings.ContainsRune(s, 0) {
+		return fmt.Errorf("%w: %s contains a null character", ErrBadValue, name)
+	}
+
+	return nil
+}
+
+// Checks the language of a frame, "" standing for the default language
+func frameLanguage(language string) (string, error) {
+	if language == "" {
+		return DefaultLanguage(), nil
+	}
+
+	return parseLanguage(language)
+}
+
+// Creates a comment frame
+// The language is an ISO 639-2 code such as "eng", "" for DefaultLanguage.
+func NewCommentFrame(language, description, text string) (*UnsynchTextFrame, error) {
+	return newUnsynchTextFrame("COMM", language, description, text)
+}
+
+// Creates an unsynchronized lyrics frame
+// The language is an ISO 639-2 code such as "eng", "" for DefaultLanguage.
+func NewLyricsFrame(language, description, text string) (*UnsynchTextFrame, error) {
+	return newUnsynchTextFrame("USLT", language, description, text)
+}
+
+func newUnsynchTextFrame(id, language, description, text string) (*UnsynchTextFrame, error) {
+	language, err := frameLanguage(language)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkField("description", description); err != nil {
+		return nil, err
+	}
+
+	f := NewUnsynchTextFrame(V23FrameTypeMap[id], description, text)
+	if err := f.SetEncoding(encodingFor(description + text)); err != nil {
+		return nil, err
+	}
+	if err := f.SetLanguage(language); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Creates a terms of use frame
+// The language is an ISO 639-2 code such as "eng", "" for DefaultLanguage.
+func NewUSERFrame(language, text string) (*DataFrame, error) {
+	language, err := frameLanguage(language)
+	if err != nil {
+		return nil, err
+	}
+
+	encoding := encodedbytes.IndexForEncoding(encodingFor(text))
+	b, err := encodedbytes.EncodedStringBytes(text, encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, 0, 4+len(b))
+	data = append(data, encoding)
+	data = append(data, language...)
+	data = append(data, b...)
+
+	return NewDataFrame(V23FrameTypeMap["USER"], data), nil
+}
+
+// Creates an attached picture frame
+// An empty MIME type is taken from the image data, a declared one must match
+// it. Pictures whose MIME type is "-->" hold a URL to the image.
+func NewAPICFrame(p Picture) (*ImageFrame, error) {
+	if p.Type > PicturePublisherLogo {
+		return nil, fmt.Errorf("%w: unknown picture type %d", ErrBadValue, p.Type)
+	}
+	if err := checkField("description", p.Description); err != nil {
+		return nil, err
+	}
+
+	mimeType := p.MIMEType
+	switch {
+	case p.IsLink():
+		if _, err := url.Parse(string(p.Data)); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrBadValue, err)
+		}
+	case mimeType == "":
+		if mimeType = sniffMIMEType(p.Data); mimeType == "" {
+			return nil, ErrImageFormat
+		}
+	default:
+		if err := CheckMIMEType(mimeType, p.Data); err != nil {
+			return nil, err
+		}
+	}
+
+	f := NewImageFrame(V23FrameTypeMap["APIC"], mimeType, p.Type, p.Description, p.Data)
+	if err := f.SetEncoding(encodingFor(p.Description)); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Creates a unique file identifier frame
+// The owner is usually a URL naming the database the identifier belongs to.
+func NewUFIDFrame(owner string, id []byte) (*IdFrame, error) {
+	if owner == "" {
+		return nil, fmt.Errorf("%w: empty owner identifier", ErrBadValue)
+	}
+	if err := checkField("owner identifier", owner); err != nil {
+		return nil, err
+	}
+	if len(id) > 64 {
+		return nil, fmt.Errorf("%w: identifier longer than 64 bytes", ErrBadValue)
+	}
+
+	return NewIdFrame(V23FrameTypeMap["UFID"], owner, id), nil
+}
+
+// Creates a popularimeter frame
+// Ratings go from 1, worst, to 255, best, with 0 meaning unknown. The play
+// count takes more than four bytes only when it needs them.
+func NewPOPMFrame(email string, rating byte, count uint64) (*DataFrame, error) {
+	if !isLatin1(email) {
+		return nil, fmt.Errorf("%w: email is not ISO-8859-1", ErrBadEncoding)
+	}
+	if err := checkField("email", email); err != nil {
+		return nil, err
+	}
+
+	counter := binary.BigEndian.AppendUint64(nil, count)
+	for len(counter) > 4 && counter[0] == 0 {
+		counter = counter[1:]
+	}
+
+	data := make([]byte, 0, len(email)+2+len(counter))
+	for _, r := range email {
+		data = append(data, byte(r))
+	}
+	data = append(data, 0, rating)
+	data = append(data, counter...)
+
+	return NewDataFrame(V23FrameTypeMap["POPM"], data), nil
+}
+
+// Creates a user defined text frame
+func NewTXXXFrame(description, value string) (*DescTextFrame, error) {
+	if err := checkField("description", description); err != nil {
+		return nil, err
+	}
+
+	f := NewDescTextFrame(V23FrameTypeMap["TXXX"], description, value, "UTF-8")
+	if err := f.SetEncoding(encodingFor(description + value)); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Creates a user defined URL link frame
+// The URL must be absolute and ISO-8859-1.
+func NewWXXXFrame(description, link string) (*DescTextFrame, error) {
+	if err := checkField("description", description); err != nil {
+		return nil, err
+	}
+	if !isLatin1(link) {
+		return nil, fmt.Errorf("%w: URL is not ISO-8859-1", ErrBadEncoding)
+	}
+	if u, err := url.Parse(link); err != nil || !u.IsAbs() {
+		return nil, fmt.Errorf("%w: %q is not an absolute URL", ErrBadValue, link)
+	}
+
+	f := NewDescTextFrame(V23FrameTypeMap["WXXX"], description, link, "UTF-8")
+	if err := f.SetEncoding(encodingFor(description)); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
@@ -0,0 +1,202 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// Version of the podcast chapters format that ChaptersJSON writes
+const PodcastChaptersVersion = "1.2.0"
+
+// Chapters in the JSON format of the podcast namespace, as read by web
+// players and chapter editors
+type PodcastChapters struct {
+	Version  string           `json:"version"`
+	Chapters []PodcastChapter `json:"chapters"`
+}
+
+// Times are in seconds
+type PodcastChapter struct {
+	StartTime float64 `json:"startTime"`
+	EndTime   float64 `json:"endTime,omitempty"`
+	Title     string  `json:"title,omitempty"`
+	Img       string  `json:"img,omitempty"`
+	URL       string  `json:"url,omitempty"`
+}
+
+// Chapter offset meaning unused
+const noChapterOffset = math.MaxUint32
+
+// Converts a chapter time, false when it does not fit the frame
+func secondsToMillis(s float64) (uint32, bool) {
+	ms := math.Round(s * 1000)
+	if math.IsNaN(ms) || ms < 0 || ms >= noChapterOffset {
+		return 0, false
+	}
+	return uint32(ms), true
+}
+
+func millisToSeconds(ms uint32) float64 {
+	return float64(ms) / 1000
+}
+
+// Chapters in the order of the top-level table of contents, or by start time
+// when there is none
+// Chapters located by byte offset only cannot be placed in time and are left
+// out.
+func (t *Tag) PodcastChapters() PodcastChapters {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	byElement := make(map[string]*ChapterFrame)
+	var chapters []*ChapterFrame
+	var toc *TOCFrame
+	for _, f := range t.frames {
+		switch f := f.(type) {
+		case *ChapterFrame:
+			if f.UseTime {
+				byElement[f.Element] = f
+				chapters = append(chapters, f)
+			}
+		case *TOCFrame:
+			if f.TopLevel && toc == nil {
+				toc = f
+			}
+		}
+	}
+
+	if toc != nil {
+		ordered := make([]*ChapterFrame, 0, len(chapters))
+		for _, element := range toc.ChildElements {
+			if cf, ok := byElement[element]; ok {
+				ordered = append(ordered, cf)
+				delete(byElement, element)
+			}
+		}
+		// Chapters missing from the table of contents go last
+		for _, cf := range chapters {
+			if _, ok := byElement[cf.Element]; ok {
+				ordered = append(ordered, cf)
+			}
+		}
+		chapters = ordered
+	} else {
+		sort.SliceStable(chapters, func(i, j int) bool {
+			return chapters[i].StartTime < chapters[j].StartTime
+		})
+	}
+
+	pc := PodcastChapters{Version: PodcastChaptersVersion, Chapters: []PodcastChapter{}}
+	for _, cf := range chapters {
+		pc.Chapters = append(pc.Chapters, PodcastChapter{
+			StartTime: millisToSeconds(cf.StartTime),
+			EndTime:   millisToSeconds(cf.EndTime),
+			Title:     trimNull(cf.Title()),
+			Img:       cf.ImageURL(),
+			URL:       trimNull(cf.Link()),
+		})
+	}
+
+	return pc
+}
+
+// Chapters as podcast chapters JSON
+func (t *Tag) ChaptersJSON() ([]byte, error) {
+	return json.MarshalIndent(t.PodcastChapters(), "", "  ")
+}
+
+// Replaces all chapters and tables of contents
+// Chapters are named chp0, chp1 and so on, listed in an ordered top-level
+// table of contents named toc. A chapter without an end time ends where the
+// next one starts, the last one at the length in TLEN if known. ID3v2.2 has
+// no chapters.
+func (t *Tag) SetPodcastChapters(pc PodcastChapters) error {
+	chapType, ok := lookupFrameType(t.version, "CHAP")
+	if !ok {
+		return ErrFrameNotAllowed
+	}
+	tocType, _ := lookupFrameType(t.version, "CTOC")
+
+	chapters := append([]PodcastChapter(nil), pc.Chapters...)
+	sort.SliceStable(chapters, func(i, j int) bool {
+		return chapters[i].StartTime < chapters[j].StartTime
+	})
+
+	var length uint32
+	if ms, err := strconv.ParseUint(t.frameText("TLEN"), 10, 32); err == nil {
+		length = uint32(ms)
+	}
+
+	times := make([]uint32, len(chapters))
+	for i, c := range chapters {
+		start, ok := secondsToMillis(c.StartTime)
+		if _, endOk := secondsToMillis(c.EndTime); !ok || !endOk {
+			return fmt.Errorf("chapters: %w: time out of range in chapter %d", ErrBadValue, i)
+		}
+		times[i] = start
+	}
+
+	var frames []Framer
+	elements := make([]string, 0, len(chapters))
+	for i, c := range chapters {
+		start := times[i]
+		end, _ := secondsToMillis(c.EndTime)
+		if c.EndTime == 0 {
+			switch {
+			case i+1 < len(chapters):
+				end = times[i+1]
+			case length > start:
+				end = length
+			default:
+				end = start
+			}
+		}
+
+		element := "chp" + strconv.Itoa(i)
+		cf := NewChapterFrame(chapType, element, start, end, noChapterOffset, noChapterOffset, true, c.Title, c.URL, "")
+		cf.SetImageURL(c.Img)
+		if size := cf.bodySize(); uint64(size) > maxFrameSize(t.version) {
+			return fmt.Errorf("chapters: chapter %d: %w: %d bytes", i, ErrFrameTooLarge, size)
+		}
+		frames = append(frames, cf)
+		elements = append(elements, element)
+	}
+
+	if len(frames) > 0 {
+		frames = append(frames, NewTOCFrame(tocType, "toc", true, true, elements))
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.removeFrames(func(f Framer) bool {
+		switch f.(type) {
+		case *ChapterFrame, *TOCFrame:
+			return true
+		}
+		return false
+	})
+	t.addFrames(frames...)
+
+	return nil
+}
+
+// Replaces all chapters with those of podcast chapters JSON
+func (t *Tag) SetChaptersJSON(data []byte) error {
+	var pc PodcastChapters
+	if err := json.Unmarshal(data, &pc); err != nil {
+		return fmt.Errorf("chapters: %w", err)
+	}
+	if pc.Chapters == nil {
+		return errors.New("chapters: no chapters list")
+	}
+
+	return t.SetPodcastChapters(pc)
+}
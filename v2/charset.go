@@ -0,0 +1,401 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"unicode"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	textunicode "golang.org/x/text/encoding/unicode"
+
+	"github.com/lion187chen/id3-go/encodedbytes"
+)
+
+// A character set that DetectCharset can recognize
+type charset struct {
+	name string
+	enc  encoding.Encoding
+	// Likelihood of a non-ASCII rune, given its bytes in the character set
+	score func(r rune, b []byte) int
+}
+
+// Candidates in order of preference when they score the same
+var charsets = []charset{
+	{"UTF-8", textunicode.UTF8, func(r rune, b []byte) int {
+		// Valid multibyte sequences rarely happen by chance
+		return 6
+	}},
+	{"GBK", simplifiedchinese.GBK, func(r rune, b []byte) int {
+		return hanScore(r, b, 0xB0, 0xD7)
+	}},
+	{"Big5", traditionalchinese.Big5, func(r rune, b []byte) int {
+		return hanScore(r, b, 0xA4, 0xC6)
+	}},
+	{"Shift_JIS", japanese.ShiftJIS, func(r rune, b []byte) int {
+		switch {
+		case r >= 0xFF61 && r <= 0xFF9F:
+			// Half-width katakana are rare in tags
+			return 0
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			return 2
+		}
+		return hanScore(r, b, 0x88, 0x9F)
+	}},
+	{"EUC-KR", korean.EUCKR, func(r rune, b []byte) int {
+		switch {
+		case unicode.Is(unicode.Hangul, r) && len(b) == 2 && b[0] >= 0xB0 && b[0] <= 0xC8 && b[1] >= 0xA1:
+			// Syllables of KS X 1001, the others are rarely used
+			return 3
+		case unicode.Is(unicode.Hangul, r):
+			return -1
+		case unicode.Is(unicode.Han, r):
+			// Hanja mixed into Hangul is unusual
+			return -6
+		}
+		return punctuationScore(r)
+	}},
+	{"windows-1251", charmap.Windows1251, func(r rune, b []byte) int {
+		if unicode.Is(unicode.Cyrillic, r) && unicode.IsLetter(r) {
+			return 1
+		}
+		return -2
+	}},
+}
+
+// Scores Han characters, most common ones when the lead byte is in [lo, hi]
+func hanScore(r rune, b []byte, lo, hi byte) int {
+	if !unicode.Is(unicode.Han, r) {
+		return punctuationScore(r)
+	}
+
+	if len(b) == 2 && b[0] >= lo && b[0] <= hi {
+		return 2
+	}
+	return 1
+}
+
+// CJK and full-width punctuation
+func punctuationScore(r rune) int {
+	if (r >= 0x3000 && r <= 0x303F) || (r >= 0xFF00 && r <= 0xFFEF) {
+		return 1
+	}
+	return -1
+}
+
+// How likely data is text in the character set, a negative score if invalid
+func (c charset) likelihood(data []byte) int {
+	decoded, err := c.enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return -1
+	}
+
+	encoder := c.enc.NewEncoder()
+	score := 0
+	var prev rune
+	for _, r := range string(decoded) {
+		switch {
+		case r == unicode.ReplacementChar, unicode.Is(unicode.Co, r), !unicode.IsPrint(r) && r != 0:
+			return -1
+		case r < 0x80:
+		default:
+			b, _ := encoder.Bytes([]byte(string(r)))
+			score += c.score(r, b)
+
+			// Case does not change inside a word
+			if unicode.IsUpper(r) && unicode.IsLower(prev) {
+				score -= 3
+			}
+		}
+		prev = r
+	}
+
+	return score
+}
+
+// Reports whether bytes read as ISO-8859-1 look like real ISO-8859-1 text
+// Western text has accented letters alone or in pairs inside words, while
+// multibyte and other single byte character sets give longer runs of high
+// bytes or use the C1 control range.
+func PlausibleLatin1(data []byte) bool {
+	isLetter := func(i int) bool {
+		return i >= 0 && i < len(data) && data[i] < 0x80 && unicode.IsLetter(rune(data[i]))
+	}
+
+	for i := 0; i < len(data); {
+		if data[i] < 0x80 {
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(data) && data[i] >= 0x80 {
+			if data[i] < 0xA0 {
+				return false
+			}
+			i++
+		}
+
+		switch i - start {
+		case 1:
+		case 2:
+			// A pair of accented letters only happens inside a word
+			if !isLetter(start-1) && !isLetter(i) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// How likely data is ISO-8859-1 text, the number of accented letters
+func latin1Likelihood(data []byte) int {
+	score := 0
+	for _, b := range data {
+		if b >= 0xC0 && b != 0xD7 && b != 0xF7 {
+			score++
+		}
+	}
+
+	return score
+}
+
+// Guesses the character set of text declared ISO-8859-1
+// Recognizes UTF-8, GBK, Big5, Shift-JIS, EUC-KR and Windows-1251. Text that
+// is plausible ISO-8859-1 is kept unless a candidate scores clearly higher.
+// Returns an empty name and nil encoding when the text reads as ISO-8859-1 or
+// no candidate fits. Short texts in the CJK character sets are easily confused.
+func DetectCharset(data []byte) (string, encoding.Encoding) {
+	best, bestScore := -1, 0
+	if PlausibleLatin1(data) {
+		bestScore = 2 * latin1Likelihood(data)
+	}
+
+	for i, c := range charsets {
+		if score := c.likelihood(data); score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+
+	if best < 0 {
+		return "", nil
+	}
+	return charsets[best].name, charsets[best].enc
+}
+
+// Reports whether data reads better as ISO-8859-1 than in another charset
+func readsAsLatin1(data []byte) bool {
+	if !PlausibleLatin1(data) {
+		return false
+	}
+
+	name, _ := DetectCharset(data)
+	return name == ""
+}
+
+// Suggested character set for a frame declared ISO-8859-1
+type CharsetSuggestion struct {
+	FrameId  string
+	Charset  string
+	Encoding encoding.Encoding
+	// Text of the frame decoded with the suggested character set
+	Text string
+}
+
+// Text of frames declared ISO-8859-1 back in its original bytes
+func latin1Texts(f Framer) (raw [][]byte, ok bool) {
+	var texts []string
+	switch f := f.(type) {
+	case *TextFrame:
+		ok, texts = f.encoding == 0, []string{f.text}
+	case *CreditsFrame:
+		ok, texts = f.encoding == 0, []string{f.text}
+	case *DescTextFrame:
+		ok, texts = f.encoding == 0, []string{f.description, f.text}
+	case *UnsynchTextFrame:
+		ok, texts = f.encoding == 0, []string{f.description, f.text}
+	case *ImageFrame:
+		ok, texts = f.encoding == 0, []string{f.description}
+	}
+
+	for _, s := range texts {
+		b, err := encodedbytes.EncodedStringBytes(trimNull(s), 0)
+		if err != nil {
+			return nil, false
+		}
+		raw = append(raw, b)
+	}
+
+	return raw, ok
+}
+
+func joinRaw(raw [][]byte) []byte {
+	var data []byte
+	for _, b := range raw {
+		if len(data) > 0 && len(b) > 0 {
+			data = append(data, ' ')
+		}
+		data = append(data, b...)
+	}
+
+	return data
+}
+
+// Flags frames declared ISO-8859-1 whose bytes are implausible as such
+// Each frame is given the most likely character set of its own text.
+func (t *Tag) SuggestCharsets() []CharsetSuggestion {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var suggestions []CharsetSuggestion
+	for _, f := range t.frames {
+		raw, ok := latin1Texts(f)
+		if !ok {
+			continue
+		}
+
+		data := joinRaw(raw)
+		name, enc := DetectCharset(data)
+		if enc == nil {
+			continue
+		}
+
+		text, _ := enc.NewDecoder().Bytes(data)
+		suggestions = append(suggestions, CharsetSuggestion{
+			FrameId:  f.Id(),
+			Charset:  name,
+			Encoding: enc,
+			Text:     string(text),
+		})
+	}
+
+	return suggestions
+}
+
+// Guesses the character set of all text declared ISO-8859-1 in the tag
+// Frames that are plausible ISO-8859-1 are left out. Returns an empty name
+// and nil encoding when there is nothing to reinterpret.
+func (t *Tag) DetectCharset() (string, encoding.Encoding) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.detectCharset()
+}
+
+func (t *Tag) detectCharset() (string, encoding.Encoding) {
+	var all [][]byte
+	for _, f := range t.frames {
+		if raw, ok := latin1Texts(f); ok {
+			if data := joinRaw(raw); !readsAsLatin1(data) {
+				all = append(all, data)
+			}
+		}
+	}
+
+	return DetectCharset(joinRaw(all))
+}
+
+// Reinterprets implausible ISO-8859-1 text with the detected character set
+// Frames that read well as ISO-8859-1 are kept as they are. Returns the
+// character set used, empty if none, and the number of frames changed.
+func (t *Tag) FixCharset() (string, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	name, enc := t.detectCharset()
+	if enc == nil {
+		return "", 0
+	}
+
+	return name, t.reinterpret(enc, func(data []byte) bool {
+		return !readsAsLatin1(data)
+	})
+}
+
+// Decodes the text of frames declared ISO-8859-1 with another encoding
+// Many taggers wrote text in the local code page, such as GBK, Big5,
+// Shift-JIS, EUC-KR or Windows-1251, while declaring ISO-8859-1. The text is
+// then stored in an encoding that can represent it. Returns the number of
+// frames that were changed.
+func (t *Tag) ReinterpretTextAs(enc encoding.Encoding) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.reinterpret(enc, func([]byte) bool { return true })
+}
+
+// Reinterprets the frames whose original bytes are accepted by filter
+// The caller must hold the write lock.
+func (t *Tag) reinterpret(enc encoding.Encoding, filter func([]byte) bool) int {
+	decoder := enc.NewDecoder()
+	recode := func(s string) string {
+		raw, err := encodedbytes.EncodedStringBytes(s, 0)
+		if err != nil {
+			return s
+		}
+
+		decoded, err := decoder.Bytes(raw)
+		if err != nil {
+			return s
+		}
+
+		return string(decoded)
+	}
+
+	n := 0
+	for _, f := range t.frames {
+		raw, ok := latin1Texts(f)
+		if !ok || !filter(joinRaw(raw)) {
+			continue
+		}
+
+		if cf, ok := f.(*CreditsFrame); ok {
+			f = &cf.TextFrame
+		}
+
+		switch f := f.(type) {
+		case *TextFrame:
+			if text := recode(f.text); text != f.text {
+				f.SetEncoding("UTF-8")
+				f.SetText(text)
+				f.SetEncoding(t.encodingFor(text))
+				n++
+			}
+		case *DescTextFrame:
+			desc, text := recode(f.description), recode(f.text)
+			if desc != f.description || text != f.text {
+				f.SetEncoding("UTF-8")
+				f.SetDescription(desc)
+				f.SetText(text)
+				f.SetEncoding(t.encodingFor(desc + text))
+				n++
+			}
+		case *UnsynchTextFrame:
+			desc, text := recode(f.description), recode(f.text)
+			if desc != f.description || text != f.text {
+				f.SetEncoding("UTF-8")
+				f.SetDescription(desc)
+				f.SetText(text)
+				f.SetEncoding(t.encodingFor(desc + text))
+				n++
+			}
+		case *ImageFrame:
+			if desc := recode(f.description); desc != f.description {
+				f.SetEncoding("UTF-8")
+				f.SetDescription(desc)
+				f.SetEncoding(t.encodingFor(desc))
+				n++
+			}
+		}
+	}
+
+	return n
+}
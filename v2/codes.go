@@ -0,0 +1,193 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+)
+
+// Country code, registrant code, year and designation code
+var isrcPattern = regexp.MustCompile(`^[A-Z]{2}[A-Z0-9]{3}[0-9]{7}$`)
+
+// Checks an ISO 639-2 language code, returning it in lower case
+func parseLanguage(code string) (string, error) {
+	lower := strings.ToLower(code)
+	if len(lower) != 3 || strings.Trim(lower, "abcdefghijklmnopqrstuvwxyz") != "" {
+		return "", fmt.Errorf("%w: %q is not three letters", ErrBadLanguage, code)
+	}
+
+	if _, err := language.ParseBase(lower); err != nil {
+		return "", fmt.Errorf("%w: %q is unknown", ErrBadLanguage, code)
+	}
+
+	return lower, nil
+}
+
+// Language of comment, lyrics and terms of use frames created without one
+var (
+	languageMu      sync.RWMutex
+	defaultLanguage = "eng"
+)
+
+// Sets the language given to comment, lyrics and terms of use frames created
+// without one, "eng" unless changed
+// The setting applies to every tag, so change it before creating any frames.
+// Tag.SetCommentLanguage overrides it for a single tag.
+func SetDefaultLanguage(code string) error {
+	lower, err := parseLanguage(code)
+	if err != nil {
+		return err
+	}
+
+	languageMu.Lock()
+	defer languageMu.Unlock()
+
+	defaultLanguage = lower
+	return nil
+}
+
+// Language given to frames created without one
+func DefaultLanguage() string {
+	languageMu.RLock()
+	defer languageMu.RUnlock()
+
+	return defaultLanguage
+}
+
+// Language of the comment and lyrics frames the tag creates, such as with
+// SetAll, the package default unless SetCommentLanguage changed it
+func (t *Tag) CommentLanguage() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.language == "" {
+		return DefaultLanguage()
+	}
+	return t.language
+}
+
+// Sets the language of the comment and lyrics frames the tag creates
+// Frames already in the tag keep theirs. An empty code restores the package
+// default.
+func (t *Tag) SetCommentLanguage(code string) error {
+	if code != "" {
+		var err error
+		if code, err = parseLanguage(code); err != nil {
+			return err
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.language = code
+	return nil
+}
+
+// Splits TLAN text into codes
+// ID3v2.4 separates values with nulls, older taggers use slashes, commas or
+// spaces, or write the codes one after another.
+func splitLanguages(text string) []string {
+	var codes []string
+	for _, field := range strings.FieldsFunc(text, func(r rune) bool {
+		return r == 0 || r == '/' || r == ',' || r == ';' || r == ' '
+	}) {
+		for len(field) > 3 && len(field)%3 == 0 {
+			codes = append(codes, field[:3])
+			field = field[3:]
+		}
+		codes = append(codes, field)
+	}
+
+	return codes
+}
+
+// Normalizes an ISRC, allowing the hyphens it is often printed with
+func parseISRC(isrc string) (string, error) {
+	code := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(isrc), "-", ""))
+	if !isrcPattern.MatchString(code) {
+		return "", fmt.Errorf("%w: %q is not of the form CC-XXX-YY-NNNNN", ErrBadISRC, isrc)
+	}
+
+	return code, nil
+}
+
+// Frame type of an ID3v2.3 frame ID in the tag's version
+func (t *Tag) frameType(id string) FrameType {
+	ft, _ := lookupFrameType(t.version, convertFrameId(id, 3, t.version))
+	return ft
+}
+
+// Languages of the audio from the TLAN frame
+// All well-formed codes are returned in lower case, along with an error
+// for the first malformed one.
+func (t *Tag) Languages() ([]string, error) {
+	var codes []string
+	var err error
+	for _, code := range splitLanguages(t.textFrameText(t.frameType("TLAN"))) {
+		lower, e := parseLanguage(code)
+		if e != nil {
+			if err == nil {
+				err = e
+			}
+			continue
+		}
+		codes = append(codes, lower)
+	}
+
+	return codes, err
+}
+
+// Sets the TLAN frame, nothing is written when a code is malformed
+func (t *Tag) SetLanguages(codes ...string) error {
+	values := make([]string, len(codes))
+	for i, code := range codes {
+		lower, err := parseLanguage(code)
+		if err != nil {
+			return err
+		}
+		values[i] = lower
+	}
+
+	sep := "/"
+	if t.version >= 4 {
+		sep = "\x00"
+	}
+
+	t.setTextFrameText(t.frameType("TLAN"), strings.Join(values, sep))
+	return nil
+}
+
+// International Standard Recording Code from the TSRC frame
+// Returns "" when there is none, and the text with an error when it is not
+// a well-formed ISRC.
+func (t *Tag) ISRC() (string, error) {
+	text := strings.TrimSpace(t.textFrameText(t.frameType("TSRC")))
+	if text == "" {
+		return "", nil
+	}
+
+	code, err := parseISRC(text)
+	if err != nil {
+		return text, err
+	}
+
+	return code, nil
+}
+
+// Sets the TSRC frame, hyphens are removed before writing
+func (t *Tag) SetISRC(isrc string) error {
+	code, err := parseISRC(isrc)
+	if err != nil {
+		return err
+	}
+
+	t.setTextFrameText(t.frameType("TSRC"), code)
+	return nil
+}
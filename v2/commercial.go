@@ -0,0 +1,27 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+// Pages where the audio can be bought, from the WCOM frames
+func (t *Tag) CommercialURLs() []string {
+	return t.linkFrameURLs("WCOM")
+}
+
+// Replaces the WCOM frames with one for each URL, none removes them
+// The URLs must be absolute and ISO-8859-1.
+func (t *Tag) SetCommercialURLs(urls ...string) error {
+	return t.setLinkFrameURLs("WCOM", urls...)
+}
+
+// Page handling payment for the audio, from WPAY
+func (t *Tag) PaymentURL() string {
+	return t.linkFrameURL("WPAY")
+}
+
+// Sets the WPAY frame, an empty URL removes it
+// The URL must be absolute and ISO-8859-1, and ID3v2.2 tags give
+// ErrFrameNotAllowed.
+func (t *Tag) SetPaymentURL(url string) error {
+	return t.setLinkFrameURL("WPAY", url)
+}
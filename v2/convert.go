@@ -0,0 +1,275 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"strings"
+
+	"github.com/lion187chen/id3-go/encodedbytes"
+)
+
+// ID3v2.3 frames whose ID3v2.4 replacement has the same layout
+var convertibleReplacements = []string{"IPLS", "TORY", "TYER"}
+
+// Frame ID of the equivalent frame in another major version, "" if none
+// IDs are translated through their ID3v2.3 form.
+func convertFrameId(id string, from, to byte) string {
+	if from == to {
+		return id
+	}
+
+	v23Id := id
+	switch from {
+	case 2:
+		v23Id = V23DeprecatedTypeMap[id]
+	case 4:
+		for v23, v24 := range V24ReplacedTypeMap {
+			if v24 == id && contains(convertibleReplacements, v23) {
+				v23Id = v23
+			}
+		}
+		// Musician credits join the involved people list
+		if id == "TMCL" {
+			v23Id = "IPLS"
+		}
+	}
+
+	switch {
+	case v23Id == "":
+		return ""
+	case to == 2:
+		for v22Id, v23 := range V23DeprecatedTypeMap {
+			if v23 == v23Id {
+				return v22Id
+			}
+		}
+		return ""
+	case to == 4 && contains(convertibleReplacements, v23Id):
+		return V24ReplacedTypeMap[v23Id]
+	}
+
+	return v23Id
+}
+
+// Equivalent frame IDs across major versions, including id itself
+func frameIdAliases(id string) []string {
+	v23Id := id
+	if len(id) == 3 {
+		v23Id = V23DeprecatedTypeMap[id]
+	}
+	for v23, v24 := range V24ReplacedTypeMap {
+		if v24 == id {
+			v23Id = v23
+		}
+	}
+
+	aliases := []string{id}
+	for _, alias := range []string{v23Id, V24ReplacedTypeMap[v23Id], convertFrameId(v23Id, 3, 2)} {
+		if alias != "" && !contains(aliases, alias) {
+			aliases = append(aliases, alias)
+		}
+	}
+
+	return aliases
+}
+
+// Tag alter preservation, file alter preservation and read only status flags
+// of each major version, ID3v2.2 has no frame flags
+var statusFlagBits = map[byte][3]byte{
+	3: {0x80, 0x40, 0x20},
+	4: {0x40, 0x20, 0x10},
+}
+
+// Format flags that change the layout of the frame body: compression,
+// encryption and grouping, and from ID3v2.4 on unsynchronisation and the
+// data length indicator
+var bodyFlags = map[byte]byte{
+	3: 0xE0,
+	4: 0x4F,
+}
+
+// Format flags whose body the frame constructors cannot read
+func opaqueBody(version, formatFlags byte) bool {
+	return formatFlags&bodyFlags[version]&^0x02 != 0
+}
+
+// Frame flags translated to another major version
+// Reports false when the body depends on format flags, whose extra header
+// data is laid out differently in each version.
+func convertFlags(status, format, from, to byte) (byte, byte, bool) {
+	if from == to {
+		return status, format, true
+	}
+
+	if format&bodyFlags[from] != 0 {
+		return 0, 0, false
+	}
+
+	var converted byte
+	for i, bit := range statusFlagBits[from] {
+		if status&bit != 0 {
+			converted |= statusFlagBits[to][i]
+		}
+	}
+
+	return converted, 0, true
+}
+
+// Rebuilds a frame for another major version
+// The result never shares state with f. Returns nil when the frame has no
+// equivalent in that version.
+func convertFrame(f Framer, from, to byte) Framer {
+	id := convertFrameId(f.Id(), from, to)
+	if id == "" {
+		return nil
+	}
+
+	status, format, ok := convertFlags(f.StatusFlags(), f.FormatFlags(), from, to)
+	if !ok {
+		return nil
+	}
+
+	ft, _ := lookupFrameType(to, id)
+	if opaqueBody(to, format) {
+		ft.constructor = ParseDataFrame
+	}
+	head := FrameHead{
+		FrameType:   ft,
+		statusFlags: status,
+		formatFlags: format,
+	}
+
+	data := append([]byte(nil), f.Bytes()...)
+	if imf, ok := f.(*ImageFrame); ok && id != f.Id() {
+		// PIC stores a three letter image format instead of a MIME type
+		if to == 2 {
+			data = picBody(imf)
+		} else {
+			data = apicBody(imf)
+		}
+	}
+	head.size = uint32(len(data))
+
+	frame, err := newFrame(head, data)
+	if err != nil {
+		return nil
+	}
+
+	if to < 4 {
+		convertV24Text(frame, f.Id())
+	}
+
+	return frame
+}
+
+// Copy of the tag converted to another major version
+// Frames are translated as by CopyFramesFrom, unknown frames are carried
+// over unchanged apart from their flags. Frames that cannot be converted,
+// having no equivalent ID or a compressed, encrypted or grouped body, and
+// unknown frames marked DiscardOnTagAlteration are returned instead of being
+// dropped silently.
+func (t *Tag) Convert(version byte) (*Tag, []Framer) {
+	c := NewTag(version)
+	c.SetPadding(t.Padding())
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var dropped []Framer
+	for _, f := range t.AllFrames() {
+		// Converting alters the tag
+		if t.discardedOnAlteration(f) {
+			dropped = append(dropped, f)
+			continue
+		}
+
+		frame := convertFrame(f, t.version, c.version)
+		if frame == nil {
+			dropped = append(dropped, f)
+			continue
+		}
+		if mergeCredits(c.frames, frame) {
+			continue
+		}
+
+		c.addFrames(frame)
+	}
+
+	return c, dropped
+}
+
+// Rewrites text that is only valid in ID3v2.4 for older versions
+func convertV24Text(f Framer, fromId string) {
+	tf, ok := f.(TextFramer)
+	if !ok {
+		if imf, ok := f.(*ImageFrame); ok && imf.encoding == encodedbytes.NativeEncoding {
+			imf.SetEncoding(encodingFor(trimNull(imf.description)))
+		}
+		return
+	}
+
+	text := tf.Text()
+
+	// Timestamps become plain years
+	if fromId == "TDRC" || fromId == "TDOR" {
+		if year := trimNull(text); len(year) > 4 {
+			tf.SetText(year[:4])
+			text = year[:4]
+		}
+	}
+
+	if tf.Encoding() == "UTF-8" {
+		desc := ""
+		switch df := f.(type) {
+		case *DescTextFrame:
+			desc = df.Description()
+		case *UnsynchTextFrame:
+			desc = df.Description()
+		}
+		tf.SetEncoding(encodingFor(text + desc))
+	}
+}
+
+// Encoding allowed before ID3v2.4 that can represent s
+func encodingFor(s string) string {
+	if isLatin1(s) {
+		return "ISO-8859-1"
+	}
+
+	return "UTF-16"
+}
+
+// APIC frame body holding the picture of an image frame
+func apicBody(f *ImageFrame) []byte {
+	mimeType := strings.TrimRight(f.mimeType, "\x00")
+
+	desc, err := encodedbytes.EncodedNullTermStringBytes(trimNull(f.description), f.encoding)
+	if err != nil {
+		return nil
+	}
+
+	data := make([]byte, 0, 1+len(mimeType)+1+1+len(desc)+len(f.data))
+	data = append(data, f.encoding)
+	data = append(data, mimeType...)
+	data = append(data, 0, f.pictureType)
+	data = append(data, desc...)
+
+	return append(data, f.data...)
+}
+
+// PIC frame body holding the picture of an image frame
+func picBody(f *ImageFrame) []byte {
+	desc, err := encodedbytes.EncodedNullTermStringBytes(trimNull(f.description), f.encoding)
+	if err != nil {
+		return nil
+	}
+
+	data := make([]byte, 0, 1+3+1+len(desc)+len(f.data))
+	data = append(data, f.encoding)
+	data = append(data, picFormat(f.mimeType)...)
+	data = append(data, f.pictureType)
+	data = append(data, desc...)
+
+	return append(data, f.data...)
+}
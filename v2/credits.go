@@ -0,0 +1,196 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"strings"
+)
+
+// Credit names a person and their role or instrument
+type Credit struct {
+	Role string
+	Name string
+}
+
+// CreditsFrame represents involved people lists, IPLS before ID3v2.4 and
+// TIPL or TMCL from it on
+// The text holds null separated pairs of role and name.
+type CreditsFrame struct {
+	TextFrame
+}
+
+// Returns nil when the encoding is unknown or cannot represent the credits
+func NewCreditsFrame(ft FrameType, credits []Credit, encoding string) *CreditsFrame {
+	f := NewTextFrame(ft, creditsText(credits), encoding)
+	if f == nil {
+		return nil
+	}
+
+	return &CreditsFrame{TextFrame: *f}
+}
+
+func ParseCreditsFrame(head FrameHead, data []byte) Framer {
+	f, ok := ParseTextFrame(head, data).(*TextFrame)
+	if !ok {
+		return nil
+	}
+
+	return &CreditsFrame{TextFrame: *f}
+}
+
+func creditsText(credits []Credit) string {
+	var sb strings.Builder
+	for _, c := range credits {
+		sb.WriteString(c.Role)
+		sb.WriteByte(0)
+		sb.WriteString(c.Name)
+		sb.WriteByte(0)
+	}
+
+	return strings.TrimSuffix(sb.String(), "\x00")
+}
+
+// Role and name pairs, a role without a name gets an empty one
+func (f CreditsFrame) Credits() []Credit {
+	text := f.Text()
+	if text == "" {
+		return nil
+	}
+
+	fields := strings.Split(text, "\x00")
+	credits := make([]Credit, 0, (len(fields)+1)/2)
+	for i := 0; i < len(fields); i += 2 {
+		c := Credit{Role: fields[i]}
+		if i+1 < len(fields) {
+			c.Name = fields[i+1]
+		}
+		credits = append(credits, c)
+	}
+
+	return credits
+}
+
+func (f *CreditsFrame) SetCredits(credits []Credit) error {
+	return f.SetText(creditsText(credits))
+}
+
+func (f *CreditsFrame) AddCredit(role, name string) error {
+	return f.SetCredits(append(f.Credits(), Credit{role, name}))
+}
+
+// Credits are written as "role: name" separated by "; "
+func (f CreditsFrame) AppendText(b []byte) ([]byte, error) {
+	for i, c := range f.Credits() {
+		if i > 0 {
+			b = append(b, "; "...)
+		}
+		b = append(b, c.Role...)
+		b = append(b, ": "...)
+		b = append(b, c.Name...)
+	}
+
+	return b, nil
+}
+
+func (f CreditsFrame) MarshalText() ([]byte, error) {
+	return f.AppendText(nil)
+}
+
+func (f CreditsFrame) String() string {
+	return frameString(f)
+}
+
+func (f CreditsFrame) Clone() Framer {
+	f.detach()
+	return &f
+}
+
+func (f CreditsFrame) Equal(other Framer) bool {
+	return framesEqual(&f, other)
+}
+
+// Frames holding credits, the involved people list before ID3v2.4 and both
+// lists from it on
+func creditsIds(version byte) []string {
+	switch version {
+	case 2:
+		return []string{"IPL"}
+	case 4:
+		return []string{"TIPL", "TMCL"}
+	}
+
+	return []string{"IPLS"}
+}
+
+// Credits of the involved people and musician credits lists
+func (t *Tag) Credits() []Credit {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var credits []Credit
+	for _, id := range creditsIds(t.version) {
+		for _, f := range t.frames {
+			if cf, ok := f.(*CreditsFrame); ok && cf.Id() == id {
+				credits = append(credits, cf.Credits()...)
+			}
+		}
+	}
+
+	return credits
+}
+
+// Adds a person to the involved people list
+// ID3v2.4 tags keep musicians in a separate list, add those to the TMCL
+// frame directly.
+func (t *Tag) AddCredit(role, name string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	id := creditsIds(t.version)[0]
+	for _, f := range t.frames {
+		if cf, ok := f.(*CreditsFrame); ok && cf.Id() == id {
+			credits := append(cf.Credits(), Credit{role, name})
+			text := creditsText(credits)
+
+			// Switch through UTF-8, which can represent both texts
+			cf.SetEncoding("UTF-8")
+			if err := cf.SetText(text); err != nil {
+				return err
+			}
+			return cf.SetEncoding(t.encodingFor(text))
+		}
+	}
+
+	ft, _ := lookupFrameType(t.version, id)
+	credits := []Credit{{role, name}}
+	f := NewCreditsFrame(ft, credits, t.encodingFor(creditsText(credits)))
+	if f == nil {
+		return ErrUnknownEncoding
+	}
+
+	t.addFrames(f)
+	return nil
+}
+
+// Adds the credits of f to a frame with the same ID already in frames
+// Converting ID3v2.4 lists to ID3v2.3 gives two IPLS frames, which are kept
+// as one. Reports whether f was merged.
+func mergeCredits(frames []Framer, f Framer) bool {
+	cf, ok := f.(*CreditsFrame)
+	if !ok {
+		return false
+	}
+
+	for _, g := range frames {
+		if other, ok := g.(*CreditsFrame); ok && other != cf && other.Id() == cf.Id() {
+			text := creditsText(append(other.Credits(), cf.Credits()...))
+			other.SetEncoding("UTF-8")
+			other.SetText(text)
+			other.SetEncoding(encodingFor(text))
+			return true
+		}
+	}
+
+	return false
+}
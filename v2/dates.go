@@ -0,0 +1,324 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How much of a date is known
+type DatePrecision int
+
+const (
+	// No date is set
+	PrecisionNone DatePrecision = iota
+	PrecisionYear
+	PrecisionMonth
+	PrecisionDay
+	PrecisionHour
+	PrecisionMinute
+	PrecisionSecond
+)
+
+// ID3v2.4 timestamp layouts, indexed by precision
+var timestampLayouts = []string{
+	PrecisionYear:   "2006",
+	PrecisionMonth:  "2006-01",
+	PrecisionDay:    "2006-01-02",
+	PrecisionHour:   "2006-01-02T15",
+	PrecisionMinute: "2006-01-02T15:04",
+	PrecisionSecond: "2006-01-02T15:04:05",
+}
+
+// Parses an ID3v2.4 timestamp, a subset of ISO 8601 in UTC
+func parseTimestamp(s string) (time.Time, DatePrecision, error) {
+	for p := PrecisionSecond; p > PrecisionNone; p-- {
+		if len(s) != len(timestampLayouts[p]) {
+			continue
+		}
+		if t, err := time.Parse(timestampLayouts[p], s); err == nil {
+			return t, p, nil
+		}
+	}
+
+	return time.Time{}, PrecisionNone, fmt.Errorf("%w: %q is not a timestamp", ErrBadDate, s)
+}
+
+func formatTimestamp(t time.Time, p DatePrecision) string {
+	return t.UTC().Format(timestampLayouts[p])
+}
+
+// Parses the year, DDMM date and HHMM time frames of older versions
+func parseLegacyDate(year, date, clock string) (time.Time, DatePrecision, error) {
+	y, err := strconv.Atoi(year)
+	if err != nil || len(year) != 4 {
+		return time.Time{}, PrecisionNone, fmt.Errorf("%w: %q is not a four digit year", ErrBadDate, year)
+	}
+
+	t := time.Date(y, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if date == "" {
+		return t, PrecisionYear, nil
+	}
+
+	day, err := time.Parse("0201", date)
+	if err != nil {
+		return t, PrecisionYear, fmt.Errorf("%w: %q is not of the form DDMM", ErrBadDate, date)
+	}
+	t = time.Date(y, day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	if clock == "" {
+		return t, PrecisionDay, nil
+	}
+
+	hm, err := time.Parse("1504", clock)
+	if err != nil {
+		return t, PrecisionDay, fmt.Errorf("%w: %q is not of the form HHMM", ErrBadDate, clock)
+	}
+	return t.Add(time.Duration(hm.Hour())*time.Hour + time.Duration(hm.Minute())*time.Minute), PrecisionMinute, nil
+}
+
+func (t *Tag) frameText(id string) string {
+	return strings.TrimSpace(t.textFrameText(t.frameType(id)))
+}
+
+// Reads a timestamp frame of ID3v2.4 or the year, date and time frames of
+// older versions
+// A missing date gives PrecisionNone and no error.
+func (t *Tag) date(v24Id, yearId, dateId, timeId string) (time.Time, DatePrecision, error) {
+	if t.version >= 4 {
+		text := t.frameText(v24Id)
+		if text == "" {
+			return time.Time{}, PrecisionNone, nil
+		}
+		return parseTimestamp(text)
+	}
+
+	year := t.frameText(yearId)
+	if year == "" {
+		return time.Time{}, PrecisionNone, nil
+	}
+
+	var date, clock string
+	if dateId != "" {
+		date = t.frameText(dateId)
+	}
+	if timeId != "" && date != "" {
+		clock = t.frameText(timeId)
+	}
+
+	return parseLegacyDate(year, date, clock)
+}
+
+// Checks that a date can be written with the precision
+func checkDate(date time.Time, p DatePrecision) error {
+	if p < PrecisionNone || p > PrecisionSecond {
+		return fmt.Errorf("%w: unknown precision %d", ErrBadDate, p)
+	}
+	if date.UTC().Year() < 0 || date.UTC().Year() > 9999 {
+		return fmt.Errorf("%w: year %d does not have four digits", ErrBadDate, date.Year())
+	}
+
+	return nil
+}
+
+// Writes a date the way date reads it
+// Precision the version cannot store is dropped, PrecisionNone removes the
+// date.
+func (t *Tag) setDate(date time.Time, p DatePrecision, v24Id, yearId, dateId, timeId string) error {
+	if err := checkDate(date, p); err != nil {
+		return err
+	}
+
+	date = date.UTC()
+	set := func(id, text string) error {
+		if id == "" {
+			return nil
+		}
+		return t.setFrameText(id, text)
+	}
+
+	if t.version >= 4 {
+		if p == PrecisionNone {
+			return set(v24Id, "")
+		}
+		return set(v24Id, formatTimestamp(date, p))
+	}
+
+	var year, day, clock string
+	if p >= PrecisionYear {
+		year = date.Format("2006")
+	}
+	if p >= PrecisionDay {
+		day = date.Format("0201")
+	}
+	if p >= PrecisionHour {
+		clock = date.Format("1504")
+	}
+
+	for _, field := range [][2]string{{yearId, year}, {dateId, day}, {timeId, clock}} {
+		if err := set(field[0], field[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Date the audio was recorded, from TDRC or TYER, TDAT and TIME
+func (t *Tag) RecordingDate() (time.Time, DatePrecision, error) {
+	return t.date("TDRC", "TYER", "TDAT", "TIME")
+}
+
+func (t *Tag) SetRecordingDate(date time.Time, p DatePrecision) error {
+	return t.setDate(date, p, "TDRC", "TYER", "TDAT", "TIME")
+}
+
+// Description of the user defined text frame holding the release date
+// before ID3v2.4
+const releaseDateDesc = "RELEASEDATE"
+
+// Date the audio was released, from TDRL
+// Before ID3v2.4 there is no release date frame, so the date is kept as an
+// ID3v2.4 timestamp in a RELEASEDATE user defined text frame. Without one,
+// the recording date frames are used as they are by most taggers.
+func (t *Tag) ReleaseDate() (time.Time, DatePrecision, error) {
+	if t.version < 4 {
+		if text := strings.TrimSpace(t.userText(releaseDateDesc)); text != "" {
+			return parseTimestamp(text)
+		}
+	}
+
+	return t.date("TDRL", "TYER", "TDAT", "TIME")
+}
+
+// Before ID3v2.4 the date goes to a RELEASEDATE user defined text frame,
+// leaving the recording date alone.
+func (t *Tag) SetReleaseDate(date time.Time, p DatePrecision) error {
+	if t.version >= 4 {
+		return t.setDate(date, p, "TDRL", "", "", "")
+	}
+	if err := checkDate(date, p); err != nil {
+		return err
+	}
+
+	text := ""
+	if p != PrecisionNone {
+		text = formatTimestamp(date, p)
+	}
+	t.setUserText(releaseDateDesc, text)
+	return nil
+}
+
+// Date the original recording was released, from TDOR or TORY
+// Before ID3v2.4 only the year is kept.
+func (t *Tag) OriginalReleaseDate() (time.Time, DatePrecision, error) {
+	return t.date("TDOR", "TORY", "", "")
+}
+
+func (t *Tag) SetOriginalReleaseDate(date time.Time, p DatePrecision) error {
+	return t.setDate(date, p, "TDOR", "TORY", "", "")
+}
+
+// Fixes common mistakes in an ID3v2.4 timestamp
+// Slashes and dots between date fields become hyphens, a space before the
+// time becomes a T, single digit fields are padded, and fractional seconds
+// and a trailing Z are dropped. The result is checked like
+// ValidateTimestamp.
+func NormalizeTimestamp(s string) (string, error) {
+	text := strings.TrimSuffix(strings.TrimSpace(trimNull(s)), "Z")
+
+	date, clock, hasClock := strings.Cut(text, "T")
+	if !hasClock {
+		date, clock, hasClock = strings.Cut(text, " ")
+	}
+
+	pad := func(fields []string) {
+		for i, f := range fields {
+			if len(f) == 1 {
+				fields[i] = "0" + f
+			}
+		}
+	}
+
+	dateFields := strings.FieldsFunc(date, func(r rune) bool {
+		return r == '-' || r == '/' || r == '.'
+	})
+	if len(dateFields) == 0 {
+		return "", fmt.Errorf("%w: %q is not a timestamp", ErrBadDate, s)
+	}
+	pad(dateFields[1:])
+	text = strings.Join(dateFields, "-")
+
+	if hasClock {
+		clock, _, _ = strings.Cut(strings.TrimSpace(clock), ".")
+		clockFields := strings.Split(clock, ":")
+		pad(clockFields)
+		text += "T" + strings.Join(clockFields, ":")
+	}
+
+	t, p, err := parseTimestamp(text)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q is not a timestamp", ErrBadDate, s)
+	}
+
+	return formatTimestamp(t, p), nil
+}
+
+// Checks a timestamp against the ISO 8601 subset of ID3v2.4
+// Allowed are yyyy, yyyy-MM, yyyy-MM-dd, yyyy-MM-ddTHH, yyyy-MM-ddTHH:mm and
+// yyyy-MM-ddTHH:mm:ss.
+func ValidateTimestamp(s string) error {
+	_, _, err := parseTimestamp(s)
+	return err
+}
+
+// ID3v2.4 frames holding a timestamp
+func isTimestampFrame(id string) bool {
+	switch id {
+	case "TDEN", "TDOR", "TDRC", "TDRL", "TDTG":
+		return true
+	}
+
+	return false
+}
+
+// Checks the text of a timestamp frame, fixing it first when lenient
+func timestampText(text string, lenient bool) (string, error) {
+	if lenient {
+		return NormalizeTimestamp(text)
+	}
+
+	return text, ValidateTimestamp(text)
+}
+
+// Makes the text setters fix malformed timestamps with NormalizeTimestamp
+func (t *Tag) SetLenientTimestamps(lenient bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.lenientTimestamps = lenient
+}
+
+func (t *Tag) lenient() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.lenientTimestamps
+}
+
+// Sets a timestamp frame such as TDRC, TDRL or TDOR
+// Malformed values fail with ErrBadDate, or are fixed first after
+// SetLenientTimestamps. Timestamp frames need ID3v2.4.
+func (t *Tag) SetTimestamp(id, text string) error {
+	if !isTimestampFrame(id) {
+		return fmt.Errorf("%w: %s is not a timestamp frame", ErrBadDate, id)
+	}
+	if t.version < 4 {
+		return fmt.Errorf("%w: %s needs ID3v2.4", ErrUnsupportedVersion, id)
+	}
+
+	ft, _ := lookupFrameType(t.version, id)
+	return t.setTextFrameText(ft, text)
+}
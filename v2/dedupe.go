@@ -0,0 +1,66 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+// Which of several clashing frames Dedupe keeps
+type DedupePolicy int
+
+const (
+	// Keeps the frame that appears first in the tag
+	KeepFirst DedupePolicy = iota
+	// Keeps the frame that appears last, usually the one written last
+	KeepLast
+)
+
+// Removes exact duplicate frames and extra instances of frames the spec
+// requires to be unique
+// Tags concatenated by several tools often repeat frames. The order of the
+// frames that are kept does not change. Returns the removed frames.
+func (t *Tag) Dedupe(policy DedupePolicy) []Framer {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n := len(t.frames)
+	seen := make(map[string]bool)
+	drop := make(map[Framer]bool)
+	for i := 0; i < n; i++ {
+		f := t.frames[i]
+		if policy == KeepLast {
+			f = t.frames[n-1-i]
+		}
+
+		keys := []string{f.Id() + "\x00" + string(f.Bytes())}
+		if key, unique := uniqueKey(f); unique {
+			keys = append(keys, key)
+		}
+		if key, unique := pictureTypeKey(f); unique {
+			keys = append(keys, key)
+		}
+
+		for _, key := range keys {
+			if seen[key] {
+				drop[f] = true
+			}
+		}
+		if !drop[f] {
+			for _, key := range keys {
+				seen[key] = true
+			}
+		}
+	}
+
+	if len(drop) == 0 {
+		return nil
+	}
+
+	var removed []Framer
+	for _, f := range t.frames {
+		if drop[f] {
+			removed = append(removed, f)
+		}
+	}
+	t.removeFrames(func(f Framer) bool { return drop[f] })
+
+	return removed
+}
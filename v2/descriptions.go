@@ -0,0 +1,32 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+// Mood of the audio, such as "Calm", from TMOO
+func (t *Tag) Mood() string {
+	return t.frameText("TMOO")
+}
+
+// Sets the TMOO frame, which ID3v2.2 does not have
+func (t *Tag) SetMood(mood string) {
+	t.setFrameText("TMOO", mood)
+}
+
+// Refinement of the title, such as "Live at Wembley", from TIT3
+func (t *Tag) Subtitle() string {
+	return t.frameText("TIT3")
+}
+
+func (t *Tag) SetSubtitle(subtitle string) {
+	t.setFrameText("TIT3", subtitle)
+}
+
+// Larger work the audio belongs to, such as "Piano Concertos", from TIT1
+func (t *Tag) ContentGroup() string {
+	return t.frameText("TIT1")
+}
+
+func (t *Tag) SetContentGroup(group string) {
+	t.setFrameText("TIT1", group)
+}
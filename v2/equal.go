@@ -0,0 +1,98 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Reports whether two frames hold the same decoded content
+// Flags, sizes, text encodings and trailing terminators are ignored.
+func framesEqual(a, b Framer) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Id() == b.Id() && contentKey(a) == contentKey(b)
+}
+
+// Decoded content of a frame as a comparable string
+func contentKey(f Framer) string {
+	fields := []string{fmt.Sprintf("%T", f)}
+
+	switch f := f.(type) {
+	case *UnsynchTextFrame:
+		fields = append(fields, f.Language(), trimNull(f.Description()), trimNull(f.Text()))
+	case *DescTextFrame:
+		fields = append(fields, trimNull(f.Description()), trimNull(f.Text()))
+	case *TextFrame:
+		fields = append(fields, trimNull(f.Text()))
+	case *CreditsFrame:
+		fields = append(fields, f.Text())
+	case *ImageFrame:
+		mimeType := strings.ToLower(trimNull(f.MIMEType()))
+		if mimeType == "image/jpg" {
+			mimeType = "image/jpeg"
+		}
+		fields = append(fields, mimeType, string(f.PictureType()), strings.TrimSpace(trimNull(f.Description())), string(f.Data()))
+	case *IdFrame:
+		fields = append(fields, trimNull(f.OwnerIdentifier()), string(f.Identifier()))
+	case *ChapterFrame:
+		fields = append(fields, f.Element, fmt.Sprint(f.StartTime, f.EndTime, f.StartByte, f.EndByte, f.UseTime), trimNull(f.Title()), trimNull(f.Link()))
+	case *TOCFrame:
+		fields = append(fields, f.Element, fmt.Sprint(f.TopLevel, f.Ordered))
+		fields = append(fields, f.ChildElements...)
+	default:
+		fields = append(fields, string(f.Bytes()))
+	}
+
+	return strings.Join(fields, "\x00")
+}
+
+// Reports whether other holds the same frames as the tag
+// Frame order, padding and flags are ignored, and frames of a tag with a
+// different version are compared after translating them to this version.
+func (t *Tag) Equal(other FrameSource) bool {
+	if other == nil {
+		return false
+	}
+
+	var from byte
+	if _, err := fmt.Sscanf(other.Version(), "2.%d", &from); err != nil {
+		return false
+	}
+
+	mine := t.AllFrames()
+	theirs := other.AllFrames()
+	if len(mine) != len(theirs) {
+		return false
+	}
+
+	keys := make([]string, len(mine))
+	for i, f := range mine {
+		keys[i] = f.Id() + "\x00" + contentKey(f)
+	}
+
+	otherKeys := make([]string, len(theirs))
+	for i, f := range theirs {
+		if from != t.version {
+			if f = convertFrame(f, from, t.version); f == nil {
+				return false
+			}
+		}
+		otherKeys[i] = f.Id() + "\x00" + contentKey(f)
+	}
+
+	sort.Strings(keys)
+	sort.Strings(otherKeys)
+	for i := range keys {
+		if keys[i] != otherKeys[i] {
+			return false
+		}
+	}
+
+	return true
+}
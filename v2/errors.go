@@ -0,0 +1,107 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lion187chen/id3-go/encodedbytes"
+)
+
+var (
+	ErrUnsupportedVersion = errors.New("version: unsupported ID3v2 version")
+	// Returned for undefined encodings and text an encoding cannot represent
+	ErrBadEncoding = encodedbytes.ErrBadEncoding
+
+	ErrTagTooLarge   = errors.New("limit: tag too large")
+	ErrFrameTooLarge = errors.New("limit: frame too large")
+	ErrTooManyFrames = errors.New("limit: too many frames")
+
+	ErrUnknownFrameId  = errors.New("frame: unknown frame id")
+	ErrBadFrameHeader  = errors.New("frame: malformed frame header")
+	ErrNotSynchsafe    = errors.New("frame: frame size is not synchsafe")
+	ErrBadFrameSize    = errors.New("frame: frame size exceeds tag")
+	ErrTruncatedFrame  = errors.New("frame: truncated frame body")
+	ErrUnknownEncoding = &kindError{"frame: unknown text encoding", ErrBadEncoding}
+	ErrInvalidFrame    = errors.New("frame: invalid frame body")
+	ErrResynchronized  = errors.New("frame: skipped damaged bytes")
+	ErrReadOnlyFrame   = errors.New("frame: frame is read-only")
+	ErrNoByteOrderMark = errors.New("frame: UTF-16 text has no byte order mark")
+
+	ErrFrameNotAllowed    = errors.New("spec: frame id not allowed in this version")
+	ErrEncodingNotAllowed = &kindError{"spec: text encoding not allowed in this version", ErrBadEncoding}
+	ErrDuplicateFrame     = errors.New("spec: frame must be unique")
+	ErrBadPadding         = errors.New("spec: padding contains non-zero bytes")
+	ErrFlagNotAllowed     = errors.New("spec: header flag not defined in this version")
+	ErrCompressedTag      = errors.New("spec: ID3v2.2 tag compression has no defined scheme")
+	ErrBadExtendedHeader  = errors.New("spec: malformed extended header")
+	ErrBadCRC             = errors.New("spec: tag CRC-32 does not match its frames")
+	ErrRestricted         = errors.New("spec: tag breaks its restrictions")
+
+	ErrBadLanguage = errors.New("value: not an ISO 639-2 language code")
+	ErrBadISRC     = errors.New("value: not an ISRC")
+	ErrBadDate     = errors.New("value: malformed date")
+	ErrBadValue    = errors.New("value: invalid field value")
+
+	ErrImageFormat  = errors.New("value: unrecognized image format")
+	ErrMIMEMismatch = errors.New("value: MIME type does not match image data")
+
+	errPadding = errors.New("frame: reached padding")
+)
+
+// Error that is also one of the broader errors above, for errors.Is
+type kindError struct {
+	msg  string
+	kind error
+}
+
+func (e *kindError) Error() string {
+	return e.msg
+}
+
+func (e *kindError) Unwrap() error {
+	return e.kind
+}
+
+// LimitError is returned when a tag exceeds one of the ParseOptions limits
+// It wraps one of ErrTagTooLarge, ErrFrameTooLarge or ErrTooManyFrames
+type LimitError struct {
+	Err     error
+	FrameId string
+	Value   int64
+	Max     int64
+}
+
+func (e *LimitError) Error() string {
+	if e.FrameId != "" {
+		return fmt.Sprintf("%v: %s is %d bytes, maximum is %d", e.Err, e.FrameId, e.Value, e.Max)
+	}
+
+	return fmt.Sprintf("%v: %d exceeds maximum of %d", e.Err, e.Value, e.Max)
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+// SpecError is returned by strict parsing for the first off-spec construct
+type SpecError struct {
+	// Offset of the frame header from the start of the tag header
+	Offset  int
+	FrameId string
+	Err     error
+}
+
+func (e *SpecError) Error() string {
+	if e.FrameId == "" {
+		return fmt.Sprintf("offset %d: %v", e.Offset, e.Err)
+	}
+
+	return fmt.Sprintf("offset %d: %s: %v", e.Offset, e.FrameId, e.Err)
+}
+
+func (e *SpecError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,327 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"unicode/utf8"
+
+	"github.com/lion187chen/id3-go/encodedbytes"
+)
+
+// Extended header flags of ID3v2.4
+const (
+	extendedUpdate       byte = 1 << 6
+	extendedCRC          byte = 1 << 5
+	extendedRestrictions byte = 1 << 4
+)
+
+// ExtendedHeader holds the optional data between an ID3v2.4 tag header and
+// its frames
+type ExtendedHeader struct {
+	// The tag updates an earlier tag in the file
+	Update bool
+	// Writes the CRC-32 of the frames and padding, which parsing checks
+	CRC bool
+	// Limits the tag declares on its contents, nil for none
+	Restrictions *Restrictions
+	// Makes WriteTo fail when the tag breaks its restrictions
+	Enforce bool
+}
+
+// Restrictions an ID3v2.4 tag declares on its contents
+type Restrictions struct {
+	// 0 to 3: at most 128 frames and 1 MB, 64 frames and 128 KB, 32 frames
+	// and 40 KB, or 32 frames and 4 KB
+	TagSize byte
+	// Only ISO-8859-1 and UTF-8 text
+	TextEncoding bool
+	// 0 for none, 1 to 3: text fields of at most 1024, 128 or 30 characters
+	TextSize byte
+	// Only PNG and JPEG pictures
+	ImageEncoding bool
+	// 0 for none, 1 to 3: pictures of at most 256x256 or 64x64 pixels, or
+	// of exactly 64x64 pixels
+	ImageSize byte
+}
+
+// Maximum frames and bytes of each tag size restriction
+var tagSizeLimits = [4][2]int{
+	{128, 1 << 20},
+	{64, 128 << 10},
+	{32, 40 << 10},
+	{32, 4 << 10},
+}
+
+// Maximum characters of each text size restriction
+var textSizeLimits = [4]int{0, 1024, 128, 30}
+
+func (r Restrictions) byte() byte {
+	b := (r.TagSize&3)<<6 | (r.TextSize&3)<<3 | r.ImageSize&3
+	if r.TextEncoding {
+		b |= 1 << 5
+	}
+	if r.ImageEncoding {
+		b |= 1 << 2
+	}
+
+	return b
+}
+
+func parseRestrictions(b byte) *Restrictions {
+	return &Restrictions{
+		TagSize:       b >> 6,
+		TextEncoding:  b&(1<<5) != 0,
+		TextSize:      b >> 3 & 3,
+		ImageEncoding: b&(1<<2) != 0,
+		ImageSize:     b & 3,
+	}
+}
+
+// Length of the extended header as written
+func (e *ExtendedHeader) size() int {
+	if e == nil {
+		return 0
+	}
+
+	size := 6
+	if e.Update {
+		size += 1
+	}
+	if e.CRC {
+		size += 6
+	}
+	if e.Restrictions != nil {
+		size += 2
+	}
+
+	return size
+}
+
+// Extended header for a body of frames and padding
+func (e *ExtendedHeader) bytes(crc uint32) []byte {
+	var flags byte
+	data := make([]byte, 6, e.size())
+
+	if e.Update {
+		flags |= extendedUpdate
+		data = append(data, 0)
+	}
+	if e.CRC {
+		flags |= extendedCRC
+		data = append(data, 5, byte(crc>>28), byte(crc>>21)&0x7F, byte(crc>>14)&0x7F, byte(crc>>7)&0x7F, byte(crc)&0x7F)
+	}
+	if e.Restrictions != nil {
+		flags |= extendedRestrictions
+		data = append(data, 1, e.Restrictions.byte())
+	}
+
+	copy(data, encodedbytes.SynchBytes(uint32(len(data))))
+	data[4], data[5] = 1, flags
+	return data
+}
+
+// Parses an ID3v2.4 extended header at the start of a tag body
+// Returns the header with the stored CRC, and the length it takes.
+func parseExtendedHeader(data []byte) (*ExtendedHeader, uint32, int, error) {
+	if len(data) < 6 {
+		return nil, 0, 0, ErrBadExtendedHeader
+	}
+
+	size, err := encodedbytes.SynchInt(data[:4])
+	if err != nil || size < 6 || int(size) > len(data) || data[4] != 1 {
+		return nil, 0, 0, ErrBadExtendedHeader
+	}
+
+	e := new(ExtendedHeader)
+	flags := data[5]
+	rest := data[6:size]
+	var crc uint32
+
+	// Each flag is followed by the length of its data
+	field := func(length int) ([]byte, bool) {
+		if len(rest) < 1+length || int(rest[0]) != length {
+			return nil, false
+		}
+		b := rest[1 : 1+length]
+		rest = rest[1+length:]
+		return b, true
+	}
+
+	if flags&extendedUpdate != 0 {
+		if _, e.Update = field(0); !e.Update {
+			return nil, 0, 0, ErrBadExtendedHeader
+		}
+	}
+	if flags&extendedCRC != 0 {
+		b, ok := field(5)
+		if !ok {
+			return nil, 0, 0, ErrBadExtendedHeader
+		}
+		e.CRC = true
+		for _, c := range b {
+			crc = crc<<7 | uint32(c&0x7F)
+		}
+	}
+	if flags&extendedRestrictions != 0 {
+		b, ok := field(1)
+		if !ok {
+			return nil, 0, 0, ErrBadExtendedHeader
+		}
+		e.Restrictions = parseRestrictions(b[0])
+	}
+
+	return e, crc, int(size), nil
+}
+
+// Extended header of an ID3v2.4 tag, nil when it has none
+func (t *Tag) Extended() *ExtendedHeader {
+	t.sizeMu.Lock()
+	defer t.sizeMu.Unlock()
+
+	if t.extended == nil {
+		return nil
+	}
+
+	e := *t.extended
+	if e.Restrictions != nil {
+		r := *e.Restrictions
+		e.Restrictions = &r
+	}
+	return &e
+}
+
+// Sets the extended header of an ID3v2.4 tag, nil removes it
+// Other versions give ErrFlagNotAllowed.
+func (t *Tag) SetExtended(e *ExtendedHeader) error {
+	t.sizeMu.Lock()
+	defer t.sizeMu.Unlock()
+
+	if t.version != 4 {
+		if e == nil {
+			return nil
+		}
+		return ErrFlagNotAllowed
+	}
+
+	if e != nil {
+		c := *e
+		if c.Restrictions != nil {
+			r := *c.Restrictions
+			c.Restrictions = &r
+		}
+		e = &c
+	}
+
+	diff := e.size() - t.extended.size()
+	if d := int(t.padding) - diff; d < 0 {
+		t.padding = 0
+		t.size += uint32(-d)
+	} else {
+		t.padding = uint(d)
+	}
+
+	t.extended = e
+	if e != nil {
+		t.Header.putFlags(t.flags | FlagExtendedHeader)
+	} else {
+		t.Header.putFlags(t.flags &^ FlagExtendedHeader)
+	}
+	t.dirty = true
+	return nil
+}
+
+// Checks the tag against the restrictions of its extended header
+// Returns every violation joined, each wrapping ErrRestricted.
+func (t *Tag) CheckRestrictions() error {
+	e := t.Extended()
+	if e == nil {
+		return nil
+	}
+
+	return t.checkRestrictions(e, t.AllFrames(), t.Size())
+}
+
+// Checks frames and the size of a tag, which includes its padding
+func (t *Tag) checkRestrictions(e *ExtendedHeader, frames []Framer, size int) error {
+	r := e.Restrictions
+	if r == nil {
+		return nil
+	}
+
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%w: "+format, append([]interface{}{ErrRestricted}, args...)...))
+	}
+
+	limit := tagSizeLimits[r.TagSize&3]
+	if len(frames) > limit[0] {
+		fail("%d frames, at most %d allowed", len(frames), limit[0])
+	}
+	if size += HeaderSize; size > limit[1] {
+		fail("%d bytes, at most %d allowed", size, limit[1])
+	}
+
+	for _, f := range frames {
+		if tf, ok := f.(TextFramer); ok {
+			if r.TextEncoding && tf.Encoding() != "ISO-8859-1" && tf.Encoding() != "UTF-8" {
+				fail("%s: %s text", f.Id(), tf.Encoding())
+			}
+			if max := textSizeLimits[r.TextSize&3]; max > 0 && utf8.RuneCountInString(tf.Text()) > max {
+				fail("%s: text longer than %d characters", f.Id(), max)
+			}
+		}
+
+		imf, ok := f.(*ImageFrame)
+		if !ok || imf.MIMEType() == "-->" {
+			continue
+		}
+		if mimeType := imf.MIMEType(); r.ImageEncoding && mimeType != "image/png" && mimeType != "image/jpeg" {
+			fail("%s: %s picture", f.Id(), mimeType)
+		}
+		width, height := imageSize(imf.Data())
+		switch r.ImageSize & 3 {
+		case 1:
+			if width > 256 || height > 256 {
+				fail("%s: %dx%d picture larger than 256x256", f.Id(), width, height)
+			}
+		case 2:
+			if width > 64 || height > 64 {
+				fail("%s: %dx%d picture larger than 64x64", f.Id(), width, height)
+			}
+		case 3:
+			if width != 64 || height != 64 {
+				fail("%s: %dx%d picture not 64x64", f.Id(), width, height)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Reads the extended header of an ID3v2.4 tag body
+// Returns the offset of the first frame. A malformed extended header is
+// dropped and reported, as is a CRC that does not match the body.
+func (t *Tag) parseExtended(data []byte, opts ParseOptions) (int, error) {
+	if t.version != 4 || !t.extendedHeader {
+		return 0, nil
+	}
+
+	e, crc, size, err := parseExtendedHeader(data)
+	if err != nil {
+		t.Header.setFlag(FlagExtendedHeader, false)
+		return 0, t.problem(opts, 0, "", err)
+	}
+	t.extended = e
+
+	if e.CRC && crc32.ChecksumIEEE(data[size:]) != crc {
+		if err := t.problem(opts, size, "", ErrBadCRC); err != nil {
+			return 0, err
+		}
+	}
+
+	return size, nil
+}
@@ -6,8 +6,10 @@ package v2
 import (
 	"bytes"
 	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/lion187chen/id3-go/encodedbytes"
@@ -34,7 +36,16 @@ type Framer interface {
 	FormatFlags() byte
 	String() string
 	Bytes() []byte
+	// RawBytes returns the header and body as they were read from the file,
+	// nil for frames that were not parsed
+	RawBytes() []byte
+	// Clone returns an independent copy that belongs to no tag
+	Clone() Framer
+	// Equal reports whether other holds the same decoded content
+	Equal(other Framer) bool
 	setOwner(*Tag)
+	setStatusFlags(byte)
+	preservedBytes(version byte) []byte
 }
 
 // FrameHead represents the header of each frame
@@ -46,17 +57,28 @@ type FrameHead struct {
 	formatFlags byte
 	size        uint32
 	owner       *Tag
+	// Header and body as read, shared with the parse buffer
+	raw []byte
+	// Header and body as parsed with ParseOptions.Preserve, written instead
+	// of the frame while it is unedited, and the version they were read as
+	preserved        []byte
+	preservedVersion byte
 }
 
 func (ft FrameType) Id() string {
 	return ft.id
 }
 
+func (ft FrameType) Description() string {
+	return ft.description
+}
+
 func (h FrameHead) Size() uint {
 	return uint(h.size)
 }
 
 func (h *FrameHead) changeSize(diff int) {
+	h.preserved = nil
 	if diff >= 0 {
 		h.size += uint32(diff)
 	} else {
@@ -76,10 +98,44 @@ func (h FrameHead) FormatFlags() byte {
 	return h.formatFlags
 }
 
+// Later changes to the frame are not reflected
+func (h FrameHead) RawBytes() []byte {
+	return cloneBytes(h.raw)
+}
+
+// Unlinks a cloned frame from its tag and the bytes it was parsed from
+func (h *FrameHead) detach() {
+	h.owner = nil
+	h.raw = cloneBytes(h.raw)
+	h.preserved = cloneBytes(h.preserved)
+}
+
+// Tells the owner that the frame changed without changing size
+func (h *FrameHead) markDirty() {
+	h.preserved = nil
+	if h.owner != nil {
+		h.owner.MarkDirty()
+	}
+}
+
 func (h *FrameHead) setOwner(t *Tag) {
 	h.owner = t
 }
 
+func (h *FrameHead) setStatusFlags(flags byte) {
+	h.preserved = nil
+	h.statusFlags = flags
+}
+
+// Bytes the frame was parsed from, if it is preserved and unedited, for a
+// tag of the version it was read as
+func (h *FrameHead) preservedBytes(version byte) []byte {
+	if version != h.preservedVersion {
+		return nil
+	}
+	return h.preserved
+}
+
 // DataFrame is the default frame for binary data
 type DataFrame struct {
 	FrameHead
@@ -109,14 +165,50 @@ func (f *DataFrame) SetData(b []byte) {
 	f.data = b
 }
 
+func (f DataFrame) AppendText(b []byte) ([]byte, error) {
+	return fmt.Appendf(b, "<binary data, %d bytes>", len(f.data)), nil
+}
+
+func (f DataFrame) MarshalText() ([]byte, error) {
+	return f.AppendText(nil)
+}
+
 func (f DataFrame) String() string {
-	return "<binary data>"
+	return frameString(f)
 }
 
 func (f DataFrame) Bytes() []byte {
 	return f.data
 }
 
+// Writes an encoded frame body
+// The body ends at the frame size, so running out of room is not an error:
+// Bytes drops a terminator that does not fit in the same way.
+func writeEncoded(w io.Writer, encode func() ([]byte, error)) (int64, error) {
+	b, err := encode()
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+
+	n, err := w.Write(b)
+	return int64(n), err
+}
+
+func (f DataFrame) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(f.data)
+	return int64(n), err
+}
+
+func (f DataFrame) Clone() Framer {
+	f.detach()
+	f.data = cloneBytes(f.data)
+	return &f
+}
+
+func (f DataFrame) Equal(other Framer) bool {
+	return framesEqual(&f, other)
+}
+
 // IdFrame represents identification tags
 type IdFrame struct {
 	FrameHead
@@ -125,9 +217,11 @@ type IdFrame struct {
 }
 
 func NewIdFrame(ft FrameType, ownerId string, id []byte) *IdFrame {
+	// The owner is stored with its terminator, as parsed
+	ownerId = trimNull(ownerId) + "\x00"
 	head := FrameHead{
 		FrameType: ft,
-		size:      uint32(1 + len(ownerId) + len(id)),
+		size:      uint32(len(ownerId) + len(id)),
 	}
 
 	return &IdFrame{
@@ -168,7 +262,7 @@ func (f IdFrame) Identifier() []byte {
 
 func (f *IdFrame) SetIdentifier(id []byte) error {
 	if len(id) > 64 {
-		return errors.New("identifier: identifier too long")
+		return fmt.Errorf("%w: identifier longer than 64 bytes", ErrBadValue)
 	}
 
 	f.changeSize(len(id) - len(f.identifier))
@@ -177,24 +271,57 @@ func (f *IdFrame) SetIdentifier(id []byte) error {
 	return nil
 }
 
+func (f IdFrame) AppendText(b []byte) ([]byte, error) {
+	b = append(b, trimNull(f.ownerIdentifier)...)
+	b = append(b, ": "...)
+	if isPrintable(f.identifier) {
+		return append(b, f.identifier...), nil
+	}
+
+	return hex.AppendEncode(b, f.identifier), nil
+}
+
+func (f IdFrame) MarshalText() ([]byte, error) {
+	return f.AppendText(nil)
+}
+
 func (f IdFrame) String() string {
-	return fmt.Sprintf("%s: %v", f.ownerIdentifier, f.identifier)
+	return frameString(f)
 }
 
-func (f IdFrame) Bytes() []byte {
+func (f IdFrame) encode() ([]byte, error) {
 	var err error
 	bytes := make([]byte, f.Size())
 	wr := encodedbytes.NewWriter(bytes)
 
 	if err = wr.WriteString(f.ownerIdentifier, encodedbytes.NativeEncoding); err != nil {
-		return bytes
+		return bytes, err
 	}
 
 	if _, err = wr.Write(f.identifier); err != nil {
-		return bytes
+		return bytes, err
 	}
 
-	return bytes
+	return bytes, nil
+}
+
+func (f IdFrame) Bytes() []byte {
+	b, _ := f.encode()
+	return b
+}
+
+func (f IdFrame) WriteTo(w io.Writer) (int64, error) {
+	return writeEncoded(w, f.encode)
+}
+
+func (f IdFrame) Clone() Framer {
+	f.detach()
+	f.identifier = cloneBytes(f.identifier)
+	return &f
+}
+
+func (f IdFrame) Equal(other Framer) bool {
+	return framesEqual(&f, other)
 }
 
 // TextFramer represents frames that contain encoded text
@@ -211,26 +338,32 @@ type TextFrame struct {
 	FrameHead
 	encoding byte
 	text     string
+	// The size has no room for a terminator after the text, as parsed
+	unterminated bool
 }
 
+// Returns nil when the encoding is unknown or cannot represent text
 func NewTextFrame(ft FrameType, text string, encoding string) *TextFrame {
 	i := byte(encodedbytes.IndexForEncoding(encoding))
-	nullLength := encodedbytes.EncodingNullLengthForIndex(i)
-	head := FrameHead{
-		FrameType: ft,
-		size:      uint32(1 + len(text) + nullLength),
+	if i == 0xFF {
+		return nil
 	}
 
-	if i == 0xFF {
+	encoded, err := encodedbytes.EncodedNullTermStringBytes(text, i)
+	if err != nil {
 		return nil
 	}
-	f := &TextFrame{
+
+	head := FrameHead{
+		FrameType: ft,
+		size:      uint32(1 + len(encoded)),
+	}
+
+	return &TextFrame{
 		FrameHead: head,
 		text:      text,
 		encoding:  i,
 	}
-	f.SetEncoding(encoding)
-	return f
 }
 
 func ParseTextFrame(head FrameHead, data []byte) Framer {
@@ -245,6 +378,7 @@ func ParseTextFrame(head FrameHead, data []byte) Framer {
 	if f.text, err = rd.ReadRestString(f.encoding); err != nil {
 		return nil
 	}
+	f.unterminated = !strings.HasSuffix(f.text, "\x00")
 
 	return f
 }
@@ -256,52 +390,88 @@ func (f TextFrame) Encoding() string {
 func (f *TextFrame) SetEncoding(encoding string) error {
 	i := byte(encodedbytes.IndexForEncoding(encoding))
 	if i == 0xFF {
-		return errors.New("encoding: invalid encoding")
+		return ErrBadEncoding
 	}
 
-	diff, err := encodedbytes.EncodedDiff(i, f.text, f.encoding, f.text)
+	diff, err := terminatedDiff(i, f.text, f.encoding, f.text, !f.unterminated)
 	if err != nil {
 		return err
 	}
 
 	f.changeSize(diff)
 	f.encoding = i
+	f.unterminated = false
 	return nil
 }
 
+// Text without trailing terminators, values of ID3v2.4 multi-value frames
+// stay separated by nulls
 func (f TextFrame) Text() string {
+	return trimNull(f.text)
+}
+
+// Text as parsed, with any trailing terminators or padding
+func (f TextFrame) RawText() string {
 	return f.text
 }
 
 func (f *TextFrame) SetText(text string) error {
-	diff, err := encodedbytes.EncodedDiff(f.encoding, text, f.encoding, f.text)
+	diff, err := terminatedDiff(f.encoding, text, f.encoding, f.text, !f.unterminated)
 	if err != nil {
 		return err
 	}
 
 	f.changeSize(diff)
 	f.text = text
+	f.unterminated = false
 	return nil
 }
 
+// Values of ID3v2.4 multi-value frames are separated by "; "
+func (f TextFrame) AppendText(b []byte) ([]byte, error) {
+	return append(b, textValues(f.text)...), nil
+}
+
+func (f TextFrame) MarshalText() ([]byte, error) {
+	return f.AppendText(nil)
+}
+
 func (f TextFrame) String() string {
-	return f.text
+	return frameString(f)
 }
 
-func (f TextFrame) Bytes() []byte {
+func (f TextFrame) encode() ([]byte, error) {
 	var err error
 	bytes := make([]byte, f.Size())
 	wr := encodedbytes.NewWriter(bytes)
 
 	if err = wr.WriteByte(f.encoding); err != nil {
-		return bytes
+		return bytes, err
 	}
 
 	if err = wr.WriteNullTermString(f.text, f.encoding); err != nil {
-		return bytes
+		return bytes, err
 	}
 
-	return bytes
+	return bytes, nil
+}
+
+func (f TextFrame) Bytes() []byte {
+	b, _ := f.encode()
+	return b
+}
+
+func (f TextFrame) WriteTo(w io.Writer) (int64, error) {
+	return writeEncoded(w, f.encode)
+}
+
+func (f TextFrame) Clone() Framer {
+	f.detach()
+	return &f
+}
+
+func (f TextFrame) Equal(other Framer) bool {
+	return framesEqual(&f, other)
 }
 
 type DescTextFrame struct {
@@ -309,10 +479,28 @@ type DescTextFrame struct {
 	description string
 }
 
+// The URL of WXXX frames is always ISO-8859-1, only the description uses
+// the encoding.
 func NewDescTextFrame(ft FrameType, desc, text string, encoding string) *DescTextFrame {
+	i := byte(encodedbytes.IndexForEncoding(encoding))
+	if i == 0xFF {
+		return nil
+	}
+
 	f := NewTextFrame(ft, text, encoding)
-	nullLength := encodedbytes.EncodingNullLengthForIndex(f.encoding)
-	f.size += uint32(len(desc) + nullLength)
+	if isLinkId(ft.Id()) {
+		f = NewTextFrame(ft, text, "ISO-8859-1")
+	}
+	if f == nil {
+		return nil
+	}
+	f.encoding = i
+
+	encoded, err := encodedbytes.EncodedNullTermStringBytes(desc, f.encoding)
+	if err != nil {
+		return nil
+	}
+	f.size += uint32(len(encoded))
 
 	return &DescTextFrame{
 		TextFrame:   *f,
@@ -335,30 +523,57 @@ func ParseDescTextFrame(head FrameHead, data []byte) Framer {
 	if f.description, err = rd.ReadNullTermString(f.encoding); err != nil {
 		return nil
 	}
-	l, err := encodedbytes.EncodedNullTermStringBytes(f.description, f.encoding)
+	l, err := encodedbytes.EncodedNullTermLen(f.description, f.encoding)
 	if err != nil {
 		return nil
 	}
-	f.size += uint32(len(l))
+	f.size += uint32(l)
 
-	if f.text, err = rd.ReadRestString(f.encoding); err != nil {
+	if f.text, err = rd.ReadRestString(f.textEncoding()); err != nil {
 		return nil
 	}
-	l, err = encodedbytes.EncodedStringBytes(f.text, f.encoding)
+	f.unterminated = !strings.HasSuffix(f.text, "\x00")
+	l, err = encodedbytes.EncodedLen(f.text, f.textEncoding())
 	if err != nil {
 		return nil
 	}
-	f.size += uint32(len(l))
+	f.size += uint32(l)
 
 	return f
 }
 
+// User defined links, whose URL is ISO-8859-1 whatever the encoding
+func isLinkId(id string) bool {
+	return id == "WXX" || id == "WXXX"
+}
+
+// Encoding of the text, the description always uses the frame encoding
+func (f DescTextFrame) textEncoding() byte {
+	if isLinkId(f.Id()) {
+		return 0
+	}
+
+	return f.encoding
+}
+
+func (f *DescTextFrame) SetText(text string) error {
+	diff, err := terminatedDiff(f.textEncoding(), text, f.textEncoding(), f.text, !f.unterminated)
+	if err != nil {
+		return err
+	}
+
+	f.changeSize(diff)
+	f.text = text
+	f.unterminated = false
+	return nil
+}
+
 func (f DescTextFrame) Description() string {
-	return f.description
+	return trimNull(f.description)
 }
 
 func (f *DescTextFrame) SetDescription(description string) error {
-	diff, err := encodedbytes.EncodedDiff(f.encoding, description, f.encoding, f.description)
+	diff, err := terminatedDiff(f.encoding, description, f.encoding, f.description, true)
 	if err != nil {
 		return err
 	}
@@ -369,52 +584,93 @@ func (f *DescTextFrame) SetDescription(description string) error {
 }
 
 func (f *DescTextFrame) SetEncoding(encoding string) error {
+	return f.setEncoding(encoding, true)
+}
+
+// Changes the encoding, with or without a terminator after the text
+func (f *DescTextFrame) setEncoding(encoding string, terminated bool) error {
 	i := byte(encodedbytes.IndexForEncoding(encoding))
 	if i == 0xFF {
-		return errors.New("encoding: invalid encoding")
+		return ErrBadEncoding
+	}
+
+	textEnc := i
+	if isLinkId(f.Id()) {
+		textEnc = 0
 	}
 
-	descDiff, err := encodedbytes.EncodedDiff(i, f.text, f.encoding, f.text)
+	textDiff, err := encodedbytes.EncodedDiff(textEnc, f.text, f.textEncoding(), f.text)
+	if terminated {
+		textDiff, err = terminatedDiff(textEnc, f.text, f.textEncoding(), f.text, !f.unterminated)
+	}
 	if err != nil {
 		return err
 	}
 
-	newNullLength := encodedbytes.EncodingNullLengthForIndex(i)
-	oldNullLength := encodedbytes.EncodingNullLengthForIndex(f.encoding)
-	nullDiff := newNullLength - oldNullLength
-
-	textDiff, err := encodedbytes.EncodedDiff(i, f.description, f.encoding, f.description)
+	descDiff, err := terminatedDiff(i, f.description, f.encoding, f.description, true)
 	if err != nil {
 		return err
 	}
 
-	f.changeSize(descDiff + nullDiff + textDiff)
+	f.changeSize(textDiff + descDiff)
 	f.encoding = i
+	f.unterminated = false
 	return nil
 }
 
+func (f DescTextFrame) AppendText(b []byte) ([]byte, error) {
+	if desc := trimNull(f.description); desc != "" {
+		b = append(b, desc...)
+		b = append(b, ": "...)
+	}
+
+	return append(b, textValues(f.text)...), nil
+}
+
+func (f DescTextFrame) MarshalText() ([]byte, error) {
+	return f.AppendText(nil)
+}
+
 func (f DescTextFrame) String() string {
-	return fmt.Sprintf("%s: %s", f.description, f.text)
+	return frameString(f)
 }
 
-func (f DescTextFrame) Bytes() []byte {
+func (f DescTextFrame) encode() ([]byte, error) {
 	var err error
 	bytes := make([]byte, f.Size())
 	wr := encodedbytes.NewWriter(bytes)
 
 	if err = wr.WriteByte(f.encoding); err != nil {
-		return bytes
+		return bytes, err
 	}
 
 	if err = wr.WriteNullTermString(f.description, f.encoding); err != nil {
-		return bytes
+		return bytes, err
 	}
 
-	if err = wr.WriteNullTermString(f.text, f.encoding); err != nil {
-		return bytes
+	if err = wr.WriteNullTermString(f.text, f.textEncoding()); err != nil {
+		return bytes, err
 	}
 
-	return bytes
+	return bytes, nil
+}
+
+func (f DescTextFrame) Bytes() []byte {
+	b, _ := f.encode()
+	return b
+}
+
+func (f DescTextFrame) WriteTo(w io.Writer) (int64, error) {
+	return writeEncoded(w, f.encode)
+}
+
+func (f DescTextFrame) Clone() Framer {
+	f.detach()
+	return &f
+}
+
+func (f DescTextFrame) Equal(other Framer) bool {
+	return framesEqual(&f, other)
 }
 
 // UnsynchTextFrame represents frames that contain unsynchronized text
@@ -429,7 +685,7 @@ func NewUnsynchTextFrame(ft FrameType, desc, text string) *UnsynchTextFrame {
 
 	return &UnsynchTextFrame{
 		DescTextFrame: *f,
-		language:      "eng",
+		language:      DefaultLanguage(),
 	}
 }
 
@@ -452,24 +708,30 @@ func ParseUnsynchTextFrame(head FrameHead, data []byte) Framer {
 	if f.description, err = rd.ReadNullTermString(f.encoding); err != nil {
 		return nil
 	}
-	l, err := encodedbytes.EncodedNullTermStringBytes(f.description, f.encoding)
+	l, err := encodedbytes.EncodedNullTermLen(f.description, f.encoding)
 	if err != nil {
 		return nil
 	}
-	f.size += uint32(len(l))
+	f.size += uint32(l)
 
 	if f.text, err = rd.ReadRestString(f.encoding); err != nil {
 		return nil
 	}
-	l, err = encodedbytes.EncodedStringBytes(f.text, f.encoding)
+	f.unterminated = !strings.HasSuffix(f.text, "\x00")
+	l, err = encodedbytes.EncodedLen(f.text, f.encoding)
 	if err != nil {
 		return nil
 	}
-	f.size += uint32(len(l))
+	f.size += uint32(l)
 
 	return f
 }
 
+// The text of unsynchronised frames is not null terminated
+func (f *UnsynchTextFrame) SetEncoding(encoding string) error {
+	return f.setEncoding(encoding, false)
+}
+
 func (f UnsynchTextFrame) Language() string {
 	return f.language
 }
@@ -480,36 +742,66 @@ func (f *UnsynchTextFrame) SetLanguage(language string) error {
 	}
 
 	f.language = language
-	f.changeSize(0)
+	f.markDirty()
 	return nil
 }
 
+func (f UnsynchTextFrame) AppendText(b []byte) ([]byte, error) {
+	b = append(b, '[')
+	b = append(b, f.language...)
+	b = append(b, "] "...)
+
+	return f.DescTextFrame.AppendText(b)
+}
+
+func (f UnsynchTextFrame) MarshalText() ([]byte, error) {
+	return f.AppendText(nil)
+}
+
 func (f UnsynchTextFrame) String() string {
-	return fmt.Sprintf("%s\t%s:\n%s", f.language, f.description, f.text)
+	return frameString(f)
 }
 
-func (f UnsynchTextFrame) Bytes() []byte {
+func (f UnsynchTextFrame) encode() ([]byte, error) {
 	var err error
 	bytes := make([]byte, f.Size())
 	wr := encodedbytes.NewWriter(bytes)
 
 	if err = wr.WriteByte(f.encoding); err != nil {
-		return bytes
+		return bytes, err
 	}
 
 	if err = wr.WriteString(f.language, encodedbytes.NativeEncoding); err != nil {
-		return bytes
+		return bytes, err
 	}
 
 	if err = wr.WriteNullTermString(f.description, f.encoding); err != nil {
-		return bytes
+		return bytes, err
 	}
 
 	if err = wr.WriteString(f.text, f.encoding); err != nil {
-		return bytes
+		return bytes, err
 	}
 
-	return bytes
+	return bytes, nil
+}
+
+func (f UnsynchTextFrame) Bytes() []byte {
+	b, _ := f.encode()
+	return b
+}
+
+func (f UnsynchTextFrame) WriteTo(w io.Writer) (int64, error) {
+	return writeEncoded(w, f.encode)
+}
+
+func (f UnsynchTextFrame) Clone() Framer {
+	f.detach()
+	return &f
+}
+
+func (f UnsynchTextFrame) Equal(other Framer) bool {
+	return framesEqual(&f, other)
 }
 
 // ImageFrame represent frames that have media attached
@@ -535,11 +827,11 @@ func ParseImageFrame(head FrameHead, data []byte) Framer {
 	if f.mimeType, err = rd.ReadNullTermString(encodedbytes.NativeEncoding); err != nil {
 		return nil
 	}
-	l, err := encodedbytes.EncodedNullTermStringBytes(f.mimeType, encodedbytes.NativeEncoding)
+	l, err := encodedbytes.EncodedNullTermLen(f.mimeType, encodedbytes.NativeEncoding)
 	if err != nil {
 		return nil
 	}
-	f.size += uint32(len(l))
+	f.size += uint32(l)
 
 	if f.pictureType, err = rd.ReadByte(); err != nil {
 		return nil
@@ -549,13 +841,14 @@ func ParseImageFrame(head FrameHead, data []byte) Framer {
 	if f.description, err = rd.ReadNullTermString(f.encoding); err != nil {
 		return nil
 	}
-	l, err = encodedbytes.EncodedNullTermStringBytes(f.description, f.encoding)
+	l, err = encodedbytes.EncodedNullTermLen(f.description, f.encoding)
 	if err != nil {
 		return nil
 	}
-	f.size += uint32(len(l))
+	f.size += uint32(l)
 
-	if f.data, err = rd.ReadRest(); err != nil {
+	// Pictures can be large, so the data is not copied out of the tag
+	if f.data, err = rd.ReadRestView(); err != nil {
 		return nil
 	}
 	f.size += uint32(len(f.data))
@@ -578,11 +871,14 @@ func ParsePicFrame(head FrameHead, data []byte) Framer {
 		return nil
 	}
 
-	switch strings.ToLower(ext) {
+	switch ext = strings.ToLower(strings.TrimRight(ext, " \x00")); ext {
 	case "jpeg", "jpg":
 		f.mimeType = "image/jpeg"
-	case "png":
-		f.mimeType = "image/png"
+	case "-->":
+		f.mimeType = ext
+	case "":
+	default:
+		f.mimeType = "image/" + ext
 	}
 
 	if f.pictureType, err = rd.ReadByte(); err != nil {
@@ -593,7 +889,8 @@ func ParsePicFrame(head FrameHead, data []byte) Framer {
 		return nil
 	}
 
-	if f.data, err = rd.ReadRest(); err != nil {
+	// Pictures can be large, so the data is not copied out of the tag
+	if f.data, err = rd.ReadRestView(); err != nil {
 		return nil
 	}
 
@@ -607,10 +904,10 @@ func (f ImageFrame) Encoding() string {
 func (f *ImageFrame) SetEncoding(encoding string) error {
 	i := byte(encodedbytes.IndexForEncoding(encoding))
 	if i == 0xFF {
-		return errors.New("encoding: invalid encoding")
+		return ErrBadEncoding
 	}
 
-	diff, err := encodedbytes.EncodedDiff(i, f.description, f.encoding, f.description)
+	diff, err := terminatedDiff(i, f.description, f.encoding, f.description, true)
 	if err != nil {
 		return err
 	}
@@ -621,10 +918,17 @@ func (f *ImageFrame) SetEncoding(encoding string) error {
 }
 
 func (f ImageFrame) MIMEType() string {
-	return f.mimeType
+	return trimNull(f.mimeType)
 }
 
 func (f *ImageFrame) SetMIMEType(mimeType string) {
+	// PIC frames always take three bytes for the image format
+	if f.Id() == "PIC" {
+		f.mimeType = trimNull(mimeType)
+		f.markDirty()
+		return
+	}
+
 	diff := len(mimeType) - len(f.mimeType)
 	if mimeType[len(mimeType)-1] != 0 {
 		nullTermBytes := append([]byte(mimeType), 0x00)
@@ -638,7 +942,7 @@ func (f *ImageFrame) SetMIMEType(mimeType string) {
 }
 
 func (f ImageFrame) Description() string {
-	return f.description
+	return trimNull(f.description)
 }
 
 func (f *ImageFrame) SetDescription(description string) {
@@ -660,6 +964,7 @@ func (f ImageFrame) PictureType() byte {
 
 func (f *ImageFrame) SetPictureType(pictureType byte) {
 	f.pictureType = pictureType
+	f.markDirty()
 }
 
 func (f *ImageFrame) SetData(b []byte) {
@@ -668,36 +973,87 @@ func (f *ImageFrame) SetData(b []byte) {
 	f.data = b
 }
 
+func (f ImageFrame) AppendText(b []byte) ([]byte, error) {
+	b = fmt.Appendf(b, "%s, picture type %d, %d bytes", trimNull(f.mimeType), f.pictureType, len(f.data))
+	if desc := strings.TrimSpace(trimNull(f.description)); desc != "" {
+		b = append(b, ": "...)
+		b = append(b, desc...)
+	}
+
+	return b, nil
+}
+
+func (f ImageFrame) MarshalText() ([]byte, error) {
+	return f.AppendText(nil)
+}
+
 func (f ImageFrame) String() string {
-	return fmt.Sprintf("%d\t%s\t%s: <binary data>", f.pictureType, f.mimeType, f.description)
+	return frameString(f)
 }
 
-func (f ImageFrame) Bytes() []byte {
+// Fields before the picture data
+func (f ImageFrame) encodeHead() ([]byte, error) {
 	var err error
-	bytes := make([]byte, f.Size())
+	size := int(f.Size()) - len(f.data)
+	if size < 0 {
+		size = 0
+	}
+	bytes := make([]byte, size)
 	wr := encodedbytes.NewWriter(bytes)
 
 	if err = wr.WriteByte(f.encoding); err != nil {
-		return bytes
+		return bytes, err
 	}
 
-	if err = wr.WriteNullTermString(f.mimeType, encodedbytes.NativeEncoding); err != nil {
-		return bytes
+	if f.Id() == "PIC" {
+		if _, err = wr.Write([]byte(picFormat(f.mimeType))); err != nil {
+			return bytes, err
+		}
+	} else if err = wr.WriteNullTermString(trimNull(f.mimeType), encodedbytes.NativeEncoding); err != nil {
+		// The setters store the terminator as part of the value
+		return bytes, err
 	}
 
 	if err = wr.WriteByte(f.pictureType); err != nil {
-		return bytes
+		return bytes, err
 	}
 
-	if err = wr.WriteNullTermString(f.description, f.encoding); err != nil {
-		return bytes
+	if err = wr.WriteNullTermString(trimNull(f.description), f.encoding); err != nil {
+		return bytes, err
 	}
 
-	if n, err := wr.Write(f.data); n < len(f.data) || err != nil {
-		return bytes
+	return bytes, nil
+}
+
+func (f ImageFrame) Bytes() []byte {
+	head, _ := f.encodeHead()
+	return append(head, f.data...)
+}
+
+// Writes the picture data without copying it
+func (f ImageFrame) WriteTo(w io.Writer) (int64, error) {
+	head, err := f.encodeHead()
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+
+	n, err := w.Write(head)
+	if err != nil {
+		return int64(n), err
 	}
 
-	return bytes
+	m, err := w.Write(f.data)
+	return int64(n + m), err
+}
+
+func (f ImageFrame) Clone() Framer {
+	f.detach()
+	f.data = cloneBytes(f.data)
+	return &f
+}
+
+func (f ImageFrame) Equal(other Framer) bool {
+	return framesEqual(&f, other)
 }
 
 func NewImageFrame(ft FrameType, mimeType string, pictureType byte, description string, data []byte) *ImageFrame {
@@ -710,6 +1066,9 @@ func NewImageFrame(ft FrameType, mimeType string, pictureType byte, description
 		pictureType: pictureType,
 	}
 	imageFrame.changeSize(2) // 1 byte for encoding field + 1 byte for pictureType field
+	if ft.Id() == "PIC" {
+		imageFrame.changeSize(3) // image format
+	}
 
 	imageFrame.SetMIMEType(mimeType)
 	if description == "" {
@@ -720,6 +1079,8 @@ func NewImageFrame(ft FrameType, mimeType string, pictureType byte, description
 }
 
 // ChapterFrame represents chapter frames
+// Changing the fields directly neither updates the frame size nor marks the
+// tag dirty, use the setters or call Tag.MarkDirty afterwards.
 type ChapterFrame struct {
 	FrameHead
 	Element    string
@@ -730,6 +1091,7 @@ type ChapterFrame struct {
 	UseTime    bool
 	titleFrame Framer
 	linkFrame  Framer
+	imageFrame Framer
 }
 
 func NewChapterFrame(ft FrameType, element string, startTime uint32, endTime uint32, startByte uint32, endByte uint32, useTime bool, title string, link string, linkTitle string) *ChapterFrame {
@@ -743,14 +1105,16 @@ func NewChapterFrame(ft FrameType, element string, startTime uint32, endTime uin
 
 	if link != "" {
 		ft := V23FrameTypeMap["WXXX"]
-		linkFrame = NewDescTextFrame(ft, linkTitle, link, "UTF-16")
+		if f := NewDescTextFrame(ft, linkTitle, link, "UTF-16"); f != nil {
+			linkFrame = f
+		}
 	}
 
 	head := FrameHead{
 		FrameType: ft,
 	}
 
-	cf := &ChapterFrame{head, element, startTime, endTime, startByte, endByte, useTime, titleFrame, linkFrame}
+	cf := &ChapterFrame{head, element, startTime, endTime, startByte, endByte, useTime, titleFrame, linkFrame, nil}
 	cf.size = uint32(len(cf.Bytes()))
 
 	return cf
@@ -827,6 +1191,8 @@ func ParseChapterFrame(head FrameHead, data []byte) Framer {
 				f.titleFrame = frame
 			case "WXXX":
 				f.linkFrame = frame
+			case "APIC":
+				f.imageFrame = frame
 			}
 
 			fsize := int(frame.Size()) + FrameHeaderSize
@@ -838,12 +1204,20 @@ func ParseChapterFrame(head FrameHead, data []byte) Framer {
 	return f
 }
 
-func (f ChapterFrame) String() string {
+func (f ChapterFrame) AppendText(b []byte) ([]byte, error) {
 	if f.UseTime {
-		return fmt.Sprintf("chapter: %d ms to %d ms: %v", f.StartTime, f.EndTime, f.Title())
-	} else {
-		return fmt.Sprintf("chapter: byte %d to %d: %v", f.StartByte, f.EndByte, f.Title())
+		return fmt.Appendf(b, "chapter: %d ms to %d ms: %v", f.StartTime, f.EndTime, f.Title()), nil
 	}
+
+	return fmt.Appendf(b, "chapter: byte %d to %d: %v", f.StartByte, f.EndByte, f.Title()), nil
+}
+
+func (f ChapterFrame) MarshalText() ([]byte, error) {
+	return f.AppendText(nil)
+}
+
+func (f ChapterFrame) String() string {
+	return frameString(f)
 }
 
 func (f ChapterFrame) Link() string {
@@ -860,22 +1234,78 @@ func (f ChapterFrame) Title() string {
 	return ""
 }
 
-func (f *ChapterFrame) Bytes() []byte {
-	f.size = uint32(len(f.Element) + 1 + 4 + 4 + 4 + 4)
+func (f *ChapterFrame) SetElement(element string) {
+	f.changeSize(len(element) - len(f.Element))
+	f.Element = element
+}
+
+// Locates the chapter by time in milliseconds
+func (f *ChapterFrame) SetTimes(start, end uint32) {
+	f.StartTime, f.EndTime = start, end
+	f.UseTime = true
+	f.markDirty()
+}
+
+// Locates the chapter by byte offsets in the audio
+func (f *ChapterFrame) SetByteOffsets(start, end uint32) {
+	f.StartByte, f.EndByte = start, end
+	f.UseTime = false
+	f.markDirty()
+}
+
+// Mime type of a picture linked by URL instead of embedded
+const imageLinkMIMEType = "-->"
+
+// URL of the chapter image, "" when the chapter has none or embeds it
+func (f ChapterFrame) ImageURL() string {
+	if imf, ok := f.imageFrame.(*ImageFrame); ok && imf.MIMEType() == imageLinkMIMEType {
+		return string(imf.Data())
+	}
+	return ""
+}
+
+// Links the chapter image by URL, an empty url removes the image
+func (f *ChapterFrame) SetImageURL(url string) {
+	f.imageFrame = nil
+	if url != "" {
+		imf := NewImageFrame(V23FrameTypeMap["APIC"], imageLinkMIMEType, 0, "", []byte(url))
+		imf.SetEncoding("ISO-8859-1")
+		f.imageFrame = imf
+	}
+
+	size := f.size
+	f.Bytes()
+	diff := int(f.size) - int(size)
+	f.size = size
+	f.changeSize(diff)
+}
 
-	var titleBytes []byte
+// Encoded title, link and image subframes
+func (f *ChapterFrame) subframeBytes() (titleBytes, linkBytes, imageBytes []byte) {
 	if f.titleFrame != nil {
 		titleBytes = V23Bytes(f.titleFrame)
-		f.size += uint32(len(titleBytes))
 	}
-
-	var linkBytes []byte
 	if f.linkFrame != nil {
 		linkBytes = V23Bytes(f.linkFrame)
-		f.size += uint32(len(linkBytes))
 	}
+	if f.imageFrame != nil {
+		imageBytes = V23Bytes(f.imageFrame)
+	}
+	return
+}
+
+// Size of the encoded frame body, which may not fit the frame header
+func (f *ChapterFrame) bodySize() int {
+	titleBytes, linkBytes, imageBytes := f.subframeBytes()
+	return len(f.Element) + 1 + 4 + 4 + 4 + 4 + len(titleBytes) + len(linkBytes) + len(imageBytes)
+}
+
+func (f *ChapterFrame) Bytes() []byte {
+	titleBytes, linkBytes, imageBytes := f.subframeBytes()
+	size := len(f.Element) + 1 + 4 + 4 + 4 + 4 + len(titleBytes) + len(linkBytes) + len(imageBytes)
+	f.size = uint32(size)
 
-	bs := make([]byte, f.size)
+	bs := make([]byte, size)
 	wr := encodedbytes.NewWriter(bs)
 
 	if err := wr.WriteNullTermString(f.Element, encodedbytes.NativeEncoding); err != nil {
@@ -919,11 +1349,39 @@ func (f *ChapterFrame) Bytes() []byte {
 	if f.linkFrame != nil {
 		wr.Write(linkBytes)
 	}
+	if f.imageFrame != nil {
+		wr.Write(imageBytes)
+	}
 
 	return bs
 }
 
+func (f *ChapterFrame) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(f.Bytes())
+	return int64(n), err
+}
+
+func (f *ChapterFrame) Clone() Framer {
+	c := *f
+	c.detach()
+	if f.titleFrame != nil {
+		c.titleFrame = f.titleFrame.Clone()
+	}
+	if f.linkFrame != nil {
+		c.linkFrame = f.linkFrame.Clone()
+	}
+	if f.imageFrame != nil {
+		c.imageFrame = f.imageFrame.Clone()
+	}
+	return &c
+}
+
+func (f *ChapterFrame) Equal(other Framer) bool {
+	return framesEqual(f, other)
+}
+
 // TOCFrame represents Table of Contents frames
+// Like ChapterFrame, its fields should be changed through the setters.
 type TOCFrame struct {
 	FrameHead
 	Element       string
@@ -982,6 +1440,21 @@ func ParseTOCFrame(head FrameHead, data []byte) Framer {
 	return f
 }
 
+func (f *TOCFrame) SetElement(element string) {
+	f.changeSize(len(element) - len(f.Element))
+	f.Element = element
+}
+
+func (f *TOCFrame) SetTopLevel(topLevel bool) {
+	f.TopLevel = topLevel
+	f.markDirty()
+}
+
+func (f *TOCFrame) SetOrdered(ordered bool) {
+	f.Ordered = ordered
+	f.markDirty()
+}
+
 func (f *TOCFrame) SetChildElements(elements []string) {
 	f.ChildElements = elements
 	old := int(f.size)
@@ -989,11 +1462,19 @@ func (f *TOCFrame) SetChildElements(elements []string) {
 	f.changeSize(now - old)
 }
 
+func (f TOCFrame) AppendText(b []byte) ([]byte, error) {
+	return fmt.Appendf(b, "toc %s: %s", f.Element, strings.Join(f.ChildElements, ", ")), nil
+}
+
+func (f TOCFrame) MarshalText() ([]byte, error) {
+	return f.AppendText(nil)
+}
+
 func (f TOCFrame) String() string {
-	return fmt.Sprintf("<TOC %v>", f.ChildElements)
+	return frameString(f)
 }
 
-func (f *TOCFrame) Bytes() []byte {
+func (f *TOCFrame) encode() ([]byte, error) {
 	var err error
 
 	size := uint32(len(f.Element) + 1 + 1 + 1)
@@ -1005,7 +1486,7 @@ func (f *TOCFrame) Bytes() []byte {
 	wr := encodedbytes.NewWriter(bs)
 
 	if err := wr.WriteNullTermString(f.Element, encodedbytes.NativeEncoding); err != nil {
-		return bs
+		return bs, err
 	}
 
 	flags := 0
@@ -1017,18 +1498,38 @@ func (f *TOCFrame) Bytes() []byte {
 	}
 
 	if err = wr.WriteByte(byte(flags)); err != nil {
-		return bs
+		return bs, err
 	}
 
 	if err = wr.WriteByte(byte(len(f.ChildElements))); err != nil {
-		return bs
+		return bs, err
 	}
 
 	for _, e := range f.ChildElements {
 		if err := wr.WriteNullTermString(e, encodedbytes.NativeEncoding); err != nil {
-			return bs
+			return bs, err
 		}
 	}
 
-	return bs
+	return bs, nil
+}
+
+func (f *TOCFrame) Bytes() []byte {
+	b, _ := f.encode()
+	return b
+}
+
+func (f *TOCFrame) WriteTo(w io.Writer) (int64, error) {
+	return writeEncoded(w, f.encode)
+}
+
+func (f *TOCFrame) Clone() Framer {
+	c := *f
+	c.detach()
+	c.ChildElements = append([]string(nil), f.ChildElements...)
+	return &c
+}
+
+func (f *TOCFrame) Equal(other Framer) bool {
+	return framesEqual(f, other)
 }
@@ -1,7 +1,17 @@
 package v2
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestUnsynchTextFrameSetEncoding(t *testing.T) {
@@ -28,3 +38,321 @@ func TestUnsynchTextFrameSetEncoding(t *testing.T) {
 		t.Errorf("expected size to decrease to %d, but it was %d", size-1, newSize)
 	}
 }
+
+func TestFrameMarshalText(t *testing.T) {
+	tests := []struct {
+		frame Framer
+		text  string
+	}{
+		{NewTextFrame(V24FrameTypeMap["TPE1"], "Paloalto\x00Basick\x00", "UTF-8"), "Paloalto; Basick"},
+		{NewDescTextFrame(V23FrameTypeMap["TXXX"], "MOOD", "Chill", "ISO-8859-1"), "MOOD: Chill"},
+		{NewUnsynchTextFrame(V23FrameTypeMap["COMM"], "", "Recorded live"), "[eng] Recorded live"},
+		{NewIdFrame(V23FrameTypeMap["UFID"], "owner", []byte{0xff, 0x00}), "owner: ff00"},
+		{NewDataFrame(V23FrameTypeMap["PCNT"], []byte{0, 0, 0, 1}), "<binary data, 4 bytes>"},
+		{NewTOCFrame(V23FrameTypeMap["CTOC"], "toc", true, true, []string{"ch1", "ch2"}), "toc toc: ch1, ch2"},
+	}
+
+	for _, test := range tests {
+		text, err := test.frame.(interface{ MarshalText() ([]byte, error) }).MarshalText()
+		if err != nil || string(text) != test.text {
+			t.Errorf("%s MarshalText = %q, %v, want %q", test.frame.Id(), text, err, test.text)
+		}
+
+		if s := test.frame.String(); s != test.text {
+			t.Errorf("%s String = %q, want %q", test.frame.Id(), s, test.text)
+		}
+	}
+
+	tag := NewTag(3)
+	tag.SetTitle("Nice Life")
+	tag.SetArtist("Paloalto")
+
+	if s := tag.String(); s != "TIT2: Nice Life\nTPE1: Paloalto\n" {
+		t.Errorf("tag String = %q", s)
+	}
+}
+
+func TestFrameBuilders(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+
+	comment, err := NewCommentFrame("DEU", "", "Grüße")
+	if err != nil || comment.Language() != "deu" || comment.Encoding() != "ISO-8859-1" {
+		t.Fatalf("NewCommentFrame: got %v, %v", comment, err)
+	}
+	picture, err := NewAPICFrame(Picture{Type: PictureFrontCover, Description: "封面", Data: buf.Bytes()})
+	if err != nil || picture.MIMEType() != "image/png" || picture.Encoding() != "UTF-16" {
+		t.Fatalf("NewAPICFrame: got %v, %v", picture, err)
+	}
+	ufid, err := NewUFIDFrame("http://musicbrainz.org", []byte("abc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	popm, err := NewPOPMFrame("user@example.com", 196, 1<<40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := append([]byte("user@example.com\x00\xc4"), 1, 0, 0, 0, 0, 0); !bytes.Equal(popm.Data(), expected) {
+		t.Errorf("NewPOPMFrame: expected %x, got %x", expected, popm.Data())
+	}
+	txxx, err := NewTXXXFrame("MOOD", "Chill")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wxxx, err := NewWXXXFrame("Home", "https://example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The URL stays ISO-8859-1 when the description needs UTF-16
+	home, err := NewWXXXFrame("主页", "https://example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := home.Bytes(); b[0] != 1 || !bytes.HasSuffix(b, []byte("\x00\x00https://example.com/\x00")) || int(home.Size()) != len(b) {
+		t.Errorf("NewWXXXFrame: expected an ISO-8859-1 URL after a UTF-16 description, got %x", b)
+	}
+	if f := ParseV23Frame(bytes.NewReader(V23Bytes(home))); f == nil || !f.Equal(home) {
+		t.Errorf("NewWXXXFrame: expected the frame read back, got %v", f)
+	}
+
+	tag := NewTag(3)
+	tag.AddFrames(comment, picture, ufid, popm, txxx, wxxx)
+	parsed, err := ParseTagData(tag.Bytes(), ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames := parsed.AllFrames()
+	if len(frames) != 6 {
+		t.Fatalf("expected 6 frames, got %d", len(frames))
+	}
+	for i, f := range tag.AllFrames() {
+		if !f.Equal(frames[i]) {
+			t.Errorf("%s: expected %v, got %v", f.Id(), f, frames[i])
+		}
+	}
+
+	invalid := []struct {
+		name string
+		err  error
+		kind error
+	}{
+		{"language", second(NewCommentFrame("en", "", "text")), ErrBadLanguage},
+		{"picture type", second(NewAPICFrame(Picture{Type: 21, Data: buf.Bytes()})), ErrBadValue},
+		{"MIME type", second(NewAPICFrame(Picture{MIMEType: "image/jpeg", Data: buf.Bytes()})), ErrMIMEMismatch},
+		{"image", second(NewAPICFrame(Picture{Data: []byte("text")})), ErrImageFormat},
+		{"owner", second(NewUFIDFrame("", []byte("abc"))), ErrBadValue},
+		{"identifier", second(NewUFIDFrame("owner", make([]byte, 65))), ErrBadValue},
+		{"email", second(NewPOPMFrame("ユーザー", 0, 0)), ErrBadEncoding},
+		{"description", second(NewTXXXFrame("MO\x00OD", "Chill")), ErrBadValue},
+		{"URL", second(NewWXXXFrame("", "example")), ErrBadValue},
+	}
+	for _, test := range invalid {
+		if !errors.Is(test.err, test.kind) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.kind, test.err)
+		}
+	}
+}
+
+func second[T any](_ T, err error) error {
+	return err
+}
+
+// Records the category of each visited frame
+type categoryVisitor struct {
+	BaseVisitor
+	seen []string
+}
+
+func (v *categoryVisitor) VisitText(f TextFramer) error {
+	v.seen = append(v.seen, "text:"+f.Id())
+	return nil
+}
+
+func (v *categoryVisitor) VisitURL(f Framer, url string) error {
+	v.seen = append(v.seen, "url:"+url)
+	return nil
+}
+
+func (v *categoryVisitor) VisitComment(f *UnsynchTextFrame) error {
+	v.seen = append(v.seen, "comment:"+f.Text())
+	return nil
+}
+
+func (v *categoryVisitor) VisitUnknown(f Framer) error {
+	v.seen = append(v.seen, "unknown:"+f.Id())
+	return nil
+}
+
+func TestTagVisit(t *testing.T) {
+	tag := NewTag(3)
+	tag.SetTitle("Nice Life")
+	tag.AddFrames(
+		NewUnsynchTextFrame(V23FrameTypeMap["COMM"], "", "Recorded live"),
+		NewDataFrame(V23FrameTypeMap["WOAR"], []byte("https://example.com/paloalto\x00")),
+		NewDescTextFrame(V23FrameTypeMap["WXXX"], "Home", "https://example.com/", "ISO-8859-1"),
+		NewImageFrame(V23FrameTypeMap["APIC"], "image/png", PictureFrontCover, "", []byte{0x89}),
+		NewIdFrame(V23FrameTypeMap["UFID"], "owner", []byte("abc")),
+	)
+
+	v := new(categoryVisitor)
+	if err := tag.Visit(v); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"text:TIT2",
+		"comment:Recorded live",
+		"url:https://example.com/paloalto",
+		"url:https://example.com/",
+		"unknown:UFID",
+	}
+	if !reflect.DeepEqual(v.seen, expected) {
+		t.Errorf("Visit: expected %v, got %v", expected, v.seen)
+	}
+
+	n := 0
+	err := tag.Walk(func(f Framer) error {
+		tag.DeleteFrame(f)
+		if n++; n == 2 {
+			return SkipAll
+		}
+		return nil
+	})
+	if err != nil || n != 2 || len(tag.AllFrames()) != 4 {
+		t.Errorf("Walk: expected to stop after deleting 2 frames, got %d, %d left, %v", n, len(tag.AllFrames()), err)
+	}
+
+	stop := errors.New("stop")
+	if err := tag.Walk(func(Framer) error { return stop }); err != stop {
+		t.Errorf("Walk: expected the callback error, got %v", err)
+	}
+}
+
+func TestDefaultLanguage(t *testing.T) {
+	if err := SetDefaultLanguage("deu"); err != nil {
+		t.Fatal(err)
+	}
+	defer SetDefaultLanguage("eng")
+	if err := SetDefaultLanguage("de"); !errors.Is(err, ErrBadLanguage) || DefaultLanguage() != "deu" {
+		t.Errorf("SetDefaultLanguage: expected ErrBadLanguage, got %v", err)
+	}
+
+	comment, err := NewCommentFrame("", "", "Kommentar")
+	if err != nil || comment.Language() != "deu" {
+		t.Errorf("NewCommentFrame: expected the default language, got %v, %v", comment, err)
+	}
+	lyrics, err := NewLyricsFrame("FRA", "", "Paroles")
+	if err != nil || lyrics.Id() != "USLT" || lyrics.Language() != "fra" {
+		t.Errorf("NewLyricsFrame: expected the given language, got %v, %v", lyrics, err)
+	}
+	user, err := NewUSERFrame("", "Terms")
+	if err != nil || string(user.Data()) != "\x00deuTerms" {
+		t.Errorf("NewUSERFrame: unexpected data %q, %v", user.Data(), err)
+	}
+
+	tag := NewTag(3)
+	if tag.CommentLanguage() != "deu" {
+		t.Errorf("CommentLanguage: expected the default, got %q", tag.CommentLanguage())
+	}
+	if err := tag.SetCommentLanguage("spa"); err != nil {
+		t.Fatal(err)
+	}
+	tag.SetAll(map[string]string{"comment": "Comentario", "lyrics": "Letra"})
+	for _, id := range []string{"COMM", "USLT"} {
+		if f, ok := tag.Frame(id).(*UnsynchTextFrame); !ok || f.Language() != "spa" {
+			t.Errorf("SetAll: expected %s in the tag's language", id)
+		}
+	}
+	if err := tag.SetCommentLanguage("xx"); !errors.Is(err, ErrBadLanguage) || tag.CommentLanguage() != "spa" {
+		t.Errorf("SetCommentLanguage: expected ErrBadLanguage, got %v", err)
+	}
+}
+
+// GEOB frame as Serato DJ writes it
+func seratoFrame(description string, data []byte) Framer {
+	body := append([]byte("\x00application/octet-stream\x00\x00"+description+"\x00"), data...)
+	return NewDataFrame(V23FrameTypeMap["GEOB"], body)
+}
+
+func TestSeratoMarkers(t *testing.T) {
+	entry := func(name string, data ...byte) []byte {
+		b := append([]byte(name+"\x00"), binary.BigEndian.AppendUint32(nil, uint32(len(data)))...)
+		return append(b, data...)
+	}
+
+	payload := []byte{1, 1}
+	payload = append(payload, entry("COLOR", 0, 0xFF, 0x99, 0xFF)...)
+	cue := binary.BigEndian.AppendUint32([]byte{0, 2}, 61500)
+	cue = append(cue, 0, 0xCC, 0, 0, 0, 0)
+	payload = append(payload, entry("CUE", append(cue, "Drop\x00"...)...)...)
+	loop := binary.BigEndian.AppendUint32([]byte{0, 0}, 1000)
+	loop = binary.BigEndian.AppendUint32(loop, 9000)
+	loop = append(loop, 0xFF, 0xFF, 0xFF, 0xFF, 0, 0x27, 0xAA, 0xE1, 1)
+	payload = append(payload, entry("LOOP", append(loop, "Intro\x00"...)...)...)
+	payload = append(payload, entry("BPMLOCK", 1)...)
+	payload = append(payload, entry("UNKNOWN", 1, 2, 3)...)
+	payload = append(payload, 0)
+
+	// Wrapped at 72 characters without padding, then null padded
+	text := base64.RawStdEncoding.EncodeToString(payload)
+	data := []byte{1, 1}
+	for len(text) > 72 {
+		data = append(append(data, text[:72]...), '\n')
+		text = text[72:]
+	}
+	data = append(append(data, text...), 0, 0, 0, 0)
+
+	tag := NewTag(3)
+	tag.AddFrames(seratoFrame("Serato Overview", []byte{1, 5}), seratoFrame("Serato Markers2", data))
+	m, err := ParseTag(bytes.NewReader(tag.Bytes())).SeratoMarkers()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &SeratoMarkers{
+		Cues:       []SeratoCue{{Index: 2, Position: 61500 * time.Millisecond, Color: color.RGBA{0xCC, 0, 0, 0xFF}, Name: "Drop"}},
+		Loops:      []SeratoLoop{{Index: 0, Start: time.Second, End: 9 * time.Second, Locked: true, Name: "Intro"}},
+		TrackColor: color.RGBA{0xFF, 0x99, 0xFF, 0xFF},
+		BPMLocked:  true,
+	}
+	if !reflect.DeepEqual(m, expected) {
+		t.Errorf("expected %+v, got %+v", expected, m)
+	}
+
+	if m, err := NewTag(3).SeratoMarkers(); m != nil || err != nil {
+		t.Errorf("expected no markers, got %v, %v", m, err)
+	}
+	if _, err := ParseSeratoMarkers2(data[:40]); !errors.Is(err, ErrInvalidFrame) {
+		t.Errorf("expected ErrInvalidFrame for truncated markers, got %v", err)
+	}
+}
+
+func TestSeratoBeatGrid(t *testing.T) {
+	data := binary.BigEndian.AppendUint32([]byte{1, 0}, 2)
+	data = binary.BigEndian.AppendUint32(data, math.Float32bits(0.5))
+	data = binary.BigEndian.AppendUint32(data, 64)
+	data = binary.BigEndian.AppendUint32(data, math.Float32bits(30.5))
+	data = binary.BigEndian.AppendUint32(data, math.Float32bits(128))
+	data = append(data, 0)
+
+	tag := NewTag(4)
+	tag.AddFrames(seratoFrame("Serato BeatGrid", data))
+	markers, err := ParseTag(bytes.NewReader(tag.Bytes())).SeratoBeatGrid()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []SeratoBeatMarker{
+		{Position: 500 * time.Millisecond, Beats: 64},
+		{Position: 30500 * time.Millisecond, BPM: 128},
+	}
+	if !reflect.DeepEqual(markers, expected) {
+		t.Errorf("expected %+v, got %+v", expected, markers)
+	}
+
+	if _, err := ParseSeratoBeatGrid(data[:12]); !errors.Is(err, ErrInvalidFrame) {
+		t.Errorf("expected ErrInvalidFrame for truncated beatgrid, got %v", err)
+	}
+}
@@ -0,0 +1,534 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FieldMapping names the same field in ID3, Vorbis comments and MP4 atoms
+type FieldMapping struct {
+	// ID3v2.3 frame ID, translated for other versions
+	Id     string
+	Vorbis string
+	// MP4 atom, "" when there is no standard atom
+	MP4 string
+}
+
+// FieldMappings lists the frames with an equivalent in other formats
+var FieldMappings = []FieldMapping{
+	{"TIT1", "GROUPING", "©grp"},
+	{"TIT2", "TITLE", "©nam"},
+	{"TIT3", "SUBTITLE", ""},
+	{"TPE1", "ARTIST", "©ART"},
+	{"TPE2", "ALBUMARTIST", "aART"},
+	{"TPE3", "CONDUCTOR", ""},
+	{"TPE4", "REMIXER", ""},
+	{"TALB", "ALBUM", "©alb"},
+	{"TCOM", "COMPOSER", "©wrt"},
+	{"TEXT", "LYRICIST", ""},
+	{"TCON", "GENRE", "©gen"},
+	{"TYER", "DATE", "©day"},
+	{"TORY", "ORIGINALDATE", ""},
+	{"TRCK", "TRACKNUMBER", "trkn"},
+	{"TPOS", "DISCNUMBER", "disk"},
+	{"TBPM", "BPM", "tmpo"},
+	{"TKEY", "INITIALKEY", ""},
+	{"TCMP", "COMPILATION", "cpil"},
+	{"TCOP", "COPYRIGHT", "cprt"},
+	{"TPUB", "LABEL", ""},
+	{"TENC", "ENCODEDBY", ""},
+	{"TSSE", "ENCODER", "©too"},
+	{"TSRC", "ISRC", ""},
+	{"TLAN", "LANGUAGE", ""},
+	{"TMED", "MEDIA", ""},
+	{"TOAL", "ORIGINALALBUM", ""},
+	{"TOPE", "ORIGINALARTIST", ""},
+	{"TOLY", "ORIGINALLYRICIST", ""},
+	{"COMM", "COMMENT", "©cmt"},
+	{"USLT", "LYRICS", "©lyr"},
+}
+
+// Mapping of a frame ID of any version
+func fieldMapping(id string) (FieldMapping, bool) {
+	aliases := frameIdAliases(id)
+	for _, m := range FieldMappings {
+		if contains(aliases, m.Id) {
+			return m, true
+		}
+	}
+
+	return FieldMapping{}, false
+}
+
+// Vorbis comment key for a frame ID, "" if none
+func VorbisKey(id string) string {
+	m, _ := fieldMapping(id)
+	return m.Vorbis
+}
+
+// MP4 atom for a frame ID, "" if none
+func MP4Atom(id string) string {
+	m, _ := fieldMapping(id)
+	return m.MP4
+}
+
+// Frame ID in the given major version for a Vorbis comment key, "" if none
+// Keys are matched case-insensitively.
+func FrameIdForVorbis(key string, version byte) string {
+	for _, m := range FieldMappings {
+		if strings.EqualFold(m.Vorbis, key) {
+			return convertFrameId(m.Id, 3, version)
+		}
+	}
+
+	return ""
+}
+
+// Frame ID in the given major version for an MP4 atom, "" if none
+func FrameIdForMP4(atom string, version byte) string {
+	for _, m := range FieldMappings {
+		if m.MP4 != "" && m.MP4 == atom {
+			return convertFrameId(m.Id, 3, version)
+		}
+	}
+
+	return ""
+}
+
+// Fields of the tag keyed by Vorbis comment key
+// Multiple values of a text frame become separate values. User defined
+// text frames are keyed by their upper case description; frames without a
+// mapping are left out.
+func (t *Tag) ToGenericMetadata() map[string][]string {
+	fields := make(map[string][]string)
+
+	for _, f := range t.snapshot() {
+		var key, text string
+
+		switch f := f.(type) {
+		case *UnsynchTextFrame:
+			key, text = VorbisKey(f.Id()), f.Text()
+		case *DescTextFrame:
+			if f.Id() != "TXXX" && f.Id() != "TXX" {
+				continue
+			}
+			key, text = strings.ToUpper(trimNull(f.Description())), f.Text()
+		case *TextFrame:
+			key, text = VorbisKey(f.Id()), f.Text()
+		}
+
+		if key == "" {
+			continue
+		}
+
+		fields[key] = append(fields[key], strings.Split(trimNull(text), "\x00")...)
+	}
+
+	return fields
+}
+
+// Decoded values of the tag keyed as SetAll accepts them
+// Frames with a Vorbis comment key are keyed by it in lower case, user
+// defined text frames by their description and other text and URL frames by
+// their ID. Comments and lyrics with a description, user defined URL frames
+// and user defined text frames whose description reads as another key are
+// keyed "<key>:<description>". Multiple values of a text frame become
+// separate values. Binary frames are left out.
+func (t *Tag) ToMap() map[string][]string {
+	fields := make(map[string][]string)
+
+	for _, f := range t.snapshot() {
+		if key, text := t.mapEntry(f); key != "" {
+			fields[key] = append(fields[key], splitValues(text)...)
+		}
+	}
+
+	return fields
+}
+
+// Decoded values of a field, keyed as SetAll accepts it
+// User defined frames are matched case-insensitively.
+func (t *Tag) Values(key string) []string {
+	id, desc, err := t.fieldKey(key)
+	if err != nil {
+		return nil
+	}
+
+	var values []string
+	for _, f := range t.fieldFrames(id, desc) {
+		if key, text := t.mapEntry(f); key != "" {
+			values = append(values, splitValues(text)...)
+		}
+	}
+
+	return values
+}
+
+// Key and text of a frame in ToMap, an empty key for binary frames
+func (t *Tag) mapEntry(f Framer) (key, text string) {
+	switch f := f.(type) {
+	case *UnsynchTextFrame:
+		key, text = strings.ToLower(VorbisKey(f.Id())), f.Text()
+		if desc := trimNull(f.Description()); desc != "" {
+			key += ":" + desc
+		}
+	case *DescTextFrame:
+		desc := trimNull(f.Description())
+		switch f.Id() {
+		case "TXX", "TXXX":
+			key, text = desc, f.Text()
+			if id, d, err := t.fieldKey(desc); desc == "" || err != nil || id != f.Id() || d != desc {
+				key = f.Id() + ":" + desc
+			}
+		case "WXX", "WXXX":
+			key, text = f.Id()+":"+desc, f.Text()
+		}
+	case *TextFrame:
+		if key = strings.ToLower(VorbisKey(f.Id())); key == "" {
+			key = f.Id()
+		}
+		text = f.Text()
+	case *DataFrame:
+		if isURLFrameId(f.Id()) {
+			key, text = f.Id(), string(f.Data())
+		}
+	}
+
+	return key, text
+}
+
+// Values of a null separated text
+func splitValues(text string) []string {
+	return strings.Split(trimNull(text), "\x00")
+}
+
+// Replaces the fields of the tag with those of a map keyed by Vorbis
+// comment key
+// Keys without a frame mapping are stored in user defined text frames. A
+// key with no values removes the field. Nothing is changed when a date is
+// malformed, which fails with ErrBadDate.
+func (t *Tag) FromGenericMetadata(fields map[string][]string) error {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		if id := FrameIdForVorbis(key, t.version); id != "" {
+			if err := t.checkFieldValues(id, fields[key]); err != nil {
+				return err
+			}
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		values := fields[key]
+
+		id := FrameIdForVorbis(key, t.version)
+		if id == "" {
+			t.setUserText(key, strings.Join(values, t.valueSeparator()))
+			continue
+		}
+
+		t.setFieldValues(id, "", values)
+	}
+
+	return nil
+}
+
+// Checks values are valid for the frame, which must be timestamps for the
+// ID3v2.4 timestamp frames
+func (t *Tag) checkFieldValues(id string, values []string) error {
+	if t.version < 4 || !isTimestampFrame(id) {
+		return nil
+	}
+
+	lenient := t.lenient()
+	for _, value := range values {
+		if _, err := timestampText(value, lenient); err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// Separator of multiple values, null characters from ID3v2.4 on
+func (t *Tag) valueSeparator() string {
+	if t.version >= 4 {
+		return "\x00"
+	}
+	return "/"
+}
+
+// Frames of the field with the id and, for frames that have one, the
+// description
+func (t *Tag) fieldFrames(id, desc string) []Framer {
+	var frames []Framer
+	for _, f := range t.Frames(id) {
+		switch f := f.(type) {
+		case *UnsynchTextFrame:
+			if trimNull(f.Description()) != desc {
+				continue
+			}
+		case *DescTextFrame:
+			if !strings.EqualFold(trimNull(f.Description()), desc) {
+				continue
+			}
+		}
+		frames = append(frames, f)
+	}
+
+	return frames
+}
+
+// Replaces the frames of a field by the values
+// The id must be a text, comment or lyrics frame, or a URL frame. The
+// description applies to comment, lyrics and user defined frames.
+func (t *Tag) setFieldValues(id, desc string, values []string) {
+	ft, ok := lookupFrameType(t.version, id)
+	if !ok {
+		return
+	}
+
+	switch {
+	case isUserText(id):
+		t.setUserText(desc, strings.Join(values, t.valueSeparator()))
+	case isUnsynchText(id):
+		for _, f := range t.fieldFrames(id, desc) {
+			t.DeleteFrame(f)
+		}
+		if len(values) > 0 {
+			text := strings.Join(values, "\n")
+			f := NewUnsynchTextFrame(ft, desc, text)
+			f.SetEncoding(t.EncodingFor(desc + text))
+			f.SetLanguage(t.CommentLanguage())
+			t.AddFrames(f)
+		}
+	case isLinkId(id):
+		for _, f := range t.fieldFrames(id, desc) {
+			t.DeleteFrame(f)
+		}
+		for _, value := range values {
+			if f := NewDescTextFrame(ft, desc, value, t.EncodingFor(desc)); f != nil {
+				t.AddFrames(f)
+			}
+		}
+	case isURLFrameId(id):
+		t.DeleteFrames(id)
+		for _, value := range values {
+			t.AddFrames(NewDataFrame(ft, []byte(value)))
+		}
+	default:
+		if t.version >= 4 && isTimestampFrame(id) {
+			values = t.timestampValues(values)
+		}
+
+		t.DeleteFrames(id)
+		if len(values) > 0 {
+			text := strings.Join(values, t.valueSeparator())
+			f := NewTextFrame(ft, text, "UTF-8")
+			f.SetEncoding(t.EncodingFor(text))
+			t.AddFrames(f)
+		}
+	}
+}
+
+// Timestamps, fixed when lenient
+// Values are checked with checkFieldValues first, malformed ones are kept as
+// given rather than dropped.
+func (t *Tag) timestampValues(values []string) []string {
+	lenient := t.lenient()
+
+	fixed := make([]string, len(values))
+	for i, value := range values {
+		text, err := timestampText(value, lenient)
+		if err != nil {
+			text = value
+		}
+		fixed[i] = text
+	}
+	return fixed
+}
+
+// Short keys SetAll accepts besides the Vorbis comment keys
+var friendlyKeys = map[string]string{
+	"track":     "TRACKNUMBER",
+	"disc":      "DISCNUMBER",
+	"year":      "DATE",
+	"key":       "INITIALKEY",
+	"publisher": "LABEL",
+	"comments":  "COMMENT",
+}
+
+// Frame ID and description a SetAll key stands for
+// Keys "<key>:<description>" name comment and lyrics frames, user defined
+// text frames with "TXXX" and user defined URL frames with "WXXX", with the
+// description. Other keys that are not fields name user defined text frames.
+func (t *Tag) fieldKey(key string) (id, desc string, err error) {
+	if prefix, desc, ok := strings.Cut(key, ":"); ok {
+		if id := t.describedFrameId(prefix); id != "" {
+			return id, desc, nil
+		}
+	}
+
+	if id, err = t.fieldFrameId(key); err != nil {
+		return "", "", err
+	}
+	if id == "" {
+		return convertFrameId("TXXX", 3, t.version), key, nil
+	}
+	return id, "", nil
+}
+
+// Frame ID in the tag's version of a key naming frames with descriptions,
+// "" for other keys
+func (t *Tag) describedFrameId(key string) string {
+	if id, err := t.fieldFrameId(key); err == nil && isUnsynchText(id) {
+		return id
+	}
+
+	for _, id := range []string{"TXXX", "WXXX"} {
+		if contains(frameIdAliases(id), key) {
+			if id = convertFrameId(id, 3, t.version); id != "" {
+				return id
+			}
+		}
+	}
+
+	return ""
+}
+
+// Frame ID a SetAll key stands for
+// Returns an empty id for keys that name user defined text frames.
+func (t *Tag) fieldFrameId(key string) (string, error) {
+	vorbisKey := key
+	if k, ok := friendlyKeys[strings.ToLower(key)]; ok {
+		vorbisKey = k
+	}
+	if id := FrameIdForVorbis(vorbisKey, t.version); id != "" {
+		return id, nil
+	}
+
+	if !validFrameId(key) {
+		return "", nil
+	}
+
+	known, id := false, ""
+	for _, version := range []byte{t.version, 3, 4, 2} {
+		if _, ok := lookupFrameType(version, key); ok && (ValidFrameId(version, key) || contains(nonStandardFrames, key)) {
+			known, id = true, convertFrameId(key, version, t.version)
+			break
+		}
+	}
+
+	switch {
+	case !known:
+		return "", nil
+	case id == "" || !(ValidFrameId(t.version, id) || contains(nonStandardFrames, id)):
+		return "", fmt.Errorf("%w: %s", ErrFrameNotAllowed, key)
+	case isUnsynchText(id) || isURLFrameId(id):
+	case id[0] != 'T' || id == "TXX" || id == "TXXX":
+		return "", fmt.Errorf("%w: %s is not a text frame", ErrBadValue, id)
+	}
+
+	return id, nil
+}
+
+// URL link frames, other than user defined ones, hold just the URL
+func isURLFrameId(id string) bool {
+	return id[0] == 'W' && id != "WXX" && id != "WXXX" && id != "WFED"
+}
+
+// Sets fields by friendly key or frame ID, replacing their frames
+// Keys are the Vorbis comment keys, such as "title", "albumartist" and
+// "tracknumber", matched case-insensitively, the short forms "track",
+// "disc", "year", "key", "publisher" and "comments", or frame IDs of any
+// version. Other keys are stored in user defined text frames with the key as
+// description. An empty value removes the field.
+func (t *Tag) SetAll(fields map[string]string) error {
+	values := make(map[string][]string, len(fields))
+	for key, value := range fields {
+		if value != "" {
+			values[key] = []string{value}
+		} else {
+			values[key] = nil
+		}
+	}
+
+	return t.SetAllValues(values)
+}
+
+// Like SetAll but with multiple values per field
+// Nothing is changed when a key names a frame that cannot hold text.
+func (t *Tag) SetAllValues(fields map[string][]string) error {
+	type field struct{ id, desc string }
+
+	keys := make([]string, 0, len(fields))
+	resolved := make(map[string]field, len(fields))
+	for key := range fields {
+		id, desc, err := t.fieldKey(key)
+		if err != nil {
+			return err
+		}
+		for _, f := range t.fieldFrames(id, desc) {
+			if t.readOnly(f) {
+				return fmt.Errorf("%s: %w", id, ErrReadOnlyFrame)
+			}
+		}
+		if err := t.checkFieldValues(id, fields[key]); err != nil {
+			return err
+		}
+
+		keys = append(keys, key)
+		resolved[key] = field{id, desc}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		t.setFieldValues(resolved[key].id, resolved[key].desc, fields[key])
+	}
+
+	return nil
+}
+
+// Text of the user defined text frame with the given description
+func (t *Tag) userText(desc string) string {
+	for _, f := range t.Frames(convertFrameId("TXXX", 3, t.version)) {
+		if df, ok := f.(*DescTextFrame); ok && strings.EqualFold(df.Description(), desc) {
+			return df.Text()
+		}
+	}
+
+	return ""
+}
+
+// Replaces the user defined text frames with a description
+func (t *Tag) setUserText(desc, text string) {
+	id := convertFrameId("TXXX", 3, t.version)
+	ft, ok := lookupFrameType(t.version, id)
+	if !ok {
+		return
+	}
+
+	for _, f := range t.Frames(id) {
+		if df, ok := f.(*DescTextFrame); ok && strings.EqualFold(trimNull(df.Description()), desc) {
+			t.DeleteFrame(f)
+		}
+	}
+
+	if text != "" {
+		f := NewDescTextFrame(ft, desc, text, "UTF-8")
+		f.SetEncoding(t.EncodingFor(desc + text))
+		t.AddFrames(f)
+	}
+}
+
+func isUserText(id string) bool {
+	return id == "TXX" || id == "TXXX"
+}
+
+func isUnsynchText(id string) bool {
+	return id == "COMM" || id == "COM" || id == "USLT" || id == "ULT"
+}
@@ -0,0 +1,261 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFieldMappings(t *testing.T) {
+	tests := []struct {
+		id, vorbis, mp4 string
+	}{
+		{"TIT2", "TITLE", "©nam"},
+		{"TT2", "TITLE", "©nam"},
+		{"TDRC", "DATE", "©day"},
+		{"COMM", "COMMENT", "©cmt"},
+		{"PRIV", "", ""},
+	}
+
+	for _, test := range tests {
+		if key := VorbisKey(test.id); key != test.vorbis {
+			t.Errorf("VorbisKey: expected %q for %s, got %q", test.vorbis, test.id, key)
+		}
+		if atom := MP4Atom(test.id); atom != test.mp4 {
+			t.Errorf("MP4Atom: expected %q for %s, got %q", test.mp4, test.id, atom)
+		}
+	}
+
+	if id := FrameIdForVorbis("date", 4); id != "TDRC" {
+		t.Errorf("FrameIdForVorbis: expected TDRC, got %q", id)
+	}
+	if id := FrameIdForMP4("©ART", 2); id != "TP1" {
+		t.Errorf("FrameIdForMP4: expected TP1, got %q", id)
+	}
+}
+
+func TestGenericMetadata(t *testing.T) {
+	fields := map[string][]string{
+		"TITLE":                 {"Nice Life"},
+		"ARTIST":                {"Paloalto", "Deepflow"},
+		"DATE":                  {"2013"},
+		"COMMENT":               {"Recorded live"},
+		"MUSICBRAINZ_ALBUMID":   {"a1b2"},
+		"REPLAYGAIN_TRACK_GAIN": {"-6.5 dB"},
+	}
+
+	for _, version := range []byte{3, 4} {
+		tag := NewTag(version)
+		tag.FromGenericMetadata(fields)
+
+		expected := fields
+		if version == 3 {
+			// Values are joined before ID3v2.4
+			expected = make(map[string][]string)
+			for key, values := range fields {
+				expected[key] = values
+			}
+			expected["ARTIST"] = []string{"Paloalto/Deepflow"}
+
+			if tag.Frame("TYER") == nil {
+				t.Errorf("FromGenericMetadata: expected DATE in TYER")
+			}
+		}
+
+		if got := tag.ToGenericMetadata(); !reflect.DeepEqual(got, expected) {
+			t.Errorf("GenericMetadata: v2.%d round trip gave %v", version, got)
+		}
+
+		tag.FromGenericMetadata(map[string][]string{"ARTIST": nil, "musicbrainz_albumid": nil})
+		got := tag.ToGenericMetadata()
+		if _, ok := got["ARTIST"]; ok {
+			t.Errorf("FromGenericMetadata: expected ARTIST to be removed")
+		}
+		if _, ok := got["MUSICBRAINZ_ALBUMID"]; ok {
+			t.Errorf("FromGenericMetadata: expected MUSICBRAINZ_ALBUMID to be removed")
+		}
+		if len(got) != len(fields)-2 {
+			t.Errorf("FromGenericMetadata: expected other fields to stay, got %v", got)
+		}
+	}
+}
+
+func TestGenericMetadataBadDate(t *testing.T) {
+	tag := NewTag(4)
+	tag.AddFrames(NewTextFrame(V24FrameTypeMap["TDRC"], "2012", "UTF-8"))
+
+	err := tag.FromGenericMetadata(map[string][]string{
+		"DATE":  {"20131125"},
+		"TITLE": {"Nice Life"},
+	})
+	if !errors.Is(err, ErrBadDate) {
+		t.Errorf("FromGenericMetadata: expected ErrBadDate, got %v", err)
+	}
+	if f := tag.Frame("TDRC"); f == nil || f.String() != "2012" {
+		t.Errorf("FromGenericMetadata: expected the existing TDRC kept, got %v", f)
+	}
+	if tag.Title() != "" {
+		t.Errorf("FromGenericMetadata: expected nothing changed, got title %q", tag.Title())
+	}
+}
+
+func TestSetAll(t *testing.T) {
+	tag := NewTag(3)
+	tag.SetTitle("Old Title")
+	tag.SetYear("2012")
+	err := tag.SetAll(map[string]string{
+		"title":       "Nice Life",
+		"albumartist": "Paloalto",
+		"track":       "3/10",
+		"Disc":        "1",
+		"TDRC":        "",
+		"WOAR":        "https://example.com/paloalto",
+		"MOOD":        "Chill",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tag.Frames("TIT2")) != 1 || tag.Title() != "Nice Life" {
+		t.Errorf("SetAll: expected the title to be replaced, got %q", tag.Title())
+	}
+	if tag.frameText("TPE2") != "Paloalto" || tag.frameText("TRCK") != "3/10" {
+		t.Errorf("SetAll: expected album artist and track, got %v", tag.AllFrames())
+	}
+	if tag.Frame("TYER") != nil || tag.frameText("TPOS") != "1" {
+		t.Errorf("SetAll: expected TDRC to remove the year and a disc number")
+	}
+	if f, ok := tag.Frame("WOAR").(*DataFrame); !ok || string(f.Data()) != "https://example.com/paloalto" {
+		t.Errorf("SetAll: expected a WOAR frame, got %v", tag.Frame("WOAR"))
+	}
+	if text := tag.userText("MOOD"); text != "Chill" {
+		t.Errorf("SetAll: expected unknown keys in TXXX, got %q", text)
+	}
+
+	v2 := NewTag(2)
+	if err := v2.SetAllValues(map[string][]string{"TPE1": {"Paloalto", "Deepflow"}}); err != nil {
+		t.Fatal(err)
+	}
+	if text := v2.frameText("TPE1"); text != "Paloalto/Deepflow" {
+		t.Errorf("SetAllValues: expected TP1 in ID3v2.2, got %q", text)
+	}
+
+	if err := tag.SetAll(map[string]string{"APIC": "cover", "artist": "Deepflow"}); !errors.Is(err, ErrBadValue) {
+		t.Errorf("SetAll: expected ErrBadValue for APIC, got %v", err)
+	}
+	if tag.Artist() != "" {
+		t.Errorf("SetAll: expected no change after an error, got %q", tag.Artist())
+	}
+	if err := v2.SetAll(map[string]string{"PCST": "1"}); !errors.Is(err, ErrFrameNotAllowed) {
+		t.Errorf("SetAll: expected ErrFrameNotAllowed for PCST in ID3v2.2, got %v", err)
+	}
+}
+
+func TestToMap(t *testing.T) {
+	tag := NewTag(4)
+	fields := map[string]string{
+		"title":  "Nice Life",
+		"artist": "Paloalto",
+		"TKEY":   "Am",
+		"WOAR":   "https://example.com/paloalto",
+		"MOOD":   "Chill",
+	}
+	if err := tag.SetAll(fields); err != nil {
+		t.Fatal(err)
+	}
+	tag.AddFrames(NewTextFrame(V23FrameTypeMap["TCOM"], "Paloalto\x00Deepflow", "UTF-8"))
+	tag.AddFrames(NewImageFrame(V23FrameTypeMap["APIC"], "image/png", PictureFrontCover, "", []byte{0x89}))
+
+	expected := map[string][]string{
+		"title":      {"Nice Life"},
+		"artist":     {"Paloalto"},
+		"initialkey": {"Am"},
+		"WOAR":       {"https://example.com/paloalto"},
+		"MOOD":       {"Chill"},
+		"composer":   {"Paloalto", "Deepflow"},
+	}
+	got := tag.ToMap()
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("ToMap: expected %v, got %v", expected, got)
+	}
+
+	if values := tag.Values("Composer"); !reflect.DeepEqual(values, expected["composer"]) {
+		t.Errorf("Values: expected composers, got %v", values)
+	}
+	if values := tag.Values("mood"); !reflect.DeepEqual(values, expected["MOOD"]) {
+		t.Errorf("Values: expected the TXXX value, got %v", values)
+	}
+
+	copied := NewTag(4)
+	if err := copied.SetAllValues(got); err != nil {
+		t.Fatal(err)
+	}
+	if again := copied.ToMap(); !reflect.DeepEqual(again, expected) {
+		t.Errorf("ToMap: expected SetAllValues to restore %v, got %v", expected, again)
+	}
+}
+
+func TestToMapRoundTrip(t *testing.T) {
+	for _, version := range []byte{2, 3, 4} {
+		tag := NewTag(version)
+		tag.SetTitle("Nice Life")
+		tag.SetAll(map[string]string{"comment": "Great", "TXXX:title": "Working title", "MOOD": "Chill"})
+		tag.SetAllValues(map[string][]string{"WXXX:Home": {"https://example.com/"}, "comment:iTunNORM": {"00000318 0000032A"}})
+
+		fields := tag.ToMap()
+		for _, key := range []string{"comment", "comment:iTunNORM", convertFrameId("WXXX", 3, version) + ":Home", convertFrameId("TXXX", 3, version) + ":title", "MOOD"} {
+			if len(fields[key]) != 1 {
+				t.Errorf("v2.%d: ToMap: expected one value for %s, got %v", version, key, fields)
+			}
+		}
+
+		copied := NewTag(version)
+		if err := copied.SetAllValues(fields); err != nil {
+			t.Fatalf("v2.%d: SetAllValues: %v", version, err)
+		}
+		if again := copied.ToMap(); !reflect.DeepEqual(again, fields) {
+			t.Errorf("v2.%d: expected %v to round-trip, got %v", version, fields, again)
+		}
+		if values := copied.Values("comment"); len(values) != 1 || values[0] != "Great" {
+			t.Errorf("v2.%d: expected the comments kept apart, got %v", version, values)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	tag := NewTag(4)
+	tag.SetTitle("Café del Mar")
+	tag.SetArtist("STRASSE")
+	tag.AddFrames(
+		NewDescTextFrame(V23FrameTypeMap["TXXX"], "Mood", "Chill", "UTF-8"),
+		NewImageFrame(V23FrameTypeMap["APIC"], "image/png", PictureFrontCover, "Cover art", []byte{0x89}),
+		NewDataFrame(V23FrameTypeMap["WOAR"], []byte("https://example.com/cafe")),
+	)
+
+	tests := []struct {
+		query    string
+		opts     SearchOptions
+		expected []string
+	}{
+		{"café", SearchOptions{}, []string{"TIT2"}},
+		{"CAFE", SearchOptions{}, []string{"WOAR"}},
+		{"cafe", SearchOptions{IgnoreDiacritics: true}, []string{"TIT2", "WOAR"}},
+		{"straße", SearchOptions{}, nil},
+		{"straße", SearchOptions{FoldCase: true}, []string{"TPE1"}},
+		{"mood", SearchOptions{}, []string{"TXXX"}},
+		{"cover", SearchOptions{}, []string{"APIC"}},
+		{"", SearchOptions{}, nil},
+	}
+	for _, test := range tests {
+		var ids []string
+		for _, f := range tag.Search(test.query, test.opts) {
+			ids = append(ids, f.Id())
+		}
+		if !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("Search(%q, %+v): expected %v, got %v", test.query, test.opts, test.expected, ids)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+)
+
+// Bits of the tag header flags byte
+const (
+	FlagUnsynchronization byte = 1 << 7
+	// ID3v2.2 only, no compression scheme was ever defined
+	FlagCompression    byte = 1 << 6
+	FlagExtendedHeader byte = 1 << 6
+	FlagExperimental   byte = 1 << 5
+	// ID3v2.4 only
+	FlagFooter byte = 1 << 4
+)
+
+// Header flags defined in a major version
+func headerFlagMask(version byte) byte {
+	switch version {
+	case 2:
+		return FlagUnsynchronization | FlagCompression
+	case 3:
+		return FlagUnsynchronization | FlagExtendedHeader | FlagExperimental
+	case 4:
+		return FlagUnsynchronization | FlagExtendedHeader | FlagExperimental | FlagFooter
+	}
+
+	return 0
+}
+
+// Decodes the flags byte into the individual flags
+func (h *Header) decodeFlags() {
+	h.unsynchronization = isBitSet(h.flags, 7)
+	h.compression = h.version == 2 && isBitSet(h.flags, 6)
+	h.extendedHeader = h.version > 2 && isBitSet(h.flags, 6)
+	h.experimental = h.version > 2 && isBitSet(h.flags, 5)
+}
+
+func (h Header) Flags() byte {
+	return h.flags
+}
+
+func (h Header) Revision() byte {
+	return h.revision
+}
+
+func (h Header) Unsynchronization() bool {
+	return h.unsynchronization
+}
+
+// Always false after ID3v2.2
+func (h Header) Compression() bool {
+	return h.compression
+}
+
+// Always false in ID3v2.2
+func (h Header) ExtendedHeader() bool {
+	return h.extendedHeader
+}
+
+// Always false in ID3v2.2
+func (h Header) Experimental() bool {
+	return h.experimental
+}
+
+// Flags the writer does not honor, so they can be kept but not turned on
+// Frames are never unsynchronised, and only SetExtended writes the extended
+// header of ID3v2.4 tags.
+func unwritableFlags(version byte) byte {
+	if version == 2 {
+		return FlagUnsynchronization
+	}
+
+	return FlagUnsynchronization | FlagExtendedHeader
+}
+
+// Sets the whole flags byte
+// Bits the version does not define give ErrFlagNotAllowed, as does turning
+// on unsynchronisation or the extended header, which the writer cannot
+// produce. Flags already set, as in a parsed tag, may be kept or cleared.
+func (h *Header) SetFlags(flags byte) error {
+	if flags&^headerFlagMask(h.version) != 0 || flags&^h.flags&unwritableFlags(h.version) != 0 {
+		return ErrFlagNotAllowed
+	}
+
+	h.putFlags(flags)
+	return nil
+}
+
+func (h *Header) putFlags(flags byte) {
+	h.flags = flags
+	h.decodeFlags()
+}
+
+func (h *Header) SetRevision(revision byte) {
+	h.revision = revision
+}
+
+// Only clearing the flag is allowed, frames are never unsynchronised
+func (h *Header) SetUnsynchronization(unsynchronization bool) error {
+	return h.setFlag(FlagUnsynchronization, unsynchronization)
+}
+
+// ID3v2.2 only
+func (h *Header) SetCompression(compression bool) error {
+	if h.version != 2 {
+		return ErrFlagNotAllowed
+	}
+
+	return h.setFlag(FlagCompression, compression)
+}
+
+// ID3v2.3 and later, only clearing the flag is allowed
+// Use Tag.SetExtended to give an ID3v2.4 tag an extended header.
+func (h *Header) SetExtendedHeader(extendedHeader bool) error {
+	if h.version < 3 {
+		return ErrFlagNotAllowed
+	}
+
+	return h.setFlag(FlagExtendedHeader, extendedHeader)
+}
+
+// ID3v2.3 and later
+func (h *Header) SetExperimental(experimental bool) error {
+	return h.setFlag(FlagExperimental, experimental)
+}
+
+func (h *Header) setFlag(bit byte, on bool) error {
+	if on {
+		return h.SetFlags(h.flags | bit)
+	}
+
+	return h.SetFlags(h.flags &^ bit)
+}
+
+// Changes the tag header, marking the tag dirty when it succeeds
+func (t *Tag) setHeader(change func(h *Header) error) error {
+	t.sizeMu.Lock()
+	defer t.sizeMu.Unlock()
+
+	if err := change(t.Header); err != nil {
+		return err
+	}
+
+	t.dirty = true
+	return nil
+}
+
+func (t *Tag) SetFlags(flags byte) error {
+	return t.setHeader(func(h *Header) error { return h.SetFlags(flags) })
+}
+
+func (t *Tag) SetRevision(revision byte) {
+	t.setHeader(func(h *Header) error {
+		h.SetRevision(revision)
+		return nil
+	})
+}
+
+func (t *Tag) SetUnsynchronization(unsynchronization bool) error {
+	return t.setHeader(func(h *Header) error { return h.SetUnsynchronization(unsynchronization) })
+}
+
+func (t *Tag) SetCompression(compression bool) error {
+	return t.setHeader(func(h *Header) error { return h.SetCompression(compression) })
+}
+
+// ID3v2.4 tags get or lose an empty extended header, as with SetExtended.
+// ID3v2.3 tags can only lose the flag, their extended header is not written.
+func (t *Tag) SetExtendedHeader(extendedHeader bool) error {
+	if t.version == 4 {
+		switch e := t.Extended(); {
+		case !extendedHeader:
+			return t.SetExtended(nil)
+		case e == nil:
+			return t.SetExtended(&ExtendedHeader{})
+		}
+		return nil
+	}
+
+	return t.setHeader(func(h *Header) error { return h.SetExtendedHeader(extendedHeader) })
+}
+
+func (t *Tag) SetExperimental(experimental bool) error {
+	return t.setHeader(func(h *Header) error { return h.SetExperimental(experimental) })
+}
+
+// Reads the body of a compressed ID3v2.2 tag
+// The version defines no compression scheme and the spec says to ignore such
+// tags, so the body is read only when it is zlib data, as some taggers wrote.
+// Otherwise the frames are skipped and their space becomes padding. Either
+// way the tag is written back uncompressed.
+func (t *Tag) inflate(data []byte, opts ParseOptions) ([]byte, error) {
+	t.compression = false
+	t.flags &^= FlagCompression
+
+	if r, err := zlib.NewReader(bytes.NewReader(data)); err == nil {
+		var reader io.Reader = r
+		if max := limit(opts.MaxTagSize, DefaultMaxTagSize); max >= 0 {
+			reader = io.LimitReader(r, int64(max)+1)
+		}
+
+		if inflated, err := io.ReadAll(reader); err == nil {
+			if err := opts.checkTagSize(uint32(len(inflated))); err != nil {
+				return nil, err
+			}
+
+			t.size = uint32(len(inflated))
+			return inflated, nil
+		}
+	}
+
+	return nil, t.problem(opts, 0, "", ErrCompressedTag)
+}
@@ -4,10 +4,20 @@
 package v2
 
 import (
+	"bytes"
 	"fmt"
+	"hash/crc32"
 	"io"
+	"iter"
+	"log/slog"
+	"math"
+	"net/url"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/lion187chen/id3-go/encodedbytes"
 )
@@ -17,94 +27,246 @@ const (
 )
 
 // Tag represents an ID3v2 tag
+//
+// A Tag is safe for concurrent use: the frame list and the size accounting
+// are guarded internally, and the frames slice is never modified in place, so
+// slices handed out by the tag stay valid. Frames themselves are not guarded;
+// a frame must not be modified while other goroutines read it.
 type Tag struct {
 	*Header
+	// mu guards frames, sizeMu guards size, padding and dirty
+	// When both are needed mu is acquired first
+	mu                    sync.RWMutex
+	sizeMu                sync.Mutex
 	frames                []Framer
 	padding               uint
 	commonMap             map[string]FrameType
 	frameHeaderSize       int
-	frameConstructor      func(io.Reader) Framer
+	frameHeadParser       func([]byte) (FrameHead, error)
 	frameBytesConstructor func(Framer) []byte
+	frameHeadConstructor  func(Framer) []byte
 	dirty                 bool
+	warnings              []ParseWarning
+	// Encoding of text written through the tag, "" to choose per string
+	textEncoding string
+	// Language of comment and lyrics frames the tag creates, "" for the
+	// package default
+	language string
+	// Whether SetTimestamp fixes malformed timestamps instead of failing
+	lenientTimestamps bool
+	// Whether frames are written in canonical order
+	canonicalOrder bool
+	// IDs of frames written before all others, see PinFirst
+	pinned []string
+	// Where recoverable problems are reported, nil for nowhere
+	logger *slog.Logger
+	stats  ParseStats
+	// Size of the tag body in the file it was parsed from
+	storedSize uint32
+	// Header and body as parsed with ParseOptions.Preserve, written instead
+	// of the frames while the tag is not dirty
+	original []byte
 }
 
 // Creates a new tag
 func NewTag(version byte) *Tag {
-	header := &Header{version: version}
+	t := new(Tag)
+	t.init(version)
 
-	t := &Tag{
-		Header: header,
-		frames: make([]Framer, 0, 5),
-		dirty:  false,
-	}
+	return t
+}
+
+// Resets the tag to an empty tag of the given version
+func (t *Tag) init(version byte) {
+	t.Header = &Header{version: version}
+	t.frames = make([]Framer, 0, 5)
+	t.padding = 0
+	t.dirty = false
+	t.warnings = nil
+	t.original = nil
 
 	switch t.version {
 	case 2:
 		t.commonMap = V22CommonFrame
-		t.frameConstructor = ParseV22Frame
+		t.frameHeadParser = parseV22FrameHead
 		t.frameHeaderSize = V22FrameHeaderSize
 		t.frameBytesConstructor = V22Bytes
+		t.frameHeadConstructor = v22Head
 	case 3:
 		t.commonMap = V23CommonFrame
-		t.frameConstructor = ParseV23Frame
+		t.frameHeadParser = parseV23FrameHead
 		t.frameHeaderSize = FrameHeaderSize
 		t.frameBytesConstructor = V23Bytes
+		t.frameHeadConstructor = v23Head
 	case 4:
 		t.commonMap = V24CommonFrame
-		t.frameConstructor = ParseV24Frame
+		t.frameHeadParser = parseV24FrameHead
 		t.frameHeaderSize = FrameHeaderSize
 		t.frameBytesConstructor = V24Bytes
+		t.frameHeadConstructor = v24Head
 	default:
 		t.commonMap = V23CommonFrame
-		t.frameConstructor = ParseV23Frame
+		t.frameHeadParser = parseV23FrameHead
 		t.frameHeaderSize = FrameHeaderSize
 		t.frameBytesConstructor = V23Bytes
+		t.frameHeadConstructor = v23Head
 	}
-
-	return t
 }
 
 // Parses a new tag
 func ParseTag(readSeeker io.ReadSeeker) *Tag {
+	t, _ := ParseTagWithOptions(readSeeker, ParseOptions{})
+	return t
+}
+
+// Parses a new tag, enforcing the given options
+// A nil tag and nil error are returned when no tag is present
+func ParseTagWithOptions(readSeeker io.ReadSeeker, opts ParseOptions) (*Tag, error) {
+	start, err := readSeeker.Seek(0, os.SEEK_CUR)
+	if err != nil {
+		return nil, nil
+	}
+
 	header := ParseHeader(readSeeker)
 
 	if header == nil {
-		return nil
+		return nil, nil
+	}
+
+	if err := opts.checkTagSize(header.size); err != nil {
+		return nil, err
+	}
+
+	// The body is read as far as the data goes, so that a header claiming
+	// up to 256 MB does not allocate it up front
+	var body bytes.Buffer
+	if _, err := io.CopyN(&body, readSeeker, int64(header.size)); err != nil && err != io.EOF {
+		return nil, nil
+	}
+
+	t, err := parseBody(header, body.Bytes(), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	end := start + int64(HeaderSize+t.StoredSize())
+	if t.Footer() {
+		end += HeaderSize
+	}
+
+	if _, err := readSeeker.Seek(end, os.SEEK_SET); err != nil {
+		return nil, nil
+	}
+
+	return t, nil
+}
+
+// Parses a tag held in memory, starting with its header
+// Frames refer to data rather than copying it, so data must not change while
+// the tag is in use. A nil tag and nil error are returned when no tag is
+// present.
+func ParseTagData(data []byte, opts ParseOptions) (*Tag, error) {
+	header := ParseHeader(bytes.NewReader(data))
+	if header == nil {
+		return nil, nil
 	}
 
+	if err := opts.checkTagSize(header.size); err != nil {
+		return nil, err
+	}
+
+	body := data[HeaderSize:]
+	if uint32(len(body)) > header.size {
+		body = body[:header.size]
+	}
+
+	return parseBody(header, body, opts)
+}
+
+// Parses the frames of a tag body, which may be shorter than the header says
+func parseBody(header *Header, data []byte, opts ParseOptions) (*Tag, error) {
+	began := time.Now()
 	t := NewTag(header.version)
 	t.Header = header
+	t.logger = opts.Logger
+	t.storedSize = header.size
+	defer func() { t.stats.Duration = time.Since(began) }()
+
+	if opts.Preserve && uint32(len(data)) == header.size {
+		t.original = append(header.Bytes(), data...)
+		// Bytes leaves out the ID3v2.2 compression flag and the footer
+		t.original[5] = header.flags
+		if header.Footer() {
+			t.original = append(t.original, header.bytes(footerId)...)
+		}
+	}
 
-	var frame Framer
-	size := int(t.size)
-	for size > 0 {
-		frame = t.frameConstructor(readSeeker)
-
-		if frame == nil {
-			break
+	if t.compression {
+		var err error
+		if data, err = t.inflate(data, opts); err != nil {
+			return nil, err
 		}
+	}
 
-		t.frames = append(t.frames, frame)
-		frame.setOwner(t)
+	start, err := t.parseExtended(data, opts)
+	if err != nil {
+		return nil, err
+	}
 
-		size -= t.frameHeaderSize + int(frame.Size())
+	if err := t.parseFrames(data, start, opts); err != nil {
+		return nil, err
 	}
 
-	t.padding = uint(size)
-	if _, err := readSeeker.Seek(int64(HeaderSize+t.Size()), os.SEEK_SET); err != nil {
-		return nil
+	if opts.Latin1Encoding != nil || opts.DetectCharset {
+		// The file still holds the original bytes, so the tag is not dirty
+		n := 0
+		if opts.Latin1Encoding != nil {
+			n = t.ReinterpretTextAs(opts.Latin1Encoding)
+		} else {
+			_, n = t.FixCharset()
+		}
+		if n > 0 {
+			t.original = nil
+		}
+		t.sizeMu.Lock()
+		t.dirty = false
+		t.sizeMu.Unlock()
 	}
 
-	return t
+	return t, nil
+}
+
+// Size of the tag excluding the header as stored in the file it was parsed
+// from, 0 for new tags
+// It differs from Size when parsing grew the tag, such as for a compressed
+// ID3v2.2 tag.
+func (t *Tag) StoredSize() int {
+	t.sizeMu.Lock()
+	defer t.sizeMu.Unlock()
+
+	return int(t.storedSize)
 }
 
 // Real size of the tag
-func (t Tag) RealSize() int {
-	size := uint(t.size) - t.padding
-	return int(size)
+func (t *Tag) RealSize() int {
+	t.sizeMu.Lock()
+	defer t.sizeMu.Unlock()
+
+	return int(uint(t.size) - t.padding)
+}
+
+// Size of the tag excluding the header
+func (t *Tag) Size() int {
+	t.sizeMu.Lock()
+	defer t.sizeMu.Unlock()
+
+	return int(t.size)
 }
 
 func (t *Tag) changeSize(diff int) {
+	t.sizeMu.Lock()
+	defer t.sizeMu.Unlock()
+
 	if d := int(t.padding) - diff; d < 0 {
 		t.padding = 0
 		t.size += uint32(-d)
@@ -115,32 +277,141 @@ func (t *Tag) changeSize(diff int) {
 	t.dirty = true
 }
 
+// Bytes the tag was parsed from when it is preserved and unedited
+func (t *Tag) preserved() []byte {
+	t.sizeMu.Lock()
+	defer t.sizeMu.Unlock()
+
+	if t.dirty {
+		return nil
+	}
+	return t.original
+}
+
 // Modified status of the tag
-func (t Tag) Dirty() bool {
+func (t *Tag) Dirty() bool {
+	t.sizeMu.Lock()
+	defer t.sizeMu.Unlock()
+
 	return t.dirty
 }
 
-func (t Tag) Bytes() []byte {
+// Marks the tag modified so that it is written on save
+// Frame setters do this themselves. It is needed after changing frame data in
+// place, such as the slice returned by Data.
+func (t *Tag) MarkDirty() {
+	t.sizeMu.Lock()
+	defer t.sizeMu.Unlock()
+
+	t.dirty = true
+}
+
+func (t *Tag) Bytes() []byte {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if original := t.preserved(); original != nil {
+		return cloneBytes(original)
+	}
+
+	t.sizeMu.Lock()
+	altered := t.dirty
+	extended := t.extended
+	t.sizeMu.Unlock()
+
+	// Frames discarded on alteration leave zeros, which read as padding
 	data := make([]byte, t.Size())
+	frames, _ := t.writtenFrames(altered)
 
-	index := 0
-	for _, f := range t.frames {
+	index := extended.size()
+	for _, f := range frames {
 		size := t.frameHeaderSize + int(f.Size())
-		copy(data[index:index+size], t.frameBytesConstructor(f))
+		b := t.preservedFrame(f)
+		if b == nil {
+			b = t.frameBytesConstructor(f)
+		}
+		if len(b) != size && t.logger != nil {
+			t.logger.Warn("id3v2: frame content does not match its size", slog.String("frame", f.Id()), slog.Int("size", size), slog.Int("content", len(b)))
+		}
+		copy(data[index:index+size], b)
 
 		index += size
 	}
 
-	return append(t.Header.Bytes(), data...)
+	if extended != nil {
+		var crc uint32
+		if extended.CRC {
+			crc = crc32.ChecksumIEEE(data[extended.size():])
+		}
+		copy(data, extended.bytes(crc))
+	}
+
+	t.sizeMu.Lock()
+	header := t.Header.Bytes()
+	t.sizeMu.Unlock()
+
+	return append(header, data...)
+}
+
+// Text form of the tag, one "ID: text" line per frame
+func (t *Tag) AppendText(b []byte) ([]byte, error) {
+	for f := range t.All() {
+		b = append(b, f.Id()...)
+		b = append(b, ": "...)
+
+		var err error
+		if a, ok := f.(interface{ AppendText([]byte) ([]byte, error) }); ok {
+			if b, err = a.AppendText(b); err != nil {
+				return nil, err
+			}
+		} else {
+			b = append(b, f.String()...)
+		}
+
+		b = append(b, '\n')
+	}
+
+	return b, nil
+}
+
+func (t *Tag) MarshalText() ([]byte, error) {
+	return t.AppendText(nil)
+}
+
+func (t *Tag) String() string {
+	return frameString(t)
+}
+
+// Problems recovered from while parsing
+// Malformed frames are only recorded in lenient mode; UTF-16 text without a
+// byte order mark always is.
+func (t *Tag) Warnings() []ParseWarning {
+	return t.warnings
 }
 
 // The amount of padding in the tag
-func (t Tag) Padding() uint {
+func (t *Tag) Padding() uint {
+	t.sizeMu.Lock()
+	defer t.sizeMu.Unlock()
+
 	return t.padding
 }
 
+// Sets the amount of padding, resizing the tag
+func (t *Tag) SetPadding(padding uint) {
+	t.sizeMu.Lock()
+	defer t.sizeMu.Unlock()
+
+	t.size = uint32(uint(t.size)-t.padding) + uint32(padding)
+	t.padding = padding
+	t.dirty = true
+}
+
 // All frames
-func (t Tag) AllFrames() []Framer {
+func (t *Tag) AllFrames() []Framer {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
 	// Most of the time each ID will only have one frame
 	m := len(t.frames)
 	frames := make([]Framer, m)
@@ -150,12 +421,43 @@ func (t Tag) AllFrames() []Framer {
 	return frames
 }
 
+// Number of frames, for iterating with FrameAt
+func (t *Tag) NumFrames() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return len(t.frames)
+}
+
+// Frame at index i of the order AllFrames returns, without copying the list
+// Adding or removing frames invalidates the indexes, so loops that change
+// the tag should use AllFrames instead. Out of range indexes return nil.
+func (t *Tag) FrameAt(i int) Framer {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if i < 0 || i >= len(t.frames) {
+		return nil
+	}
+	return t.frames[i]
+}
+
 // All frames with specified ID
-func (t Tag) Frames(id string) []Framer {
+// Equivalent IDs from other major versions also match, so Frames("TYER")
+// finds a TDRC frame and Frames("TIT2") finds a TT2 frame
+func (t *Tag) Frames(id string) []Framer {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.framesById(id)
+}
+
+func (t *Tag) framesById(id string) []Framer {
 	rv := make([]Framer, 0, 1)
+	ids := frameIdAliases(id)
 
 	for _, f := range t.frames {
-		if f.Id() == id {
+		if contains(ids, f.Id()) {
 			rv = append(rv, f)
 		}
 	}
@@ -163,65 +465,222 @@ func (t Tag) Frames(id string) []Framer {
 	return rv
 }
 
+// All frames whose ID starts with the prefix
+// FramesMatching("T") returns the text frames and FramesMatching("W") the URL
+// frames of any version, as their IDs share the first letter.
+func (t *Tag) FramesMatching(prefix string) []Framer {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var frames []Framer
+	for _, f := range t.frames {
+		if strings.HasPrefix(f.Id(), prefix) {
+			frames = append(frames, f)
+		}
+	}
+
+	return frames
+}
+
+// Values of the text information frames keyed by frame ID
+// Multiple values of a frame become separate values. User defined text,
+// comment and lyrics frames are left out, as their ID does not identify them.
+func (t *Tag) TextFrameMap() map[string][]string {
+	fields := make(map[string][]string)
+
+	for _, f := range t.snapshot() {
+		switch f.(type) {
+		case *DescTextFrame, *UnsynchTextFrame:
+			continue
+		}
+		if tf, ok := f.(TextFramer); ok {
+			fields[f.Id()] = append(fields[f.Id()], splitValues(tf.Text())...)
+		}
+	}
+
+	return fields
+}
+
 // First frame with specified ID
-func (t Tag) Frame(id string) Framer {
-	if frames := t.Frames(id); len(frames) != 0 {
+// An exact match is preferred over a frame with an equivalent ID
+func (t *Tag) Frame(id string) Framer {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.frame(id)
+}
+
+func (t *Tag) frame(id string) Framer {
+	for _, f := range t.frames {
+		if f.Id() == id {
+			return f
+		}
+	}
+
+	if frames := t.framesById(id); len(frames) > 0 {
 		return frames[0]
 	}
 
 	return nil
 }
 
-// Delete and return all frames with specified ID
-func (t *Tag) DeleteFrames(id string) []Framer {
-	frames := t.Frames(id)
-	if frames == nil {
-		return nil
+// First frame with the ID, or an equivalent one, and the description
+// Comment, lyrics, user defined text and URL, and picture frames are told
+// apart by their description rather than their ID alone.
+func (t *Tag) FrameBy(id, description string) Framer {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for _, f := range t.framesById(id) {
+		if hasDescription(f, description) {
+			return f
+		}
 	}
 
-	diff := 0
-	i := 0
-	for i < len(t.frames) {
-		frame := t.frames[i]
-		if frame.Id() != id {
-			i++
-		} else {
-			frame.setOwner(nil)
-			diff += t.frameHeaderSize + int(frame.Size())
-			t.frames = append(t.frames[:i], t.frames[i+1:]...)
+	return nil
+}
+
+// Delete and return the frames with the ID, or an equivalent one, and the
+// description
+func (t *Tag) DeleteFrameBy(id, description string) []Framer {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ids := frameIdAliases(id)
+	var deleted []Framer
+	t.removeFrames(func(f Framer) bool {
+		if !contains(ids, f.Id()) || !hasDescription(f, description) {
+			return false
+		}
+		deleted = append(deleted, f)
+		return true
+	})
+
+	return deleted
+}
+
+// Reports whether the frame has a description field holding description
+func hasDescription(f Framer, description string) bool {
+	df, ok := f.(interface{ Description() string })
+	return ok && trimNull(df.Description()) == description
+}
+
+// Iterates over all frames in tag order
+// The frames are those present when iteration starts; the tag may be
+// modified from inside the loop.
+func (t *Tag) All() iter.Seq[Framer] {
+	return func(yield func(Framer) bool) {
+		for _, f := range t.snapshot() {
+			if !yield(f) {
+				return
+			}
 		}
 	}
-	t.changeSize(-diff)
+}
+
+// Iterates over frames with specified ID or an equivalent ID
+func (t *Tag) ByID(id string) iter.Seq[Framer] {
+	ids := frameIdAliases(id)
+
+	return func(yield func(Framer) bool) {
+		for _, f := range t.snapshot() {
+			if contains(ids, f.Id()) && !yield(f) {
+				return
+			}
+		}
+	}
+}
+
+// Iterates over the ID and text of every text frame
+func (t *Tag) TextFrames() iter.Seq2[string, string] {
+	return func(yield func(string, string) bool) {
+		for _, f := range t.snapshot() {
+			if tf, ok := f.(TextFramer); ok && !yield(tf.Id(), tf.Text()) {
+				return
+			}
+		}
+	}
+}
+
+// Current frame slice, safe to range over without holding the lock since
+// the slice is never modified in place
+func (t *Tag) snapshot() []Framer {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.frames[:len(t.frames):len(t.frames)]
+}
+
+// Delete and return all frames with specified ID or an equivalent ID
+func (t *Tag) DeleteFrames(id string) []Framer {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ids := frameIdAliases(id)
+	frames := t.framesById(id)
+	t.removeFrames(func(f Framer) bool { return contains(ids, f.Id()) })
 
 	return frames
 }
 
 // Delete the specified frame
 func (t *Tag) DeleteFrame(delFrame Framer) []Framer {
-	frames := t.AllFrames()
-	if frames == nil {
-		return nil
-	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
 
+	frames := make([]Framer, len(t.frames))
+	copy(frames, t.frames)
+	t.removeFrames(func(f Framer) bool { return f == delFrame })
+
+	return frames
+}
+
+// Deletes the frames for which match returns true, in one pass
+// Match is called with the tag locked, so it must not call methods of the
+// tag. Returns the deleted frames.
+func (t *Tag) DeleteFramesFunc(match func(Framer) bool) []Framer {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var deleted []Framer
+	t.removeFrames(func(f Framer) bool {
+		if !match(f) {
+			return false
+		}
+		deleted = append(deleted, f)
+		return true
+	})
+
+	return deleted
+}
+
+// Removes matching frames, building a new slice so that slices handed out
+// earlier are left untouched
+func (t *Tag) removeFrames(match func(Framer) bool) {
 	diff := 0
-	i := 0
-	for i < len(t.frames) {
-		frame := t.frames[i]
-		if frame == delFrame {
+	kept := make([]Framer, 0, len(t.frames))
+	for _, frame := range t.frames {
+		if match(frame) {
 			frame.setOwner(nil)
 			diff += t.frameHeaderSize + int(frame.Size())
-			t.frames = append(t.frames[:i], t.frames[i+1:]...)
 		} else {
-			i++
+			kept = append(kept, frame)
 		}
 	}
-	t.changeSize(-diff)
 
-	return frames
+	t.frames = kept
+	t.changeSize(-diff)
 }
 
 // Add frames
 func (t *Tag) AddFrames(frames ...Framer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.addFrames(frames...)
+}
+
+func (t *Tag) addFrames(frames ...Framer) {
 	for _, frame := range frames {
 		t.changeSize(t.frameHeaderSize + int(frame.Size()))
 
@@ -230,35 +689,255 @@ func (t *Tag) AddFrames(frames ...Framer) {
 	}
 }
 
-func (t Tag) Title() string {
+// Replaces a frame with another in the same position
+// Reports whether old was in the tag; the tag is unchanged when it was not.
+func (t *Tag) ReplaceFrame(old, new Framer) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	i := slices.Index(t.frames, old)
+	if i < 0 {
+		return false
+	}
+
+	frames := slices.Clone(t.frames)
+	frames[i] = new
+	t.frames = frames
+
+	old.setOwner(nil)
+	new.setOwner(t)
+	t.changeSize(int(new.Size()) - int(old.Size()))
+
+	return true
+}
+
+// Inserts a frame before the frame at index i
+// Indexes past the last frame append it, negative ones insert it first.
+// Frames are written in this order unless the canonical order is set.
+func (t *Tag) InsertFrameAt(i int, f Framer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	i = min(max(i, 0), len(t.frames))
+	t.frames = slices.Insert(slices.Clip(t.frames), i, f)
+
+	f.setOwner(t)
+	t.changeSize(t.frameHeaderSize + int(f.Size()))
+}
+
+// Merge adds the frames of another tag that are not already present
+// Frames the spec requires to be unique are kept from this tag, and frames
+// without an equivalent in this tag's version are dropped.
+// Returns the frames that were added.
+func (t *Tag) Merge(other *Tag) []Framer {
+	others := other.AllFrames()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	keys := make(map[string]bool)
+	for _, f := range t.frames {
+		if key, unique := uniqueKey(f); unique {
+			keys[key] = true
+		} else {
+			keys[f.Id()+"\x00"+string(f.Bytes())] = true
+		}
+	}
+
+	var added []Framer
+	for _, f := range others {
+		frame := convertFrame(f, other.version, t.version)
+		if frame == nil || mergeCredits(added, frame) {
+			continue
+		}
+
+		key, unique := uniqueKey(frame)
+		if !unique {
+			key = frame.Id() + "\x00" + string(frame.Bytes())
+		}
+		if keys[key] {
+			continue
+		}
+		keys[key] = true
+
+		t.addFrames(frame)
+		added = append(added, frame)
+	}
+
+	return added
+}
+
+// Deep copy of the tag
+// The copy shares no frames or buffers with t, so either can be modified or
+// discarded without affecting the other.
+func (t *Tag) Clone() *Tag {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	c := NewTag(t.version)
+
+	t.sizeMu.Lock()
+	header := *t.Header
+	c.padding = t.padding
+	c.dirty = t.dirty
+	t.sizeMu.Unlock()
+
+	c.Header = &header
+	c.textEncoding = t.textEncoding
+	c.language = t.language
+	c.lenientTimestamps = t.lenientTimestamps
+	c.canonicalOrder = t.canonicalOrder
+	c.pinned = t.pinned
+	c.logger = t.logger
+	c.stats = t.stats.clone()
+	c.storedSize = t.storedSize
+	c.original = t.original
+	c.warnings = append([]ParseWarning(nil), t.warnings...)
+	c.frames = make([]Framer, len(t.frames))
+	for i, f := range t.frames {
+		c.frames[i] = f.Clone()
+		c.frames[i].setOwner(c)
+	}
+
+	return c
+}
+
+// FrameSource is any tag whose frames can be copied, such as the Tagger
+// of an opened file
+type FrameSource interface {
+	Version() string
+	AllFrames() []Framer
+}
+
+// Copies frames with the given IDs from src, or all frames when no IDs are
+// given
+// Frames are deep copies translated to this tag's version: IDs are mapped to
+// their equivalents and text is re-encoded where the version requires it.
+// A copied frame replaces frames the spec allows only once, such as the
+// title or a comment with the same description. Returns the number of
+// frames copied.
+func (t *Tag) CopyFramesFrom(src FrameSource, ids ...string) int {
+	var from byte
+	if _, err := fmt.Sscanf(src.Version(), "2.%d", &from); err != nil {
+		return 0
+	}
+
+	var wanted []string
+	for _, id := range ids {
+		wanted = append(wanted, frameIdAliases(id)...)
+	}
+
+	frames := src.AllFrames()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n := 0
+	var added []Framer
+	for _, f := range frames {
+		if len(wanted) > 0 && !contains(wanted, f.Id()) {
+			continue
+		}
+
+		frame := convertFrame(f, from, t.version)
+		if frame == nil {
+			continue
+		}
+		if mergeCredits(added, frame) {
+			n++
+			continue
+		}
+
+		if key, unique := uniqueKey(frame); unique {
+			t.removeFrames(func(g Framer) bool {
+				k, _ := uniqueKey(g)
+				return k == key
+			})
+		}
+
+		t.addFrames(frame)
+		added = append(added, frame)
+		n++
+	}
+
+	return n
+}
+
+// Rebuilds frames held as binary data using the current frame types
+// Frames whose ID gained a constructor through RegisterFrameType become typed
+// frames. Returns the number of frames that were converted.
+func (t *Tag) Reinterpret() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n := 0
+	frames := make([]Framer, len(t.frames))
+	for i, f := range t.frames {
+		frames[i] = f
+
+		df, ok := f.(*DataFrame)
+		if !ok {
+			continue
+		}
+
+		ft, ok := lookupFrameType(t.version, df.Id())
+		if !ok {
+			continue
+		}
+
+		head := FrameHead{
+			FrameType:   ft,
+			statusFlags: df.statusFlags,
+			formatFlags: df.formatFlags,
+			size:        uint32(len(df.data)),
+		}
+
+		frame, err := newFrame(head, append([]byte(nil), df.data...))
+		if _, raw := frame.(*DataFrame); err != nil || raw {
+			continue
+		}
+
+		df.setOwner(nil)
+		frame.setOwner(t)
+		t.changeSize(int(frame.Size()) - int(df.Size()))
+		frames[i] = frame
+		n++
+	}
+	t.frames = frames
+
+	return n
+}
+
+func (t *Tag) Title() string {
 	return t.textFrameText(t.commonMap["Title"])
 }
 
-func (t Tag) Artist() string {
+func (t *Tag) Artist() string {
 	return t.textFrameText(t.commonMap["Artist"])
 }
 
-func (t Tag) Album() string {
+func (t *Tag) Album() string {
 	return t.textFrameText(t.commonMap["Album"])
 }
 
-func (t Tag) Year() string {
+func (t *Tag) Year() string {
 	return t.textFrameText(t.commonMap["Year"])
 }
 
-func (t Tag) Genre() string {
+func (t *Tag) Genre() string {
 	return t.textFrameText(t.commonMap["Genre"])
 }
 
-func (t Tag) Length() int {
-	length, err := strconv.ParseInt(t.textFrameText(t.commonMap["Length"]), 10, 32)
+func (t *Tag) Length() int {
+	text := strings.TrimSpace(trimNull(t.textFrameText(t.commonMap["Length"])))
+	length, err := strconv.ParseInt(text, 10, 32)
 	if err != nil {
 		return -1
 	}
 	return int(length)
 }
 
-func (t Tag) Comments() []string {
+func (t *Tag) Comments() []string {
 	frames := t.Frames(t.commonMap["Comments"].Id())
 	if frames == nil {
 		return nil
@@ -296,8 +975,65 @@ func (t *Tag) SetLength(length int) {
 	t.setTextFrameText(t.commonMap["Length"], fmt.Sprintf("%d", length))
 }
 
+// Sets the year as four digits, in TYER or in TDRC from ID3v2.4 on
+func (t *Tag) SetYearInt(year int) error {
+	if year < 0 || year > 9999 {
+		return fmt.Errorf("%w: year %d does not have four digits", ErrBadDate, year)
+	}
+
+	return t.setTextFrameText(t.commonMap["Year"], fmt.Sprintf("%04d", year))
+}
+
+// Sets the year of the date, see SetYearInt
+func (t *Tag) SetYearTime(date time.Time) error {
+	return t.SetYearInt(date.Year())
+}
+
+// Sets the track number and, when total is not zero, the number of tracks
+// A zero track removes the TRCK frame.
+func (t *Tag) SetTrack(track, total int) error {
+	return t.setPosition("TRCK", "track", track, total)
+}
+
+// Sets the disc number and, when total is not zero, the number of discs
+// A zero disc removes the TPOS frame.
+func (t *Tag) SetDisc(disc, total int) error {
+	return t.setPosition("TPOS", "disc", disc, total)
+}
+
+// Sets a "n/total" frame
+func (t *Tag) setPosition(id, name string, n, total int) error {
+	switch {
+	case n < 0 || total < 0:
+		return fmt.Errorf("%w: negative %s number", ErrBadValue, name)
+	case n == 0 && total > 0:
+		return fmt.Errorf("%w: %s total without a %s number", ErrBadValue, name, name)
+	case n == 0:
+		return t.setFrameText(id, "")
+	case total == 0:
+		return t.setFrameText(id, strconv.Itoa(n))
+	}
+
+	return t.setFrameText(id, strconv.Itoa(n)+"/"+strconv.Itoa(total))
+}
+
+// Sets the beats per minute, rounded as TBPM holds an integer
+// Zero removes the TBPM frame.
+func (t *Tag) SetBPM(bpm float64) error {
+	if bpm < 0 || math.IsNaN(bpm) || math.IsInf(bpm, 0) {
+		return fmt.Errorf("%w: %v beats per minute", ErrBadValue, bpm)
+	}
+
+	text := ""
+	if n := math.Round(bpm); n > 0 {
+		text = strconv.FormatFloat(n, 'f', 0, 64)
+	}
+	return t.setFrameText("TBPM", text)
+}
+
+// First text frame of the given type, the caller must hold mu
 func (t *Tag) textFrame(ft FrameType) TextFramer {
-	if frame := t.Frame(ft.Id()); frame != nil {
+	if frame := t.frame(ft.Id()); frame != nil {
 		if textFramer, ok := frame.(TextFramer); ok {
 			return textFramer
 		}
@@ -306,7 +1042,10 @@ func (t *Tag) textFrame(ft FrameType) TextFramer {
 	return nil
 }
 
-func (t Tag) textFrameText(ft FrameType) string {
+func (t *Tag) textFrameText(ft FrameType) string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
 	if frame := t.textFrame(ft); frame != nil {
 		return frame.Text()
 	}
@@ -314,20 +1053,262 @@ func (t Tag) textFrameText(ft FrameType) string {
 	return ""
 }
 
-func (t *Tag) setTextFrameText(ft FrameType, text string) {
-	if frame := t.textFrame(ft); frame != nil {
+// Sets the text frame with an ID3v2.3 ID, empty text removes it
+// Frames without an equivalent in the tag's version are ignored. Read-only
+// frames give ErrReadOnlyFrame.
+func (t *Tag) setFrameText(id, text string) error {
+	ft := t.frameType(id)
+	switch {
+	case ft.Id() == "":
+		return nil
+	case text == "":
+		return t.deleteWritableFrames(ft)
+	default:
+		return t.setTextFrameText(ft, text)
+	}
+}
+
+// Deletes the frames of the type unless one of them is read-only
+func (t *Tag) deleteWritableFrames(ft FrameType) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	existing := t.framesById(ft.Id())
+	for _, f := range existing {
+		if t.readOnly(f) {
+			return fmt.Errorf("%s: %w", ft.Id(), ErrReadOnlyFrame)
+		}
+	}
+
+	t.removeFrames(func(f Framer) bool { return slices.Contains(existing, f) })
+	return nil
+}
+
+// URL of the link frame with an ID3v2.3 ID, "" when there is none
+func (t *Tag) linkFrameURL(id string) string {
+	if links := t.linkFrameURLs(id); len(links) > 0 {
+		return links[0]
+	}
+	return ""
+}
+
+// URLs of the link frames with an ID3v2.3 ID
+func (t *Tag) linkFrameURLs(id string) []string {
+	ft := t.frameType(id)
+	if ft.Id() == "" {
+		return nil
+	}
+
+	var links []string
+	for _, f := range t.Frames(ft.Id()) {
+		if df, ok := f.(*DataFrame); ok {
+			links = append(links, strings.TrimSpace(trimNull(string(df.Data()))))
+		}
+	}
+	return links
+}
+
+// Sets the link frame with an ID3v2.3 ID, an empty URL removes it
+// URLs must be absolute and ISO-8859-1. Versions without the frame give
+// ErrFrameNotAllowed.
+func (t *Tag) setLinkFrameURL(id, link string) error {
+	if link == "" {
+		return t.setLinkFrameURLs(id)
+	}
+	return t.setLinkFrameURLs(id, link)
+}
+
+// Replaces the link frames with an ID3v2.3 ID by one frame for each URL
+// Nothing is changed when any URL is malformed.
+func (t *Tag) setLinkFrameURLs(id string, links ...string) error {
+	ft := t.frameType(id)
+	if ft.Id() == "" {
+		return fmt.Errorf("%s: %w", id, ErrFrameNotAllowed)
+	}
+	for _, link := range links {
+		if !isLatin1(link) {
+			return fmt.Errorf("%s: %w: URL is not ISO-8859-1", ft.Id(), ErrBadEncoding)
+		}
+		if u, err := url.Parse(link); err != nil || !u.IsAbs() {
+			return fmt.Errorf("%s: %w: %q is not an absolute URL", ft.Id(), ErrBadValue, link)
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	existing := t.framesById(ft.Id())
+	for _, f := range existing {
+		if t.readOnly(f) {
+			return fmt.Errorf("%s: %w", ft.Id(), ErrReadOnlyFrame)
+		}
+	}
+
+	t.removeFrames(func(f Framer) bool { return slices.Contains(existing, f) })
+	for _, link := range links {
+		data := make([]byte, 0, len(link))
+		for _, r := range link {
+			data = append(data, byte(r))
+		}
+		t.addFrames(NewDataFrame(ft, data))
+	}
+
+	return nil
+}
+
+// Sets the first frame of the type to the text, adding one if there is none
+// ID3v2.4 timestamps are checked as SetTimestamp does.
+func (t *Tag) setTextFrameText(ft FrameType, text string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.version >= 4 && isTimestampFrame(ft.Id()) {
+		var err error
+		if text, err = timestampText(text, t.lenientTimestamps); err != nil {
+			return err
+		}
+	}
+
+	encoding := t.encodingFor(text)
+	frame := t.textFrame(ft)
+	if frame != nil && t.readOnly(frame) {
+		return fmt.Errorf("%s: %w", ft.Id(), ErrReadOnlyFrame)
+	}
+	if frame == nil {
+		f := NewTextFrame(ft, text, encoding)
+		if f == nil {
+			return fmt.Errorf("%s: %w", ft.Id(), ErrBadEncoding)
+		}
+		if uint64(f.Size()) > maxFrameSize(t.version) {
+			return fmt.Errorf("%s: %w: %d bytes", ft.Id(), ErrFrameTooLarge, f.Size())
+		}
+		t.addFrames(f)
+		return nil
+	}
+
+	// Switch through UTF-8, which can represent both texts
+	set := func(text, encoding string) error {
 		frame.SetEncoding("UTF-8")
-		frame.SetText(text)
-	} else {
-		f := NewTextFrame(ft, text, "UTF-8")
-		t.AddFrames(f)
+		if err := frame.SetText(text); err != nil {
+			return err
+		}
+		return frame.SetEncoding(encoding)
+	}
+
+	oldText, oldEncoding := frame.Text(), frame.Encoding()
+	err := set(text, encoding)
+	if err == nil && uint64(frame.Size()) > maxFrameSize(t.version) {
+		err = fmt.Errorf("%w: %d bytes", ErrFrameTooLarge, frame.Size())
+	}
+	if err != nil {
+		set(oldText, oldEncoding)
+		return fmt.Errorf("%s: %w", ft.Id(), err)
+	}
+
+	return nil
+}
+
+// Sets the text frame with the ID, of this or another version, empty text
+// removes it
+// Timestamp frames of ID3v2.4 are checked as by SetTimestamp. Fails with
+// ErrFrameNotAllowed when the version has no such frame, ErrBadValue for
+// frames that are not text frames, and ErrBadEncoding or ErrFrameTooLarge
+// when the text cannot be stored; the frame is then left unchanged.
+func (t *Tag) SetFrameText(id, text string) error {
+	ft, ok := lookupFrameType(t.version, id)
+	ok = ok && (ValidFrameId(t.version, id) || contains(nonStandardFrames, id))
+	if !ok && len(id) == 3 {
+		ft, _ = lookupFrameType(t.version, convertFrameId(id, 2, t.version))
+	} else if !ok {
+		ft = t.frameType(id)
+	}
+
+	switch ftId := ft.Id(); {
+	case ftId == "" || !(ValidFrameId(t.version, ftId) || contains(nonStandardFrames, ftId)):
+		return fmt.Errorf("%w: %s", ErrFrameNotAllowed, id)
+	case ftId[0] != 'T' || ftId == "TXX" || ftId == "TXXX":
+		return fmt.Errorf("%w: %s is not a text frame", ErrBadValue, ftId)
+	case text == "":
+		t.DeleteFrames(ftId)
+		return nil
+	}
+
+	return t.setTextFrameText(ft, text)
+}
+
+// Encoding of text written through the tag, "" when chosen per string
+func (t *Tag) TextEncoding() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.textEncoding
+}
+
+// Fixes the encoding of text written through the tag
+// Text that ISO-8859-1 cannot represent falls back to the default, unless
+// encodedbytes.SetLatin1Policy allows replacing its characters. An empty
+// encoding restores the default: ISO-8859-1 when the text fits, otherwise
+// UTF-16 before ID3v2.4 and UTF-8 from it on.
+func (t *Tag) SetTextEncoding(encoding string) error {
+	if encoding != "" {
+		i := encodedbytes.IndexForEncoding(encoding)
+		if i == 0xFF {
+			return ErrUnknownEncoding
+		}
+		if i > 1 && t.version < 4 {
+			return fmt.Errorf("%w: %s needs ID3v2.4", ErrEncodingNotAllowed, encoding)
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.textEncoding = encoding
+	return nil
+}
+
+// Encoding the tag writes text in
+func (t *Tag) EncodingFor(text string) string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.encodingFor(text)
+}
+
+// Encoding for text, the caller must hold mu
+func (t *Tag) encodingFor(text string) string {
+	switch {
+	case t.textEncoding == "ISO-8859-1" && encodedbytes.CurrentLatin1Policy() != encodedbytes.Latin1Error:
+		return t.textEncoding
+	case t.textEncoding != "" && (t.textEncoding != "ISO-8859-1" || isLatin1(text)):
+		return t.textEncoding
+	case isLatin1(text):
+		return "ISO-8859-1"
+	}
+
+	fallback := "UTF-16"
+	if t.version >= 4 {
+		fallback = "UTF-8"
+	}
+	if t.textEncoding != "" && t.logger != nil {
+		t.logger.Warn("id3v2: text encoding cannot represent text", slog.String("encoding", t.textEncoding), slog.String("fallback", fallback))
 	}
+
+	return fallback
 }
 
 func ParseHeader(reader io.Reader) *Header {
-	data := make([]byte, HeaderSize)
+	return parseHeader(reader, "ID3")
+}
+
+// Parses a header, or a footer, starting with the given identifier
+func parseHeader(reader io.Reader, id string) *Header {
+	buf := headerBuffers.Get().(*[HeaderSize]byte)
+	defer headerBuffers.Put(buf)
+
+	data := buf[:]
 	n, err := io.ReadFull(reader, data)
-	if n < HeaderSize || err != nil || string(data[:3]) != "ID3" {
+	if n < HeaderSize || err != nil || string(data[:3]) != id {
 		return nil
 	}
 
@@ -343,19 +1324,7 @@ func ParseHeader(reader io.Reader) *Header {
 		size:     size,
 	}
 
-	switch header.version {
-	case 2:
-		header.unsynchronization = isBitSet(header.flags, 7)
-		header.compression = isBitSet(header.flags, 6)
-	case 3:
-		header.unsynchronization = isBitSet(header.flags, 7)
-		header.extendedHeader = isBitSet(header.flags, 6)
-		header.experimental = isBitSet(header.flags, 5)
-	case 4:
-		header.unsynchronization = isBitSet(header.flags, 7)
-		header.extendedHeader = isBitSet(header.flags, 6)
-		header.experimental = isBitSet(header.flags, 5)
-	}
+	header.decodeFlags()
 
 	return header
 }
@@ -369,6 +1338,8 @@ type Header struct {
 	experimental      bool
 	extendedHeader    bool
 	size              uint32
+	// ID3v2.4 extended header, nil when the tag has none
+	extended *ExtendedHeader
 }
 
 func (h Header) Version() string {
@@ -379,11 +1350,25 @@ func (h Header) Size() int {
 	return int(h.size)
 }
 
+// Only WriteAppendedTo writes the footer the flag announces, so it is left
+// out.
 func (h Header) Bytes() []byte {
+	h.flags &^= FlagFooter
+	return h.bytes("ID3")
+}
+
+// Header, or footer, starting with the given identifier
+func (h Header) bytes(id string) []byte {
 	data := make([]byte, 0, HeaderSize)
 
-	data = append(data, "ID3"...)
-	data = append(data, h.version, h.revision, h.flags)
+	// Tags are never written compressed, ID3v2.2 defines no scheme for it
+	flags := h.flags
+	if h.version == 2 {
+		flags &^= FlagCompression
+	}
+
+	data = append(data, id...)
+	data = append(data, h.version, h.revision, flags)
 	data = append(data, encodedbytes.SynchBytes(h.size)...)
 
 	return data
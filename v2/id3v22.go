@@ -35,7 +35,7 @@ var (
 		"ETC": FrameType{id: "ETC", description: "Event timing codes", constructor: ParseDataFrame},
 		"EQU": FrameType{id: "EQU", description: "Equalization", constructor: ParseDataFrame},
 		"GEO": FrameType{id: "GEO", description: "General encapsulated object", constructor: ParseDataFrame},
-		"IPL": FrameType{id: "IPL", description: "Involved people list", constructor: ParseDataFrame},
+		"IPL": FrameType{id: "IPL", description: "Involved people list", constructor: ParseCreditsFrame},
 		"LNK": FrameType{id: "LNK", description: "Linked information", constructor: ParseDataFrame},
 		"MCI": FrameType{id: "MCI", description: "Music CD Identifier", constructor: ParseDataFrame},
 		"MLL": FrameType{id: "MLL", description: "MPEG location lookup table", constructor: ParseDataFrame},
@@ -94,19 +94,23 @@ var (
 )
 
 func ParseV22Frame(reader io.Reader) Framer {
-	data := make([]byte, V22FrameHeaderSize)
-	if n, err := io.ReadFull(reader, data); n < V22FrameHeaderSize || err != nil {
-		return nil
-	}
-
-	t, ok := V22FrameTypeMap[string(data[:3])]
-	if !ok {
-		return nil
-	}
+	frame, _ := readFrame(reader, V22FrameHeaderSize, parseV22FrameHead, ParseOptions{})
+	return frame
+}
 
+func parseV22FrameHead(data []byte) (FrameHead, error) {
+	id := frameIdString(data[:3])
 	size, err := encodedbytes.NormInt(data[3:6])
 	if err != nil {
-		return nil
+		return FrameHead{}, ErrBadFrameHeader
+	}
+
+	t, ok := lookupFrameType(2, id)
+	if !ok {
+		if !validFrameId(id) {
+			return FrameHead{}, ErrBadFrameHeader
+		}
+		err = ErrUnknownFrameId
 	}
 
 	h := FrameHead{
@@ -114,19 +118,19 @@ func ParseV22Frame(reader io.Reader) Framer {
 		size:      size,
 	}
 
-	frameData := make([]byte, size)
-	if n, err := io.ReadFull(reader, frameData); n < int(size) || err != nil {
-		return nil
-	}
-
-	return t.constructor(h, frameData)
+	return h, err
 }
 
 func V22Bytes(f Framer) []byte {
+	return append(v22Head(f), f.Bytes()...)
+}
+
+// Frame header without flags, which ID3v2.2 does not have
+func v22Head(f Framer) []byte {
 	headBytes := make([]byte, 0, V22FrameHeaderSize)
 
 	headBytes = append(headBytes, f.Id()...)
 	headBytes = append(headBytes, encodedbytes.NormBytes(uint32(f.Size()))[1:]...)
 
-	return append(headBytes, f.Bytes()...)
+	return headBytes
 }
@@ -5,7 +5,12 @@ package v2
 
 import (
 	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
 	"testing"
+
+	"github.com/lion187chen/id3-go/encodedbytes"
 )
 
 func TestV22Frame(t *testing.T) {
@@ -30,3 +35,113 @@ func TestV22Frame(t *testing.T) {
 		t.Errorf("V23Bytes produces different byte slice, expected %v not %v", textData, b)
 	}
 }
+
+// A v2.2 tag with the compression flag set and the given body
+func compressedV22Tag(body []byte) []byte {
+	data := []byte{'I', 'D', '3', 2, 0, FlagCompression}
+	data = append(data, encodedbytes.SynchBytes(uint32(len(body)))...)
+	return append(data, body...)
+}
+
+func TestV22Compression(t *testing.T) {
+	tag := NewTag(2)
+	tag.SetTitle("Michael Yang")
+	tag.SetArtist("Paloalto")
+	plain := tag.Bytes()[HeaderSize:]
+
+	var zipped bytes.Buffer
+	w := zlib.NewWriter(&zipped)
+	w.Write(plain)
+	w.Close()
+
+	data := compressedV22Tag(zipped.Bytes())
+	parsed := ParseTag(bytes.NewReader(data))
+	if parsed == nil || parsed.Title() != "Michael Yang" || parsed.Artist() != "Paloalto" {
+		t.Fatalf("ParseTag: expected the zlib body to be read")
+	}
+	if parsed.Compression() || parsed.StoredSize() != zipped.Len() || parsed.Size() != len(plain) {
+		t.Errorf("ParseTag: expected an uncompressed tag grown from %d bytes", zipped.Len())
+	}
+
+	garbage := compressedV22Tag(bytes.Repeat([]byte{'x'}, 64))
+	parsed, err := ParseTagWithOptions(bytes.NewReader(garbage), ParseOptions{Lenient: true})
+	if err != nil || parsed == nil {
+		t.Fatalf("ParseTagWithOptions: expected an empty tag, got %v", err)
+	}
+	if len(parsed.AllFrames()) != 0 || parsed.Padding() != 64 || parsed.Compression() {
+		t.Errorf("ParseTagWithOptions: expected the compressed frames ignored")
+	}
+	if warnings := parsed.Warnings(); len(warnings) != 1 || !errors.Is(warnings[0].Err, ErrCompressedTag) {
+		t.Errorf("ParseTagWithOptions: expected an ErrCompressedTag warning, got %v", warnings)
+	}
+}
+
+func TestV22RoundTrip(t *testing.T) {
+	tag := NewTag(2)
+	tag.SetTitle("Michael Yang")
+	tag.AddFrames(
+		NewUnsynchTextFrame(V22FrameTypeMap["COM"], "", "Nice Life"),
+		NewImageFrame(V22FrameTypeMap["PIC"], "image/png", PictureFrontCover, "Cover", []byte{0x89, 'P', 'N', 'G'}),
+		NewImageFrame(V22FrameTypeMap["PIC"], "-->", PictureBackCover, "Back", []byte("http://example.com/back.gif")),
+	)
+
+	var written bytes.Buffer
+	if _, err := tag.WriteTo(&written); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(written.Bytes(), tag.Bytes()) {
+		t.Errorf("WriteTo: expected the same bytes as Bytes")
+	}
+
+	parsed := ParseTag(bytes.NewReader(written.Bytes()))
+	if parsed == nil || parsed.Version() != "2.2.0" || parsed.Title() != "Michael Yang" {
+		t.Fatalf("ParseTag: expected the ID3v2.2 tag read back")
+	}
+	pics := parsed.Frames("PIC")
+	if len(pics) != 2 {
+		t.Fatalf("ParseTag: expected 2 pictures, got %d", len(pics))
+	}
+	if pic := pics[0].(*ImageFrame); pic.MIMEType() != "image/png" || pic.Description() != "Cover" || !bytes.Equal(pic.Data(), []byte{0x89, 'P', 'N', 'G'}) {
+		t.Errorf("ParseTag: expected the PNG picture, got %v", pic)
+	}
+	if pic := pics[1].(*ImageFrame); pic.MIMEType() != "-->" || string(pic.Data()) != "http://example.com/back.gif" {
+		t.Errorf("ParseTag: expected the picture link, got %v", pic)
+	}
+	if !bytes.Equal(parsed.Bytes(), written.Bytes()) {
+		t.Errorf("Bytes: expected a parsed ID3v2.2 tag to be written back unchanged")
+	}
+
+	// Legacy players read v2.3 tags converted down
+	v23 := NewTag(3)
+	v23.SetArtist("Paloalto")
+	v23.AddFrames(NewImageFrame(V23FrameTypeMap["APIC"], "image/jpeg", PictureFrontCover, "Cover", []byte{0xFF, 0xD8}))
+	v22, dropped := v23.Convert(2)
+	if len(dropped) != 0 {
+		t.Errorf("Convert: expected no frames dropped, got %v", dropped)
+	}
+	parsed = ParseTag(bytes.NewReader(v22.Bytes()))
+	if parsed == nil || parsed.Artist() != "Paloalto" {
+		t.Fatalf("Convert: expected the artist in the ID3v2.2 tag")
+	}
+	if pic, ok := parsed.Frame("PIC").(*ImageFrame); !ok || pic.MIMEType() != "image/jpeg" || pic.Description() != "Cover" || !bytes.Equal(pic.Data(), []byte{0xFF, 0xD8}) || pic.Size() != 1+3+1+6+2 {
+		t.Errorf("Convert: expected a PIC frame, got %v", parsed.Frame("PIC"))
+	}
+
+	// and v2.2 tags converted up keep the picture as it was
+	cover := []byte{0x89, 'P', 'N', 'G'}
+	v23, _ = tag.Convert(3)
+	parsed = ParseTag(bytes.NewReader(v23.Bytes()))
+	if pic, ok := parsed.Frame("APIC").(*ImageFrame); !ok || pic.MIMEType() != "image/png" || pic.Description() != "Cover" || !bytes.Equal(pic.Data(), cover) {
+		t.Errorf("Convert: expected the PNG picture in an APIC frame, got %v", parsed.Frame("APIC"))
+	}
+
+	tag.SetCompression(true)
+	if flags := tag.Bytes()[5]; flags&FlagCompression != 0 {
+		t.Errorf("Bytes: expected the tag written uncompressed without the flag")
+	}
+
+	tag.AddFrames(NewTextFrame(V23FrameTypeMap["TIT2"], "Nice Life", "ISO-8859-1"))
+	if _, err := tag.WriteTo(io.Discard); !errors.Is(err, ErrFrameNotAllowed) {
+		t.Errorf("WriteTo: expected ErrFrameNotAllowed for a four character ID, got %v", err)
+	}
+}
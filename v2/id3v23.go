@@ -19,6 +19,7 @@ var (
 		"Year":     V23FrameTypeMap["TYER"],
 		"Genre":    V23FrameTypeMap["TCON"],
 		"Comments": V23FrameTypeMap["COMM"],
+		"Length":   V23FrameTypeMap["TLEN"],
 	}
 
 	// V23DeprecatedTypeMap contains deprecated frame IDs from ID3v2.2
@@ -53,7 +54,7 @@ var (
 		"ETCO": FrameType{id: "ETCO", description: "Event timing codes", constructor: ParseDataFrame},
 		"GEOB": FrameType{id: "GEOB", description: "General encapsulated object", constructor: ParseDataFrame},
 		"GRID": FrameType{id: "GRID", description: "Group identification registration", constructor: ParseDataFrame},
-		"IPLS": FrameType{id: "IPLS", description: "Involved people list", constructor: ParseDataFrame},
+		"IPLS": FrameType{id: "IPLS", description: "Involved people list", constructor: ParseCreditsFrame},
 		"LINK": FrameType{id: "LINK", description: "Linked information", constructor: ParseDataFrame},
 		"MCDI": FrameType{id: "MCDI", description: "Music CD identifier", constructor: ParseDataFrame},
 		"MLLT": FrameType{id: "MLLT", description: "MPEG location lookup table", constructor: ParseDataFrame},
@@ -65,6 +66,7 @@ var (
 		"RBUF": FrameType{id: "RBUF", description: "Recommended buffer size", constructor: ParseDataFrame},
 		"RVAD": FrameType{id: "RVAD", description: "Relative volume adjustment", constructor: ParseDataFrame},
 		"RVRB": FrameType{id: "RVRB", description: "Reverb", constructor: ParseDataFrame},
+		"SEEK": FrameType{id: "SEEK", description: "Seek frame", constructor: ParseDataFrame},
 		"SYLT": FrameType{id: "SYLT", description: "Synchronized lyric/text", constructor: ParseDataFrame},
 		"SYTC": FrameType{id: "SYTC", description: "Synchronized tempo codes", constructor: ParseDataFrame},
 		"TALB": FrameType{id: "TALB", description: "Album/Movie/Show title", constructor: ParseTextFrame},
@@ -81,13 +83,16 @@ var (
 		"TEXT": FrameType{id: "TEXT", description: "Lyricist/Text writer", constructor: ParseTextFrame},
 		"TFLT": FrameType{id: "TFLT", description: "File type", constructor: ParseTextFrame},
 		"TIME": FrameType{id: "TIME", description: "Time", constructor: ParseTextFrame},
+		"TIPL": FrameType{id: "TIPL", description: "Involved people list", constructor: ParseCreditsFrame},
 		"TIT1": FrameType{id: "TIT1", description: "Content group description", constructor: ParseTextFrame},
 		"TIT2": FrameType{id: "TIT2", description: "Title/songname/content description", constructor: ParseTextFrame},
 		"TIT3": FrameType{id: "TIT3", description: "Subtitle/Description refinement", constructor: ParseTextFrame},
 		"TKEY": FrameType{id: "TKEY", description: "Initial key", constructor: ParseTextFrame},
 		"TLAN": FrameType{id: "TLAN", description: "Language(s)", constructor: ParseTextFrame},
 		"TLEN": FrameType{id: "TLEN", description: "Length", constructor: ParseTextFrame},
+		"TMCL": FrameType{id: "TMCL", description: "Musician credits list", constructor: ParseCreditsFrame},
 		"TMED": FrameType{id: "TMED", description: "Media type", constructor: ParseTextFrame},
+		"TMOO": FrameType{id: "TMOO", description: "Mood", constructor: ParseTextFrame},
 		"TOAL": FrameType{id: "TOAL", description: "Original album/movie/show title", constructor: ParseTextFrame},
 		"TOFN": FrameType{id: "TOFN", description: "Original filename", constructor: ParseTextFrame},
 		"TOLY": FrameType{id: "TOLY", description: "Original lyricist(s)/text writer(s)", constructor: ParseTextFrame},
@@ -112,6 +117,11 @@ var (
 		"UFID": FrameType{id: "UFID", description: "Unique file identifier", constructor: ParseIdFrame},
 		"USER": FrameType{id: "USER", description: "Terms of use", constructor: ParseDataFrame},
 		"TCMP": FrameType{id: "TCMP", description: "Part of a compilation (iTunes extension)", constructor: ParseTextFrame},
+		"PCST": FrameType{id: "PCST", description: "Podcast flag (iTunes extension)", constructor: ParseDataFrame},
+		"TGID": FrameType{id: "TGID", description: "Podcast episode identifier (iTunes extension)", constructor: ParseTextFrame},
+		"TDES": FrameType{id: "TDES", description: "Podcast description (iTunes extension)", constructor: ParseTextFrame},
+		"TKWD": FrameType{id: "TKWD", description: "Podcast keywords (iTunes extension)", constructor: ParseTextFrame},
+		"WFED": FrameType{id: "WFED", description: "Podcast feed URL (iTunes extension)", constructor: ParseTextFrame},
 		"USLT": FrameType{id: "USLT", description: "Unsychronized lyric/text transcription", constructor: ParseUnsynchTextFrame},
 		"WCOM": FrameType{id: "WCOM", description: "Commercial information", constructor: ParseDataFrame},
 		"WCOP": FrameType{id: "WCOP", description: "Copyright/Legal information", constructor: ParseDataFrame},
@@ -126,24 +136,27 @@ var (
 )
 
 func ParseV23Frame(reader io.Reader) Framer {
-	data := make([]byte, FrameHeaderSize)
-	if n, err := io.ReadFull(reader, data); n < FrameHeaderSize || err != nil {
-		return nil
-	}
-
-	id := string(bytes.Trim(data[:4], "\x00"))
-	t, ok := V23FrameTypeMap[id]
-	if !ok {
-		return nil
-	}
+	frame, _ := readFrame(reader, FrameHeaderSize, parseV23FrameHead, ParseOptions{})
+	return frame
+}
 
+func parseV23FrameHead(data []byte) (FrameHead, error) {
+	id := frameIdString(bytes.Trim(data[:4], "\x00"))
 	size, err := encodedbytes.NormInt(data[4:8])
 	if err != nil {
-		return nil
+		return FrameHead{}, ErrBadFrameHeader
 	}
 
 	if id == "" && size == 0 {
-		return nil
+		return FrameHead{}, errPadding
+	}
+
+	t, ok := lookupFrameType(3, id)
+	if !ok {
+		if !validFrameId(id) {
+			return FrameHead{}, ErrBadFrameHeader
+		}
+		err = ErrUnknownFrameId
 	}
 
 	h := FrameHead{
@@ -153,29 +166,20 @@ func ParseV23Frame(reader io.Reader) Framer {
 		size:        size,
 	}
 
-	frameData := make([]byte, size)
-	if n, err := io.ReadFull(reader, frameData); n < int(size) || err != nil {
-		return nil
-	}
-
-	// can't reference these from the table or they will cause an
-	// initialization loop
-	switch id {
-	case "CHAP":
-		t.constructor = ParseChapterFrame
-	case "CTOC":
-		t.constructor = ParseTOCFrame
-	}
-
-	return t.constructor(h, frameData)
+	return h, err
 }
 
 func V23Bytes(f Framer) []byte {
+	return append(v23Head(f), f.Bytes()...)
+}
+
+// Frame header with a plain integer size
+func v23Head(f Framer) []byte {
 	headBytes := make([]byte, 0, FrameHeaderSize)
 
 	headBytes = append(headBytes, f.Id()...)
 	headBytes = append(headBytes, encodedbytes.NormBytes(uint32(f.Size()))...)
 	headBytes = append(headBytes, f.StatusFlags(), f.FormatFlags())
 
-	return append(headBytes, f.Bytes()...)
+	return headBytes
 }
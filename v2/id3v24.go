@@ -19,36 +19,59 @@ var (
 		"Year":     V23FrameTypeMap["TDRC"],
 		"Genre":    V23FrameTypeMap["TCON"],
 		"Comments": V23FrameTypeMap["COMM"],
+		"Length":   V23FrameTypeMap["TLEN"],
 	}
 
 	// V23DeprecatedTypeMap contains deprecated frame IDs from ID3v2.2
 	V24DeprecatedTypeMap = V23DeprecatedTypeMap
 
-	// V23FrameTypeMap specifies the frame IDs and constructors allowed in ID3v2.3
-	V24FrameTypeMap = V23FrameTypeMap
-)
+	// V24FrameTypeMap specifies the frame IDs and constructors allowed in ID3v2.4
+	V24FrameTypeMap = mergeFrameTypes(V23FrameTypeMap, map[string]FrameType{
+		"ASPI": FrameType{id: "ASPI", description: "Audio seek point index", constructor: ParseDataFrame},
+		"EQU2": FrameType{id: "EQU2", description: "Equalisation (2)", constructor: ParseDataFrame},
+		"RVA2": FrameType{id: "RVA2", description: "Relative volume adjustment (2)", constructor: ParseDataFrame},
+		"SIGN": FrameType{id: "SIGN", description: "Signature frame", constructor: ParseDataFrame},
+		"TDEN": FrameType{id: "TDEN", description: "Encoding time", constructor: ParseTextFrame},
+		"TDTG": FrameType{id: "TDTG", description: "Tagging time", constructor: ParseTextFrame},
+		"TPRO": FrameType{id: "TPRO", description: "Produced notice", constructor: ParseTextFrame},
+		"TSOA": FrameType{id: "TSOA", description: "Album sort order", constructor: ParseTextFrame},
+		"TSOP": FrameType{id: "TSOP", description: "Performer sort order", constructor: ParseTextFrame},
+		"TSOT": FrameType{id: "TSOT", description: "Title sort order", constructor: ParseTextFrame},
+		"TSST": FrameType{id: "TSST", description: "Set subtitle", constructor: ParseTextFrame},
+	})
 
-func ParseV24Frame(reader io.Reader) Framer {
-	data := make([]byte, FrameHeaderSize)
-	if n, err := io.ReadFull(reader, data); n < FrameHeaderSize || err != nil {
-		return nil
+	// V24ReplacedTypeMap maps ID3v2.3 frame IDs to the ID3v2.4 frames that
+	// replace them
+	V24ReplacedTypeMap = map[string]string{
+		"EQUA": "EQU2", "IPLS": "TIPL", "RVAD": "RVA2", "TORY": "TDOR",
+		"TYER": "TDRC",
 	}
+)
 
-	id := string(bytes.Trim(data[:4], "\x00"))
-	t, ok := V24FrameTypeMap[id]
-	if !ok {
-		t = FrameType{id: id, description: "Unknown frame", constructor: ParseDataFrame}
-	}
+func ParseV24Frame(reader io.Reader) Framer {
+	frame, _ := readFrame(reader, FrameHeaderSize, parseV24FrameHead, ParseOptions{})
+	return frame
+}
 
+func parseV24FrameHead(data []byte) (FrameHead, error) {
+	id := frameIdString(bytes.Trim(data[:4], "\x00"))
+	// Some taggers write plain integers, keep them readable for lenient mode
 	size, err := encodedbytes.SynchInt(data[4:8])
 	if err != nil {
-		return nil
+		size, _ = encodedbytes.NormInt(data[4:8])
+		err = ErrNotSynchsafe
 	}
 
 	if id == "" && size == 0 {
-		return nil
+		return FrameHead{}, errPadding
+	}
+
+	if !validFrameId(id) {
+		return FrameHead{}, ErrBadFrameHeader
 	}
 
+	t, _ := lookupFrameType(4, id)
+
 	h := FrameHead{
 		FrameType:   t,
 		statusFlags: data[8],
@@ -56,23 +79,20 @@ func ParseV24Frame(reader io.Reader) Framer {
 		size:        size,
 	}
 
-	frameData := make([]byte, size)
-	if n, err := io.ReadFull(reader, frameData); n < int(size) || err != nil {
-		return nil
-	}
-
-	if t.constructor == nil {
-		return nil
-	}
-	return t.constructor(h, frameData)
+	return h, err
 }
 
 func V24Bytes(f Framer) []byte {
+	return append(v24Head(f), f.Bytes()...)
+}
+
+// Frame header with a synchsafe size
+func v24Head(f Framer) []byte {
 	headBytes := make([]byte, 0, FrameHeaderSize)
 
 	headBytes = append(headBytes, f.Id()...)
 	headBytes = append(headBytes, encodedbytes.SynchBytes(uint32(f.Size()))...)
 	headBytes = append(headBytes, f.StatusFlags(), f.FormatFlags())
 
-	return append(headBytes, f.Bytes()...)
+	return headBytes
 }
@@ -0,0 +1,2437 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	textunicode "golang.org/x/text/encoding/unicode"
+
+	"github.com/lion187chen/id3-go/encodedbytes"
+)
+
+func testTagBytes() []byte {
+	tag := NewTag(3)
+	tag.SetTitle("Nice Life")
+	tag.SetArtist("Paloalto")
+	tag.SetAlbum("Chief Life")
+	return tag.Bytes()
+}
+
+func TestParseTagLimits(t *testing.T) {
+	data := testTagBytes()
+
+	if tag, err := ParseTagWithOptions(bytes.NewReader(data), ParseOptions{}); tag == nil || err != nil {
+		t.Fatalf("ParseTagWithOptions with defaults failed: %v", err)
+	}
+
+	tests := []struct {
+		opts ParseOptions
+		err  error
+	}{
+		{ParseOptions{MaxTagSize: 10}, ErrTagTooLarge},
+		{ParseOptions{MaxFrameSize: 5}, ErrFrameTooLarge},
+		{ParseOptions{MaxFrameCount: 2}, ErrTooManyFrames},
+	}
+
+	for _, test := range tests {
+		tag, err := ParseTagWithOptions(bytes.NewReader(data), test.opts)
+		if tag != nil || !errors.Is(err, test.err) {
+			t.Errorf("ParseTagWithOptions(%+v) = %v, %v, want error %v", test.opts, tag, err, test.err)
+		}
+
+		var limitErr *LimitError
+		if !errors.As(err, &limitErr) {
+			t.Errorf("ParseTagWithOptions(%+v) error is not a *LimitError", test.opts)
+		}
+	}
+
+	disabled := ParseOptions{MaxTagSize: -1, MaxFrameSize: -1, MaxFrameCount: -1}
+	if tag, err := ParseTagWithOptions(bytes.NewReader(data), disabled); tag == nil || err != nil {
+		t.Errorf("ParseTagWithOptions with disabled limits failed: %v", err)
+	}
+}
+
+func TestParseTagHugeDeclaredSize(t *testing.T) {
+	// Header declaring the maximum synchsafe size with no body behind it
+	data := []byte{'I', 'D', '3', 3, 0, 0, 0x7f, 0x7f, 0x7f, 0x7f}
+
+	if _, err := ParseTagWithOptions(bytes.NewReader(data), ParseOptions{}); !errors.Is(err, ErrTagTooLarge) {
+		t.Errorf("ParseTagWithOptions on 256MB tag returned %v, want %v", err, ErrTagTooLarge)
+	}
+}
+
+func TestParseTagLenientDescribed(t *testing.T) {
+	tag := NewTag(3)
+	tag.SetTitle("Nice Life")
+	tag.AddFrames(
+		NewDescTextFrame(V23FrameTypeMap["TXXX"], "MOOD", "Chill", "ISO-8859-1"),
+		NewUnsynchTextFrame(V23FrameTypeMap["COMM"], "", "Chief Life"),
+	)
+	data := tag.Bytes()
+
+	// Encoding 4 is one past the defined encodings
+	txxx := bytes.Index(data, []byte("TXXX"))
+	comm := bytes.Index(data, []byte("COMM"))
+	data[txxx+FrameHeaderSize] = 4
+	data[comm+FrameHeaderSize] = 4
+
+	if strict := ParseTag(bytes.NewReader(data)); strict == nil || strict.Title() != "Nice Life" {
+		t.Errorf("ParseTag: expected the frames before the corrupt frame")
+	}
+
+	lenient, err := ParseTagWithOptions(bytes.NewReader(data), ParseOptions{Lenient: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(lenient.AllFrames()); n != 3 {
+		t.Errorf("lenient parse recovered %d frames, want 3", n)
+	}
+
+	warnings := lenient.Warnings()
+	if len(warnings) != 2 {
+		t.Fatalf("lenient parse warnings = %v, want two", warnings)
+	}
+	for i, id := range []string{"TXXX", "COMM"} {
+		if warnings[i].FrameId != id || !errors.Is(warnings[i].Err, ErrUnknownEncoding) {
+			t.Errorf("lenient parse warning = %v, want unknown encoding on %s", warnings[i], id)
+		}
+	}
+	if b := lenient.Bytes(); !bytes.Equal(b, data) {
+		t.Errorf("lenient parse did not preserve the damaged frames")
+	}
+}
+
+func TestParseTagConcurrency(t *testing.T) {
+	tag := NewTag(4)
+	tag.AddFrames(
+		NewTextFrame(V24FrameTypeMap["TIT2"], "Dvořák", "UTF-16"),
+		NewTextFrame(V24FrameTypeMap["TPE1"], "Björk", "ISO-8859-1"),
+		NewTextFrame(V24FrameTypeMap["TALB"], "Ρόδα", "UTF-16BE"),
+	)
+	data := tag.Bytes()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				parsed := ParseTag(bytes.NewReader(data))
+				if parsed == nil {
+					t.Errorf("ParseTag: expected a tag")
+					return
+				}
+				if parsed.Title() != "Dvořák" || parsed.Artist() != "Björk" || parsed.Album() != "Ρόδα" {
+					t.Errorf("ParseTag: got %q, %q, %q", parsed.Title(), parsed.Artist(), parsed.Album())
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestParseTagLenient(t *testing.T) {
+	tag := NewTag(3)
+	tag.SetTitle("Nice Life")
+	tag.AddFrames(NewTextFrame(V23FrameTypeMap["TALB"], "Chief Life", "ISO-8859-1"))
+	tag.SetArtist("Paloalto")
+	data := tag.Bytes()
+
+	// Corrupt the encoding byte of the album frame
+	i := bytes.Index(data, []byte("TALB"))
+	data[i+FrameHeaderSize] = 9
+
+	strict := ParseTag(bytes.NewReader(data))
+	if n := len(strict.AllFrames()); n != 1 {
+		t.Errorf("ParseTag on corrupt frame parsed %d frames, want 1", n)
+	}
+
+	lenient, err := ParseTagWithOptions(bytes.NewReader(data), ParseOptions{Lenient: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(lenient.AllFrames()); n != 3 {
+		t.Errorf("lenient parse recovered %d frames, want 3", n)
+	}
+
+	if s := lenient.Artist(); s != "Paloalto" {
+		t.Errorf("lenient parse incorrect artist, %v", s)
+	}
+
+	warnings := lenient.Warnings()
+	if len(warnings) != 1 || warnings[0].FrameId != "TALB" || !errors.Is(warnings[0].Err, ErrUnknownEncoding) {
+		t.Errorf("lenient parse warnings = %v, want unknown encoding on TALB", warnings)
+	}
+
+	if warnings[0].Offset != i {
+		t.Errorf("lenient parse warning offset = %d, want %d", warnings[0].Offset, i)
+	}
+
+	// The damaged frame is kept as raw data and written back unchanged
+	if b := lenient.Bytes(); !bytes.Equal(b, data) {
+		t.Errorf("lenient parse did not preserve damaged frame")
+	}
+}
+
+func TestParseTagLenientTruncated(t *testing.T) {
+	data := testTagBytes()
+	data = data[:len(data)-4]
+
+	tag, err := ParseTagWithOptions(bytes.NewReader(data), ParseOptions{Lenient: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(tag.AllFrames()); n != 3 {
+		t.Errorf("lenient parse of truncated tag recovered %d frames, want 3", n)
+	}
+
+	warnings := tag.Warnings()
+	if len(warnings) != 1 || !errors.Is(warnings[0].Err, ErrTruncatedFrame) {
+		t.Errorf("lenient parse warnings = %v, want truncated frame", warnings)
+	}
+}
+
+func TestParseTagStrict(t *testing.T) {
+	compliant := NewTag(3)
+	compliant.AddFrames(
+		NewTextFrame(V23FrameTypeMap["TIT2"], "Nice Life", "ISO-8859-1"),
+		NewTextFrame(V23FrameTypeMap["TPE1"], "Paloalto", "UTF-16"),
+	)
+
+	utf8 := NewTag(3)
+	utf8.AddFrames(NewTextFrame(V23FrameTypeMap["TIT2"], "Nice Life", "UTF-8"))
+
+	duplicate := NewTag(3)
+	duplicate.AddFrames(
+		NewTextFrame(V23FrameTypeMap["TIT2"], "Nice Life", "ISO-8859-1"),
+		NewTextFrame(V23FrameTypeMap["TIT2"], "Chief Life", "ISO-8859-1"),
+	)
+
+	wrongVersion := NewTag(3)
+	wrongVersion.AddFrames(NewTextFrame(V23FrameTypeMap["TDRC"], "2013", "ISO-8859-1"))
+
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"compliant", compliant.Bytes(), nil},
+		{"utf8", utf8.Bytes(), ErrEncodingNotAllowed},
+		{"duplicate", duplicate.Bytes(), ErrDuplicateFrame},
+		{"wrong version", wrongVersion.Bytes(), ErrFrameNotAllowed},
+	}
+
+	for _, test := range tests {
+		_, err := ParseTagWithOptions(bytes.NewReader(test.data), ParseOptions{Strict: true})
+		if !errors.Is(err, test.err) {
+			t.Errorf("strict parse of %s tag returned %v, want %v", test.name, err, test.err)
+		}
+
+		if tag := ParseTag(bytes.NewReader(test.data)); tag == nil {
+			t.Errorf("default parse of %s tag failed", test.name)
+		}
+	}
+}
+
+func TestParseTagNotSynchsafe(t *testing.T) {
+	tag := NewTag(4)
+	tag.AddFrames(NewTextFrame(V23FrameTypeMap["TIT2"], string(bytes.Repeat([]byte("a"), 200)), "ISO-8859-1"))
+	data := tag.Bytes()
+
+	// Rewrite the frame size as a plain integer
+	copy(data[HeaderSize+4:HeaderSize+8], []byte{0, 0, 0, 202})
+
+	if _, err := ParseTagWithOptions(bytes.NewReader(data), ParseOptions{Strict: true}); !errors.Is(err, ErrNotSynchsafe) {
+		t.Errorf("strict parse returned %v, want %v", err, ErrNotSynchsafe)
+	}
+
+	lenient, err := ParseTagWithOptions(bytes.NewReader(data), ParseOptions{Lenient: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(lenient.Title()); n != 200 {
+		t.Errorf("lenient parse read %d bytes of title, want 200", n)
+	}
+}
+
+func TestParseTagResync(t *testing.T) {
+	tag := NewTag(3)
+	tag.AddFrames(
+		NewTextFrame(V23FrameTypeMap["TIT2"], "Nice Life", "ISO-8859-1"),
+		NewTextFrame(V23FrameTypeMap["TALB"], "Chief Life", "ISO-8859-1"),
+		NewTextFrame(V23FrameTypeMap["TPE1"], "Paloalto", "ISO-8859-1"),
+	)
+	data := tag.Bytes()
+
+	// Damage the album frame header
+	i := bytes.Index(data, []byte("TALB"))
+	copy(data[i:], []byte{0xFF, 0xFE, 0x00, 0x13})
+
+	if n := len(ParseTag(bytes.NewReader(data)).AllFrames()); n != 1 {
+		t.Errorf("ParseTag on damaged header parsed %d frames, want 1", n)
+	}
+
+	resynced, err := ParseTagWithOptions(bytes.NewReader(data), ParseOptions{Lenient: true, Resync: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := resynced.Artist(); s != "Paloalto" {
+		t.Errorf("resync parse incorrect artist, %q", s)
+	}
+
+	if n := len(resynced.AllFrames()); n != 2 {
+		t.Errorf("resync parse recovered %d frames, want 2", n)
+	}
+
+	var skipped bool
+	for _, w := range resynced.Warnings() {
+		skipped = skipped || errors.Is(w.Err, ErrResynchronized)
+	}
+	if !skipped {
+		t.Errorf("resync parse warnings = %v, want skipped bytes", resynced.Warnings())
+	}
+
+	// The damaged region is turned into padding without changing the tag size
+	if resynced.Size() != tag.Size() {
+		t.Errorf("resync parse changed tag size from %d to %d", tag.Size(), resynced.Size())
+	}
+}
+
+func TestTagConcurrentAccess(t *testing.T) {
+	tag := NewTag(3)
+	tag.SetTitle("Nice Life")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				tag.SetArtist(fmt.Sprintf("Artist %d", j))
+				tag.AddFrames(NewTextFrame(V23FrameTypeMap["TCOM"], "Composer", "ISO-8859-1"))
+				tag.DeleteFrames("TCOM")
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				tag.Title()
+				tag.Artist()
+				tag.AllFrames()
+				for range tag.All() {
+				}
+				tag.Bytes()
+				tag.Size()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The accounted size must still match the serialized frames
+	if n := len(tag.Frames("TCOM")); n != 0 {
+		t.Errorf("concurrent add and delete left %d frames", n)
+	}
+
+	parsed := ParseTag(bytes.NewReader(tag.Bytes()))
+	if parsed == nil || len(parsed.AllFrames()) != 2 {
+		t.Errorf("tag written after concurrent edits does not parse back")
+	}
+}
+
+func TestTagIterators(t *testing.T) {
+	tag := NewTag(3)
+	tag.SetTitle("Nice Life")
+	tag.SetArtist("Paloalto")
+	tag.AddFrames(
+		NewTextFrame(V23FrameTypeMap["TCOM"], "Composer", "ISO-8859-1"),
+		NewTextFrame(V23FrameTypeMap["TCOM"], "Lyricist", "ISO-8859-1"),
+		NewImageFrame(V23FrameTypeMap["APIC"], "image/png", 3, "cover", []byte{0x89, 'P', 'N', 'G'}),
+	)
+
+	var n int
+	for range tag.All() {
+		n++
+	}
+	if n != 5 {
+		t.Errorf("All yielded %d frames, want 5", n)
+	}
+
+	n = 0
+	for f := range tag.ByID("TCOM") {
+		if f.Id() != "TCOM" {
+			t.Errorf("ByID yielded %s frame", f.Id())
+		}
+		n++
+	}
+	if n != 2 {
+		t.Errorf("ByID yielded %d frames, want 2", n)
+	}
+
+	texts := make(map[string]string)
+	for id, text := range tag.TextFrames() {
+		if _, ok := texts[id]; !ok {
+			texts[id] = strings.TrimRight(text, "\x00")
+		}
+	}
+	if len(texts) != 3 || texts["TIT2"] != "Nice Life" || texts["TCOM"] != "Composer" {
+		t.Errorf("TextFrames yielded %v", texts)
+	}
+
+	// Modifying the tag while iterating must not disturb the loop
+	n = 0
+	for f := range tag.All() {
+		tag.DeleteFrame(f)
+		n++
+	}
+	if n != 5 || len(tag.AllFrames()) != 0 {
+		t.Errorf("deleting while iterating visited %d frames and left %d", n, len(tag.AllFrames()))
+	}
+}
+
+func TestFrameAliases(t *testing.T) {
+	v24 := NewTag(4)
+	v24.AddFrames(NewTextFrame(V24FrameTypeMap["TDRC"], "2013", "UTF-8"))
+
+	if f := v24.Frame("TYER"); f == nil || f.Id() != "TDRC" {
+		t.Errorf("Frame(TYER) on v2.4 tag = %v, want TDRC frame", f)
+	}
+
+	v22 := NewTag(2)
+	v22.AddFrames(NewTextFrame(V22FrameTypeMap["TT2"], "Nice Life", "ISO-8859-1"))
+
+	for _, id := range []string{"TIT2", "TT2"} {
+		if n := len(v22.Frames(id)); n != 1 {
+			t.Errorf("Frames(%s) on v2.2 tag returned %d frames, want 1", id, n)
+		}
+	}
+
+	if f := v22.Frame("TYER"); f != nil {
+		t.Errorf("Frame(TYER) on v2.2 tag without year = %v", f)
+	}
+
+	if n := len(v22.DeleteFrames("TIT2")); n != 1 || len(v22.AllFrames()) != 0 {
+		t.Errorf("DeleteFrames(TIT2) on v2.2 tag deleted %d frames", n)
+	}
+}
+
+func TestCopyFramesFrom(t *testing.T) {
+	src := NewTag(4)
+	src.AddFrames(
+		NewTextFrame(V24FrameTypeMap["TIT2"], "Nice Life", "UTF-8"),
+		NewTextFrame(V24FrameTypeMap["TPE1"], "팔로알토", "UTF-8"),
+		NewTextFrame(V24FrameTypeMap["TDRC"], "2013-11-25", "UTF-8"),
+	)
+	src = ParseTag(bytes.NewReader(src.Bytes()))
+
+	dst := NewTag(3)
+	dst.SetTitle("Chief Life")
+
+	if n := dst.CopyFramesFrom(src, "TYER"); n != 1 {
+		t.Errorf("CopyFramesFrom(TYER) copied %d frames, want 1", n)
+	}
+
+	if s := dst.Year(); s != "2013" {
+		t.Errorf("copied year = %q, want 2013", s)
+	}
+
+	if n := dst.CopyFramesFrom(src); n != 3 {
+		t.Errorf("CopyFramesFrom copied %d frames, want 3", n)
+	}
+
+	if n := len(dst.AllFrames()); n != 3 {
+		t.Errorf("tag has %d frames after copy, want 3", n)
+	}
+
+	if s := dst.Title(); s != "Nice Life" {
+		t.Errorf("copied title = %q", s)
+	}
+
+	artist := dst.Frame("TPE1").(*TextFrame)
+	if artist.Encoding() != "UTF-16" || artist.Text() != "팔로알토" {
+		t.Errorf("copied artist = %q in %s", artist.Text(), artist.Encoding())
+	}
+
+	for _, issue := range dst.Validate() {
+		if issue.Severity == SeverityError {
+			t.Errorf("copied frames are not valid ID3v2.3: %v", issue)
+		}
+	}
+
+	// Copies are independent of the source
+	artist.SetText("Basick")
+	if s := src.Artist(); s != "팔로알토" {
+		t.Errorf("editing the copy changed the source artist to %q", s)
+	}
+
+	parsed := ParseTag(bytes.NewReader(dst.Bytes()))
+	if parsed == nil || len(parsed.AllFrames()) != 3 {
+		t.Errorf("tag does not parse back after copy")
+	}
+}
+
+func TestClone(t *testing.T) {
+	tag := NewTag(3)
+	tag.SetTitle("Nice Life")
+	tag.AddFrames(
+		NewImageFrame(V23FrameTypeMap["APIC"], "image/png", 3, "cover", []byte{0x89, 'P', 'N', 'G'}),
+		NewTOCFrame(V23FrameTypeMap["CTOC"], "toc", true, true, []string{"ch1"}),
+	)
+	original := tag.Bytes()
+
+	c := tag.Clone()
+	if !bytes.Equal(c.Bytes(), original) || c.Size() != tag.Size() {
+		t.Fatalf("clone serializes differently from the original")
+	}
+
+	c.SetTitle("Chief Life")
+	c.Frame("APIC").(*ImageFrame).Data()[0] = 0
+	c.Frame("CTOC").(*TOCFrame).ChildElements[0] = "ch2"
+	c.DeleteFrames("TIT2")
+
+	if !bytes.Equal(tag.Bytes(), original) {
+		t.Errorf("modifying the clone changed the original")
+	}
+
+	f := tag.Frame("TIT2")
+	fc := f.Clone()
+	fc.(*TextFrame).SetText("Chief Life")
+	if s := tag.Title(); s != "Nice Life" || tag.Size() != ParseTag(bytes.NewReader(original)).Size() {
+		t.Errorf("modifying a cloned frame changed its tag")
+	}
+}
+
+func TestEqual(t *testing.T) {
+	latin1 := NewTextFrame(V23FrameTypeMap["TIT2"], "Nice Life", "ISO-8859-1")
+	utf16 := NewTextFrame(V23FrameTypeMap["TIT2"], "Nice Life", "UTF-16")
+	other := NewTextFrame(V23FrameTypeMap["TIT2"], "Chief Life", "ISO-8859-1")
+
+	if !latin1.Equal(utf16) {
+		t.Errorf("frames differing only in encoding are not equal")
+	}
+
+	if latin1.Equal(other) || latin1.Equal(NewTextFrame(V23FrameTypeMap["TIT3"], "Nice Life", "ISO-8859-1")) {
+		t.Errorf("frames with different content are equal")
+	}
+
+	a := NewTag(3)
+	a.AddFrames(latin1, NewTextFrame(V23FrameTypeMap["TYER"], "2013", "ISO-8859-1"))
+	a.SetPadding(1024)
+
+	b := NewTag(4)
+	b.AddFrames(
+		NewTextFrame(V24FrameTypeMap["TDRC"], "2013", "UTF-8"),
+		NewTextFrame(V24FrameTypeMap["TIT2"], "Nice Life", "UTF-8"),
+	)
+
+	parsed := ParseTag(bytes.NewReader(a.Bytes()))
+	if !a.Equal(parsed) || !a.Equal(b) {
+		t.Errorf("equivalent tags are not equal")
+	}
+
+	b.SetTitle("Chief Life")
+	if a.Equal(b) {
+		t.Errorf("tags with different titles are equal")
+	}
+}
+
+func TestTextEncodingPolicy(t *testing.T) {
+	tests := []struct {
+		version  byte
+		fixed    string
+		text     string
+		expected string
+	}{
+		{3, "", "Nice Life", "ISO-8859-1"},
+		{3, "", "팔로알토", "UTF-16"},
+		{4, "", "Nice Life", "ISO-8859-1"},
+		{4, "", "팔로알토", "UTF-8"},
+		{3, "UTF-16", "Nice Life", "UTF-16"},
+		{4, "ISO-8859-1", "팔로알토", "UTF-8"},
+	}
+
+	for _, test := range tests {
+		tag := NewTag(test.version)
+		if err := tag.SetTextEncoding(test.fixed); err != nil {
+			t.Fatal(err)
+		}
+
+		tag.SetTitle("Placeholder")
+		tag.SetTitle(test.text)
+		f := tag.Frame("TIT2").(*TextFrame)
+		if f.Encoding() != test.expected {
+			t.Errorf("SetTitle: v2.%d expected %s for %q, got %s", test.version, test.expected, test.text, f.Encoding())
+		}
+
+		parsed := ParseTag(bytes.NewReader(tag.Bytes()))
+		if parsed == nil || parsed.Title() != test.text {
+			t.Errorf("SetTitle: v2.%d %s text did not round trip", test.version, f.Encoding())
+		}
+	}
+
+	if err := NewTag(3).SetTextEncoding("UTF-8"); err == nil {
+		t.Errorf("SetTextEncoding: expected UTF-8 to be refused for ID3v2.3")
+	}
+	if err := NewTag(4).SetTextEncoding("EBCDIC"); err != ErrUnknownEncoding {
+		t.Errorf("SetTextEncoding: expected ErrUnknownEncoding, got %v", err)
+	}
+}
+
+func TestParseUTF16WithoutBOM(t *testing.T) {
+	tag := NewTag(3)
+	tag.SetTextEncoding("UTF-16")
+	tag.SetTitle("Hi")
+	data := tag.Bytes()
+
+	// Replace the big-endian text with little-endian text lacking a BOM
+	i := bytes.Index(data, []byte("\xfe\xff\x00H\x00i\x00\x00"))
+	copy(data[i:], "H\x00i\x00!\x00\x00\x00")
+
+	parsed := ParseTag(bytes.NewReader(data))
+	if title := parsed.Title(); title != "Hi!" {
+		t.Errorf("ParseTag: expected little-endian title Hi!, got %q", title)
+	}
+
+	warnings := parsed.Warnings()
+	if len(warnings) != 1 || !errors.Is(warnings[0].Err, ErrNoByteOrderMark) || !strings.Contains(warnings[0].String(), "little-endian") {
+		t.Errorf("ParseTag: expected a byte order warning, got %v", warnings)
+	}
+
+	if warnings := ParseTag(bytes.NewReader(tag.Bytes())).Warnings(); len(warnings) != 0 {
+		t.Errorf("ParseTag: unexpected warnings %v", warnings)
+	}
+}
+
+func TestReinterpretTextAs(t *testing.T) {
+	tests := []struct {
+		enc  encoding.Encoding
+		text string
+	}{
+		{simplifiedchinese.GBK, "七里香"},
+		{charmap.Windows1251, "Кино"},
+	}
+
+	for _, test := range tests {
+		// What a tagger using the local code page wrote as ISO-8859-1
+		raw, _ := test.enc.NewEncoder().String(test.text)
+		latin1, _ := charmap.ISO8859_1.NewDecoder().String(raw)
+
+		tag := NewTag(3)
+		tag.SetTitle(latin1)
+		tag.SetArtist("Jay Chou")
+		data := tag.Bytes()
+
+		parsed, err := ParseTagWithOptions(bytes.NewReader(data), ParseOptions{Latin1Encoding: test.enc})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if title := parsed.Title(); title != test.text {
+			t.Errorf("ParseTagWithOptions: expected title %q, got %q", test.text, title)
+		}
+		if f := parsed.Frame("TIT2").(*TextFrame); f.Encoding() != "UTF-16" {
+			t.Errorf("ParseTagWithOptions: expected UTF-16 title, got %s", f.Encoding())
+		}
+		if parsed.Dirty() {
+			t.Errorf("ParseTagWithOptions: reinterpreted tag should not be dirty")
+		}
+
+		parsed = ParseTag(bytes.NewReader(data))
+		if n := parsed.ReinterpretTextAs(test.enc); n != 1 {
+			t.Errorf("ReinterpretTextAs: expected 1 frame changed, got %d", n)
+		}
+		if artist := parsed.Artist(); artist != "Jay Chou" {
+			t.Errorf("ReinterpretTextAs: ASCII artist changed to %q", artist)
+		}
+
+		reparsed := ParseTag(bytes.NewReader(parsed.Bytes()))
+		if title := reparsed.Title(); title != test.text {
+			t.Errorf("ReinterpretTextAs: expected %q after writing, got %q", test.text, title)
+		}
+	}
+}
+
+func TestDetectCharset(t *testing.T) {
+	tests := []struct {
+		enc      encoding.Encoding
+		text     string
+		expected string
+	}{
+		{simplifiedchinese.GBK, "七里香", "GBK"},
+		{simplifiedchinese.GBK, "我爱你中国", "GBK"},
+		{traditionalchinese.Big5, "張學友 吻別", "Big5"},
+		{japanese.ShiftJIS, "宇多田ヒカル", "Shift_JIS"},
+		{korean.EUCKR, "안녕하세요", "EUC-KR"},
+		{charmap.Windows1251, "Группа крови", "windows-1251"},
+		{textunicode.UTF8, "Beyoncé", "UTF-8"},
+		{charmap.ISO8859_1, "Björk", ""},
+		{charmap.ISO8859_1, "Canção", ""},
+		{charmap.ISO8859_1, "Coração", ""},
+		{charmap.ISO8859_1, "Straßé", ""},
+		{charmap.ISO8859_1, "Café Müller", ""},
+		{charmap.ISO8859_1, "Françoise Hardy", ""},
+		{charmap.ISO8859_1, "Ágætis byrjun", ""},
+		{charmap.ISO8859_1, "Señorita", ""},
+		{charmap.ISO8859_1, "Voilà à toi", ""},
+	}
+
+	for _, test := range tests {
+		raw, _ := test.enc.NewEncoder().String(test.text)
+		if name, _ := DetectCharset([]byte(raw)); name != test.expected {
+			t.Errorf("DetectCharset: expected %q for %s, got %q", test.expected, test.text, name)
+		}
+	}
+}
+
+func TestFixCharset(t *testing.T) {
+	raw, _ := simplifiedchinese.GBK.NewEncoder().String("七里香")
+	latin1, _ := charmap.ISO8859_1.NewDecoder().String(raw)
+
+	tag := NewTag(3)
+	tag.SetTitle(latin1)
+	tag.SetArtist("Björk")
+	tag.SetAlbum("Coração")
+	data := tag.Bytes()
+
+	suggestions := ParseTag(bytes.NewReader(data)).SuggestCharsets()
+	if len(suggestions) != 1 || suggestions[0].FrameId != "TIT2" || suggestions[0].Charset != "GBK" || suggestions[0].Text != "七里香" {
+		t.Errorf("SuggestCharsets: expected GBK for TIT2 only, got %+v", suggestions)
+	}
+
+	parsed, err := ParseTagWithOptions(bytes.NewReader(data), ParseOptions{DetectCharset: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title := parsed.Title(); title != "七里香" {
+		t.Errorf("ParseTagWithOptions: expected detected title 七里香, got %q", title)
+	}
+	if artist := parsed.Artist(); artist != "Björk" {
+		t.Errorf("ParseTagWithOptions: Latin-1 artist changed to %q", artist)
+	}
+	if album := parsed.Album(); album != "Coração" {
+		t.Errorf("ParseTagWithOptions: Latin-1 album changed to %q", album)
+	}
+	if name, _ := parsed.FixCharset(); name != "" {
+		t.Errorf("FixCharset: expected nothing left to fix, got %s", name)
+	}
+}
+
+func TestLatin1Policy(t *testing.T) {
+	encodedbytes.SetLatin1Policy(encodedbytes.Latin1Transliterate)
+	defer encodedbytes.SetLatin1Policy(encodedbytes.Latin1Error)
+
+	tag := NewTag(3)
+	tag.SetTextEncoding("ISO-8859-1")
+	tag.SetArtist("Dvořák")
+	if f := tag.Frame("TPE1").(*TextFrame); f.Encoding() != "ISO-8859-1" {
+		t.Errorf("SetArtist: expected ISO-8859-1, got %s", f.Encoding())
+	}
+
+	parsed := ParseTag(bytes.NewReader(tag.Bytes()))
+	if artist := parsed.Artist(); artist != "Dvorák" {
+		t.Errorf("SetArtist: expected transliterated Dvorák, got %q", artist)
+	}
+}
+
+func TestTrailingNulls(t *testing.T) {
+	tag := NewTag(3)
+	tag.AddFrames(NewTextFrame(V23FrameTypeMap["TIT2"], "Nice Life\x00\x00\x00", "ISO-8859-1"))
+	data := tag.Bytes()
+
+	parsed := ParseTag(bytes.NewReader(data))
+	f := parsed.Frame("TIT2").(*TextFrame)
+	if f.Text() != "Nice Life" || parsed.Title() != "Nice Life" {
+		t.Errorf("Text: expected trailing nulls trimmed, got %q", f.Text())
+	}
+	if f.RawText() != "Nice Life\x00\x00\x00\x00" {
+		t.Errorf("RawText: expected text as parsed, got %q", f.RawText())
+	}
+	if !bytes.Equal(parsed.Bytes(), data) {
+		t.Errorf("Bytes: padding inside the frame was not kept")
+	}
+}
+
+func TestCredits(t *testing.T) {
+	tag := NewTag(3)
+	tag.AddCredit("producer", "Basick")
+	tag.AddCredit("mixer", "팔로알토")
+
+	parsed := ParseTag(bytes.NewReader(tag.Bytes()))
+	expected := []Credit{{"producer", "Basick"}, {"mixer", "팔로알토"}}
+	if credits := parsed.Credits(); fmt.Sprint(credits) != fmt.Sprint(expected) {
+		t.Errorf("Credits: expected %v, got %v", expected, credits)
+	}
+	if s := parsed.Frame("IPLS").String(); s != "producer: Basick; mixer: 팔로알토" {
+		t.Errorf("String: unexpected %q", s)
+	}
+
+	v24 := NewTag(4)
+	v24.CopyFramesFrom(parsed)
+	if f, ok := v24.Frame("TIPL").(*CreditsFrame); !ok || len(f.Credits()) != 2 {
+		t.Errorf("CopyFramesFrom: expected IPLS to become TIPL")
+	}
+
+	ft := V24FrameTypeMap["TMCL"]
+	v24.AddFrames(NewCreditsFrame(ft, []Credit{{"bass", "Paloalto"}}, "UTF-8"))
+	if n := len(v24.Credits()); n != 3 {
+		t.Errorf("Credits: expected both v2.4 lists, got %d credits", n)
+	}
+
+	v23 := NewTag(3)
+	v23.CopyFramesFrom(v24)
+	if frames := v23.Frames("IPLS"); len(frames) != 1 || len(frames[0].(*CreditsFrame).Credits()) != 3 {
+		t.Errorf("CopyFramesFrom: expected TIPL and TMCL in one IPLS frame, got %v", frames)
+	}
+	if n := len(ParseTag(bytes.NewReader(v23.Bytes())).Credits()); n != 3 {
+		t.Errorf("Credits: expected 3 credits after writing, got %d", n)
+	}
+}
+
+func TestLanguages(t *testing.T) {
+	tag := NewTag(3)
+	if err := tag.SetLanguages("kor", "ENG"); err != nil {
+		t.Fatal(err)
+	}
+	if codes, err := tag.Languages(); err != nil || fmt.Sprint(codes) != "[kor eng]" {
+		t.Errorf("Languages: expected [kor eng], got %v, %v", codes, err)
+	}
+
+	if err := tag.SetLanguages("english"); !errors.Is(err, ErrBadLanguage) {
+		t.Errorf("SetLanguages: expected ErrBadLanguage, got %v", err)
+	}
+	if err := tag.SetLanguages("xxx"); !errors.Is(err, ErrBadLanguage) {
+		t.Errorf("SetLanguages: expected unknown code to fail, got %v", err)
+	}
+
+	tag.DeleteFrames("TLAN")
+	tag.AddFrames(NewTextFrame(V23FrameTypeMap["TLAN"], "engxxx", "ISO-8859-1"))
+	codes, err := tag.Languages()
+	if fmt.Sprint(codes) != "[eng]" || !errors.Is(err, ErrBadLanguage) {
+		t.Errorf("Languages: expected [eng] and an error, got %v, %v", codes, err)
+	}
+	if issues := tag.Validate(); len(issues) != 1 {
+		t.Errorf("Validate: expected an issue for the unknown code, got %v", issues)
+	}
+}
+
+func TestISRC(t *testing.T) {
+	tag := NewTag(4)
+	if isrc, err := tag.ISRC(); isrc != "" || err != nil {
+		t.Errorf("ISRC: expected nothing, got %q, %v", isrc, err)
+	}
+
+	if err := tag.SetISRC("kr-a01-13-00123"); err != nil {
+		t.Fatal(err)
+	}
+	if isrc, err := tag.ISRC(); isrc != "KRA011300123" || err != nil {
+		t.Errorf("ISRC: expected KRA011300123, got %q, %v", isrc, err)
+	}
+
+	for _, isrc := range []string{"KRA0113001", "KR-A01-13-0012X", "1RA011300123"} {
+		if err := tag.SetISRC(isrc); !errors.Is(err, ErrBadISRC) {
+			t.Errorf("SetISRC(%q): expected ErrBadISRC, got %v", isrc, err)
+		}
+	}
+}
+
+func TestDates(t *testing.T) {
+	date := time.Date(2013, time.November, 25, 18, 30, 0, 0, time.UTC)
+
+	v24 := NewTag(4)
+	if err := v24.SetRecordingDate(date, PrecisionMinute); err != nil {
+		t.Fatal(err)
+	}
+	v24.SetReleaseDate(date, PrecisionDay)
+	v24.SetOriginalReleaseDate(date, PrecisionYear)
+	if s := v24.Frame("TDRC").String(); s != "2013-11-25T18:30" {
+		t.Errorf("SetRecordingDate: expected 2013-11-25T18:30, got %q", s)
+	}
+	if got, p, err := v24.ReleaseDate(); !got.Equal(date.Truncate(24*time.Hour)) || p != PrecisionDay || err != nil {
+		t.Errorf("ReleaseDate: got %v, %v, %v", got, p, err)
+	}
+
+	v23 := NewTag(3)
+	v23.SetRecordingDate(date, PrecisionMinute)
+	v23.SetOriginalReleaseDate(date, PrecisionDay)
+	for id, expected := range map[string]string{"TYER": "2013", "TDAT": "2511", "TIME": "1830", "TORY": "2013"} {
+		if f := v23.Frame(id); f == nil || f.String() != expected {
+			t.Errorf("SetRecordingDate: expected %s %q, got %v", id, expected, f)
+		}
+	}
+
+	parsed := ParseTag(bytes.NewReader(v23.Bytes()))
+	if got, p, err := parsed.RecordingDate(); !got.Equal(date) || p != PrecisionMinute || err != nil {
+		t.Errorf("RecordingDate: got %v, %v, %v", got, p, err)
+	}
+	if _, p, _ := parsed.OriginalReleaseDate(); p != PrecisionYear {
+		t.Errorf("OriginalReleaseDate: expected year precision, got %v", p)
+	}
+	if got, _, err := parsed.ReleaseDate(); !got.Equal(date) || err != nil {
+		t.Errorf("ReleaseDate: expected the recording date without a release date, got %v, %v", got, err)
+	}
+
+	// The release date does not overwrite the recording date
+	released := time.Date(2014, time.March, 7, 0, 0, 0, 0, time.UTC)
+	if err := v23.SetReleaseDate(released, PrecisionDay); err != nil {
+		t.Fatal(err)
+	}
+	parsed = ParseTag(bytes.NewReader(v23.Bytes()))
+	if got, p, err := parsed.ReleaseDate(); !got.Equal(released) || p != PrecisionDay || err != nil {
+		t.Errorf("ReleaseDate: got %v, %v, %v", got, p, err)
+	}
+	if got, p, err := parsed.RecordingDate(); !got.Equal(date) || p != PrecisionMinute || err != nil {
+		t.Errorf("SetReleaseDate: expected the recording date kept, got %v, %v, %v", got, p, err)
+	}
+	if v23.SetReleaseDate(time.Time{}, PrecisionNone); len(v23.Frames("TXXX")) != 0 {
+		t.Errorf("SetReleaseDate: expected the release date removed")
+	}
+
+	v23.SetRecordingDate(date, PrecisionYear)
+	if v23.Frame("TDAT") != nil || v23.Frame("TIME") != nil {
+		t.Errorf("SetRecordingDate: expected TDAT and TIME removed for a year")
+	}
+
+	// Malformed timestamps are refused, but may come from other taggers
+	if v24.SetYear("25.11.2013"); v24.Year() == "25.11.2013" {
+		t.Errorf("SetYear: expected a malformed timestamp refused")
+	}
+	v24.DeleteFrames("TDRC")
+	v24.AddFrames(NewTextFrame(V23FrameTypeMap["TDRC"], "25.11.2013", "ISO-8859-1"))
+	if _, _, err := v24.RecordingDate(); !errors.Is(err, ErrBadDate) {
+		t.Errorf("RecordingDate: expected ErrBadDate, got %v", err)
+	}
+	if _, p, err := NewTag(4).RecordingDate(); p != PrecisionNone || err != nil {
+		t.Errorf("RecordingDate: expected no date, got %v, %v", p, err)
+	}
+}
+
+func TestTimestamps(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"2023", "2023"},
+		{"2023/05/01", "2023-05-01"},
+		{"2023.5.1", "2023-05-01"},
+		{"2023-05-01 18:30", "2023-05-01T18:30"},
+		{"2023-05-01T18:30:05.250Z", "2023-05-01T18:30:05"},
+		{"2023-13-01", ""},
+		{"May 2023", ""},
+	}
+
+	for _, test := range tests {
+		normalized, err := NormalizeTimestamp(test.text)
+		if normalized != test.expected || (err != nil) != (test.expected == "") {
+			t.Errorf("NormalizeTimestamp(%q): expected %q, got %q, %v", test.text, test.expected, normalized, err)
+		}
+	}
+
+	tag := NewTag(4)
+	if err := tag.SetTimestamp("TDRL", "2023/05/01"); !errors.Is(err, ErrBadDate) {
+		t.Errorf("SetTimestamp: expected ErrBadDate, got %v", err)
+	}
+	tag.SetLenientTimestamps(true)
+	if err := tag.SetTimestamp("TDRL", "2023/05/01"); err != nil || tag.Frame("TDRL").String() != "2023-05-01" {
+		t.Errorf("SetTimestamp: expected lenient fix, got %v", err)
+	}
+	if err := tag.SetTimestamp("TIT2", "2023"); err == nil {
+		t.Errorf("SetTimestamp: expected TIT2 to be refused")
+	}
+	if err := NewTag(3).SetTimestamp("TDRC", "2023"); err == nil {
+		t.Errorf("SetTimestamp: expected ID3v2.3 to be refused")
+	}
+
+	if tag.SetYear("2023/05/01"); tag.Year() != "2023-05-01" {
+		t.Errorf("SetYear: expected lenient fix, got %q", tag.Year())
+	}
+
+	// Every text setter checks timestamps
+	strict := NewTag(4)
+	if err := strict.SetFrameText("TDRL", "2023/05/01"); !errors.Is(err, ErrBadDate) {
+		t.Errorf("SetFrameText: expected ErrBadDate, got %v", err)
+	}
+	if err := strict.SetAll(map[string]string{"title": "Nice Life", "date": "May 2023"}); !errors.Is(err, ErrBadDate) || strict.Title() != "" {
+		t.Errorf("SetAll: expected ErrBadDate and nothing changed, got %v", err)
+	}
+	if strict.SetYear("2023/05/01"); strict.Frame("TDRC") != nil {
+		t.Errorf("SetYear: expected a malformed timestamp refused")
+	}
+
+	strict.AddFrames(NewTextFrame(V23FrameTypeMap["TDRC"], "2023/05/01", "ISO-8859-1"))
+	if issues := strict.Validate(); len(issues) == 0 || !strings.Contains(issues[0].Message, "not a timestamp") {
+		t.Errorf("Validate: expected the malformed TDRC, got %v", issues)
+	}
+}
+
+func TestMusicBrainzIDs(t *testing.T) {
+	ids := MusicBrainzIDs{
+		Recording:    "9b2ad3c7-3f0d-4b8e-a7b6-1f4bd1f0a6c2",
+		Release:      "2f2a2a40-5d54-4b83-9d2b-8d1f2a8a3a11",
+		ReleaseGroup: "5e1b7a8c-1c6c-4c4e-9f4e-2f3a7b9d0e21",
+		Artists:      []string{"a1b2c3d4-0000-4000-8000-000000000001", "a1b2c3d4-0000-4000-8000-000000000002"},
+	}
+
+	for _, version := range []byte{3, 4} {
+		tag := NewTag(version)
+		tag.SetMusicBrainzIDs(ids)
+
+		parsed := ParseTag(bytes.NewReader(tag.Bytes()))
+		if got := parsed.MusicBrainzIDs(); fmt.Sprint(got) != fmt.Sprint(ids) {
+			t.Errorf("MusicBrainzIDs: v2.%d expected %v, got %v", version, ids, got)
+		}
+		if f := parsed.Frame("UFID").(*IdFrame); f.String() != "http://musicbrainz.org: "+ids.Recording {
+			t.Errorf("SetMusicBrainzIDs: unexpected UFID %q", f.String())
+		}
+
+		parsed.SetMusicBrainzIDs(MusicBrainzIDs{})
+		if !parsed.MusicBrainzIDs().IsZero() || len(parsed.AllFrames()) != 0 {
+			t.Errorf("SetMusicBrainzIDs: expected all identifiers removed, got %v", parsed.AllFrames())
+		}
+	}
+}
+
+func TestAcoustID(t *testing.T) {
+	tag := NewTag(3)
+	tag.SetAcoustID("0b6f4c8e-7d52-4d1a-9a7e-3f5e6b1c2d3e")
+	tag.SetAcoustIDFingerprint("AQADtEmUaEkSRZEG")
+
+	parsed := ParseTag(bytes.NewReader(tag.Bytes()))
+	if id := parsed.AcoustID(); id != "0b6f4c8e-7d52-4d1a-9a7e-3f5e6b1c2d3e" {
+		t.Errorf("AcoustID: unexpected %q", id)
+	}
+	if fp := parsed.AcoustIDFingerprint(); fp != "AQADtEmUaEkSRZEG" {
+		t.Errorf("AcoustIDFingerprint: unexpected %q", fp)
+	}
+
+	parsed.SetAcoustID("")
+	if parsed.AcoustID() != "" || len(parsed.Frames("TXXX")) != 1 {
+		t.Errorf("SetAcoustID: expected only the fingerprint left")
+	}
+}
+
+func TestPodcast(t *testing.T) {
+	tag := NewTag(3)
+	tag.SetPodcast(true)
+	tag.SetPodcastID("https://example.com/episodes/42")
+	tag.SetPodcastDescription("Episode 42")
+	tag.SetPodcastKeywords("hip-hop", "korea")
+	tag.SetPodcastFeed("https://example.com/feed.xml")
+
+	parsed := ParseTag(bytes.NewReader(tag.Bytes()))
+	if !parsed.Podcast() || parsed.PodcastID() != "https://example.com/episodes/42" || parsed.PodcastDescription() != "Episode 42" {
+		t.Errorf("Podcast: fields did not round trip")
+	}
+	if keywords := parsed.PodcastKeywords(); fmt.Sprint(keywords) != "[hip-hop korea]" {
+		t.Errorf("PodcastKeywords: unexpected %v", keywords)
+	}
+	if feed := parsed.PodcastFeed(); feed != "https://example.com/feed.xml" {
+		t.Errorf("PodcastFeed: unexpected %q", feed)
+	}
+	if _, ok := parsed.Frame("WFED").(*TextFrame); !ok {
+		t.Errorf("WFED: expected a text frame")
+	}
+
+	parsed.SetPodcast(false)
+	parsed.SetPodcastFeed("")
+	if parsed.Podcast() || parsed.Frame("WFED") != nil {
+		t.Errorf("SetPodcast: expected PCST and WFED removed")
+	}
+
+	v22 := NewTag(2)
+	v22.SetPodcast(true)
+	v22.SetPodcastID("42")
+	if len(v22.AllFrames()) != 0 {
+		t.Errorf("SetPodcast: expected ID3v2.2 to be left alone")
+	}
+}
+
+func TestChaptersJSON(t *testing.T) {
+	tag := NewTag(3)
+	tag.AddFrames(NewTextFrame(V23FrameTypeMap["TLEN"], "90000", "ISO-8859-1"))
+	err := tag.SetChaptersJSON([]byte(`{"version":"1.2.0","chapters":[
+		{"startTime":30.5,"title":"Interview","url":"https://example.com/guest"},
+		{"startTime":0,"title":"Intro","img":"https://example.com/intro.jpg"}]}`))
+	if err != nil {
+		t.Fatalf("SetChaptersJSON: %v", err)
+	}
+
+	parsed := ParseTag(bytes.NewReader(tag.Bytes()))
+	pc := parsed.PodcastChapters()
+	expected := []PodcastChapter{
+		{StartTime: 0, EndTime: 30.5, Title: "Intro", Img: "https://example.com/intro.jpg"},
+		{StartTime: 30.5, EndTime: 90, Title: "Interview", URL: "https://example.com/guest"},
+	}
+	if fmt.Sprint(pc.Chapters) != fmt.Sprint(expected) {
+		t.Errorf("PodcastChapters: expected %v, got %v", expected, pc.Chapters)
+	}
+	if toc, ok := parsed.Frame("CTOC").(*TOCFrame); !ok || fmt.Sprint(toc.ChildElements) != "[chp0 chp1]" {
+		t.Errorf("SetChaptersJSON: expected an ordered table of contents")
+	}
+
+	data, err := parsed.ChaptersJSON()
+	if err != nil {
+		t.Fatalf("ChaptersJSON: %v", err)
+	}
+	again := NewTag(4)
+	if err := again.SetChaptersJSON(data); err != nil {
+		t.Fatalf("SetChaptersJSON: %v", err)
+	}
+	if fmt.Sprint(again.PodcastChapters().Chapters) != fmt.Sprint(expected) {
+		t.Errorf("ChaptersJSON: chapters did not round trip")
+	}
+
+	if err := NewTag(2).SetChaptersJSON(data); err != ErrFrameNotAllowed {
+		t.Errorf("SetChaptersJSON: expected ErrFrameNotAllowed for ID3v2.2, got %v", err)
+	}
+
+	// Times that do not fit the frame are rejected rather than wrapped
+	for _, times := range []string{`"startTime":-1`, `"startTime":0,"endTime":5000000`, `"startTime":4294967.295`} {
+		err := again.SetChaptersJSON([]byte(`{"chapters":[{` + times + `}]}`))
+		if !errors.Is(err, ErrBadValue) {
+			t.Errorf("SetChaptersJSON: expected ErrBadValue for %s, got %v", times, err)
+		}
+	}
+	if len(again.PodcastChapters().Chapters) != len(expected) {
+		t.Errorf("SetChaptersJSON: expected the chapters kept after an error")
+	}
+}
+
+func TestPictures(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 3, 2))); err != nil {
+		t.Fatal(err)
+	}
+
+	tag := NewTag(3)
+	f, err := NewPictureFrame(V23FrameTypeMap["APIC"], 3, "cover", buf.Bytes())
+	if err != nil {
+		t.Fatalf("NewPictureFrame: %v", err)
+	}
+	tag.AddFrames(f)
+
+	if _, err := NewPictureFrame(V23FrameTypeMap["APIC"], 3, "", []byte("not an image")); err != ErrImageFormat {
+		t.Errorf("NewPictureFrame: expected ErrImageFormat, got %v", err)
+	}
+
+	pictures := ParseTag(bytes.NewReader(tag.Bytes())).Pictures()
+	if len(pictures) != 1 {
+		t.Fatalf("Pictures: expected 1 picture, got %d", len(pictures))
+	}
+	p := pictures[0]
+	if p.MIMEType != "image/png" || p.Type != 3 || p.Description != "cover" || p.Width != 3 || p.Height != 2 {
+		t.Errorf("Pictures: unexpected %s %d %q %dx%d", p.MIMEType, p.Type, p.Description, p.Width, p.Height)
+	}
+
+	if err := CheckMIMEType("image/jpeg", buf.Bytes()); !errors.Is(err, ErrMIMEMismatch) {
+		t.Errorf("CheckMIMEType: expected ErrMIMEMismatch, got %v", err)
+	}
+
+	// Extended WebP header of a 640x480 canvas
+	webp := []byte("RIFF\x00\x00\x00\x00WEBPVP8X\x0a\x00\x00\x00\x00\x00\x00\x00\x7f\x02\x00\xdf\x01\x00")
+	if err := f.SetImageData(webp); err != nil {
+		t.Fatalf("SetImageData: %v", err)
+	}
+	if p := f.Picture(); p.MIMEType != "image/webp" || p.Width != 640 || p.Height != 480 {
+		t.Errorf("SetImageData: unexpected %s %dx%d", p.MIMEType, p.Width, p.Height)
+	}
+	if err := f.SetImageData([]byte("text")); err != ErrImageFormat || f.MIMEType() != "image/webp" {
+		t.Errorf("SetImageData: expected ErrImageFormat and the frame unchanged")
+	}
+}
+
+func TestPictureImage(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	f := NewImageFrame(V23FrameTypeMap["APIC"], "image/png", 3, "", nil)
+	if err := f.SetImage(src, "png"); err != nil {
+		t.Fatalf("SetImage: %v", err)
+	}
+
+	img, err := f.Picture().Image()
+	if err != nil {
+		t.Fatalf("Image: %v", err)
+	}
+	if img.Bounds() != src.Bounds() {
+		t.Errorf("Image: expected bounds %v, got %v", src.Bounds(), img.Bounds())
+	}
+
+	if err := f.SetImage(src, "image/jpeg"); err != nil || f.MIMEType() != "image/jpeg" {
+		t.Errorf("SetImage: expected a JPEG image, got %s and %v", f.MIMEType(), err)
+	}
+	if err := f.SetImage(src, "tiff"); err == nil {
+		t.Errorf("SetImage: expected an error for TIFF")
+	}
+
+	if _, err := (Picture{Data: []byte("text")}).Image(); err != ErrImageFormat {
+		t.Errorf("Image: expected ErrImageFormat, got %v", err)
+	}
+}
+
+func TestPictureTypes(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 32, 32))); err != nil {
+		t.Fatal(err)
+	}
+	icon := buf.Bytes()
+
+	tag := NewTag(3)
+	if err := tag.AddPicture(PictureFileIcon, "first", icon); err != nil {
+		t.Fatalf("AddPicture: %v", err)
+	}
+	if err := tag.AddPicture(PictureFileIcon, "second", icon); err != nil {
+		t.Fatalf("AddPicture: %v", err)
+	}
+	if err := tag.AddPicture(PictureArtist, "artist", icon); err != nil {
+		t.Fatalf("AddPicture: %v", err)
+	}
+	if pictures := tag.Pictures(); len(pictures) != 2 || pictures[0].Description != "second" {
+		t.Errorf("AddPicture: expected the second file icon to replace the first")
+	}
+
+	jpegData := []byte("\xff\xd8\xff\xe0 not really a jpeg")
+	if err := tag.SetFrontCover(jpegData, "image/png"); !errors.Is(err, ErrMIMEMismatch) {
+		t.Errorf("SetFrontCover: expected ErrMIMEMismatch, got %v", err)
+	}
+	tag.SetFrontCover(icon, "")
+	if err := tag.SetFrontCover(jpegData, "image/jpeg"); err != nil {
+		t.Fatalf("SetFrontCover: %v", err)
+	}
+
+	var covers []Picture
+	for _, p := range tag.Pictures() {
+		if p.Type == PictureFrontCover {
+			covers = append(covers, p)
+		}
+	}
+	if len(covers) != 1 || covers[0].MIMEType != "image/jpeg" {
+		t.Errorf("SetFrontCover: expected one JPEG front cover, got %v", covers)
+	}
+
+	if err := NewTag(2).SetFrontCover(icon, ""); err != ErrFrameNotAllowed {
+		t.Errorf("SetFrontCover: expected ErrFrameNotAllowed for ID3v2.2, got %v", err)
+	}
+
+	// Two file icons with different descriptions
+	tag.AddFrames(NewImageFrame(V23FrameTypeMap["APIC"], "image/png", PictureFileIcon, "extra", icon))
+	found := false
+	for _, issue := range tag.Validate() {
+		found = found || strings.Contains(issue.Message, "only one picture")
+	}
+	if !found {
+		t.Errorf("Validate: expected a duplicate file icon to be reported")
+	}
+
+	if name := PictureTypeName(PictureBrightFish); name != "A bright coloured fish" {
+		t.Errorf("PictureTypeName: unexpected %q", name)
+	}
+}
+
+func TestDedupe(t *testing.T) {
+	build := func() *Tag {
+		tag := NewTag(3)
+		tag.AddFrames(
+			NewTextFrame(V23FrameTypeMap["TIT2"], "First", "ISO-8859-1"),
+			NewUnsynchTextFrame(V23FrameTypeMap["COMM"], "", "Same"),
+			NewTextFrame(V23FrameTypeMap["TIT2"], "Second", "ISO-8859-1"),
+			NewUnsynchTextFrame(V23FrameTypeMap["COMM"], "", "Same"),
+			NewDataFrame(V23FrameTypeMap["PRIV"], []byte("data")),
+			NewDataFrame(V23FrameTypeMap["PRIV"], []byte("data")),
+			NewDataFrame(V23FrameTypeMap["PRIV"], []byte("other")),
+		)
+		return tag
+	}
+
+	tag := build()
+	size := tag.RealSize()
+	removed := tag.Dedupe(KeepFirst)
+	if len(removed) != 3 {
+		t.Fatalf("Dedupe: expected 3 frames removed, got %d", len(removed))
+	}
+	if title := tag.Title(); title != "First" {
+		t.Errorf("Dedupe: expected the first title, got %q", title)
+	}
+	if n := len(tag.Frames("PRIV")); n != 2 {
+		t.Errorf("Dedupe: expected 2 distinct PRIV frames, got %d", n)
+	}
+	if tag.RealSize() >= size {
+		t.Errorf("Dedupe: expected the tag to shrink")
+	}
+
+	tag = build()
+	tag.Dedupe(KeepLast)
+	if title := tag.Title(); title != "Second" {
+		t.Errorf("Dedupe: expected the last title, got %q", title)
+	}
+	if tag.Dedupe(KeepLast) != nil {
+		t.Errorf("Dedupe: expected nothing left to remove")
+	}
+
+	// Each performer may have a link, but only once
+	tag = NewTag(3)
+	tag.AddFrames(
+		NewDataFrame(V23FrameTypeMap["WOAR"], []byte("https://example.com/a")),
+		NewDataFrame(V23FrameTypeMap["WOAR"], []byte("https://example.com/b")),
+		NewDataFrame(V23FrameTypeMap["WOAR"], []byte("https://example.com/a")),
+	)
+	if removed := tag.Dedupe(KeepFirst); len(removed) != 1 || len(tag.Frames("WOAR")) != 2 {
+		t.Errorf("Dedupe: expected only the repeated WOAR removed, got %d removed", len(removed))
+	}
+}
+
+func TestCanonicalOrder(t *testing.T) {
+	frames := func() []Framer {
+		return []Framer{
+			NewImageFrame(V23FrameTypeMap["APIC"], "image/png", PictureFrontCover, "", []byte("\x89PNG\r\n\x1a\n")),
+			NewDescTextFrame(V23FrameTypeMap["TXXX"], "b", "2", "ISO-8859-1"),
+			NewTextFrame(V23FrameTypeMap["TPE1"], "Artist", "ISO-8859-1"),
+			NewUnsynchTextFrame(V23FrameTypeMap["COMM"], "", "Comment"),
+			NewDescTextFrame(V23FrameTypeMap["TXXX"], "a", "1", "ISO-8859-1"),
+			NewIdFrame(V23FrameTypeMap["UFID"], "owner", []byte("id")),
+			NewTextFrame(V23FrameTypeMap["TIT2"], "Title", "ISO-8859-1"),
+		}
+	}
+
+	forward, backward := NewTag(3), NewTag(3)
+	forward.AddFrames(frames()...)
+	list := frames()
+	for i := len(list) - 1; i >= 0; i-- {
+		backward.AddFrames(list[i])
+	}
+
+	if bytes.Equal(forward.Bytes(), backward.Bytes()) {
+		t.Fatalf("Bytes: expected the original order by default")
+	}
+
+	forward.SetCanonicalOrder(true)
+	backward.SetCanonicalOrder(true)
+	if !bytes.Equal(forward.Bytes(), backward.Bytes()) {
+		t.Errorf("SetCanonicalOrder: expected identical tags")
+	}
+
+	var ids []string
+	for _, f := range ParseTag(bytes.NewReader(forward.Bytes())).AllFrames() {
+		ids = append(ids, f.Id())
+	}
+	if order := strings.Join(ids, " "); order != "UFID TIT2 TPE1 TXXX TXXX COMM APIC" {
+		t.Errorf("SetCanonicalOrder: unexpected order %s", order)
+	}
+	if f := forward.AllFrames()[0]; f.Id() != "APIC" {
+		t.Errorf("SetCanonicalOrder: expected the frames in the tag to keep their order")
+	}
+}
+
+func TestPinFirst(t *testing.T) {
+	tag := NewTag(3)
+	tag.AddFrames(
+		NewTextFrame(V23FrameTypeMap["TIT2"], "Title", "ISO-8859-1"),
+		NewUnsynchTextFrame(V23FrameTypeMap["COMM"], "", "First"),
+		NewImageFrame(V23FrameTypeMap["APIC"], "image/png", PictureBackCover, "Back", []byte{1}),
+		NewImageFrame(V23FrameTypeMap["APIC"], "image/png", PictureFrontCover, "Front", []byte{2}),
+		NewUnsynchTextFrame(V23FrameTypeMap["COMM"], "other", "Second"),
+	)
+
+	tag.PinFirst("APIC", "COMM")
+	order := func() string {
+		var ids []string
+		for _, f := range ParseTag(bytes.NewReader(tag.Bytes())).AllFrames() {
+			ids = append(ids, f.Id())
+			if imf, ok := f.(*ImageFrame); ok {
+				ids[len(ids)-1] += ":" + imf.Description()
+			}
+		}
+		return strings.Join(ids, " ")
+	}
+
+	if got := order(); got != "APIC:Back APIC:Front COMM COMM TIT2" {
+		t.Errorf("PinFirst: unexpected order %s", got)
+	}
+	if f := tag.AllFrames()[0]; f.Id() != "TIT2" {
+		t.Errorf("PinFirst: expected the frames in the tag to keep their order")
+	}
+
+	tag.SetCanonicalOrder(true)
+	tag.PinFirst("COMM")
+	if got := order(); got != "COMM COMM TIT2 APIC:Back APIC:Front" {
+		t.Errorf("PinFirst: unexpected order with the canonical order %s", got)
+	}
+
+	tag.PinFirst()
+	if got := order(); got != "TIT2 COMM COMM APIC:Back APIC:Front" {
+		t.Errorf("PinFirst: expected no pins, got %s", got)
+	}
+
+	// IDs of other versions pin their equivalents
+	v22, _ := tag.Convert(2)
+	v22.SetCanonicalOrder(false)
+	v22.PinFirst("APIC", "TIT2")
+	var ids []string
+	for _, f := range ParseTag(bytes.NewReader(v22.Bytes())).AllFrames() {
+		ids = append(ids, f.Id())
+	}
+	if got := strings.Join(ids, " "); got != "PIC PIC TT2 COM COM" {
+		t.Errorf("PinFirst: unexpected ID3v2.2 order %s", got)
+	}
+}
+
+func TestUnknownFrames(t *testing.T) {
+	frame := func(id string, status, format byte, body string) []byte {
+		b := append([]byte(id), encodedbytes.NormBytes(uint32(len(body)))...)
+		return append(append(b, status, format), body...)
+	}
+
+	var body []byte
+	body = append(body, frame("TIT2", 0, 0, "\x00Title")...)
+	body = append(body, frame("XTST", 0x20, 0, "experimental")...)
+	body = append(body, frame("TALB", 0, 0x80, "\x00\x00\x00\x06compressed")...)
+	body = append(body, frame("TPE1", 0, 0, "\x00Artist")...)
+	data := append([]byte("ID3\x03\x00\x00"), encodedbytes.SynchBytes(uint32(len(body)))...)
+	data = append(data, body...)
+
+	tag := ParseTag(bytes.NewReader(data))
+	if tag == nil || len(tag.AllFrames()) != 4 {
+		t.Fatalf("ParseTag: expected 4 frames")
+	}
+	if _, ok := tag.Frame("TALB").(*DataFrame); !ok {
+		t.Errorf("ParseTag: expected the compressed frame kept as binary data")
+	}
+
+	if !bytes.Equal(tag.Bytes(), data) {
+		t.Errorf("Bytes: expected the tag written unchanged")
+	}
+
+	tag.SetTitle("Another title")
+	out := tag.Bytes()
+	for _, f := range [][]byte{frame("XTST", 0x20, 0, "experimental"), frame("TALB", 0, 0x80, "\x00\x00\x00\x06compressed")} {
+		if !bytes.Contains(out, f) {
+			t.Errorf("Bytes: expected %q written unchanged after an edit", f[:4])
+		}
+	}
+
+	v24, dropped := tag.Convert(4)
+	if len(dropped) != 1 || dropped[0].Id() != "TALB" {
+		t.Errorf("Convert: expected the compressed frame reported, got %v", dropped)
+	}
+	f := v24.Frame("XTST")
+	if f == nil || f.StatusFlags() != 0x10 || string(f.Bytes()) != "experimental" {
+		t.Fatalf("Convert: expected XTST with its read only flag translated")
+	}
+
+	back, _ := ParseTag(bytes.NewReader(v24.Bytes())).Convert(3)
+	if f := back.Frame("XTST"); f == nil || f.StatusFlags() != 0x20 {
+		t.Errorf("Convert: expected XTST to survive the round trip")
+	}
+
+	if _, dropped := tag.Convert(2); len(dropped) != 2 {
+		t.Errorf("Convert: expected XTST and TALB reported for ID3v2.2, got %d frames", len(dropped))
+	}
+}
+
+func TestRawBytes(t *testing.T) {
+	raw := []byte("TIT2\x00\x00\x00\x06\x00\x00\x00Title")
+	data := append([]byte("ID3\x03\x00\x00"), encodedbytes.SynchBytes(uint32(len(raw)))...)
+	data = append(data, raw...)
+
+	tag := ParseTag(bytes.NewReader(data))
+	f := tag.Frame("TIT2").(*TextFrame)
+	if !bytes.Equal(f.RawBytes(), raw) {
+		t.Errorf("RawBytes: expected %q, got %q", raw, f.RawBytes())
+	}
+
+	f.SetText("Changed")
+	if !bytes.Equal(f.RawBytes(), raw) {
+		t.Errorf("RawBytes: expected the bytes read from the file after an edit")
+	}
+
+	if f := ParseV23Frame(bytes.NewReader(raw)); f == nil || !bytes.Equal(f.RawBytes(), raw) {
+		t.Errorf("ParseV23Frame: expected the raw bytes to be kept")
+	}
+	if NewTextFrame(V23FrameTypeMap["TIT2"], "New", "ISO-8859-1").RawBytes() != nil {
+		t.Errorf("RawBytes: expected nil for a frame that was not parsed")
+	}
+}
+
+func TestHeaderFlags(t *testing.T) {
+	tag := NewTag(3)
+	if err := tag.SetExperimental(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := tag.SetUnsynchronization(true); err != ErrFlagNotAllowed {
+		t.Errorf("SetUnsynchronization: expected ErrFlagNotAllowed, got %v", err)
+	}
+	if err := tag.SetExtendedHeader(true); err != ErrFlagNotAllowed {
+		t.Errorf("SetExtendedHeader: expected ErrFlagNotAllowed in ID3v2.3, got %v", err)
+	}
+	tag.SetRevision(1)
+
+	// A tag from another writer keeps the flags until they are cleared
+	data := tag.Bytes()
+	data[5] |= FlagUnsynchronization
+	parsed := ParseTag(bytes.NewReader(data))
+	if parsed.Flags() != FlagUnsynchronization|FlagExperimental || !parsed.Experimental() || !parsed.Unsynchronization() || parsed.ExtendedHeader() {
+		t.Errorf("SetFlags: unexpected flags %08b", parsed.Flags())
+	}
+	if parsed.Revision() != 1 || parsed.Version() != "2.3.1" {
+		t.Errorf("SetRevision: unexpected version %s", parsed.Version())
+	}
+	if err := parsed.SetFlags(parsed.Flags()); err != nil {
+		t.Errorf("SetFlags: unexpected error keeping the flags: %v", err)
+	}
+
+	parsed.SetUnsynchronization(false)
+	if parsed.Flags() != FlagExperimental || parsed.Unsynchronization() {
+		t.Errorf("SetUnsynchronization: unexpected flags %08b", parsed.Flags())
+	}
+
+	if err := tag.SetFlags(FlagFooter); err != ErrFlagNotAllowed {
+		t.Errorf("SetFlags: expected ErrFlagNotAllowed for a footer in ID3v2.3, got %v", err)
+	}
+	if err := tag.SetCompression(true); err != ErrFlagNotAllowed {
+		t.Errorf("SetCompression: expected ErrFlagNotAllowed in ID3v2.3, got %v", err)
+	}
+
+	v22 := NewTag(2)
+	if err := v22.SetExtendedHeader(true); err != ErrFlagNotAllowed {
+		t.Errorf("SetExtendedHeader: expected ErrFlagNotAllowed in ID3v2.2, got %v", err)
+	}
+	if err := v22.SetCompression(true); err != nil || !v22.Compression() || v22.ExtendedHeader() {
+		t.Errorf("SetCompression: expected the ID3v2.2 compression flag")
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	tag := NewTag(4)
+	tag.SetTitle("Title")
+	tag.SetArtist("Artist")
+	tag.AddFrames(NewImageFrame(V23FrameTypeMap["APIC"], "image/png", PictureFrontCover, "", bytes.Repeat([]byte{1}, 5000)))
+	tag.SetPadding(10000)
+
+	var buf bytes.Buffer
+	n, err := tag.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	if n != int64(buf.Len()) || !bytes.Equal(buf.Bytes(), tag.Bytes()) {
+		t.Errorf("WriteTo: expected the same bytes as Bytes")
+	}
+
+	// Text the declared encoding cannot represent
+	f := tag.Frame("TIT2").(*TextFrame)
+	f.SetEncoding("UTF-8")
+	f.SetText("タイトル")
+	f.encoding = 0
+	if _, err := tag.WriteTo(io.Discard); err == nil || !strings.HasPrefix(err.Error(), "write: TIT2") {
+		t.Errorf("WriteTo: expected an error for TIT2, got %v", err)
+	}
+}
+
+func TestDirtyFrames(t *testing.T) {
+	tag := NewTag(3)
+	tag.SetTitle("Title")
+	tag.AddFrames(
+		NewUnsynchTextFrame(V23FrameTypeMap["COMM"], "", "Comment"),
+		NewImageFrame(V23FrameTypeMap["APIC"], "image/png", PictureFrontCover, "", []byte("\x89PNG\r\n\x1a\n")),
+		NewChapterFrame(V23FrameTypeMap["CHAP"], "chp0", 0, 1000, noChapterOffset, noChapterOffset, true, "", "", ""),
+		NewTOCFrame(V23FrameTypeMap["CTOC"], "toc", true, false, []string{"chp0"}),
+	)
+	data := tag.Bytes()
+
+	edits := []struct {
+		name string
+		edit func(*Tag)
+	}{
+		{"SetText", func(t *Tag) { t.Frame("TIT2").(*TextFrame).SetText("Eltit") }},
+		{"SetLanguage", func(t *Tag) { t.Frame("COMM").(*UnsynchTextFrame).SetLanguage("kor") }},
+		{"SetPictureType", func(t *Tag) { t.Frame("APIC").(*ImageFrame).SetPictureType(PictureBackCover) }},
+		{"SetTimes", func(t *Tag) { t.Frame("CHAP").(*ChapterFrame).SetTimes(0, 2000) }},
+		{"SetOrdered", func(t *Tag) { t.Frame("CTOC").(*TOCFrame).SetOrdered(true) }},
+		{"SetElement", func(t *Tag) { t.Frame("CTOC").(*TOCFrame).SetElement("contents") }},
+	}
+
+	for _, test := range edits {
+		parsed := ParseTag(bytes.NewReader(data))
+		if parsed.Dirty() {
+			t.Fatalf("ParseTag: expected a clean tag")
+		}
+
+		test.edit(parsed)
+		if !parsed.Dirty() {
+			t.Errorf("%s: expected the tag to be dirty", test.name)
+		}
+		if reparsed := ParseTag(bytes.NewReader(parsed.Bytes())); reparsed == nil || !reparsed.Equal(parsed) {
+			t.Errorf("%s: expected the edit to be written", test.name)
+		}
+	}
+}
+
+func benchmarkTagBytes() []byte {
+	tag := NewTag(3)
+	tag.SetTitle("Nice Life")
+	tag.SetArtist("Paloalto")
+	tag.SetAlbum("Chief Life")
+	tag.SetYear("2013")
+	tag.SetGenre("Hip-Hop")
+	tag.AddFrames(NewDescTextFrame(V23FrameTypeMap["COMM"], "", "A comment", "ISO-8859-1"))
+	tag.AddFrames(NewImageFrame(V23FrameTypeMap["APIC"], "image/jpeg", PictureFrontCover, "", make([]byte, 64<<10)))
+	for i := 0; i < 20; i++ {
+		tag.AddFrames(NewDescTextFrame(V23FrameTypeMap["TXXX"], fmt.Sprintf("key%d", i), "value", "ISO-8859-1"))
+	}
+	return tag.Bytes()
+}
+
+func BenchmarkParseTag(b *testing.B) {
+	data := benchmarkTagBytes()
+	reader := bytes.NewReader(data)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		reader.Reset(data)
+		if ParseTag(reader) == nil {
+			b.Fatal("no tag parsed")
+		}
+	}
+}
+
+func BenchmarkParseHeader(b *testing.B) {
+	data := testTagBytes()
+	reader := bytes.NewReader(data)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		reader.Reset(data)
+		if ParseHeader(reader) == nil {
+			b.Fatal("no header parsed")
+		}
+	}
+}
+
+func TestParseTagData(t *testing.T) {
+	data := benchmarkTagBytes()
+
+	tag, err := ParseTagData(data, ParseOptions{})
+	if err != nil || tag == nil {
+		t.Fatalf("ParseTagData: expected a tag, got %v", err)
+	}
+	if tag.Title() != "Nice Life" || !bytes.Equal(tag.Bytes(), data) {
+		t.Errorf("ParseTagData: expected the tag to round trip")
+	}
+
+	if tag, err := ParseTagData(data[:5], ParseOptions{}); tag != nil || err != nil {
+		t.Errorf("ParseTagData: expected no tag for a short header, got %v, %v", tag, err)
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	if !errors.Is(ErrUnknownEncoding, ErrBadEncoding) || !errors.Is(ErrEncodingNotAllowed, ErrBadEncoding) {
+		t.Errorf("expected encoding errors to be ErrBadEncoding")
+	}
+
+	f := NewTextFrame(V23FrameTypeMap["TIT2"], "Title", "ISO-8859-1")
+	if err := f.SetEncoding("EBCDIC"); !errors.Is(err, ErrBadEncoding) {
+		t.Errorf("SetEncoding: expected ErrBadEncoding, got %v", err)
+	}
+	if err := NewTag(3).SetTextEncoding("UTF-8"); !errors.Is(err, ErrBadEncoding) {
+		t.Errorf("SetTextEncoding: expected ErrBadEncoding for UTF-8 in ID3v2.3, got %v", err)
+	}
+
+	id := NewIdFrame(V23FrameTypeMap["UFID"], "owner", []byte("id"))
+	if err := id.SetIdentifier(make([]byte, 65)); !errors.Is(err, ErrBadValue) {
+		t.Errorf("SetIdentifier: expected ErrBadValue, got %v", err)
+	}
+
+	if err := NewTag(3).SetTimestamp("TDRC", "2013"); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("SetTimestamp: expected ErrUnsupportedVersion in ID3v2.3, got %v", err)
+	}
+	if err := NewTag(3).UnmarshalJSON([]byte(`{"version":"2.7.0"}`)); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("UnmarshalJSON: expected ErrUnsupportedVersion, got %v", err)
+	}
+}
+
+func TestParseLogger(t *testing.T) {
+	tag := NewTag(3)
+	tag.SetTitle("Nice Life")
+	tag.SetPadding(16)
+	data := tag.Bytes()
+	data[len(data)-8] = 1
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	parsed, err := ParseTagWithOptions(bytes.NewReader(data), ParseOptions{Logger: logger})
+	if err != nil || parsed == nil {
+		t.Fatalf("ParseTagWithOptions: expected a tag, got %v", err)
+	}
+	if !strings.Contains(buf.String(), ErrBadPadding.Error()) {
+		t.Errorf("Logger: expected the bad padding logged, got %q", buf.String())
+	}
+
+	buf.Reset()
+	parsed.SetTextEncoding("ISO-8859-1")
+	parsed.SetArtist("팔로알토")
+	if !strings.Contains(buf.String(), "fallback=UTF-16") {
+		t.Errorf("Logger: expected the encoding fallback logged, got %q", buf.String())
+	}
+}
+
+func TestParseStats(t *testing.T) {
+	tag := NewTag(3)
+	tag.SetTitle("Nice Life")
+	tag.SetArtist("팔로알토")
+	tag.AddFrames(ParseDataFrame(FrameHead{FrameType: FrameType{id: "XTST", constructor: ParseDataFrame}}, []byte{1, 2}))
+	tag.AddFrames(ParseDataFrame(FrameHead{FrameType: FrameType{id: "XTST", constructor: ParseDataFrame}}, []byte{3}))
+	tag.SetPadding(32)
+
+	parsed, err := ParseTagWithOptions(bytes.NewReader(tag.Bytes()), ParseOptions{})
+	if err != nil || parsed == nil {
+		t.Fatalf("ParseTagWithOptions: expected a tag, got %v", err)
+	}
+
+	stats := parsed.ParseStats()
+	if stats.FramesParsed != 4 || stats.FramesSkipped != 0 || stats.PaddingBytes != 32 {
+		t.Errorf("ParseStats: expected 4 frames and 32 bytes of padding, got %+v", stats)
+	}
+	if len(stats.UnknownFrameIds) != 1 || stats.UnknownFrameIds[0] != "XTST" {
+		t.Errorf("ParseStats: expected XTST unknown, got %v", stats.UnknownFrameIds)
+	}
+	if stats.TextBytes["ISO-8859-1"] != len("Nice Life\x00") || stats.TextBytes["UTF-16"] == 0 {
+		t.Errorf("ParseStats: unexpected text bytes %v", stats.TextBytes)
+	}
+	if stats.Duration <= 0 {
+		t.Errorf("ParseStats: expected the parse duration")
+	}
+
+	if stats := NewTag(3).ParseStats(); stats.FramesParsed != 0 || stats.TextBytes != nil {
+		t.Errorf("ParseStats: expected zero stats for a new tag, got %+v", stats)
+	}
+}
+
+func TestReplaceAndInsertFrame(t *testing.T) {
+	tag := NewTag(3)
+	front := NewImageFrame(V23FrameTypeMap["APIC"], "image/png", PictureFrontCover, "front", []byte{0x89})
+	back := NewImageFrame(V23FrameTypeMap["APIC"], "image/png", PictureBackCover, "back", []byte{0x89})
+	tag.AddFrames(back)
+	tag.SetTitle("Nice Life")
+
+	before := tag.AllFrames()
+	tag.InsertFrameAt(0, front)
+	frames := tag.AllFrames()
+	if len(frames) != 3 || frames[0] != front || frames[1] != back {
+		t.Errorf("InsertFrameAt: expected the front cover first, got %v", frames)
+	}
+	if before[0] != back {
+		t.Errorf("InsertFrameAt: expected earlier slices to be unchanged")
+	}
+
+	title := NewTextFrame(V23FrameTypeMap["TIT2"], "Chief Life", "ISO-8859-1")
+	if !tag.ReplaceFrame(tag.Frame("TIT2"), title) {
+		t.Fatal("ReplaceFrame: expected the title frame to be found")
+	}
+	if frames := tag.AllFrames(); frames[2] != title || tag.Title() != "Chief Life" {
+		t.Errorf("ReplaceFrame: expected the title in place, got %v", frames)
+	}
+	if tag.ReplaceFrame(NewTextFrame(V23FrameTypeMap["TALB"], "", "ISO-8859-1"), title) {
+		t.Errorf("ReplaceFrame: expected false for a frame not in the tag")
+	}
+
+	tag.InsertFrameAt(99, NewTextFrame(V23FrameTypeMap["TALB"], "Chief Life", "ISO-8859-1"))
+	if frames := tag.AllFrames(); frames[3].Id() != "TALB" {
+		t.Errorf("InsertFrameAt: expected an index past the end to append, got %v", frames)
+	}
+
+	parsed, err := ParseTagData(tag.Bytes(), ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	written := parsed.AllFrames()
+	if len(written) != 4 || !written[0].Equal(front) || !written[2].Equal(title) {
+		t.Errorf("expected the frames to be written in order, got %v", written)
+	}
+}
+
+func TestPreserveRoundTrip(t *testing.T) {
+	file, err := os.ReadFile("../test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	size, _ := encodedbytes.SynchInt(file[6:10])
+
+	frame := func(id string, status, format byte, body string) string {
+		return id + string(encodedbytes.NormBytes(uint32(len(body)))) + string([]byte{status, format}) + body
+	}
+	tag := func(header string, body string) []byte {
+		return append([]byte(header), append(encodedbytes.SynchBytes(uint32(len(body))), body...)...)
+	}
+
+	corpus := map[string][]byte{
+		// UTF-16 lyrics description without a byte order mark
+		"test.mp3": file[:HeaderSize+size],
+		"big endian UTF-16 and odd padding": tag("ID3\x03\x00\x00",
+			frame("TIT2", 0, 0, "\x01\xfe\xff\x00T\x00i\x00t\x00l\x00e")+
+				frame("TXXX", 0x40, 0, "\x00MOOD\x00Chill\x00\x00")+
+				"\x00\x00junk\x00"),
+		"ID3v2.2": tag("ID3\x02\x00\x00", "TT2\x00\x00\x06\x00Title"+"\x00\x00\x00\x00"),
+		"ID3v2.4 flags": tag("ID3\x04\x00\x00",
+			"TIT2\x00\x00\x00\x07\x00\x00\x03Title\x00"+
+				"TPE1\x00\x00\x00\x0d\x20\x00\x03Paloalto\x00Basick"),
+	}
+
+	for name, data := range corpus {
+		preserved, err := ParseTagData(data, ParseOptions{Preserve: true})
+		if err != nil || preserved == nil {
+			t.Fatalf("%s: ParseTagData: %v", name, err)
+		}
+		if !bytes.Equal(preserved.Bytes(), data) {
+			t.Errorf("%s: Bytes: expected the tag unchanged", name)
+		}
+		var buf bytes.Buffer
+		if _, err := preserved.WriteTo(&buf); err != nil || !bytes.Equal(buf.Bytes(), data) {
+			t.Errorf("%s: WriteTo: expected the tag unchanged, %v", name, err)
+		}
+
+		preserved.SetTitle("Nice Life")
+		edited, _ := ParseTagData(preserved.Bytes(), ParseOptions{})
+		if edited == nil || edited.Title() != "Nice Life" {
+			t.Errorf("%s: expected an edit to be written", name)
+		}
+	}
+
+	if plain, _ := ParseTagData(corpus["test.mp3"], ParseOptions{}); bytes.Equal(plain.Bytes(), corpus["test.mp3"]) {
+		t.Errorf("expected test.mp3 to be rewritten without Preserve")
+	}
+
+	// After an edit, frames that did not change are still written as read
+	mood := frame("TXXX", 0, 0, "\x01\xfe\xff\x00M\x00O\x00O\x00D\x00\x00\xfe\xff\x00C\x00h\x00i\x00l\x00l")
+	unknown := frame("XABC", 0, 0, "\x00\x01\x02")
+	private := frame("PRIV", 0, 0, "owner\x00"+strings.Repeat("x", 200))
+	data := tag("ID3\x03\x00\x00", frame("TIT2", 0, 0, "\x00Title")+mood+unknown+private)
+	preserved, err := ParseTagData(data, ParseOptions{Preserve: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	preserved.SetTitle("Nice Life")
+	for _, written := range [][]byte{preserved.Bytes(), writeTag(t, preserved)} {
+		if !bytes.Contains(written, []byte(mood+unknown+private)) {
+			t.Errorf("expected the unchanged frames written as read")
+		}
+		if edited, _ := ParseTagData(written, ParseOptions{}); edited == nil || edited.Title() != "Nice Life" {
+			t.Errorf("expected the edited title written")
+		}
+	}
+	v24 := NewTag(4)
+	v24.AddFrames(preserved.Frame("PRIV").Clone())
+	if bytes.Contains(v24.Bytes(), []byte(private)) {
+		t.Errorf("expected frames to be encoded again in another version")
+	}
+	preserved.Frames("TXXX")[0].(*DescTextFrame).SetText("Calm")
+	if bytes.Contains(preserved.Bytes(), []byte(mood)) {
+		t.Errorf("expected an edited frame to be encoded again")
+	}
+}
+
+func writeTag(t *testing.T, tag *Tag) []byte {
+	var buf bytes.Buffer
+	if _, err := tag.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestNativeSetters(t *testing.T) {
+	for _, version := range []byte{2, 3, 4} {
+		tag := NewTag(version)
+		if err := tag.SetYearInt(812); err != nil || tag.Year() != "0812" {
+			t.Errorf("v2.%d: SetYearInt: expected 0812, got %q, %v", version, tag.Year(), err)
+		}
+		if err := tag.SetYearTime(time.Date(2013, 11, 25, 0, 0, 0, 0, time.UTC)); err != nil || tag.Year() != "2013" {
+			t.Errorf("v2.%d: SetYearTime: expected 2013, got %q, %v", version, tag.Year(), err)
+		}
+		if err := tag.SetTrack(3, 10); err != nil || tag.frameText("TRCK") != "3/10" {
+			t.Errorf("v2.%d: SetTrack: expected 3/10, got %q, %v", version, tag.frameText("TRCK"), err)
+		}
+		if err := tag.SetDisc(1, 0); err != nil || tag.frameText("TPOS") != "1" {
+			t.Errorf("v2.%d: SetDisc: expected 1, got %q, %v", version, tag.frameText("TPOS"), err)
+		}
+		if err := tag.SetBPM(127.6); err != nil || tag.frameText("TBPM") != "128" {
+			t.Errorf("v2.%d: SetBPM: expected 128, got %q, %v", version, tag.frameText("TBPM"), err)
+		}
+
+		tag.SetTrack(0, 0)
+		tag.SetBPM(0)
+		if tag.frameText("TRCK") != "" || tag.frameText("TBPM") != "" {
+			t.Errorf("v2.%d: expected zero values to remove the frames", version)
+		}
+	}
+
+	tag := NewTag(4)
+	if err := tag.SetYearInt(10000); !errors.Is(err, ErrBadDate) {
+		t.Errorf("SetYearInt: expected ErrBadDate, got %v", err)
+	}
+	for _, err := range []error{tag.SetTrack(-1, 0), tag.SetDisc(0, 2), tag.SetBPM(math.NaN())} {
+		if !errors.Is(err, ErrBadValue) {
+			t.Errorf("expected ErrBadValue, got %v", err)
+		}
+	}
+
+	tag.SetYearInt(2013)
+	tag.SetTrack(3, 10)
+	tag.SetDisc(1, 2)
+	tag.SetBPM(128)
+	for _, id := range []string{"TDRC", "TRCK", "TPOS", "TBPM"} {
+		tag.SetFrameStatus(tag.Frame(id), ReadOnly)
+	}
+	for _, err := range []error{tag.SetYearInt(2014), tag.SetTrack(4, 10), tag.SetDisc(0, 0), tag.SetBPM(90)} {
+		if !errors.Is(err, ErrReadOnlyFrame) {
+			t.Errorf("expected ErrReadOnlyFrame, got %v", err)
+		}
+	}
+	if tag.Year() != "2013" || tag.frameText("TRCK") != "3/10" || tag.frameText("TPOS") != "1/2" || tag.frameText("TBPM") != "128" {
+		t.Errorf("expected read only frames unchanged")
+	}
+}
+
+func TestSetFrameText(t *testing.T) {
+	tag := NewTag(3)
+	if err := tag.SetFrameText("TT2", "Nice Life"); err != nil || tag.Title() != "Nice Life" {
+		t.Errorf("SetFrameText: expected an ID3v2.2 ID to set TIT2, got %q, %v", tag.Title(), err)
+	}
+	if err := tag.SetFrameText("TIT2", ""); err != nil || tag.Frame("TIT2") != nil {
+		t.Errorf("SetFrameText: expected empty text to remove the frame, %v", err)
+	}
+
+	tests := []struct {
+		id, text string
+		err      error
+	}{
+		{"TDRC", "2013", ErrFrameNotAllowed},
+		{"APIC", "cover", ErrBadValue},
+		{"TXXX", "Chill", ErrBadValue},
+	}
+	for _, test := range tests {
+		if err := tag.SetFrameText(test.id, test.text); !errors.Is(err, test.err) {
+			t.Errorf("SetFrameText(%s): expected %v, got %v", test.id, test.err, err)
+		}
+	}
+
+	v24 := NewTag(4)
+	if err := v24.SetFrameText("TDRC", "2013/11/25"); !errors.Is(err, ErrBadDate) {
+		t.Errorf("SetFrameText: expected timestamps to be checked, got %v", err)
+	}
+
+}
+
+func TestFrameStatus(t *testing.T) {
+	tag := NewTag(3)
+	tag.SetTitle("Nice Life")
+	title := tag.Frame("TIT2")
+
+	if err := tag.SetFrameStatus(title, ReadOnly); err != nil || title.StatusFlags() != 0x20 {
+		t.Fatalf("SetFrameStatus: expected the ID3v2.3 read only bit, got %#x, %v", title.StatusFlags(), err)
+	}
+	if err := tag.SetFrameText("TIT2", "Nicer Life"); !errors.Is(err, ErrReadOnlyFrame) {
+		t.Errorf("SetFrameText: expected ErrReadOnlyFrame, got %v", err)
+	}
+	if err := tag.SetAll(map[string]string{"title": "Nicer Life"}); !errors.Is(err, ErrReadOnlyFrame) {
+		t.Errorf("SetAll: expected ErrReadOnlyFrame, got %v", err)
+	}
+	if tag.SetTitle("Nicer Life"); tag.Title() != "Nice Life" {
+		t.Errorf("SetTitle: expected a read only title unchanged, got %q", tag.Title())
+	}
+	tag.SetFrameStatus(title, 0)
+	if tag.SetTitle("Nicer Life"); tag.Title() != "Nicer Life" {
+		t.Errorf("SetTitle: expected the title changed once the flag is cleared, got %q", tag.Title())
+	}
+
+	tag.SetRecordingDate(time.Date(2014, 3, 7, 0, 0, 0, 0, time.UTC), PrecisionDay)
+	tag.SetFrameStatus(tag.Frame("TYER"), ReadOnly)
+	if err := tag.SetRecordingDate(time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC), PrecisionYear); !errors.Is(err, ErrReadOnlyFrame) || tag.Year() != "2014" {
+		t.Errorf("SetRecordingDate: expected ErrReadOnlyFrame for a read only year, got %v", err)
+	}
+	if err := tag.SetRecordingDate(time.Time{}, PrecisionNone); !errors.Is(err, ErrReadOnlyFrame) || tag.Year() != "2014" {
+		t.Errorf("SetRecordingDate: expected ErrReadOnlyFrame removing a read only year, got %v", err)
+	}
+	tag.SetFrameStatus(tag.Frame("TYER"), 0)
+
+	ft, _ := lookupFrameType(3, "XSIG")
+	signature := NewDataFrame(ft, []byte("signed"))
+	measured := NewDataFrame(V23FrameTypeMap["MLLT"], []byte{1, 2, 3})
+	tag.AddFrames(signature, measured)
+	tag.SetFrameStatus(signature, DiscardOnTagAlteration)
+	tag.SetFrameStatus(title, DiscardOnTagAlteration|DiscardOnFileAlteration)
+	tag.SetFrameStatus(measured, DiscardOnFileAlteration)
+
+	data := tag.Bytes()
+	var written bytes.Buffer
+	if _, err := tag.WriteTo(&written); err != nil || !bytes.Equal(written.Bytes(), data) {
+		t.Errorf("WriteTo: expected the same bytes as Bytes, %v", err)
+	}
+	parsed := ParseTag(bytes.NewReader(data))
+	if parsed == nil || parsed.Frame("XSIG") != nil || parsed.Title() != "Nicer Life" {
+		t.Fatalf("Bytes: expected only the unknown frame left out of a changed tag")
+	}
+	if parsed.Size() != tag.Size() || parsed.Padding() != uint(FrameHeaderSize+len("signed")) {
+		t.Errorf("Bytes: expected the discarded frame written as padding, got %d bytes", parsed.Padding())
+	}
+
+	if _, dropped := tag.Convert(4); len(dropped) != 1 || dropped[0] != signature {
+		t.Errorf("Convert: expected the unknown frame dropped, got %v", dropped)
+	}
+
+	removed := tag.FileAltered()
+	if len(removed) != 2 || tag.Frame("MLLT") != nil || tag.Frame("TIT2") != nil {
+		t.Errorf("FileAltered: expected the title and MLLT removed, got %v", removed)
+	}
+
+	v24 := NewTag(4)
+	v24.SetTitle("Nice Life")
+	if v24.SetFrameStatus(v24.Frame("TIT2"), DiscardOnFileAlteration); v24.Frame("TIT2").StatusFlags() != 0x20 {
+		t.Errorf("SetFrameStatus: expected the ID3v2.4 file alter bit, got %#x", v24.Frame("TIT2").StatusFlags())
+	}
+	if status := v24.FrameStatus(v24.Frame("TIT2")); status != DiscardOnFileAlteration {
+		t.Errorf("FrameStatus: expected DiscardOnFileAlteration, got %v", status)
+	}
+
+	v22 := NewTag(2)
+	v22.SetTitle("Nice Life")
+	if err := v22.SetFrameStatus(v22.Frame("TT2"), ReadOnly); !errors.Is(err, ErrFlagNotAllowed) {
+		t.Errorf("SetFrameStatus: expected ErrFlagNotAllowed for ID3v2.2, got %v", err)
+	}
+}
+
+func TestExtendedHeader(t *testing.T) {
+	tag := NewTag(4)
+	tag.SetTitle("Nice Life")
+	tag.SetPadding(64)
+	extended := &ExtendedHeader{
+		Update:       true,
+		CRC:          true,
+		Restrictions: &Restrictions{TagSize: 3, TextEncoding: true, TextSize: 3},
+	}
+	if err := tag.SetExtended(extended); err != nil {
+		t.Fatal(err)
+	}
+
+	data := tag.Bytes()
+	var written bytes.Buffer
+	if _, err := tag.WriteTo(&written); err != nil || !bytes.Equal(written.Bytes(), data) {
+		t.Errorf("WriteTo: expected the same bytes as Bytes, %v", err)
+	}
+	if data[5]&FlagExtendedHeader == 0 || !bytes.Equal(data[HeaderSize:HeaderSize+6], []byte{0, 0, 0, 15, 1, 0x70}) {
+		t.Errorf("Bytes: expected an extended header with three flags, got %v", data[:HeaderSize+6])
+	}
+
+	parsed, err := ParseTagWithOptions(bytes.NewReader(data), ParseOptions{Strict: true})
+	if err != nil || parsed.Title() != "Nice Life" {
+		t.Fatalf("ParseTagWithOptions: expected the frames after the extended header, %v", err)
+	}
+	if e := parsed.Extended(); e == nil || !e.Update || !e.CRC || *e.Restrictions != *extended.Restrictions {
+		t.Errorf("Extended: expected the extended header read back, got %+v", e)
+	}
+	if parsed.Size() != tag.Size() || parsed.Padding() != tag.Padding() {
+		t.Errorf("ParseTagWithOptions: expected %d bytes with %d of padding, got %d and %d", tag.Size(), tag.Padding(), parsed.Size(), parsed.Padding())
+	}
+
+	data[len(data)-20] ^= 0xFF
+	if _, err := ParseTagWithOptions(bytes.NewReader(data), ParseOptions{Strict: true}); !errors.Is(err, ErrBadCRC) {
+		t.Errorf("ParseTagWithOptions: expected ErrBadCRC for a changed frame, got %v", err)
+	}
+
+	if err := tag.CheckRestrictions(); err != nil {
+		t.Errorf("CheckRestrictions: expected no violations, got %v", err)
+	}
+	// Padding counts towards the tag size
+	tag.SetPadding(4 << 10)
+	if err := tag.CheckRestrictions(); !errors.Is(err, ErrRestricted) || !strings.Contains(err.Error(), "bytes") {
+		t.Errorf("CheckRestrictions: expected the padded tag to be too large, got %v", err)
+	}
+	tag.SetPadding(64)
+	tag.SetArtist("Paloalto, Beenzino, Deepflow and Nuck Feat.")
+	tag.AddFrames(NewTextFrame(V24FrameTypeMap["TALB"], "Chillin", "UTF-16"))
+	err = tag.CheckRestrictions()
+	if !errors.Is(err, ErrRestricted) || !strings.Contains(err.Error(), "TPE1") || !strings.Contains(err.Error(), "UTF-16") {
+		t.Errorf("CheckRestrictions: expected the long artist and UTF-16 album, got %v", err)
+	}
+	if _, err := tag.WriteTo(io.Discard); err != nil {
+		t.Errorf("WriteTo: expected restrictions to be declared only, got %v", err)
+	}
+	extended.Enforce = true
+	tag.SetExtended(extended)
+	if _, err := tag.WriteTo(io.Discard); !errors.Is(err, ErrRestricted) {
+		t.Errorf("WriteTo: expected enforced restrictions, got %v", err)
+	}
+
+	size := tag.Size()
+	if tag.SetExtendedHeader(false); tag.Extended() != nil || tag.ExtendedHeader() || tag.Size() != size {
+		t.Errorf("SetExtendedHeader: expected the extended header removed into padding")
+	}
+	if err := NewTag(3).SetExtended(extended); !errors.Is(err, ErrFlagNotAllowed) {
+		t.Errorf("SetExtended: expected ErrFlagNotAllowed for ID3v2.3, got %v", err)
+	}
+}
+
+func TestWriteAppendedTo(t *testing.T) {
+	tag := NewTag(4)
+	tag.SetTitle("Appended")
+	tag.SetPadding(100)
+
+	var buf bytes.Buffer
+	n, err := tag.WriteAppendedTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 2*HeaderSize + tag.RealSize(); n != int64(want) || buf.Len() != want {
+		t.Errorf("WriteAppendedTo: expected %d bytes without padding, got %d", want, n)
+	}
+
+	data := append([]byte("audio"), buf.Bytes()...)
+	parsed, offset, err := ParseAppendedTag(bytes.NewReader(data), int64(len(data)), ParseOptions{})
+	if err != nil || parsed == nil {
+		t.Fatalf("ParseAppendedTag: expected a tag, got %v", err)
+	}
+	if offset != 5 || !parsed.Footer() || parsed.Title() != "Appended" {
+		t.Errorf("ParseAppendedTag: unexpected tag at %d, footer %v, title %q", offset, parsed.Footer(), parsed.Title())
+	}
+	if tag, _, _ := ParseAppendedTag(bytes.NewReader(data), int64(len(data)-1), ParseOptions{}); tag != nil {
+		t.Errorf("ParseAppendedTag: expected no tag without a footer")
+	}
+
+	reader := bytes.NewReader(buf.Bytes())
+	ParseTag(reader)
+	if pos, _ := reader.Seek(0, io.SeekCurrent); pos != int64(buf.Len()) {
+		t.Errorf("ParseTag: expected to skip the footer, stopped at %d", pos)
+	}
+	if b := parsed.Bytes(); b[5]&FlagFooter != 0 || len(b) != HeaderSize+parsed.Size() {
+		t.Errorf("Bytes: expected no footer")
+	}
+
+	if _, err := NewTag(3).WriteAppendedTo(&buf); err != ErrFlagNotAllowed {
+		t.Errorf("WriteAppendedTo: expected ErrFlagNotAllowed for ID3v2.3, got %v", err)
+	}
+
+	seek := NewTag(4)
+	seek.AddFrames(NewSeekFrame(1234))
+	if offset, ok := ParseTag(bytes.NewReader(seek.Bytes())).SeekOffset(); !ok || offset != 1234 {
+		t.Errorf("SeekOffset: expected 1234, got %d, %v", offset, ok)
+	}
+}
+
+func TestOriginal(t *testing.T) {
+	for _, version := range []byte{2, 3, 4} {
+		tag := NewTag(version)
+		tag.SetOriginalArtist("The Beatles")
+		tag.SetOriginalAlbum("Abbey Road")
+		tag.SetOriginalLyricist("George Harrison")
+		if err := tag.SetOriginalYear(1969); err != nil {
+			t.Fatal(err)
+		}
+
+		parsed := ParseTag(bytes.NewReader(tag.Bytes()))
+		year, err := parsed.OriginalYear()
+		if parsed.OriginalArtist() != "The Beatles" || parsed.OriginalAlbum() != "Abbey Road" || parsed.OriginalLyricist() != "George Harrison" || year != 1969 || err != nil {
+			t.Errorf("v2.%d: unexpected original %q %q %q %d, %v", version, parsed.OriginalArtist(), parsed.OriginalAlbum(), parsed.OriginalLyricist(), year, err)
+		}
+
+		yearId := map[byte]string{2: "TOR", 3: "TORY", 4: "TDOR"}[version]
+		if parsed.Frame(yearId) == nil {
+			t.Errorf("v2.%d: expected the year in %s", version, yearId)
+		}
+
+		tag.SetOriginalArtist("")
+		if err := tag.SetOriginalYear(0); err != nil {
+			t.Fatal(err)
+		}
+		if year, _ := tag.OriginalYear(); year != 0 || tag.OriginalArtist() != "" {
+			t.Errorf("v2.%d: expected the original artist and year removed", version)
+		}
+	}
+
+	tag := NewTag(3)
+	tag.AddFrames(NewTextFrame(V23FrameTypeMap["TORY"], "late sixties", "ISO-8859-1"))
+	if _, err := tag.OriginalYear(); !errors.Is(err, ErrBadDate) {
+		t.Errorf("OriginalYear: expected ErrBadDate, got %v", err)
+	}
+}
+
+func TestRadioStation(t *testing.T) {
+	tag := NewTag(4)
+	tag.SetRadioStation("Radio Paradise")
+	tag.SetRadioStationOwner("Bill and Rebecca Goldsmith")
+	if err := tag.SetRadioStationURL("https://radioparadise.com/"); err != nil {
+		t.Fatal(err)
+	}
+
+	parsed := ParseTag(bytes.NewReader(tag.Bytes()))
+	if parsed.RadioStation() != "Radio Paradise" || parsed.RadioStationOwner() != "Bill and Rebecca Goldsmith" || parsed.RadioStationURL() != "https://radioparadise.com/" {
+		t.Errorf("RadioStation: unexpected %q %q %q", parsed.RadioStation(), parsed.RadioStationOwner(), parsed.RadioStationURL())
+	}
+
+	if err := tag.SetRadioStationURL("radioparadise.com"); !errors.Is(err, ErrBadValue) {
+		t.Errorf("SetRadioStationURL: expected ErrBadValue for a relative URL, got %v", err)
+	}
+	if err := tag.SetRadioStationURL("https://例子.com/"); !errors.Is(err, ErrBadEncoding) {
+		t.Errorf("SetRadioStationURL: expected ErrBadEncoding, got %v", err)
+	}
+	if err := tag.SetRadioStationURL(""); err != nil || tag.Frame("WORS") != nil {
+		t.Errorf("SetRadioStationURL: expected the frame removed, got %v", err)
+	}
+
+	v22 := NewTag(2)
+	v22.SetRadioStation("Radio Paradise")
+	if v22.RadioStation() != "" || len(v22.AllFrames()) != 0 {
+		t.Errorf("SetRadioStation: expected nothing set in ID3v2.2")
+	}
+	if err := v22.SetRadioStationURL("https://radioparadise.com/"); !errors.Is(err, ErrFrameNotAllowed) {
+		t.Errorf("SetRadioStationURL: expected ErrFrameNotAllowed in ID3v2.2, got %v", err)
+	}
+}
+
+func TestProvenance(t *testing.T) {
+	p := Provenance{
+		EncoderSettings: "LAME 3.100 -V0",
+		EncodedBy:       "Mastering House",
+		FileOwner:       "Label Records",
+		FileType:        "MPG/3",
+		MediaType:       "DIG",
+	}
+
+	for _, version := range []byte{3, 4} {
+		tag := NewTag(version)
+		tag.SetProvenance(p)
+		if got := ParseTag(bytes.NewReader(tag.Bytes())).Provenance(); got != p {
+			t.Errorf("v2.%d: expected %+v, got %+v", version, p, got)
+		}
+
+		tag.SetProvenance(Provenance{EncodedBy: "Someone Else"})
+		if len(tag.AllFrames()) != 1 || tag.EncodedBy() != "Someone Else" {
+			t.Errorf("v2.%d: expected empty fields removed", version)
+		}
+	}
+
+	v22 := NewTag(2)
+	v22.SetProvenance(p)
+	if got := v22.Provenance(); got.FileOwner != "" || got.EncoderSettings != p.EncoderSettings || v22.Frame("TSS") == nil {
+		t.Errorf("v2.2: expected all but the file owner, got %+v", got)
+	}
+}
+
+func TestDescriptions(t *testing.T) {
+	for _, version := range []byte{3, 4} {
+		tag := NewTag(version)
+		tag.SetMood("Calm")
+		tag.SetSubtitle("Live at Wembley")
+		tag.SetContentGroup("Piano Concertos")
+
+		parsed := ParseTag(bytes.NewReader(tag.Bytes()))
+		if parsed.Mood() != "Calm" || parsed.Subtitle() != "Live at Wembley" || parsed.ContentGroup() != "Piano Concertos" {
+			t.Errorf("v2.%d: got %q, %q, %q", version, parsed.Mood(), parsed.Subtitle(), parsed.ContentGroup())
+		}
+		if !ValidFrameId(version, "TMOO") {
+			t.Errorf("v2.%d: expected TMOO to be valid", version)
+		}
+	}
+
+	v22 := NewTag(2)
+	v22.SetMood("Calm")
+	v22.SetSubtitle("Live")
+	if v22.Mood() != "" || v22.Frame("TT3") == nil {
+		t.Errorf("v2.2: expected TT3 without a mood")
+	}
+}
+
+func TestCommercialURLs(t *testing.T) {
+	tag := NewTag(3)
+	stores := []string{"https://store.example.com/album", "https://shop.example.org/a?id=1"}
+	if err := tag.SetCommercialURLs(stores...); err != nil {
+		t.Fatal(err)
+	}
+	if err := tag.SetPaymentURL("https://pay.example.com/"); err != nil {
+		t.Fatal(err)
+	}
+
+	parsed := ParseTag(bytes.NewReader(tag.Bytes()))
+	if got := parsed.CommercialURLs(); strings.Join(got, " ") != strings.Join(stores, " ") {
+		t.Errorf("expected %v, got %v", stores, got)
+	}
+	if got := parsed.PaymentURL(); got != "https://pay.example.com/" {
+		t.Errorf("expected payment URL, got %q", got)
+	}
+
+	if err := tag.SetCommercialURLs(stores[0], "store"); !errors.Is(err, ErrBadValue) {
+		t.Errorf("expected ErrBadValue for a relative URL, got %v", err)
+	}
+	if len(tag.CommercialURLs()) != 2 {
+		t.Errorf("expected a failed set to keep the frames")
+	}
+	if err := tag.SetCommercialURLs(); err != nil || tag.Frame("WCOM") != nil {
+		t.Errorf("expected the WCOM frames to be removed, got %v", err)
+	}
+
+	v22 := NewTag(2)
+	if err := v22.SetCommercialURLs(stores[0]); err != nil || v22.Frame("WCM") == nil {
+		t.Errorf("v2.2: expected a WCM frame, got %v", err)
+	}
+	if err := v22.SetPaymentURL("https://pay.example.com/"); !errors.Is(err, ErrFrameNotAllowed) {
+		t.Errorf("v2.2: expected ErrFrameNotAllowed, got %v", err)
+	}
+}
+
+func TestFrameAt(t *testing.T) {
+	tag := NewTag(3)
+	tag.SetTitle("Title")
+	tag.SetArtist("Artist")
+	tag.SetAlbum("Album")
+
+	all := tag.AllFrames()
+	if tag.NumFrames() != len(all) {
+		t.Fatalf("expected %d frames, got %d", len(all), tag.NumFrames())
+	}
+	for i, f := range all {
+		if tag.FrameAt(i) != f {
+			t.Errorf("frame %d: expected %s, got %v", i, f.Id(), tag.FrameAt(i))
+		}
+	}
+	if tag.FrameAt(-1) != nil || tag.FrameAt(len(all)) != nil {
+		t.Errorf("expected nil for out of range indexes")
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		for i := 0; i < tag.NumFrames(); i++ {
+			_ = tag.FrameAt(i).Id()
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations, got %v", allocs)
+	}
+}
+
+func TestDeleteFramesFunc(t *testing.T) {
+	tag := NewTag(3)
+	tag.SetTitle("Title")
+	norm, _ := NewCommentFrame("eng", "iTunNORM", " 000001F4 000001F4")
+	comment, _ := NewCommentFrame("eng", "", "Keep me")
+	tag.AddFrames(norm, comment)
+
+	expected := NewTag(3)
+	expected.SetTitle("Title")
+	keep, _ := NewCommentFrame("eng", "", "Keep me")
+	expected.AddFrames(keep)
+
+	deleted := tag.DeleteFramesFunc(func(f Framer) bool {
+		uf, ok := f.(*UnsynchTextFrame)
+		return ok && uf.Description() == "iTunNORM"
+	})
+	if len(deleted) != 1 || deleted[0] != norm {
+		t.Errorf("expected the iTunNORM comment to be deleted, got %v", deleted)
+	}
+	if tag.NumFrames() != 2 || tag.Comments()[0] != comment.String() {
+		t.Errorf("expected the title and other comment to be kept, got %v", tag.AllFrames())
+	}
+	if tag.RealSize() != expected.RealSize() {
+		t.Errorf("expected size %d, got %d", expected.RealSize(), tag.RealSize())
+	}
+
+	if deleted := tag.DeleteFramesFunc(func(Framer) bool { return false }); deleted != nil {
+		t.Errorf("expected nothing deleted, got %v", deleted)
+	}
+}
+
+func TestFramesMatching(t *testing.T) {
+	tag := NewTag(4)
+	tag.SetTitle("Title")
+	tag.SetFrameText("TPE1", "One\x00Two")
+	mood, _ := NewTXXXFrame("Mood", "Calm")
+	comment, _ := NewCommentFrame("eng", "", "Comment")
+	tag.AddFrames(mood, comment)
+	if err := tag.SetRadioStationURL("https://radio.example.com/"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := tag.FramesMatching("T"); len(got) != 3 {
+		t.Errorf("expected 3 text frames, got %v", got)
+	}
+	if got := tag.FramesMatching("W"); len(got) != 1 || got[0].Id() != "WORS" {
+		t.Errorf("expected the WORS frame, got %v", got)
+	}
+	if got := tag.FramesMatching("APIC"); got != nil {
+		t.Errorf("expected no frames, got %v", got)
+	}
+
+	expected := map[string][]string{"TIT2": {"Title"}, "TPE1": {"One", "Two"}}
+	if got := tag.TextFrameMap(); fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestFrameBy(t *testing.T) {
+	tag := NewTag(3)
+	norm, _ := NewCommentFrame("eng", "iTunNORM", " 000001F4")
+	comment, _ := NewCommentFrame("eng", "", "Comment")
+	mood, _ := NewTXXXFrame("Mood", "Calm")
+	tag.AddFrames(norm, comment, mood)
+
+	if f := tag.FrameBy("COMM", ""); f != comment {
+		t.Errorf("expected the comment without description, got %v", f)
+	}
+	if f := tag.FrameBy("COMM", "iTunNORM"); f != norm {
+		t.Errorf("expected the iTunNORM comment, got %v", f)
+	}
+	if f := tag.FrameBy("TXXX", "mood"); f != nil {
+		t.Errorf("expected descriptions to match exactly, got %v", f)
+	}
+	// ID3v2.2 IDs find the ID3v2.3 frames
+	if f := tag.FrameBy("TXX", "Mood"); f != mood {
+		t.Errorf("expected the Mood frame, got %v", f)
+	}
+
+	if deleted := tag.DeleteFrameBy("COMM", "iTunNORM"); len(deleted) != 1 || deleted[0] != norm {
+		t.Errorf("expected the iTunNORM comment deleted, got %v", deleted)
+	}
+	if tag.NumFrames() != 2 || tag.FrameBy("COMM", "") != comment {
+		t.Errorf("expected the other frames kept, got %v", tag.AllFrames())
+	}
+	if deleted := tag.DeleteFrameBy("TIT2", ""); deleted != nil {
+		t.Errorf("expected nothing deleted, got %v", deleted)
+	}
+}
+
+func TestParseTagTrustsNoSize(t *testing.T) {
+	// A header claiming the largest size the default limits allow, with no
+	// body after it
+	data := []byte{'I', 'D', '3', 3, 0, 0, 0x1F, 0x7F, 0x7F, 0x7F}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	tag, err := ParseTagWithOptions(bytes.NewReader(data), ParseOptions{})
+	runtime.ReadMemStats(&after)
+
+	if err != nil || tag == nil || len(tag.AllFrames()) != 0 {
+		t.Errorf("expected an empty tag, got %v, %v", tag, err)
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+		t.Errorf("expected the claimed size not to be allocated, allocated %d bytes", allocated)
+	}
+}
@@ -0,0 +1,389 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/lion187chen/id3-go/encodedbytes"
+)
+
+// JSONOptions control the JSON form of a tag
+type JSONOptions struct {
+	// Report only the size of binary payloads instead of their base64 data
+	OmitBinary bool
+	// Indent nested values for reading
+	Indent bool
+}
+
+// JSON document describing a tag
+type jsonTag struct {
+	Version string      `json:"version"`
+	Flags   byte        `json:"flags"`
+	Size    int         `json:"size"`
+	Padding uint        `json:"padding"`
+	Frames  []jsonFrame `json:"frames"`
+}
+
+// JSON document describing a frame
+// Only the fields that apply to the frame type are set
+type jsonFrame struct {
+	Id          string `json:"id"`
+	Name        string `json:"name,omitempty"`
+	StatusFlags byte   `json:"statusFlags,omitempty"`
+	FormatFlags byte   `json:"formatFlags,omitempty"`
+	Size        uint   `json:"size"`
+
+	Encoding    string `json:"encoding,omitempty"`
+	Language    string `json:"language,omitempty"`
+	Description string `json:"description,omitempty"`
+	Text        string `json:"text,omitempty"`
+	Owner       string `json:"owner,omitempty"`
+	MIMEType    string `json:"mimeType,omitempty"`
+	PictureType *byte  `json:"pictureType,omitempty"`
+
+	Chapter *jsonChapter `json:"chapter,omitempty"`
+	TOC     *jsonTOC     `json:"toc,omitempty"`
+
+	Data     []byte `json:"data,omitempty"`
+	DataSize int    `json:"dataSize,omitempty"`
+}
+
+type jsonChapter struct {
+	Element   string `json:"element"`
+	StartTime uint32 `json:"startTime"`
+	EndTime   uint32 `json:"endTime"`
+	StartByte uint32 `json:"startByte"`
+	EndByte   uint32 `json:"endByte"`
+	UseTime   bool   `json:"useTime"`
+	Title     string `json:"title,omitempty"`
+	Link      string `json:"link,omitempty"`
+	Image     string `json:"image,omitempty"`
+}
+
+type jsonTOC struct {
+	Element       string   `json:"element"`
+	TopLevel      bool     `json:"topLevel"`
+	Ordered       bool     `json:"ordered"`
+	ChildElements []string `json:"childElements"`
+}
+
+// Encodes the tag as JSON with binary payloads in base64
+func (t *Tag) MarshalJSON() ([]byte, error) {
+	return t.JSON(JSONOptions{})
+}
+
+// Encodes the tag as JSON
+func (t *Tag) JSON(opts JSONOptions) ([]byte, error) {
+	doc := jsonTag{
+		Version: t.Version(),
+		Flags:   t.flags,
+		Size:    t.Size(),
+		Padding: t.Padding(),
+	}
+
+	frames := t.AllFrames()
+	doc.Frames = make([]jsonFrame, 0, len(frames))
+	for _, f := range frames {
+		doc.Frames = append(doc.Frames, t.jsonFrame(f, opts))
+	}
+
+	if opts.Indent {
+		return json.MarshalIndent(doc, "", "  ")
+	}
+
+	return json.Marshal(doc)
+}
+
+func (t *Tag) jsonFrame(f Framer, opts JSONOptions) jsonFrame {
+	jf := jsonFrame{
+		Id:          f.Id(),
+		StatusFlags: f.StatusFlags(),
+		FormatFlags: f.FormatFlags(),
+		Size:        f.Size(),
+	}
+
+	if ft, ok := lookupFrameType(t.version, f.Id()); ok {
+		jf.Name = ft.description
+	}
+
+	var data []byte
+	switch f := f.(type) {
+	case *UnsynchTextFrame:
+		jf.Encoding = f.Encoding()
+		jf.Language = f.Language()
+		jf.Description = trimNull(f.Description())
+		jf.Text = trimNull(f.Text())
+	case *DescTextFrame:
+		jf.Encoding = f.Encoding()
+		jf.Description = trimNull(f.Description())
+		jf.Text = trimNull(f.Text())
+	case *TextFrame:
+		jf.Encoding = f.Encoding()
+		jf.Text = trimNull(f.Text())
+	case *CreditsFrame:
+		jf.Encoding = f.Encoding()
+		jf.Text = f.Text()
+	case *ImageFrame:
+		pictureType := f.PictureType()
+		jf.Encoding = f.Encoding()
+		jf.MIMEType = trimNull(f.MIMEType())
+		jf.PictureType = &pictureType
+		jf.Description = trimNull(f.Description())
+		data = f.Data()
+	case *IdFrame:
+		jf.Owner = trimNull(f.OwnerIdentifier())
+		data = f.Identifier()
+	case *ChapterFrame:
+		jf.Chapter = &jsonChapter{
+			Element:   f.Element,
+			StartTime: f.StartTime,
+			EndTime:   f.EndTime,
+			StartByte: f.StartByte,
+			EndByte:   f.EndByte,
+			UseTime:   f.UseTime,
+			Title:     trimNull(f.Title()),
+			Link:      trimNull(f.Link()),
+			Image:     f.ImageURL(),
+		}
+	case *TOCFrame:
+		jf.TOC = &jsonTOC{
+			Element:       f.Element,
+			TopLevel:      f.TopLevel,
+			Ordered:       f.Ordered,
+			ChildElements: f.ChildElements,
+		}
+	default:
+		data = f.Bytes()
+	}
+
+	if opts.OmitBinary {
+		jf.DataSize = len(data)
+	} else {
+		jf.Data = data
+	}
+
+	return jf
+}
+
+// Builds a tag from the JSON form produced by MarshalJSON
+func NewTagFromJSON(data []byte) (*Tag, error) {
+	t := new(Tag)
+	if err := t.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Replaces the contents of the tag with the JSON form produced by
+// MarshalJSON
+// Frames need an ID and the fields of their type; names, sizes and flags
+// of the tag header are ignored. Binary payloads must be present, so
+// documents written with OmitBinary cannot be imported.
+func (t *Tag) UnmarshalJSON(data []byte) error {
+	var doc jsonTag
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	var version, revision byte
+	if _, err := fmt.Sscanf(doc.Version, "2.%d.%d", &version, &revision); err != nil || version < 2 || version > 4 {
+		return fmt.Errorf("json: %w: %q", ErrUnsupportedVersion, doc.Version)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.init(version)
+	t.revision = revision
+
+	for _, jf := range doc.Frames {
+		frame, err := t.jsonToFrame(jf)
+		if err != nil {
+			return err
+		}
+		t.addFrames(frame)
+	}
+
+	t.sizeMu.Lock()
+	t.size += uint32(doc.Padding)
+	t.padding = doc.Padding
+	t.dirty = true
+	t.sizeMu.Unlock()
+
+	return nil
+}
+
+func (t *Tag) jsonToFrame(jf jsonFrame) (Framer, error) {
+	id := jf.Id
+	if len(id) != frameIdSize(t.version) || !validFrameId(id) {
+		return nil, fmt.Errorf("json: invalid frame id %q", id)
+	}
+
+	ft, _ := lookupFrameType(t.version, id)
+
+	switch id {
+	case "CHAP":
+		if c := jf.Chapter; c != nil {
+			cf := NewChapterFrame(ft, c.Element, c.StartTime, c.EndTime, c.StartByte, c.EndByte, c.UseTime, c.Title, c.Link, "")
+			cf.SetImageURL(c.Image)
+			return cf, nil
+		}
+	case "CTOC":
+		if c := jf.TOC; c != nil {
+			return NewTOCFrame(ft, c.Element, c.TopLevel, c.Ordered, c.ChildElements), nil
+		}
+	}
+
+	body, err := t.jsonFrameBody(jf)
+	if err != nil {
+		return nil, fmt.Errorf("json: frame %s: %w", id, err)
+	}
+
+	head := FrameHead{
+		FrameType:   ft,
+		statusFlags: jf.StatusFlags,
+		formatFlags: jf.FormatFlags,
+		size:        uint32(len(body)),
+	}
+
+	frame, err := newFrame(head, body)
+	if err != nil {
+		return nil, fmt.Errorf("json: frame %s: %w", id, err)
+	}
+
+	return frame, nil
+}
+
+// Encodes the fields of a JSON frame as a frame body
+func (t *Tag) jsonFrameBody(jf jsonFrame) ([]byte, error) {
+	id := jf.Id
+
+	var enc byte
+	switch {
+	case jf.Encoding != "":
+		if enc = encodedbytes.IndexForEncoding(jf.Encoding); enc == 0xFF {
+			return nil, ErrUnknownEncoding
+		}
+	case t.version == 4:
+		enc = encodedbytes.NativeEncoding
+	case isLatin1(jf.Text) && isLatin1(jf.Description):
+		enc = 0
+	default:
+		enc = encodedbytes.IndexForEncoding("UTF-16")
+	}
+
+	w := &bodyWriter{encoding: enc}
+
+	switch {
+	case id == "TXX" || id == "TXXX" || id == "WXX" || id == "WXXX":
+		w.byte(enc)
+		w.nullTermString(jf.Description)
+		w.nullTermString(jf.Text)
+	case id[0] == 'T', id == "IPL", id == "IPLS", id == "WFED":
+		w.byte(enc)
+		w.nullTermString(jf.Text)
+	case id == "COM" || id == "COMM" || id == "ULT" || id == "USLT":
+		language := jf.Language
+		if len(language) != 3 {
+			language = DefaultLanguage()
+		}
+		w.byte(enc)
+		w.raw([]byte(language))
+		w.nullTermString(jf.Description)
+		w.string(jf.Text)
+	case id == "PIC":
+		w.byte(enc)
+		w.raw([]byte(picFormat(jf.MIMEType)))
+		w.byte(pictureType(jf))
+		w.nullTermString(jf.Description)
+		w.raw(jf.Data)
+	case id == "APIC":
+		w.byte(enc)
+		w.raw(append([]byte(jf.MIMEType), 0))
+		w.byte(pictureType(jf))
+		w.nullTermString(jf.Description)
+		w.raw(jf.Data)
+	case id == "UFI" || id == "UFID":
+		w.raw(append([]byte(jf.Owner), 0))
+		w.raw(jf.Data)
+	case id[0] == 'W' && jf.Data == nil:
+		w.raw([]byte(jf.Text))
+	default:
+		w.raw(jf.Data)
+	}
+
+	if jf.Data == nil && jf.DataSize > 0 {
+		return nil, fmt.Errorf("binary data omitted")
+	}
+
+	return w.data, w.err
+}
+
+// Accumulates a frame body, keeping the first encoding error
+type bodyWriter struct {
+	data     []byte
+	encoding byte
+	err      error
+}
+
+func (w *bodyWriter) byte(b byte) {
+	w.data = append(w.data, b)
+}
+
+func (w *bodyWriter) raw(b []byte) {
+	w.data = append(w.data, b...)
+}
+
+func (w *bodyWriter) string(s string) {
+	b, err := encodedbytes.EncodedStringBytes(s, w.encoding)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	w.data = append(w.data, b...)
+}
+
+func (w *bodyWriter) nullTermString(s string) {
+	b, err := encodedbytes.EncodedNullTermStringBytes(s, w.encoding)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	w.data = append(w.data, b...)
+}
+
+func pictureType(jf jsonFrame) byte {
+	if jf.PictureType == nil {
+		return 0
+	}
+
+	return *jf.PictureType
+}
+
+// Three letter image format of ID3v2.2 pictures
+func picFormat(mimeType string) string {
+	switch mimeType = trimNull(mimeType); strings.ToLower(mimeType) {
+	case "image/jpeg", "image/jpg":
+		return "JPG"
+	case "image/png":
+		return "PNG"
+	case "-->":
+		return mimeType
+	}
+
+	format := strings.ToUpper(strings.TrimPrefix(mimeType, "image/")) + "   "
+	return format[:3]
+}
+
+func isLatin1(s string) bool {
+	for _, r := range s {
+		if r > 0xFF {
+			return false
+		}
+	}
+
+	return true
+}
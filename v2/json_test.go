@@ -0,0 +1,139 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestTagJSON(t *testing.T) {
+	tag := NewTag(3)
+	tag.SetTitle("Nice Life")
+	tag.AddFrames(
+		NewUnsynchTextFrame(V23FrameTypeMap["COMM"], "note", "Recorded live"),
+		NewImageFrame(V23FrameTypeMap["APIC"], "image/png", 3, "cover", []byte{0x89, 'P', 'N', 'G'}),
+	)
+
+	data, err := json.Marshal(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc jsonTag
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.Version != "2.3.0" || len(doc.Frames) != 3 {
+		t.Fatalf("JSON has version %s and %d frames", doc.Version, len(doc.Frames))
+	}
+
+	if f := doc.Frames[0]; f.Id != "TIT2" || f.Text != "Nice Life" || f.Name == "" {
+		t.Errorf("JSON title frame = %+v", f)
+	}
+
+	if f := doc.Frames[1]; f.Language != "eng" || f.Description != "note" || f.Text != "Recorded live" {
+		t.Errorf("JSON comment frame = %+v", f)
+	}
+
+	if f := doc.Frames[2]; f.MIMEType != "image/png" || f.PictureType == nil || *f.PictureType != 3 || string(f.Data) != "\x89PNG" {
+		t.Errorf("JSON picture frame = %+v", f)
+	}
+
+	data, err = tag.JSON(JSONOptions{OmitBinary: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc = jsonTag{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if f := doc.Frames[2]; f.Data != nil || f.DataSize != 4 {
+		t.Errorf("JSON without binary picture frame = %+v", f)
+	}
+}
+
+func TestTagFromJSON(t *testing.T) {
+	tag := NewTag(3)
+	tag.SetTitle("Nice Life")
+	tag.AddFrames(
+		NewUnsynchTextFrame(V23FrameTypeMap["COMM"], "note", "Recorded live"),
+		NewDescTextFrame(V23FrameTypeMap["TXXX"], "MOOD", "Chill", "ISO-8859-1"),
+		NewIdFrame(V23FrameTypeMap["UFID"], "http://musicbrainz.org", []byte("0123")),
+	)
+
+	data, err := json.Marshal(tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imported, err := NewTagFromJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	again, err := json.Marshal(imported)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var before, after jsonTag
+	json.Unmarshal(data, &before)
+	json.Unmarshal(again, &after)
+
+	if len(before.Frames) != len(after.Frames) {
+		t.Fatalf("JSON round trip has %d frames, want %d", len(after.Frames), len(before.Frames))
+	}
+
+	// Sizes may differ where the original frame was written with extra nulls
+	for i := range before.Frames {
+		before.Frames[i].Size, after.Frames[i].Size = 0, 0
+		if b, a := fmt.Sprint(before.Frames[i]), fmt.Sprint(after.Frames[i]); b != a {
+			t.Errorf("JSON round trip changed frame %s to %s", b, a)
+		}
+	}
+
+	doc := []byte(`{
+		"version": "2.3.0",
+		"frames": [
+			{"id": "TPE1", "text": "팔로알토"},
+			{"id": "COMM", "language": "kor", "text": "Hello"},
+			{"id": "APIC", "mimeType": "image/png", "pictureType": 3, "data": "iVBORw=="}
+		]
+	}`)
+
+	imported, err = NewTagFromJSON(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tf, ok := imported.Frame("TPE1").(*TextFrame); !ok || tf.Encoding() != "UTF-16" || trimNull(tf.Text()) != "팔로알토" {
+		t.Errorf("imported artist frame = %v", imported.Frame("TPE1"))
+	}
+
+	if imf, ok := imported.Frame("APIC").(*ImageFrame); !ok || imf.PictureType() != 3 || len(imf.Data()) != 4 {
+		t.Errorf("imported picture frame = %v", imported.Frame("APIC"))
+	}
+
+	parsed := ParseTag(bytes.NewReader(imported.Bytes()))
+	if parsed == nil || len(parsed.AllFrames()) != 3 {
+		t.Errorf("imported tag does not parse back")
+	}
+
+	for _, bad := range []string{
+		`{"version": "2.5.0"}`,
+		`{"version": "2.3.0", "frames": [{"id": "TT2"}]}`,
+		`{"version": "2.3.0", "frames": [{"id": "APIC", "dataSize": 4}]}`,
+		`{"version": "2.3.0", "frames": [{"id": "TIT2", "encoding": "EBCDIC"}]}`,
+	} {
+		if _, err := NewTagFromJSON([]byte(bad)); err == nil {
+			t.Errorf("NewTagFromJSON(%s) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import "log/slog"
+
+// Sets the logger that recoverable problems are reported to, nil for none
+// Tags parsed with ParseOptions.Logger already use that logger. Problems are
+// logged at warning level, so that services can watch the quality of the tags
+// they read without failing on them.
+func (t *Tag) SetLogger(logger *slog.Logger) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.logger = logger
+}
+
+// Logs a problem the parser recovered from, offset is from the tag body
+func (t *Tag) logAnomaly(offset int, id string, err error) {
+	if t.logger == nil {
+		return
+	}
+
+	attrs := []any{slog.Int("offset", HeaderSize+offset), slog.Any("err", err)}
+	if id != "" {
+		attrs = append(attrs, slog.String("frame", id))
+	}
+	t.logger.Warn("id3v2: recovered from malformed tag", attrs...)
+}
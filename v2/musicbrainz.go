@@ -0,0 +1,96 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"strings"
+)
+
+// Owner of the UFID frame holding the MusicBrainz recording ID
+const MusicBrainzOwner = "http://musicbrainz.org"
+
+// MusicBrainzIDs holds the identifiers MusicBrainz Picard writes
+// The recording ID is stored in a UFID frame, the others in user defined
+// text frames.
+type MusicBrainzIDs struct {
+	Recording    string
+	Track        string
+	Release      string
+	ReleaseGroup string
+	Work         string
+	Artists      []string
+	AlbumArtists []string
+}
+
+// Descriptions of the user defined text frames
+const (
+	mbTrack        = "MusicBrainz Release Track Id"
+	mbRelease      = "MusicBrainz Album Id"
+	mbReleaseGroup = "MusicBrainz Release Group Id"
+	mbWork         = "MusicBrainz Work Id"
+	mbArtists      = "MusicBrainz Artist Id"
+	mbAlbumArtists = "MusicBrainz Album Artist Id"
+)
+
+// Reports whether no identifier is set
+func (ids MusicBrainzIDs) IsZero() bool {
+	return ids.Recording == "" && ids.Track == "" && ids.Release == "" &&
+		ids.ReleaseGroup == "" && ids.Work == "" && len(ids.Artists) == 0 &&
+		len(ids.AlbumArtists) == 0
+}
+
+// MusicBrainz identifiers of the tag
+func (t *Tag) MusicBrainzIDs() MusicBrainzIDs {
+	ids := MusicBrainzIDs{
+		Track:        t.userText(mbTrack),
+		Release:      t.userText(mbRelease),
+		ReleaseGroup: t.userText(mbReleaseGroup),
+		Work:         t.userText(mbWork),
+		Artists:      splitIDs(t.userText(mbArtists)),
+		AlbumArtists: splitIDs(t.userText(mbAlbumArtists)),
+	}
+
+	for _, f := range t.Frames(convertFrameId("UFID", 3, t.version)) {
+		if idf, ok := f.(*IdFrame); ok && trimNull(idf.OwnerIdentifier()) == MusicBrainzOwner {
+			ids.Recording = string(idf.Identifier())
+		}
+	}
+
+	return ids
+}
+
+// Replaces the MusicBrainz identifiers of the tag
+// Empty fields remove their frames. Multiple artist IDs are separated by
+// slashes before ID3v2.4, as Picard writes them.
+func (t *Tag) SetMusicBrainzIDs(ids MusicBrainzIDs) {
+	sep := "/"
+	if t.version >= 4 {
+		sep = "\x00"
+	}
+
+	t.setUserText(mbTrack, ids.Track)
+	t.setUserText(mbRelease, ids.Release)
+	t.setUserText(mbReleaseGroup, ids.ReleaseGroup)
+	t.setUserText(mbWork, ids.Work)
+	t.setUserText(mbArtists, strings.Join(ids.Artists, sep))
+	t.setUserText(mbAlbumArtists, strings.Join(ids.AlbumArtists, sep))
+
+	id := convertFrameId("UFID", 3, t.version)
+	for _, f := range t.Frames(id) {
+		if idf, ok := f.(*IdFrame); ok && trimNull(idf.OwnerIdentifier()) == MusicBrainzOwner {
+			t.DeleteFrame(f)
+		}
+	}
+
+	if ids.Recording != "" {
+		ft, _ := lookupFrameType(t.version, id)
+		t.AddFrames(NewIdFrame(ft, MusicBrainzOwner, []byte(ids.Recording)))
+	}
+}
+
+func splitIDs(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return r == 0 || r == '/'
+	})
+}
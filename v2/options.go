@@ -0,0 +1,91 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"log/slog"
+
+	"golang.org/x/text/encoding"
+)
+
+const (
+	// Default limits applied when the corresponding ParseOptions field is zero
+	DefaultMaxTagSize    = 64 << 20
+	DefaultMaxFrameSize  = 32 << 20
+	DefaultMaxFrameCount = 4096
+)
+
+// ParseOptions controls how tags are read
+//
+// Limits guard against malicious files declaring huge tags or frames.
+// A zero limit uses the default, a negative limit disables the check.
+//
+// In lenient mode malformed frames are recorded as warnings on the tag and
+// skipped instead of ending the frame list. In strict mode anything off-spec
+// fails the parse with a *SpecError; strict takes precedence over lenient.
+//
+// With Resync a damaged frame header no longer ends the frame list: the
+// parser scans forward for the next plausible frame and carries on from
+// there. Combine it with Lenient to get a warning for each skipped region.
+//
+// Latin1Encoding decodes text declared as ISO-8859-1 with a legacy code page
+// instead, see ReinterpretTextAs. Without it, DetectCharset guesses the code
+// page of text that is implausible as ISO-8859-1, see FixCharset.
+//
+// Logger receives every problem the parser recovers from, in any mode, and
+// stays with the tag, see SetLogger.
+//
+// With Preserve the tag keeps the bytes it was parsed from and writes them
+// unchanged until it is edited, so that frame order, flags, encodings and
+// padding survive a round trip exactly. Once the tag is edited, each frame
+// that was not changed, including unknown frames, is still written as it was
+// read. Tags whose text was reinterpreted through Latin1Encoding or
+// DetectCharset are not preserved.
+type ParseOptions struct {
+	MaxTagSize    int
+	MaxFrameSize  int
+	MaxFrameCount int
+	Lenient       bool
+	Strict        bool
+	Resync        bool
+
+	Latin1Encoding encoding.Encoding
+	DetectCharset  bool
+
+	Logger *slog.Logger
+
+	Preserve bool
+}
+
+func limit(value, def int) int {
+	if value == 0 {
+		return def
+	}
+
+	return value
+}
+
+func (o ParseOptions) checkTagSize(size uint32) error {
+	if max := limit(o.MaxTagSize, DefaultMaxTagSize); max >= 0 && int64(size) > int64(max) {
+		return &LimitError{Err: ErrTagTooLarge, Value: int64(size), Max: int64(max)}
+	}
+
+	return nil
+}
+
+func (o ParseOptions) checkFrameSize(head FrameHead) error {
+	if max := limit(o.MaxFrameSize, DefaultMaxFrameSize); max >= 0 && int64(head.size) > int64(max) {
+		return &LimitError{Err: ErrFrameTooLarge, FrameId: head.Id(), Value: int64(head.size), Max: int64(max)}
+	}
+
+	return nil
+}
+
+func (o ParseOptions) checkFrameCount(count int) error {
+	if max := limit(o.MaxFrameCount, DefaultMaxFrameCount); max >= 0 && count > max {
+		return &LimitError{Err: ErrTooManyFrames, Value: int64(count), Max: int64(max)}
+	}
+
+	return nil
+}
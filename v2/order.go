@@ -0,0 +1,116 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"sort"
+	"strings"
+)
+
+// Writes frames in canonical order instead of the order they were added
+// Identifier frames come first, then text frames and URL frames by ID, other
+// frames by ID, and pictures and encapsulated objects last. Frames with the
+// same ID are ordered by description. Tags written the same way from the
+// same content are then identical, which keeps diffs of tagged files stable.
+// The frames are not reordered in the tag itself.
+func (t *Tag) SetCanonicalOrder(canonical bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.canonicalOrder = canonical
+
+	t.sizeMu.Lock()
+	t.dirty = true
+	t.sizeMu.Unlock()
+}
+
+// Writes frames with the given IDs before all others, in the order of the
+// IDs, as some players only honor the first APIC or COMM frame
+// Frames with the same ID keep their order, as do the remaining frames, which
+// follow in the canonical order if it is set. IDs of any version match their
+// equivalents, so "APIC" also pins PIC frames. Calling it without IDs removes
+// the pins. The frames are not reordered in the tag itself.
+func (t *Tag) PinFirst(ids ...string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pinned = append([]string(nil), ids...)
+
+	t.sizeMu.Lock()
+	t.dirty = true
+	t.sizeMu.Unlock()
+}
+
+// Frames in the order they are written, the caller must hold mu
+func (t *Tag) orderedFrames() []Framer {
+	frames := t.frames
+	if t.canonicalOrder {
+		frames = canonicalOrder(frames)
+	}
+	if len(t.pinned) > 0 {
+		frames = pinFirst(frames, t.pinned)
+	}
+
+	return frames
+}
+
+// Frames with the pinned IDs moved to the front, leaving frames untouched
+func pinFirst(frames []Framer, ids []string) []Framer {
+	aliases := make([][]string, len(ids))
+	for i, id := range ids {
+		aliases[i] = frameIdAliases(id)
+	}
+
+	rank := func(f Framer) int {
+		for i := range ids {
+			if contains(aliases[i], f.Id()) {
+				return i
+			}
+		}
+		return len(ids)
+	}
+
+	sorted := append([]Framer(nil), frames...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return rank(sorted[i]) < rank(sorted[j])
+	})
+
+	return sorted
+}
+
+// Position of a frame's group in the canonical order
+func frameGroup(id string) int {
+	switch {
+	case id == "UFI" || id == "UFID":
+		return 0
+	case id == "APIC" || id == "PIC" || id == "GEOB" || id == "GEO":
+		return 4
+	case strings.HasPrefix(id, "T"):
+		return 1
+	case strings.HasPrefix(id, "W"):
+		return 2
+	}
+
+	return 3
+}
+
+// Frames sorted into canonical order, leaving frames untouched
+func canonicalOrder(frames []Framer) []Framer {
+	sorted := append([]Framer(nil), frames...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].Id(), sorted[j].Id()
+		if ga, gb := frameGroup(a), frameGroup(b); ga != gb {
+			return ga < gb
+		}
+		if a != b {
+			return a < b
+		}
+
+		ka, _ := uniqueKey(sorted[i])
+		kb, _ := uniqueKey(sorted[j])
+		return ka < kb
+	})
+
+	return sorted
+}
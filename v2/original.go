@@ -0,0 +1,53 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import "time"
+
+// Performer of the original recording of a cover or remake, from TOPE
+func (t *Tag) OriginalArtist() string {
+	return t.frameText("TOPE")
+}
+
+func (t *Tag) SetOriginalArtist(artist string) {
+	t.setFrameText("TOPE", artist)
+}
+
+// Album the original recording was released on, from TOAL
+func (t *Tag) OriginalAlbum() string {
+	return t.frameText("TOAL")
+}
+
+func (t *Tag) SetOriginalAlbum(album string) {
+	t.setFrameText("TOAL", album)
+}
+
+// Lyricist of the original recording, from TOLY
+func (t *Tag) OriginalLyricist() string {
+	return t.frameText("TOLY")
+}
+
+func (t *Tag) SetOriginalLyricist(lyricist string) {
+	t.setFrameText("TOLY", lyricist)
+}
+
+// Year the original recording was released, from TDOR or TORY
+// Returns 0 when there is none, along with an error when it is malformed.
+func (t *Tag) OriginalYear() (int, error) {
+	date, p, err := t.OriginalReleaseDate()
+	if err != nil || p == PrecisionNone {
+		return 0, err
+	}
+
+	return date.Year(), nil
+}
+
+// Sets the original release date to the year, 0 removes it
+func (t *Tag) SetOriginalYear(year int) error {
+	if year == 0 {
+		return t.SetOriginalReleaseDate(time.Time{}, PrecisionNone)
+	}
+
+	return t.SetOriginalReleaseDate(time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC), PrecisionYear)
+}
@@ -0,0 +1,359 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/lion187chen/id3-go/encodedbytes"
+)
+
+// ParseWarning describes a problem that was recovered from in lenient mode
+type ParseWarning struct {
+	// Offset of the frame header from the start of the tag header
+	Offset  int
+	FrameId string
+	Err     error
+}
+
+func (w ParseWarning) String() string {
+	if w.FrameId == "" {
+		return fmt.Sprintf("offset %d: %v", w.Offset, w.Err)
+	}
+
+	return fmt.Sprintf("offset %d: %s: %v", w.Offset, w.FrameId, w.Err)
+}
+
+func (t *Tag) warn(offset int, id string, err error) {
+	t.warnings = append(t.warnings, ParseWarning{
+		Offset:  HeaderSize + offset,
+		FrameId: id,
+		Err:     err,
+	})
+	t.logAnomaly(offset, id, err)
+}
+
+// Handles a malformed frame according to the parse mode
+// A non-nil error aborts the parse
+func (t *Tag) problem(opts ParseOptions, offset int, id string, err error) error {
+	if opts.Strict {
+		return &SpecError{Offset: HeaderSize + offset, FrameId: id, Err: err}
+	}
+
+	if opts.Lenient {
+		t.warn(offset, id, err)
+	} else {
+		t.logAnomaly(offset, id, err)
+	}
+
+	return nil
+}
+
+// Parses the frames of the tag body from start, after any extended header
+// Limit violations and, in strict mode, spec violations are returned as
+// errors. Anything else ends the frame list, or is recorded as a warning and
+// skipped in lenient mode. Frames with unknown IDs are always kept as binary
+// data. With resync enabled, damaged frame headers are skipped by scanning
+// for the next plausible frame.
+func (t *Tag) parseFrames(data []byte, start int, opts ParseOptions) error {
+	// The extended header counts as it will be written back
+	used := t.extended.size()
+	offset := start
+	seen := make(map[string]bool)
+
+	// Moves offset past damaged bytes, reports whether parsing can go on
+	resync := func() bool {
+		if !opts.Resync {
+			return false
+		}
+
+		next := t.nextFrame(data, offset+1)
+		if next < 0 {
+			return false
+		}
+
+		t.problem(opts, offset, "", fmt.Errorf("%w: skipped %d bytes", ErrResynchronized, next-offset))
+		offset = next
+		return true
+	}
+
+frames:
+	for offset < int(t.size) {
+		if offset+t.frameHeaderSize > len(data) {
+			if !isZero(data[offset:]) {
+				if err := t.problem(opts, offset, "", ErrTruncatedFrame); err != nil {
+					return err
+				}
+			}
+			break
+		}
+
+		// Frame IDs never start with a null byte
+		if data[offset] == 0 {
+			if isZero(data[offset:]) {
+				break
+			}
+			if opts.Strict {
+				return t.problem(opts, offset, "", ErrBadPadding)
+			}
+			t.logAnomaly(offset, "", ErrBadPadding)
+			if resync() {
+				continue
+			}
+			break
+		}
+
+		head, err := t.frameHeadParser(data[offset : offset+t.frameHeaderSize])
+		unknown := err == ErrUnknownFrameId
+		if err == errPadding {
+			break
+		} else if err == ErrUnknownFrameId && opts.Strict && ValidFrameId(t.version, head.Id()) {
+			// Experimental frames are kept as binary data
+			err = nil
+		} else if err != nil {
+			// Unknown IDs and plain integer sizes still give a usable head
+			recoverable := err == ErrUnknownFrameId || err == ErrNotSynchsafe
+			// Unknown frames are kept as binary data so that a rewrite
+			// carries them through
+			keep := err == ErrUnknownFrameId || opts.Lenient
+			if err := t.problem(opts, offset, head.Id(), err); err != nil {
+				return err
+			}
+			if !recoverable || !keep {
+				t.stats.FramesSkipped++
+				if resync() {
+					continue
+				}
+				break
+			}
+		}
+
+		if err := opts.checkFrameSize(head); err != nil {
+			return err
+		}
+
+		start := offset + t.frameHeaderSize
+		end := start + int(head.size)
+		truncated := end > len(data)
+		if truncated {
+			cause := ErrTruncatedFrame
+			if end > int(t.size) {
+				cause = ErrBadFrameSize
+			}
+			if err := t.problem(opts, offset, head.Id(), cause); err != nil {
+				return err
+			}
+
+			switch {
+			case cause == ErrBadFrameSize && resync():
+				t.stats.FramesSkipped++
+				continue frames
+			case !opts.Lenient:
+				t.stats.FramesSkipped++
+				break frames
+			}
+
+			end = len(data)
+			head.size = uint32(end - start)
+		}
+
+		head.raw = data[offset:end:end]
+		if opts.Preserve && !truncated {
+			head.preserved, head.preservedVersion = head.raw, t.version
+		}
+		if opaqueBody(t.version, head.formatFlags) {
+			// Compressed, encrypted or grouped bodies are kept as they are
+			head.constructor = ParseDataFrame
+		}
+
+		frame, err := newFrame(head, data[start:end])
+		if err != nil {
+			if err := t.problem(opts, offset, head.Id(), diagnoseFrame(head, data[start:end])); err != nil {
+				return err
+			}
+			if !opts.Lenient {
+				t.stats.FramesSkipped++
+				break
+			}
+
+			// Keep the raw body so that nothing is lost on write
+			frame = ParseDataFrame(head, data[start:end])
+		}
+
+		if opts.Strict {
+			if err := checkFrameSpec(t.version, frame); err != nil {
+				return t.problem(opts, offset, head.Id(), err)
+			}
+
+			if key, unique := uniqueKey(frame); unique {
+				if seen[key] {
+					return t.problem(opts, offset, head.Id(), ErrDuplicateFrame)
+				}
+				seen[key] = true
+			}
+			if key, unique := pictureTypeKey(frame); unique {
+				if seen[key] {
+					return t.problem(opts, offset, head.Id(), ErrDuplicateFrame)
+				}
+				seen[key] = true
+			}
+		}
+
+		if err := opts.checkFrameCount(len(t.frames) + 1); err != nil {
+			return err
+		}
+
+		t.checkByteOrder(offset, head.Id(), data[start:end])
+		t.frames = append(t.frames, frame)
+		frame.setOwner(t)
+		t.stats.count(frame, data[start:end], unknown)
+
+		offset = end
+		used += t.frameHeaderSize + int(frame.Size())
+	}
+
+	// Skipped bytes become padding when the tag is written back
+	if used > int(t.size) {
+		t.size = uint32(used)
+	}
+	t.padding = uint(int(t.size) - used)
+	t.stats.PaddingBytes = int(t.padding)
+	return nil
+}
+
+// Records a warning for UTF-16 text whose byte order had to be guessed
+func (t *Tag) checkByteOrder(offset int, id string, body []byte) {
+	if !hasEncodingByte(id) || len(body) < 3 || body[0] != 1 {
+		return
+	}
+
+	text := body[1:]
+	switch id {
+	case "COM", "COMM", "ULT", "USLT":
+		text = text[min(3, len(text)):]
+	case "PIC", "APIC", "GEO", "GEOB":
+		// The text follows fields of other encodings
+		return
+	}
+
+	if encodedbytes.MissingUTF16BOM(text) {
+		order := "big-endian"
+		if encodedbytes.GuessUTF16LittleEndian(text) {
+			order = "little-endian"
+		}
+		t.warn(offset, id, fmt.Errorf("%w, read as %s", ErrNoByteOrderMark, order))
+	}
+}
+
+// Offset of the next plausible frame header at or after offset, -1 if none
+func (t *Tag) nextFrame(data []byte, offset int) int {
+	for i := offset; i+t.frameHeaderSize <= len(data); i++ {
+		if t.plausibleFrame(data, i) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// A plausible frame has a known ID and a size that fits in the tag, and is
+// followed by the end of the tag, padding or another known frame ID
+func (t *Tag) plausibleFrame(data []byte, offset int) bool {
+	head, err := t.frameHeadParser(data[offset : offset+t.frameHeaderSize])
+	if err != nil || head.size == 0 || !ValidFrameId(t.version, head.Id()) {
+		return false
+	}
+
+	next := offset + t.frameHeaderSize + int(head.size)
+	if next > len(data) {
+		return false
+	}
+	if next+t.frameHeaderSize > len(data) || data[next] == 0 {
+		return true
+	}
+
+	nextHead, err := t.frameHeadParser(data[next : next+t.frameHeaderSize])
+	return err == nil && ValidFrameId(t.version, nextHead.Id())
+}
+
+// Scratch buffers for reading tag and frame headers, which are no larger
+// than the tag header
+var headerBuffers = sync.Pool{
+	New: func() interface{} {
+		return new([HeaderSize]byte)
+	},
+}
+
+// Reads a single frame using a version specific header parser
+func readFrame(reader io.Reader, headerSize int, parseHead func([]byte) (FrameHead, error), opts ParseOptions) (Framer, error) {
+	buf := headerBuffers.Get().(*[HeaderSize]byte)
+	defer headerBuffers.Put(buf)
+
+	data := buf[:headerSize]
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return nil, err
+	}
+
+	head, err := parseHead(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := opts.checkFrameSize(head); err != nil {
+		return nil, err
+	}
+
+	// Read incrementally rather than allocating the size the header claims
+	var raw bytes.Buffer
+	raw.Write(data)
+	if n, err := io.CopyN(&raw, reader, int64(head.size)); err != nil {
+		if err == io.EOF && n > 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	head.raw = raw.Bytes()
+
+	return newFrame(head, head.raw[headerSize:])
+}
+
+func newFrame(head FrameHead, data []byte) (Framer, error) {
+	if head.constructor == nil {
+		return nil, ErrInvalidFrame
+	}
+
+	frame := head.constructor(head, data)
+	if frame == nil {
+		return nil, ErrInvalidFrame
+	}
+
+	return frame, nil
+}
+
+// Works out why a frame body could not be parsed
+func diagnoseFrame(head FrameHead, data []byte) error {
+	if hasEncodingByte(head.Id()) && len(data) > 0 && int(data[0]) >= 4 {
+		return ErrUnknownEncoding
+	}
+
+	return ErrInvalidFrame
+}
+
+// Frames whose body starts with a text encoding byte
+func hasEncodingByte(id string) bool {
+	if strings.HasPrefix(id, "T") {
+		return true
+	}
+
+	switch id {
+	case "COM", "COMM", "ULT", "USLT", "PIC", "APIC", "GEO", "GEOB", "WXX", "WXXX", "USER", "IPL", "IPLS", "WFED":
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,336 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"strings"
+)
+
+// Picture types of attached picture frames
+const (
+	PictureOther byte = iota
+	// 32x32 pixel PNG file icon, only one allowed
+	PictureFileIcon
+	// Only one allowed
+	PictureOtherFileIcon
+	PictureFrontCover
+	PictureBackCover
+	PictureLeaflet
+	PictureMedia
+	PictureLeadArtist
+	PictureArtist
+	PictureConductor
+	PictureBand
+	PictureComposer
+	PictureLyricist
+	PictureRecordingLocation
+	PictureDuringRecording
+	PictureDuringPerformance
+	PictureVideoCapture
+	PictureBrightFish
+	PictureIllustration
+	PictureBandLogo
+	PicturePublisherLogo
+)
+
+var pictureTypeNames = []string{
+	"Other",
+	"32x32 pixels file icon",
+	"Other file icon",
+	"Cover (front)",
+	"Cover (back)",
+	"Leaflet page",
+	"Media",
+	"Lead artist/lead performer/soloist",
+	"Artist/performer",
+	"Conductor",
+	"Band/Orchestra",
+	"Composer",
+	"Lyricist/text writer",
+	"Recording Location",
+	"During recording",
+	"During performance",
+	"Movie/video screen capture",
+	"A bright coloured fish",
+	"Illustration",
+	"Band/artist logotype",
+	"Publisher/Studio logotype",
+}
+
+// Description of a picture type from the spec
+func PictureTypeName(pictureType byte) string {
+	if int(pictureType) < len(pictureTypeNames) {
+		return pictureTypeNames[pictureType]
+	}
+	return fmt.Sprintf("Unknown (%d)", pictureType)
+}
+
+// Key of picture types the spec allows only once per tag
+func pictureTypeKey(f Framer) (string, bool) {
+	imf, ok := f.(*ImageFrame)
+	if !ok || (imf.pictureType != PictureFileIcon && imf.pictureType != PictureOtherFileIcon) {
+		return "", false
+	}
+
+	return fmt.Sprintf("%s\x01%d", f.Id(), imf.pictureType), true
+}
+
+// Picture is the content of an attached picture frame
+type Picture struct {
+	MIMEType    string
+	Type        byte
+	Description string
+	Data        []byte
+	// Dimensions read from the image header, zero when the format is not
+	// recognized or the picture is a link
+	Width  int
+	Height int
+}
+
+// Reports whether the picture data is a URL rather than an image
+func (p Picture) IsLink() bool {
+	return p.MIMEType == imageLinkMIMEType
+}
+
+// Decodes the image, which must be JPEG, PNG or GIF
+func (p Picture) Image() (image.Image, error) {
+	if p.IsLink() {
+		return nil, errors.New("picture: image is a link")
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(p.Data))
+	if err == image.ErrFormat {
+		return nil, ErrImageFormat
+	}
+	return img, err
+}
+
+// Encodes an image as "jpeg" or "png", given as a name or MIME type
+func encodeImage(img image.Image, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch strings.TrimPrefix(strings.ToLower(format), "image/") {
+	case "jpeg", "jpg":
+		err = jpeg.Encode(&buf, img, nil)
+	case "png":
+		err = png.Encode(&buf, img)
+	default:
+		return nil, fmt.Errorf("picture: cannot encode %s images", format)
+	}
+
+	return buf.Bytes(), err
+}
+
+// Returns the image format of data as a MIME type, "" when not recognized
+// JPEG, PNG, GIF, WebP and BMP are recognized.
+func SniffMIMEType(data []byte) string {
+	return sniffMIMEType(data)
+}
+
+// Checks that a declared MIME type matches the image data
+// Returns ErrImageFormat when the data is not a recognized image and
+// ErrMIMEMismatch when it is another format.
+func CheckMIMEType(mimeType string, data []byte) error {
+	sniffed := sniffMIMEType(data)
+	if sniffed == "" {
+		return ErrImageFormat
+	}
+
+	mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+	if mimeType != sniffed && !(mimeType == "image/jpg" && sniffed == "image/jpeg") {
+		return fmt.Errorf("%w: declared %s, data is %s", ErrMIMEMismatch, mimeType, sniffed)
+	}
+
+	return nil
+}
+
+// Width and height from the image header without decoding the pixels
+func imageSize(data []byte) (int, int) {
+	switch sniffMIMEType(data) {
+	case "image/webp":
+		return webpSize(data)
+	case "image/bmp":
+		// Width and height of the BITMAPINFOHEADER, height is negative for
+		// top-down images
+		if len(data) < 26 {
+			return 0, 0
+		}
+		w := int32(binary.LittleEndian.Uint32(data[18:]))
+		h := int32(binary.LittleEndian.Uint32(data[22:]))
+		if h < 0 {
+			h = -h
+		}
+		return int(w), int(h)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0
+	}
+	return config.Width, config.Height
+}
+
+// Width and height of lossy, lossless and extended WebP images
+func webpSize(data []byte) (int, int) {
+	if len(data) < 30 {
+		return 0, 0
+	}
+
+	switch string(data[12:16]) {
+	case "VP8 ":
+		if !bytes.Equal(data[23:26], []byte{0x9D, 0x01, 0x2A}) {
+			return 0, 0
+		}
+		w := binary.LittleEndian.Uint16(data[26:]) & 0x3FFF
+		h := binary.LittleEndian.Uint16(data[28:]) & 0x3FFF
+		return int(w), int(h)
+	case "VP8L":
+		if data[20] != 0x2F {
+			return 0, 0
+		}
+		bits := binary.LittleEndian.Uint32(data[21:])
+		return int(bits&0x3FFF) + 1, int(bits>>14&0x3FFF) + 1
+	case "VP8X":
+		w := uint32(data[24]) | uint32(data[25])<<8 | uint32(data[26])<<16
+		h := uint32(data[27]) | uint32(data[28])<<8 | uint32(data[29])<<16
+		return int(w) + 1, int(h) + 1
+	}
+
+	return 0, 0
+}
+
+// Content of the frame with the image dimensions read from its header
+func (f ImageFrame) Picture() Picture {
+	p := Picture{
+		MIMEType:    f.MIMEType(),
+		Type:        f.pictureType,
+		Description: f.Description(),
+		Data:        f.data,
+	}
+
+	if !p.IsLink() {
+		p.Width, p.Height = imageSize(f.data)
+	}
+
+	return p
+}
+
+// Replaces the image, taking the MIME type from the data
+// Returns ErrImageFormat and leaves the frame unchanged when the data is not
+// a recognized image.
+func (f *ImageFrame) SetImageData(data []byte) error {
+	mimeType := sniffMIMEType(data)
+	if mimeType == "" {
+		return ErrImageFormat
+	}
+
+	f.SetMIMEType(mimeType)
+	f.SetData(data)
+	return nil
+}
+
+// Replaces the image with img encoded as "jpeg" or "png"
+func (f *ImageFrame) SetImage(img image.Image, format string) error {
+	data, err := encodeImage(img, format)
+	if err != nil {
+		return err
+	}
+
+	return f.SetImageData(data)
+}
+
+// Creates a picture frame whose MIME type is sniffed from the image data
+// Returns ErrImageFormat when the data is not a recognized image.
+func NewPictureFrame(ft FrameType, pictureType byte, description string, data []byte) (*ImageFrame, error) {
+	mimeType := sniffMIMEType(data)
+	if mimeType == "" {
+		return nil, ErrImageFormat
+	}
+
+	return NewImageFrame(ft, mimeType, pictureType, description, data), nil
+}
+
+// Pictures of all attached picture frames
+func (t *Tag) Pictures() []Picture {
+	var pictures []Picture
+	for _, f := range t.Frames("APIC") {
+		if imf, ok := f.(*ImageFrame); ok {
+			pictures = append(pictures, imf.Picture())
+		}
+	}
+
+	return pictures
+}
+
+// Adds a picture, taking the MIME type from the image data
+// Pictures it would clash with, those with the same description and for the
+// file icon types those of the same type, are replaced. ID3v2.2 tags are not
+// supported.
+func (t *Tag) AddPicture(pictureType byte, description string, data []byte) error {
+	mimeType := sniffMIMEType(data)
+	if mimeType == "" {
+		return ErrImageFormat
+	}
+
+	return t.addPicture(mimeType, pictureType, description, data, false)
+}
+
+// Replaces all front covers with the image
+// An empty mimeType is taken from the image data. A declared type must match
+// the data when it is a recognized format.
+func (t *Tag) SetFrontCover(data []byte, mimeType string) error {
+	switch err := CheckMIMEType(mimeType, data); {
+	case mimeType == "":
+		mimeType = sniffMIMEType(data)
+		if mimeType == "" {
+			return ErrImageFormat
+		}
+	case errors.Is(err, ErrMIMEMismatch):
+		return err
+	}
+
+	return t.addPicture(mimeType, PictureFrontCover, "", data, true)
+}
+
+func (t *Tag) addPicture(mimeType string, pictureType byte, description string, data []byte, replaceType bool) error {
+	ft, ok := lookupFrameType(t.version, "APIC")
+	if !ok {
+		return ErrFrameNotAllowed
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	f := NewImageFrame(ft, mimeType, pictureType, description, data)
+	if err := f.SetEncoding(t.encodingFor(description)); err != nil {
+		return err
+	}
+
+	key, _ := uniqueKey(f)
+	typeKey, typeUnique := pictureTypeKey(f)
+	t.removeFrames(func(g Framer) bool {
+		imf, ok := g.(*ImageFrame)
+		if !ok {
+			return false
+		}
+		if k, _ := uniqueKey(g); k == key {
+			return true
+		}
+		if k, _ := pictureTypeKey(g); typeUnique && k == typeKey {
+			return true
+		}
+		return replaceType && imf.pictureType == pictureType
+	})
+	t.addFrames(f)
+
+	return nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"strings"
+)
+
+// Reports whether the PCST frame marks the file as a podcast episode
+func (t *Tag) Podcast() bool {
+	return t.Frame("PCST") != nil
+}
+
+// Adds or removes the PCST frame, which ID3v2.2 does not have
+func (t *Tag) SetPodcast(podcast bool) {
+	if t.version < 3 {
+		return
+	}
+	if !podcast {
+		t.DeleteFrames("PCST")
+		return
+	}
+
+	if !t.Podcast() {
+		ft, _ := lookupFrameType(t.version, "PCST")
+		t.AddFrames(NewDataFrame(ft, []byte{0, 0, 0, 0}))
+	}
+}
+
+// Episode identifier from the TGID frame, usually the GUID of the feed item
+func (t *Tag) PodcastID() string {
+	return t.frameText("TGID")
+}
+
+func (t *Tag) SetPodcastID(id string) {
+	t.setFrameText("TGID", id)
+}
+
+// Episode description from the TDES frame
+func (t *Tag) PodcastDescription() string {
+	return t.textFrameText(t.frameType("TDES"))
+}
+
+func (t *Tag) SetPodcastDescription(description string) {
+	t.setFrameText("TDES", description)
+}
+
+// Keywords from the comma separated TKWD frame
+func (t *Tag) PodcastKeywords() []string {
+	var keywords []string
+	for _, k := range strings.Split(t.frameText("TKWD"), ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			keywords = append(keywords, k)
+		}
+	}
+
+	return keywords
+}
+
+func (t *Tag) SetPodcastKeywords(keywords ...string) {
+	t.setFrameText("TKWD", strings.Join(keywords, ","))
+}
+
+// Feed URL from the WFED frame, which iTunes writes as a text frame
+func (t *Tag) PodcastFeed() string {
+	return t.frameText("WFED")
+}
+
+func (t *Tag) SetPodcastFeed(url string) {
+	t.setFrameText("WFED", url)
+}
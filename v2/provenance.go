@@ -0,0 +1,86 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+// Provenance holds the frames describing how and by whom a file was made
+type Provenance struct {
+	// Software and settings used for encoding, TSSE
+	EncoderSettings string
+	// Person or organisation that encoded the file, TENC
+	EncodedBy string
+	// Owner or licensee of the file, TOWN
+	FileOwner string
+	// Type of audio file, such as "MPG/3", TFLT
+	FileType string
+	// Media the audio was transferred from, such as "CD/A", TMED
+	MediaType string
+}
+
+// Software and settings used for encoding, from TSSE
+func (t *Tag) EncoderSettings() string {
+	return t.frameText("TSSE")
+}
+
+func (t *Tag) SetEncoderSettings(settings string) {
+	t.setFrameText("TSSE", settings)
+}
+
+// Person or organisation that encoded the file, from TENC
+func (t *Tag) EncodedBy() string {
+	return t.frameText("TENC")
+}
+
+func (t *Tag) SetEncodedBy(encodedBy string) {
+	t.setFrameText("TENC", encodedBy)
+}
+
+// Owner or licensee of the file, from TOWN
+func (t *Tag) FileOwner() string {
+	return t.frameText("TOWN")
+}
+
+// Sets the TOWN frame, which ID3v2.2 does not have
+func (t *Tag) SetFileOwner(owner string) {
+	t.setFrameText("TOWN", owner)
+}
+
+// Type of audio file, from TFLT
+func (t *Tag) FileType() string {
+	return t.frameText("TFLT")
+}
+
+func (t *Tag) SetFileType(fileType string) {
+	t.setFrameText("TFLT", fileType)
+}
+
+// Media the audio was transferred from, from TMED
+func (t *Tag) MediaType() string {
+	return t.frameText("TMED")
+}
+
+func (t *Tag) SetMediaType(mediaType string) {
+	t.setFrameText("TMED", mediaType)
+}
+
+// Provenance frames of the tag
+func (t *Tag) Provenance() Provenance {
+	return Provenance{
+		EncoderSettings: t.EncoderSettings(),
+		EncodedBy:       t.EncodedBy(),
+		FileOwner:       t.FileOwner(),
+		FileType:        t.FileType(),
+		MediaType:       t.MediaType(),
+	}
+}
+
+// Replaces the provenance frames of the tag
+// Empty fields remove their frames, so that every file stamped with the same
+// provenance ends up with the same frames.
+func (t *Tag) SetProvenance(p Provenance) {
+	t.SetEncoderSettings(p.EncoderSettings)
+	t.SetEncodedBy(p.EncodedBy)
+	t.SetFileOwner(p.FileOwner)
+	t.SetFileType(p.FileType)
+	t.SetMediaType(p.MediaType)
+}
@@ -0,0 +1,36 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+// Name of the internet radio station the audio was recorded from, from TRSN
+func (t *Tag) RadioStation() string {
+	return t.frameText("TRSN")
+}
+
+// Sets the TRSN frame, which ID3v2.2 does not have
+func (t *Tag) SetRadioStation(name string) {
+	t.setFrameText("TRSN", name)
+}
+
+// Owner of the internet radio station, from TRSO
+func (t *Tag) RadioStationOwner() string {
+	return t.frameText("TRSO")
+}
+
+// Sets the TRSO frame, which ID3v2.2 does not have
+func (t *Tag) SetRadioStationOwner(owner string) {
+	t.setFrameText("TRSO", owner)
+}
+
+// Homepage of the internet radio station, from WORS
+func (t *Tag) RadioStationURL() string {
+	return t.linkFrameURL("WORS")
+}
+
+// Sets the WORS frame, an empty URL removes it
+// The URL must be absolute and ISO-8859-1, and ID3v2.2 tags give
+// ErrFrameNotAllowed.
+func (t *Tag) SetRadioStationURL(url string) error {
+	return t.setLinkFrameURL("WORS", url)
+}
@@ -0,0 +1,161 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"fmt"
+	"sync"
+)
+
+var (
+	// Guards the registered frame types against registration during parsing
+	registryMu sync.RWMutex
+
+	// Frame types added through RegisterFrameType, per major version
+	// The exported tables are never changed after init, so they can be read
+	// without the lock.
+	registered = map[byte]map[string]FrameType{}
+)
+
+// NewFrameType creates a frame type for use with RegisterFrameType
+// A nil constructor keeps frames of this type as binary data
+func NewFrameType(id, description string, constructor func(FrameHead, []byte) Framer) FrameType {
+	if constructor == nil {
+		constructor = ParseDataFrame
+	}
+
+	return FrameType{id: id, description: description, constructor: constructor}
+}
+
+// RegisterFrameType teaches the parser about a frame ID in a major version
+// Frames with this ID are built with the type's constructor instead of being
+// kept as binary data. An existing registration for the ID is replaced.
+func RegisterFrameType(version byte, id string, ft FrameType) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if frameTypeMap(version) == nil || len(id) != frameIdSize(version) || !validFrameId(id) {
+		return fmt.Errorf("register: invalid frame id %q for ID3v2.%d", id, version)
+	}
+
+	ft.id = id
+	if ft.constructor == nil {
+		ft.constructor = ParseDataFrame
+	}
+
+	if registered[version] == nil {
+		registered[version] = make(map[string]FrameType)
+	}
+	registered[version][id] = ft
+
+	return nil
+}
+
+// RegisterFrameConstructor replaces the constructor used for a frame ID
+// Unknown IDs are registered as a new frame type
+func RegisterFrameConstructor(version byte, id string, constructor func(FrameHead, []byte) Framer) error {
+	ft, ok := lookupFrameType(version, id)
+	if !ok {
+		ft = NewFrameType(id, "Unknown frame", nil)
+	}
+	ft.constructor = constructor
+
+	return RegisterFrameType(version, id, ft)
+}
+
+// Frame type table for a major version
+func frameTypeMap(version byte) map[string]FrameType {
+	switch version {
+	case 2:
+		return V22FrameTypeMap
+	case 3:
+		return V23FrameTypeMap
+	case 4:
+		return V24FrameTypeMap
+	}
+
+	return nil
+}
+
+// IDs of the standard frames, shared so that parsing does not allocate them
+var standardIds = func() map[string]string {
+	ids := make(map[string]string)
+	for _, m := range []map[string]FrameType{V22FrameTypeMap, V23FrameTypeMap, V24FrameTypeMap} {
+		for id := range m {
+			ids[id] = id
+		}
+	}
+	return ids
+}()
+
+// Frame ID read from a header
+func frameIdString(b []byte) string {
+	if id, ok := standardIds[string(b)]; ok {
+		return id
+	}
+
+	return string(b)
+}
+
+func frameIdSize(version byte) int {
+	if version == 2 {
+		return 3
+	}
+
+	return 4
+}
+
+// Frame type for an ID in a major version
+// Unknown IDs get a binary data frame type and false
+func lookupFrameType(version byte, id string) (FrameType, bool) {
+	registryMu.RLock()
+	t, custom := registered[version][id]
+	registryMu.RUnlock()
+	if custom {
+		return t, true
+	}
+
+	t, ok := frameTypeMap(version)[id]
+	if !ok {
+		return FrameType{id: id, description: "Unknown frame", constructor: ParseDataFrame}, false
+	}
+
+	// can't reference these from the table or they will cause an
+	// initialization loop
+	switch id {
+	case "CHAP":
+		t.constructor = ParseChapterFrame
+	case "CTOC":
+		t.constructor = ParseTOCFrame
+	}
+
+	return t, true
+}
+
+func registeredFrame(version byte, id string) bool {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	_, ok := registered[version][id]
+	return ok
+}
+
+// Copy of m with the frame types of added
+func mergeFrameTypes(m, added map[string]FrameType) map[string]FrameType {
+	c := copyFrameTypes(m)
+	for id, ft := range added {
+		c[id] = ft
+	}
+
+	return c
+}
+
+func copyFrameTypes(m map[string]FrameType) map[string]FrameType {
+	c := make(map[string]FrameType, len(m))
+	for id, ft := range m {
+		c[id] = ft
+	}
+
+	return c
+}
@@ -0,0 +1,172 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+// Proprietary frame used by MusicMatch
+type nconFrame struct {
+	*DataFrame
+}
+
+func parseNCONFrame(head FrameHead, data []byte) Framer {
+	return nconFrame{&DataFrame{head, data}}
+}
+
+func TestRegisterFrameType(t *testing.T) {
+	tag := NewTag(3)
+	tag.AddFrames(NewDataFrame(NewFrameType("NCON", "MusicMatch data", nil), []byte{1, 2, 3}))
+	data := tag.Bytes()
+
+	if f, ok := ParseTag(bytes.NewReader(data)).Frame("NCON").(*DataFrame); !ok {
+		t.Fatalf("unregistered NCON frame was not kept as binary data")
+	} else if !bytes.Equal(f.Data(), []byte{1, 2, 3}) {
+		t.Errorf("unregistered NCON frame has data %v", f.Data())
+	}
+
+	if err := RegisterFrameType(3, "NCON", NewFrameType("", "MusicMatch data", parseNCONFrame)); err != nil {
+		t.Fatal(err)
+	}
+
+	f, ok := ParseTag(bytes.NewReader(data)).Frame("NCON").(nconFrame)
+	if !ok {
+		t.Fatalf("registered NCON frame was not parsed with its constructor")
+	}
+
+	if !bytes.Equal(f.Data(), []byte{1, 2, 3}) {
+		t.Errorf("registered NCON frame has data %v", f.Data())
+	}
+
+	if !ValidFrameId(3, "NCON") || ValidFrameId(4, "NCON") {
+		t.Errorf("NCON registration leaked to other versions")
+	}
+
+	if err := RegisterFrameConstructor(3, "TIT2", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterFrameConstructor(3, "TIT2", ParseTextFrame); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		version byte
+		id      string
+	}{
+		{2, "NCON"},
+		{3, "NCO"},
+		{3, "nc0n"},
+		{5, "NCON"},
+	} {
+		if err := RegisterFrameType(test.version, test.id, NewFrameType("", "", nil)); err == nil {
+			t.Errorf("RegisterFrameType(%d, %q) succeeded", test.version, test.id)
+		}
+	}
+}
+
+func TestReinterpret(t *testing.T) {
+	tag := NewTag(3)
+	tag.SetTitle("Nice Life")
+	tag.AddFrames(NewDataFrame(NewFrameType("RGAD", "Replay gain adjustment", nil), []byte{0, 0, 0, 0, 0, 0, 0, 0}))
+
+	parsed, err := ParseTagWithOptions(bytes.NewReader(tag.Bytes()), ParseOptions{Lenient: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := parsed.Reinterpret(); n != 0 {
+		t.Errorf("Reinterpret before registration converted %d frames", n)
+	}
+
+	if err := RegisterFrameConstructor(3, "RGAD", parseNCONFrame); err != nil {
+		t.Fatal(err)
+	}
+
+	size := parsed.Size()
+	if n := parsed.Reinterpret(); n != 1 {
+		t.Errorf("Reinterpret after registration converted %d frames, want 1", n)
+	}
+
+	if _, ok := parsed.Frame("RGAD").(nconFrame); !ok {
+		t.Errorf("Reinterpret left RGAD as %T", parsed.Frame("RGAD"))
+	}
+
+	if parsed.Size() != size || !bytes.Equal(parsed.Bytes(), tag.Bytes()) {
+		t.Errorf("Reinterpret changed the serialized tag")
+	}
+}
+
+func TestValidFrameId(t *testing.T) {
+	tests := []struct {
+		id         string
+		v2, v3, v4 bool
+	}{
+		{"TIT2", false, true, true},
+		{"TT2", true, false, false},
+		{"TYER", false, true, false},
+		{"TDRC", false, false, true},
+		{"TMOO", false, true, true},
+		{"ASPI", false, false, true},
+		{"EQU2", false, false, true},
+		{"RVA2", false, false, true},
+		{"SEEK", false, false, true},
+		{"SIGN", false, false, true},
+		{"TDEN", false, false, true},
+		{"TDOR", false, false, true},
+		{"TDRL", false, false, true},
+		{"TDTG", false, false, true},
+		{"TIPL", false, false, true},
+		{"TMCL", false, false, true},
+		{"TPRO", false, false, true},
+		{"TSOA", false, false, true},
+		{"TSOP", false, false, true},
+		{"TSOT", false, false, true},
+		{"TSST", false, false, true},
+		{"ABCD", false, false, false},
+	}
+
+	for _, test := range tests {
+		for version, expected := range map[byte]bool{2: test.v2, 3: test.v3, 4: test.v4} {
+			if got := ValidFrameId(version, test.id); got != expected {
+				t.Errorf("ValidFrameId(%d, %q): expected %v, got %v", version, test.id, expected, got)
+			}
+		}
+	}
+
+	// Every frame added in ID3v2.4 is valid there
+	for _, id := range V24AddedFrames {
+		if !ValidFrameId(4, id) {
+			t.Errorf("ValidFrameId(4, %q): expected true", id)
+		}
+	}
+}
+
+func TestRegisterFrameTypeConcurrency(t *testing.T) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			RegisterFrameType(3, "XRAC", NewFrameType("", "Race test", nil))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			NewTXXXFrame("MOOD", "Chill")
+			ValidFrameId(3, "XRAC")
+		}
+	}()
+	wg.Wait()
+
+	if _, ok := V23FrameTypeMap["XRAC"]; ok {
+		t.Errorf("RegisterFrameType: expected the exported table left unchanged")
+	}
+	if !ValidFrameId(3, "XRAC") {
+		t.Errorf("RegisterFrameType: expected XRAC to be valid")
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// SearchOptions configures Search
+type SearchOptions struct {
+	// Folds case with the full Unicode rules, so that "STRASSE" matches
+	// "straße", rather than just comparing lower case
+	FoldCase bool
+	// Ignores accents and other combining marks, so that "cafe" matches "café"
+	IgnoreDiacritics bool
+}
+
+// Normalizes text for comparison
+func (o SearchOptions) normalize(s string) string {
+	if o.IgnoreDiacritics {
+		t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+		if stripped, _, err := transform.String(t, s); err == nil {
+			s = stripped
+		}
+	}
+
+	if o.FoldCase {
+		return cases.Fold().String(s)
+	}
+	return strings.ToLower(s)
+}
+
+// Frames whose decoded text contains the query, ignoring case
+// Descriptions, picture descriptions, chapter titles, URLs and the owners of
+// identifier frames are searched as well as the text. An empty query matches
+// nothing.
+func (t *Tag) Search(query string, opts SearchOptions) []Framer {
+	query = opts.normalize(query)
+	if query == "" {
+		return nil
+	}
+
+	var found []Framer
+	for _, f := range t.snapshot() {
+		for _, text := range searchTexts(f) {
+			if strings.Contains(opts.normalize(text), query) {
+				found = append(found, f)
+				break
+			}
+		}
+	}
+
+	return found
+}
+
+// Decoded text fields of a frame
+func searchTexts(f Framer) []string {
+	switch f := f.(type) {
+	case *UnsynchTextFrame:
+		return []string{f.Description(), f.Text()}
+	case *DescTextFrame:
+		return []string{f.Description(), f.Text()}
+	case TextFramer:
+		return []string{f.Text()}
+	case *ImageFrame:
+		return []string{f.Description()}
+	case *ChapterFrame:
+		return []string{f.Title(), f.Link()}
+	case *IdFrame:
+		texts := []string{f.OwnerIdentifier()}
+		if isPrintable(f.Identifier()) {
+			texts = append(texts, string(f.Identifier()))
+		}
+		return texts
+	case *DataFrame:
+		if isURLFrameId(f.Id()) {
+			return []string{string(f.Data())}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,260 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/lion187chen/id3-go/encodedbytes"
+)
+
+// Descriptions of the GEOB frames written by Serato DJ
+const (
+	seratoMarkersDesc  = "Serato Markers2"
+	seratoBeatGridDesc = "Serato BeatGrid"
+)
+
+// Hot cue set in Serato DJ
+type SeratoCue struct {
+	// Slot of the cue, from 0
+	Index    int
+	Position time.Duration
+	Color    color.RGBA
+	Name     string
+}
+
+// Saved loop set in Serato DJ
+type SeratoLoop struct {
+	// Slot of the loop, from 0
+	Index  int
+	Start  time.Duration
+	End    time.Duration
+	Locked bool
+	Name   string
+}
+
+// Cues, loops and track settings from a "Serato Markers2" GEOB frame
+type SeratoMarkers struct {
+	Cues  []SeratoCue
+	Loops []SeratoLoop
+	// Color of the track in the library, with zero alpha when there is none
+	TrackColor color.RGBA
+	// The beatgrid is locked against edits
+	BPMLocked bool
+}
+
+// Marker of a "Serato BeatGrid" GEOB frame
+type SeratoBeatMarker struct {
+	Position time.Duration
+	// Beats until the next marker, 0 for the last marker
+	Beats int
+	// Tempo from the last marker on, 0 for the others
+	BPM float64
+}
+
+// Fields of a GEOB frame body
+type object struct {
+	mimeType    string
+	filename    string
+	description string
+	data        []byte
+}
+
+func parseObject(body []byte) (object, error) {
+	var o object
+	rd := encodedbytes.NewReader(body)
+
+	encoding, err := rd.ReadByte()
+	if err != nil {
+		return o, fmt.Errorf("GEOB: %w", ErrInvalidFrame)
+	}
+	if o.mimeType, err = rd.ReadNullTermString(0); err != nil {
+		return o, fmt.Errorf("GEOB: %w", err)
+	}
+	if o.filename, err = rd.ReadNullTermString(encoding); err != nil {
+		return o, fmt.Errorf("GEOB: %w", err)
+	}
+	if o.description, err = rd.ReadNullTermString(encoding); err != nil {
+		return o, fmt.Errorf("GEOB: %w", err)
+	}
+	o.data, err = rd.ReadRest()
+	return o, err
+}
+
+// Data of the GEOB frame with the description, nil when there is none
+// Malformed GEOB frames are skipped.
+func (t *Tag) objectData(description string) []byte {
+	ft := t.frameType("GEOB")
+	if ft.Id() == "" {
+		return nil
+	}
+
+	for _, f := range t.Frames(ft.Id()) {
+		df, ok := f.(*DataFrame)
+		if !ok {
+			continue
+		}
+		if o, err := parseObject(df.Data()); err == nil && o.description == description {
+			return o.data
+		}
+	}
+
+	return nil
+}
+
+// Cues and loops Serato DJ stored in the tag, nil when there are none
+func (t *Tag) SeratoMarkers() (*SeratoMarkers, error) {
+	data := t.objectData(seratoMarkersDesc)
+	if data == nil {
+		return nil, nil
+	}
+	return ParseSeratoMarkers2(data)
+}
+
+// Beatgrid Serato DJ stored in the tag, nil when there is none
+func (t *Tag) SeratoBeatGrid() ([]SeratoBeatMarker, error) {
+	data := t.objectData(seratoBeatGridDesc)
+	if data == nil {
+		return nil, nil
+	}
+	return ParseSeratoBeatGrid(data)
+}
+
+// Decodes the object data of a "Serato Markers2" GEOB frame
+// The entries are base64 encoded after a two byte version. Entries other
+// than cues, loops, the track color and the beatgrid lock are skipped.
+func ParseSeratoMarkers2(data []byte) (*SeratoMarkers, error) {
+	fail := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%s: %w: "+format, append([]interface{}{seratoMarkersDesc, ErrInvalidFrame}, args...)...)
+	}
+
+	if len(data) < 2 || data[0] != 1 || data[1] != 1 {
+		return nil, fail("unknown version")
+	}
+
+	// Serato wraps the text every 72 characters, pads it with nulls and
+	// sometimes leaves a stray character that base64 cannot end with
+	text := string(data[2:])
+	if i := strings.IndexByte(text, 0); i >= 0 {
+		text = text[:i]
+	}
+	text = strings.TrimRight(strings.ReplaceAll(text, "\n", ""), "=")
+	if len(text)%4 == 1 {
+		text = text[:len(text)-1]
+	}
+	payload, err := base64.RawStdEncoding.DecodeString(text)
+	if err != nil {
+		return nil, fail("%v", err)
+	}
+
+	if len(payload) < 2 || payload[0] != 1 || payload[1] != 1 {
+		return nil, fail("unknown payload version")
+	}
+	payload = payload[2:]
+
+	m := new(SeratoMarkers)
+	for len(payload) > 0 {
+		end := bytes.IndexByte(payload, 0)
+		if end < 0 {
+			return nil, fail("unterminated entry name")
+		}
+		name := string(payload[:end])
+		if name == "" {
+			break
+		}
+		payload = payload[end+1:]
+
+		if len(payload) < 4 {
+			return nil, fail("%s entry: truncated", name)
+		}
+		size := binary.BigEndian.Uint32(payload)
+		if uint64(size) > uint64(len(payload)-4) {
+			return nil, fail("%s entry: truncated", name)
+		}
+		entry := payload[4 : 4+size]
+		payload = payload[4+size:]
+
+		switch name {
+		case "CUE":
+			if len(entry) < 13 {
+				return nil, fail("CUE entry: %d bytes", len(entry))
+			}
+			m.Cues = append(m.Cues, SeratoCue{
+				Index:    int(entry[1]),
+				Position: time.Duration(binary.BigEndian.Uint32(entry[2:])) * time.Millisecond,
+				Color:    color.RGBA{entry[7], entry[8], entry[9], 0xFF},
+				Name:     seratoString(entry[12:]),
+			})
+		case "LOOP":
+			if len(entry) < 20 {
+				return nil, fail("LOOP entry: %d bytes", len(entry))
+			}
+			m.Loops = append(m.Loops, SeratoLoop{
+				Index:  int(entry[1]),
+				Start:  time.Duration(binary.BigEndian.Uint32(entry[2:])) * time.Millisecond,
+				End:    time.Duration(binary.BigEndian.Uint32(entry[6:])) * time.Millisecond,
+				Locked: entry[18] != 0,
+				Name:   seratoString(entry[19:]),
+			})
+		case "COLOR":
+			if len(entry) < 4 {
+				return nil, fail("COLOR entry: %d bytes", len(entry))
+			}
+			m.TrackColor = color.RGBA{entry[1], entry[2], entry[3], 0xFF}
+		case "BPMLOCK":
+			if len(entry) < 1 {
+				return nil, fail("BPMLOCK entry: empty")
+			}
+			m.BPMLocked = entry[0] != 0
+		}
+	}
+
+	return m, nil
+}
+
+// Decodes the object data of a "Serato BeatGrid" GEOB frame
+func ParseSeratoBeatGrid(data []byte) ([]SeratoBeatMarker, error) {
+	fail := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%s: %w: "+format, append([]interface{}{seratoBeatGridDesc, ErrInvalidFrame}, args...)...)
+	}
+
+	if len(data) < 6 || data[0] != 1 || data[1] != 0 {
+		return nil, fail("unknown version")
+	}
+
+	count := binary.BigEndian.Uint32(data[2:])
+	data = data[6:]
+	if uint64(count)*8 > uint64(len(data)) {
+		return nil, fail("%d markers in %d bytes", count, len(data))
+	}
+
+	markers := make([]SeratoBeatMarker, count)
+	for i := range markers {
+		b := data[i*8 : i*8+8]
+		seconds := math.Float32frombits(binary.BigEndian.Uint32(b))
+		markers[i].Position = time.Duration(float64(seconds) * float64(time.Second))
+		if i < len(markers)-1 {
+			markers[i].Beats = int(binary.BigEndian.Uint32(b[4:]))
+		} else {
+			markers[i].BPM = float64(math.Float32frombits(binary.BigEndian.Uint32(b[4:])))
+		}
+	}
+
+	return markers, nil
+}
+
+// Text of a null terminated UTF-8 field
+func seratoString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
@@ -0,0 +1,141 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"fmt"
+	"strings"
+)
+
+var (
+	// V24AddedFrames are the frame IDs introduced in ID3v2.4
+	V24AddedFrames = []string{
+		"ASPI", "EQU2", "RVA2", "SEEK", "SIGN", "TDEN", "TDOR", "TDRC",
+		"TDRL", "TDTG", "TIPL", "TMCL", "TMOO", "TPRO", "TSOA", "TSOP",
+		"TSOT", "TSST",
+	}
+
+	// V24RemovedFrames are the ID3v2.3 frame IDs dropped in ID3v2.4
+	V24RemovedFrames = []string{
+		"EQUA", "IPLS", "RVAD", "TDAT", "TIME", "TORY", "TRDA", "TSIZ",
+		"TYER",
+	}
+
+	// ID3v2.4 frames accepted in ID3v2.3 tags, as taggers write them anyway
+	v23ToleratedFrames = []string{"TMOO"}
+
+	// Frames registered in the tables that are not part of any standard
+	nonStandardFrames = []string{"TCMP", "PCST", "TGID", "TDES", "TKWD", "WFED"}
+
+	// Frames that may only appear once per tag regardless of content
+	singletonFrames = []string{
+		"BUF", "CNT", "ETC", "EQU", "MCI", "MLL", "REV", "RVA", "STC",
+		"ASPI", "EQUA", "ETCO", "MCDI", "MLLT", "OWNE", "PCNT", "POSS",
+		"RBUF", "RVAD", "RVRB", "SEEK", "SYTC", "IPL", "IPLS", "PCST",
+	}
+)
+
+// ValidFrameId reports whether the frame ID is defined for the major version
+// Experimental IDs starting with X, Y or Z and IDs added with
+// RegisterFrameType are always accepted
+func ValidFrameId(version byte, id string) bool {
+	if !validFrameId(id) {
+		return false
+	}
+
+	if strings.IndexByte("XYZ", id[0]) >= 0 {
+		return true
+	}
+
+	if contains(nonStandardFrames, id) {
+		return false
+	}
+
+	if registeredFrame(version, id) {
+		return true
+	}
+
+	_, ok := lookupFrameType(version, id)
+	switch version {
+	case 2:
+		return ok
+	case 3:
+		return ok && (!contains(V24AddedFrames, id) || contains(v23ToleratedFrames, id))
+	case 4:
+		return ok && !contains(V24RemovedFrames, id)
+	}
+
+	return false
+}
+
+// Key identifying frames that the spec allows only once per tag
+// Frames that may repeat freely return false
+func uniqueKey(f Framer) (string, bool) {
+	id := f.Id()
+
+	switch id {
+	case "TXX", "TXXX", "WXX", "WXXX":
+		if df, ok := f.(*DescTextFrame); ok {
+			return id + "\x00" + df.Description(), true
+		}
+	case "COM", "COMM", "ULT", "USLT":
+		if uf, ok := f.(*UnsynchTextFrame); ok {
+			return id + "\x00" + uf.Language() + uf.Description(), true
+		}
+	case "PIC", "APIC":
+		if imf, ok := f.(*ImageFrame); ok {
+			return id + "\x00" + imf.Description(), true
+		}
+	case "UFI", "UFID":
+		if idf, ok := f.(*IdFrame); ok {
+			return id + "\x00" + idf.OwnerIdentifier(), true
+		}
+	case "WCM", "WCOM", "WAR", "WOAR":
+		// Several stores or performers may each have a link
+		return id + "\x00" + strings.TrimSpace(trimNull(string(f.Bytes()))), true
+	case "CHAP":
+		if cf, ok := f.(*ChapterFrame); ok {
+			return id + "\x00" + cf.Element, true
+		}
+	case "CTOC":
+		if tf, ok := f.(*TOCFrame); ok {
+			return id + "\x00" + tf.Element, true
+		}
+	default:
+		if strings.HasPrefix(id, "T") || strings.HasPrefix(id, "W") || contains(singletonFrames, id) {
+			return id, true
+		}
+		return "", false
+	}
+
+	// Keyed frames that failed to parse only clash with identical copies
+	return id + "\x00" + string(f.Bytes()), true
+}
+
+// Checks a parsed frame against the rules of the tag version
+func checkFrameSpec(version byte, f Framer) error {
+	if !ValidFrameId(version, f.Id()) {
+		return ErrFrameNotAllowed
+	}
+
+	if version < 4 {
+		if ef, ok := f.(interface{ Encoding() string }); ok {
+			if e := ef.Encoding(); e != "ISO-8859-1" && e != "UTF-16" {
+				return fmt.Errorf("%w: %s", ErrEncodingNotAllowed, e)
+			}
+		}
+	}
+
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,69 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"time"
+
+	"github.com/lion187chen/id3-go/encodedbytes"
+)
+
+// ParseStats describes how a tag was read, for monitoring the health of
+// incoming metadata
+type ParseStats struct {
+	FramesParsed int
+	// Frames dropped because they were malformed
+	FramesSkipped int
+	// IDs of frames kept as binary data because they are not known, in the
+	// order first seen
+	UnknownFrameIds []string
+	PaddingBytes    int
+	// Bytes of text by encoding name, counting all frames with an encoding
+	// byte
+	TextBytes map[string]int
+	Duration  time.Duration
+}
+
+// Statistics of the parse that produced the tag, zero for new tags
+func (t *Tag) ParseStats() ParseStats {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.stats.clone()
+}
+
+func (s ParseStats) clone() ParseStats {
+	s.UnknownFrameIds = append([]string(nil), s.UnknownFrameIds...)
+	if s.TextBytes != nil {
+		textBytes := make(map[string]int, len(s.TextBytes))
+		for k, v := range s.TextBytes {
+			textBytes[k] = v
+		}
+		s.TextBytes = textBytes
+	}
+
+	return s
+}
+
+// Counts a parsed frame with its body
+func (s *ParseStats) count(f Framer, body []byte, unknown bool) {
+	s.FramesParsed++
+
+	if unknown {
+		seen := false
+		for _, id := range s.UnknownFrameIds {
+			seen = seen || id == f.Id()
+		}
+		if !seen {
+			s.UnknownFrameIds = append(s.UnknownFrameIds, f.Id())
+		}
+	}
+
+	if hasEncodingByte(f.Id()) && len(body) > 0 && int(body[0]) < len(encodedbytes.EncodingMap) {
+		if s.TextBytes == nil {
+			s.TextBytes = make(map[string]int)
+		}
+		s.TextBytes[encodedbytes.EncodingForIndex(body[0])] += len(body) - 1
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import "fmt"
+
+// Frame status flags, which each version stores in different bits
+type FrameStatus byte
+
+const (
+	// The frame is left out when the tag is changed, if its ID is unknown
+	DiscardOnTagAlteration FrameStatus = 1 << iota
+	// The frame is removed by FileAltered, as it describes the audio
+	DiscardOnFileAlteration
+	// The content must not be changed without clearing the flag, as with
+	// frames that are signed
+	ReadOnly
+)
+
+// Status flags of a frame as the tag's version stores them
+func (t *Tag) FrameStatus(f Framer) FrameStatus {
+	var status FrameStatus
+	for i, bit := range statusFlagBits[t.version] {
+		if f.StatusFlags()&bit != 0 {
+			status |= 1 << i
+		}
+	}
+
+	return status
+}
+
+// Sets the status flags of a frame as the tag's version stores them
+// ID3v2.2 frames have no flags, so any status gives ErrFlagNotAllowed.
+// Clearing ReadOnly lets the tag change the frame again.
+func (t *Tag) SetFrameStatus(f Framer, status FrameStatus) error {
+	bits, ok := statusFlagBits[t.version]
+	if !ok && status != 0 {
+		return fmt.Errorf("%s: %w", f.Id(), ErrFlagNotAllowed)
+	}
+
+	flags := f.StatusFlags()
+	for i, bit := range bits {
+		if status&(1<<i) != 0 {
+			flags |= bit
+		} else {
+			flags &^= bit
+		}
+	}
+
+	if flags != f.StatusFlags() {
+		f.setStatusFlags(flags)
+		t.MarkDirty()
+	}
+
+	return nil
+}
+
+// Reports whether the tag refuses to change the frame
+func (t *Tag) readOnly(f Framer) bool {
+	return t.FrameStatus(f)&ReadOnly != 0
+}
+
+// Reports whether a changed tag leaves out the frame
+// The flag only applies to frames this package does not know.
+func (t *Tag) discardedOnAlteration(f Framer) bool {
+	if t.FrameStatus(f)&DiscardOnTagAlteration == 0 {
+		return false
+	}
+
+	_, known := lookupFrameType(t.version, f.Id())
+	return !known
+}
+
+// Frames to write, leaving out the frames discarded when the tag was
+// changed, along with the bytes they took, which are written as padding
+// The caller must hold mu.
+func (t *Tag) writtenFrames(altered bool) ([]Framer, int) {
+	frames := t.orderedFrames()
+	if !altered {
+		return frames, 0
+	}
+
+	written := make([]Framer, 0, len(frames))
+	discarded := 0
+	for _, f := range frames {
+		if t.discardedOnAlteration(f) {
+			discarded += t.frameHeaderSize + int(f.Size())
+			continue
+		}
+		written = append(written, f)
+	}
+
+	return written, discarded
+}
+
+// Removes the frames marked to be discarded when the audio changes
+// Tools that re-encode, trim or otherwise alter the audio call it before
+// saving. Returns the removed frames.
+func (t *Tag) FileAltered() []Framer {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var removed []Framer
+	t.removeFrames(func(f Framer) bool {
+		if t.FrameStatus(f)&DiscardOnFileAlteration == 0 {
+			return false
+		}
+		removed = append(removed, f)
+		return true
+	})
+
+	return removed
+}
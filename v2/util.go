@@ -3,6 +3,109 @@
 // license that can be found in the LICENSE file.
 package v2
 
+import (
+	"bytes"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/lion187chen/id3-go/encodedbytes"
+)
+
 func isBitSet(flag, index byte) bool {
 	return flag&(1<<index) != 0
 }
+
+func isZero(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Frame IDs are made of capital letters and digits
+func validFrameId(id string) bool {
+	if len(id) != 3 && len(id) != 4 {
+		return false
+	}
+
+	for _, c := range id {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Detects the image format from the leading magic bytes
+func sniffMIMEType(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		return "image/jpeg"
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return "image/png"
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return "image/gif"
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return "image/webp"
+	case bytes.HasPrefix(data, []byte("BM")):
+		return "image/bmp"
+	}
+
+	return ""
+}
+
+// Change in the encoded size of a null terminated string
+// Either string may already end in its terminator. The old string has no
+// terminator unless oldTerminated is set.
+func terminatedDiff(newEncoding byte, newString string, oldEncoding byte, oldString string, oldTerminated bool) (int, error) {
+	diff, err := encodedbytes.EncodedDiff(newEncoding, trimNull(newString), oldEncoding, trimNull(oldString))
+
+	diff += encodedbytes.EncodingNullLengthForIndex(newEncoding)
+	if oldTerminated {
+		diff -= encodedbytes.EncodingNullLengthForIndex(oldEncoding)
+	}
+
+	return diff, err
+}
+
+func trimNull(s string) string {
+	return strings.TrimRight(s, "\x00")
+}
+
+// Text frame values separated by "; " instead of terminators
+func textValues(text string) string {
+	return strings.ReplaceAll(trimNull(text), "\x00", "; ")
+}
+
+func isPrintable(data []byte) bool {
+	if !utf8.Valid(data) {
+		return false
+	}
+
+	for _, r := range string(data) {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func frameString(f interface{ MarshalText() ([]byte, error) }) string {
+	b, _ := f.MarshalText()
+	return string(b)
+}
+
+// Copy of data that does not share its backing array, nil stays nil
+func cloneBytes(data []byte) []byte {
+	if data == nil {
+		return nil
+	}
+
+	return append([]byte{}, data...)
+}
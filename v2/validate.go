@@ -0,0 +1,238 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lion187chen/id3-go/encodedbytes"
+)
+
+// Severity ranks issues reported by Validate
+type Severity int
+
+const (
+	// Informational, e.g. data that would be lost when exporting to ID3v1
+	SeverityInfo Severity = iota
+	// Allowed by the spec but likely to confuse readers
+	SeverityWarning
+	// Violates the spec
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+
+	return fmt.Sprintf("severity(%d)", int(s))
+}
+
+// Issue is a single problem found by Validate
+type Issue struct {
+	Severity Severity
+	FrameId  string
+	// Frame the issue refers to, nil for tag level issues
+	Frame   Framer
+	Message string
+}
+
+func (i Issue) String() string {
+	if i.FrameId == "" {
+		return fmt.Sprintf("%v: %s", i.Severity, i.Message)
+	}
+
+	return fmt.Sprintf("%v: %s: %s", i.Severity, i.FrameId, i.Message)
+}
+
+const (
+	// Field widths of an ID3v1 tag
+	v1TextFieldSize = 30
+	v1YearFieldSize = 4
+)
+
+var (
+	positionPattern = regexp.MustCompile(`^[0-9]+(/[0-9]+)?$`)
+	yearPattern     = regexp.MustCompile(`^[0-9]{4}$`)
+	numberPattern   = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// Validate checks the tag against structural and semantic rules
+// An empty result means no problems were found
+func (t *Tag) Validate() []Issue {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var issues []Issue
+	seen := make(map[string]bool)
+
+	add := func(f Framer, severity Severity, format string, args ...interface{}) {
+		issue := Issue{Severity: severity, Frame: f, Message: fmt.Sprintf(format, args...)}
+		if f != nil {
+			issue.FrameId = f.Id()
+		}
+		issues = append(issues, issue)
+	}
+
+	for _, f := range t.frames {
+		id := f.Id()
+
+		if !ValidFrameId(t.version, id) {
+			add(f, SeverityError, "frame is not defined in ID3v2.%d", t.version)
+		}
+
+		if key, unique := uniqueKey(f); unique {
+			if seen[key] {
+				add(f, SeverityError, "frame must be unique but appears more than once")
+			}
+			seen[key] = true
+		}
+		if key, unique := pictureTypeKey(f); unique {
+			if seen[key] {
+				add(f, SeverityError, "only one picture of type %q is allowed", PictureTypeName(f.(*ImageFrame).PictureType()))
+			}
+			seen[key] = true
+		}
+
+		if ef, ok := f.(interface{ Encoding() string }); ok && t.version < 4 {
+			if e := ef.Encoding(); e != "ISO-8859-1" && e != "UTF-16" {
+				add(f, SeverityError, "%s encoding is not allowed in ID3v2.%d", e, t.version)
+			}
+		}
+
+		if df, ok := f.(*DataFrame); ok && hasEncodingByte(id) {
+			t.validateRawText(df, add)
+		}
+
+		if tf, ok := f.(TextFramer); ok {
+			t.validateText(tf, add)
+		}
+
+		if imf, ok := f.(*ImageFrame); ok {
+			validateImage(imf, add)
+		}
+	}
+
+	t.validateV1Export(add)
+
+	return issues
+}
+
+func (t *Tag) validateText(f TextFramer, add func(Framer, Severity, string, ...interface{})) {
+	text := f.Text()
+
+	// ID3v2.4 separates multiple values with terminators
+	if i := strings.IndexByte(text, 0); i >= 0 && t.version < 4 && strings.Trim(text[i:], "\x00") != "" {
+		add(f, SeverityWarning, "text after terminator is ignored by readers")
+	}
+	text = strings.TrimRight(text, "\x00")
+
+	switch f.Id() {
+	case "TRK", "TRCK", "TPA", "TPOS":
+		if !positionPattern.MatchString(text) {
+			add(f, SeverityWarning, "%q is not of the form N or N/M", text)
+		}
+	case "TYE", "TYER", "TOR", "TORY":
+		if !yearPattern.MatchString(text) {
+			add(f, SeverityWarning, "%q is not a four digit year", text)
+		}
+	case "TLE", "TLEN", "TBP", "TBPM", "TDY", "TDLY":
+		if !numberPattern.MatchString(text) {
+			add(f, SeverityWarning, "%q is not a number", text)
+		}
+	case "TLA", "TLAN":
+		for _, code := range splitLanguages(text) {
+			if _, err := parseLanguage(code); err != nil {
+				add(f, SeverityWarning, "%v", err)
+			}
+		}
+	case "TDEN", "TDOR", "TDRC", "TDRL", "TDTG":
+		if t.version >= 4 {
+			if err := ValidateTimestamp(text); err != nil {
+				add(f, SeverityWarning, "%v", err)
+			}
+		}
+	case "TRC", "TSRC":
+		if _, err := parseISRC(text); err != nil {
+			add(f, SeverityWarning, "%v", err)
+		}
+	}
+}
+
+// Frames kept as raw data still need their text terminators
+func (t *Tag) validateRawText(f *DataFrame, add func(Framer, Severity, string, ...interface{})) {
+	data := f.Data()
+	if len(data) == 0 {
+		add(f, SeverityError, "frame is empty")
+		return
+	}
+
+	if data[0] >= byte(len(encodedbytes.EncodingMap)) {
+		add(f, SeverityError, "unknown text encoding %d", data[0])
+		return
+	}
+
+	switch f.Id() {
+	case "COM", "COMM", "ULT", "USLT", "TXX", "TXXX", "WXX", "WXXX", "APIC", "GEOB":
+		null := strings.Repeat("\x00", encodedbytes.EncodingNullLengthForIndex(data[0]))
+		if !strings.Contains(string(data[1:]), null) {
+			add(f, SeverityError, "missing terminator after description")
+		}
+	}
+}
+
+func validateImage(f *ImageFrame, add func(Framer, Severity, string, ...interface{})) {
+	mimeType := strings.TrimRight(f.MIMEType(), "\x00")
+
+	// A MIME type of "-->" means the data is a link to the image
+	if mimeType == "-->" {
+		return
+	}
+
+	if len(f.Data()) == 0 {
+		add(f, SeverityError, "picture has no image data")
+		return
+	}
+
+	if err := CheckMIMEType(mimeType, f.Data()); errors.Is(err, ErrImageFormat) {
+		add(f, SeverityWarning, "image data is not a recognized format")
+	} else if err != nil {
+		add(f, SeverityWarning, "MIME type %q does not match %s image data", mimeType, sniffMIMEType(f.Data()))
+	}
+}
+
+func (t *Tag) validateV1Export(add func(Framer, Severity, string, ...interface{})) {
+	fields := []struct {
+		name string
+		size int
+	}{
+		{"Title", v1TextFieldSize},
+		{"Artist", v1TextFieldSize},
+		{"Album", v1TextFieldSize},
+		{"Year", v1YearFieldSize},
+	}
+
+	for _, field := range fields {
+		f := t.textFrame(t.commonMap[field.name])
+		if f == nil {
+			continue
+		}
+
+		text := strings.TrimRight(f.Text(), "\x00")
+		latin1, err := encodedbytes.EncodedStringBytes(text, 0)
+		if err != nil {
+			add(f, SeverityInfo, "%s cannot be represented in an ID3v1 tag", strings.ToLower(field.name))
+		} else if len(latin1) > field.size {
+			add(f, SeverityInfo, "%s is truncated to %d bytes in an ID3v1 tag", strings.ToLower(field.name), field.size)
+		}
+	}
+}
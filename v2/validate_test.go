@@ -0,0 +1,63 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"strings"
+	"testing"
+)
+
+func hasIssue(issues []Issue, id string, severity Severity, message string) bool {
+	for _, issue := range issues {
+		if issue.FrameId == id && issue.Severity == severity && strings.Contains(issue.Message, message) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestValidate(t *testing.T) {
+	tag := NewTag(3)
+	tag.AddFrames(
+		NewTextFrame(V23FrameTypeMap["TIT2"], "A title that is much too long for ID3v1", "ISO-8859-1"),
+		NewTextFrame(V23FrameTypeMap["TPE1"], "Paloalto", "UTF-8"),
+		NewTextFrame(V23FrameTypeMap["TRCK"], "eleven", "ISO-8859-1"),
+		NewTextFrame(V23FrameTypeMap["TALB"], "Chief Life", "ISO-8859-1"),
+		NewTextFrame(V23FrameTypeMap["TALB"], "Chief Life", "ISO-8859-1"),
+		NewTextFrame(V23FrameTypeMap["TDRC"], "2013", "ISO-8859-1"),
+		NewImageFrame(V23FrameTypeMap["APIC"], "image/png", 3, "cover", []byte{0xFF, 0xD8, 0xFF, 0xE0}),
+	)
+
+	issues := tag.Validate()
+
+	tests := []struct {
+		id       string
+		severity Severity
+		message  string
+	}{
+		{"TIT2", SeverityInfo, "truncated"},
+		{"TPE1", SeverityError, "UTF-8 encoding"},
+		{"TRCK", SeverityWarning, "N/M"},
+		{"TALB", SeverityError, "unique"},
+		{"TDRC", SeverityError, "not defined"},
+		{"APIC", SeverityWarning, "does not match image/jpeg"},
+	}
+
+	for _, test := range tests {
+		if !hasIssue(issues, test.id, test.severity, test.message) {
+			t.Errorf("Validate did not report %v %q for %s, got %v", test.severity, test.message, test.id, issues)
+		}
+	}
+
+	clean := NewTag(3)
+	clean.AddFrames(
+		NewTextFrame(V23FrameTypeMap["TIT2"], "Nice Life", "ISO-8859-1"),
+		NewTextFrame(V23FrameTypeMap["TRCK"], "11/12", "ISO-8859-1"),
+	)
+
+	if issues := clean.Validate(); len(issues) != 0 {
+		t.Errorf("Validate on clean tag reported %v", issues)
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import "errors"
+
+// Returned by a Walk callback to stop walking without an error
+var SkipAll = errors.New("skip remaining frames")
+
+// Calls fn for every frame in order until it returns an error
+// The frames are those of the tag when Walk is called, so fn may add and
+// delete frames. An fn returning SkipAll stops the walk and Walk returns nil.
+func (t *Tag) Walk(fn func(f Framer) error) error {
+	for _, f := range t.snapshot() {
+		if err := fn(f); err != nil {
+			if err == SkipAll {
+				return nil
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FrameVisitor has a method for each category of frame
+// Embed BaseVisitor to handle only some categories.
+type FrameVisitor interface {
+	// Text information frames, including user defined and credits frames
+	VisitText(f TextFramer) error
+	// URL link frames, including user defined ones
+	VisitURL(f Framer, url string) error
+	VisitPicture(f *ImageFrame) error
+	// Comment and unsynchronised lyrics frames
+	VisitComment(f *UnsynchTextFrame) error
+	// Frames of the other categories and unrecognized frames
+	VisitUnknown(f Framer) error
+}
+
+// BaseVisitor ignores every frame
+type BaseVisitor struct{}
+
+func (BaseVisitor) VisitText(TextFramer) error           { return nil }
+func (BaseVisitor) VisitURL(Framer, string) error        { return nil }
+func (BaseVisitor) VisitPicture(*ImageFrame) error       { return nil }
+func (BaseVisitor) VisitComment(*UnsynchTextFrame) error { return nil }
+func (BaseVisitor) VisitUnknown(Framer) error            { return nil }
+
+// Walks the frames, calling the visitor method for the category of each
+func (t *Tag) Visit(v FrameVisitor) error {
+	return t.Walk(func(f Framer) error {
+		return visitFrame(v, f)
+	})
+}
+
+func visitFrame(v FrameVisitor, f Framer) error {
+	switch f := f.(type) {
+	case *UnsynchTextFrame:
+		return v.VisitComment(f)
+	case *ImageFrame:
+		return v.VisitPicture(f)
+	case *DataFrame:
+		if isURLFrameId(f.Id()) {
+			return v.VisitURL(f, trimNull(string(f.Data())))
+		}
+	case TextFramer:
+		if f.Id()[0] == 'W' {
+			return v.VisitURL(f, f.Text())
+		}
+		return v.VisitText(f)
+	}
+
+	return v.VisitUnknown(f)
+}
@@ -0,0 +1,200 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package v2
+
+import (
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// Zeros written as padding at a time
+var zeros = make([]byte, 4096)
+
+// Streams the tag to w without building it in memory
+// Unlike Bytes, a frame whose text cannot be encoded or whose content does
+// not match its size fails with an error. Returns the number of bytes
+// written.
+func (t *Tag) WriteTo(w io.Writer) (int64, error) {
+	return t.writeTo(w, false)
+}
+
+// Streams the tag, appended tags without padding and followed by a footer
+func (t *Tag) writeTo(w io.Writer, appended bool) (int64, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if original := t.preserved(); original != nil && !appended {
+		n, err := w.Write(original)
+		return int64(n), err
+	}
+
+	t.sizeMu.Lock()
+	h := *t.Header
+	padding := t.padding
+	altered := t.dirty
+	extended := t.extended
+	t.sizeMu.Unlock()
+
+	if err := t.checkSizes(h.size); err != nil {
+		return 0, err
+	}
+	if extended != nil && extended.Enforce {
+		// Appended tags are written without their padding
+		size := int(h.size)
+		if appended {
+			size -= int(padding)
+		}
+		if err := t.checkRestrictions(extended, t.frames, size); err != nil {
+			return 0, fmt.Errorf("write: %w", err)
+		}
+	}
+
+	frames, discarded := t.writtenFrames(altered)
+	padding += uint(discarded)
+
+	// Appended tags end with a footer instead of padding
+	header := h.Bytes()
+	var footer []byte
+	if appended {
+		h.size -= uint32(padding)
+		h.flags |= FlagFooter
+		header, footer = h.bytes("ID3"), h.bytes(footerId)
+		padding = 0
+	}
+
+	// The extended header holds the CRC of what follows it
+	var ext []byte
+	if extended != nil {
+		var crc uint32
+		if extended.CRC {
+			sum := crc32.NewIEEE()
+			if _, err := t.writeBody(sum, frames, padding); err != nil {
+				return 0, err
+			}
+			crc = sum.Sum32()
+		}
+		ext = extended.bytes(crc)
+	}
+
+	n, err := w.Write(append(header, ext...))
+	written := int64(n)
+	if err != nil {
+		return written, err
+	}
+
+	m, err := t.writeBody(w, frames, padding)
+	written += m
+	if err != nil || footer == nil {
+		return written, err
+	}
+
+	n, err = w.Write(footer)
+	return written + int64(n), err
+}
+
+// Writes frames followed by padding
+func (t *Tag) writeBody(w io.Writer, frames []Framer, padding uint) (int64, error) {
+	var written int64
+	for _, f := range frames {
+		if b := t.preservedFrame(f); b != nil {
+			n, err := w.Write(b)
+			written += int64(n)
+			if err != nil {
+				return written, err
+			}
+			continue
+		}
+
+		n, err := w.Write(t.frameHeadConstructor(f))
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+
+		m, err := writeFrameBody(w, f)
+		written += m
+		if err != nil {
+			return written, fmt.Errorf("write: %s: %w", f.Id(), err)
+		}
+		if m != int64(f.Size()) {
+			return written, fmt.Errorf("write: %s: wrote %d bytes, header says %d", f.Id(), m, f.Size())
+		}
+	}
+
+	for padding > 0 {
+		chunk := zeros
+		if padding < uint(len(chunk)) {
+			chunk = chunk[:padding]
+		}
+
+		n, err := w.Write(chunk)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+		padding -= uint(n)
+	}
+
+	return written, nil
+}
+
+// Bytes an unedited frame was parsed from with ParseOptions.Preserve, nil
+// when the frame has to be encoded
+func (t *Tag) preservedFrame(f Framer) []byte {
+	b := f.preservedBytes(t.version)
+	if len(b) != t.frameHeaderSize+int(f.Size()) {
+		return nil
+	}
+	return b
+}
+
+// Largest size of the tag, held in a synchsafe integer
+const maxTagSize = 1<<28 - 1
+
+// Largest frame size the frame header of a version can hold
+func maxFrameSize(version byte) uint64 {
+	switch version {
+	case 2:
+		return 1<<24 - 1
+	case 3:
+		return 1<<32 - 1
+	}
+
+	return 1<<28 - 1
+}
+
+// Checks that the tag and frame sizes fit their headers, before anything is
+// written. The caller must hold mu.
+func (t *Tag) checkSizes(size uint32) error {
+	if size > maxTagSize {
+		return fmt.Errorf("write: %w: %d bytes", ErrTagTooLarge, size)
+	}
+
+	max := maxFrameSize(t.version)
+	for _, f := range t.frames {
+		if uint64(f.Size()) > max {
+			return fmt.Errorf("write: %s: %w: %d bytes", f.Id(), ErrFrameTooLarge, f.Size())
+		}
+		// ID3v2.2 frame headers hold three character IDs
+		if t.version == 2 && len(f.Id()) != 3 {
+			return fmt.Errorf("write: %s: %w", f.Id(), ErrFrameNotAllowed)
+		}
+	}
+
+	return nil
+}
+
+// Writes the body of a frame, streaming the frame types of this package
+// Other frames, including types that embed one of them, are written through
+// Bytes.
+func writeFrameBody(w io.Writer, f Framer) (int64, error) {
+	switch f.(type) {
+	case *DataFrame, *IdFrame, *TextFrame, *CreditsFrame, *DescTextFrame, *UnsynchTextFrame, *ImageFrame, *ChapterFrame, *TOCFrame:
+		return f.(io.WriterTo).WriteTo(w)
+	}
+
+	n, err := w.Write(f.Bytes())
+	return int64(n), err
+}
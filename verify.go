@@ -0,0 +1,78 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package id3
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	v1 "github.com/lion187chen/id3-go/v1"
+	v2 "github.com/lion187chen/id3-go/v2"
+)
+
+// Returned by Save when the tag read back differs from the one written
+var ErrVerify = errors.New("verify: written tag differs from the tag in memory")
+
+// Sets whether Save reads the tag back after writing and compares it frame
+// by frame with the tag in memory, returning ErrVerify if they differ
+func (f *File) SetVerifyWrites(verify bool) {
+	f.verify = verify
+}
+
+// Reparses the tag just written and compares it with the tag in memory
+func (f *File) verifyWrite() error {
+	size, err := f.file.Size()
+	if err != nil {
+		return err
+	}
+	reader := io.NewSectionReader(f.file, 0, size)
+
+	switch tag := f.Tagger.(type) {
+	case *v1.Tag:
+		written := v1.ParseTag(reader)
+		if written == nil || !bytes.Equal(written.Bytes(), tag.Bytes()) {
+			return ErrVerify
+		}
+	case *v2.Tag:
+		var written *v2.Tag
+		if f.appended {
+			written, err = parseAppendedTag(f.file, f.opts)
+		} else {
+			written, err = v2.ParseTagWithOptions(reader, f.opts)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrVerify, err)
+		}
+		if written == nil {
+			return fmt.Errorf("%w: no tag found", ErrVerify)
+		}
+		return compareFrames(tag.AllFrames(), written.AllFrames())
+	}
+
+	return nil
+}
+
+// Compares frames regardless of their order, as written tags may be reordered
+func compareFrames(want, got []v2.Framer) error {
+	if len(want) != len(got) {
+		return fmt.Errorf("%w: %d frames written, %d read back", ErrVerify, len(want), len(got))
+	}
+
+	pending := make(map[string]int)
+	for _, f := range got {
+		pending[f.Id()+string(f.Bytes())]++
+	}
+
+	for _, f := range want {
+		key := f.Id() + string(f.Bytes())
+		if pending[key] == 0 {
+			return fmt.Errorf("%w: frame %s", ErrVerify, f.Id())
+		}
+		pending[key]--
+	}
+
+	return nil
+}
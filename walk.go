@@ -0,0 +1,178 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package id3
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	v2 "github.com/lion187chen/id3-go/v2"
+)
+
+// WalkOption configures Walk
+type WalkOption func(*walkConfig)
+
+type walkConfig struct {
+	jobs      int
+	writeBack bool
+	match     func(path string) bool
+	parseOpts v2.ParseOptions
+}
+
+// Processes up to n files in parallel, runtime.NumCPU() by default
+func WithJobs(n int) WalkOption {
+	return func(c *walkConfig) { c.jobs = n }
+}
+
+// Saves edits made by the callback when it returns nil
+// Without this option files are opened read-only and edits are discarded.
+func WithWriteBack() WalkOption {
+	return func(c *walkConfig) { c.writeBack = true }
+}
+
+// Selects the files to process, by default those with an .mp3 extension
+func WithMatch(match func(path string) bool) WalkOption {
+	return func(c *walkConfig) { c.match = match }
+}
+
+// Parses tags with the given options
+func WithParseOptions(opts v2.ParseOptions) WalkOption {
+	return func(c *walkConfig) { c.parseOpts = opts }
+}
+
+// FileError records the failure of a single file during Walk
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// WalkError collects the files that failed during Walk, sorted by path
+type WalkError struct {
+	Errors []*FileError
+}
+
+func (e *WalkError) Error() string {
+	if len(e.Errors) == 1 {
+		return "walk: " + e.Errors[0].Error()
+	}
+
+	return fmt.Sprintf("walk: %d files failed, first %v", len(e.Errors), e.Errors[0])
+}
+
+func (e *WalkError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+
+	return errs
+}
+
+// Walk calls fn for every matching file under root using a pool of workers
+// fn may be called concurrently from several goroutines. Files that cannot
+// be parsed or for which fn fails do not stop the walk; they are returned
+// together as a *WalkError. Errors walking the tree itself are returned
+// directly.
+func Walk(root string, fn func(path string, f *File) error, opts ...WalkOption) error {
+	c := walkConfig{
+		jobs:  runtime.NumCPU(),
+		match: isMp3,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.jobs < 1 {
+		c.jobs = 1
+	}
+
+	paths := make(chan string)
+	var mu sync.Mutex
+	var errs []*FileError
+
+	var wg sync.WaitGroup
+	for i := 0; i < c.jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				if err := c.process(path, fn); err != nil {
+					mu.Lock()
+					errs = append(errs, &FileError{Path: path, Err: err})
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && c.match(path) {
+			paths <- path
+		}
+		return nil
+	})
+	close(paths)
+	wg.Wait()
+
+	if err != nil {
+		return err
+	}
+
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Path < errs[j].Path })
+		return &WalkError{Errors: errs}
+	}
+
+	return nil
+}
+
+func (c walkConfig) process(path string, fn func(string, *File) error) error {
+	var file *File
+	var err error
+
+	if c.writeBack {
+		file, err = OpenWithOptions(path, c.parseOpts)
+	} else {
+		var osFile *os.File
+		if osFile, err = os.Open(path); err == nil {
+			if file, err = ParseWithOptions(osFile, c.parseOpts); err != nil {
+				osFile.Close()
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := fn(path, file); err != nil {
+		file.file.Close()
+		return err
+	}
+
+	if !c.writeBack {
+		return file.file.Close()
+	}
+
+	return file.Close()
+}
+
+func isMp3(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".mp3")
+}
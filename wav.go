@@ -0,0 +1,157 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package id3
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+
+	v2 "github.com/lion187chen/id3-go/v2"
+)
+
+const (
+	riffHeaderSize  = 12
+	chunkHeaderSize = 8
+	wavTagChunkId   = "id3 "
+)
+
+var ErrNotWAV = errors.New("wav: not a RIFF/WAVE file")
+
+// WAVFile represents a RIFF/WAVE file tagged with an "id3 " chunk
+type WAVFile struct {
+	*v2.Tag
+	file *os.File
+	// Offset of the tag chunk header and size of its data, zero when the
+	// file has no tag chunk
+	chunkOffset int64
+	chunkSize   int64
+}
+
+// Opens a WAV file for editing its tag
+func OpenWAV(name string) (*WAVFile, error) {
+	fi, err := os.OpenFile(name, os.O_RDWR, 0666)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := ParseWAV(fi)
+	if err != nil {
+		fi.Close()
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// Parses the tag chunk of an open WAV file
+// A new ID3v2 tag is created when the file has none.
+func ParseWAV(file *os.File) (*WAVFile, error) {
+	header := make([]byte, riffHeaderSize)
+	if _, err := file.ReadAt(header, 0); err != nil || string(header[:4]) != "RIFF" || string(header[8:]) != "WAVE" {
+		return nil, ErrNotWAV
+	}
+
+	res := &WAVFile{file: file}
+
+	end := riffHeaderSize - 4 + int64(binary.LittleEndian.Uint32(header[4:]))
+	if stat, err := file.Stat(); err != nil {
+		return nil, err
+	} else if stat.Size() < end {
+		end = stat.Size()
+	}
+
+	chunk := make([]byte, chunkHeaderSize)
+	for offset := int64(riffHeaderSize); offset+chunkHeaderSize <= end; {
+		if _, err := file.ReadAt(chunk, offset); err != nil {
+			return nil, err
+		}
+
+		size := int64(binary.LittleEndian.Uint32(chunk[4:]))
+		if id := string(chunk[:4]); id == wavTagChunkId || id == "ID3 " {
+			tag := v2.ParseTag(io.NewSectionReader(file, offset+chunkHeaderSize, size))
+			if tag == nil {
+				return nil, errors.New("wav: invalid tag chunk")
+			}
+
+			res.Tag = tag
+			res.chunkOffset = offset
+			res.chunkSize = size
+			return res, nil
+		}
+
+		offset += chunkHeaderSize + size + size&1
+	}
+
+	res.Tag = v2.NewTag(LatestVersion)
+
+	return res, nil
+}
+
+// Saves any edits to the tag chunk, updating the RIFF size
+// A tag that fits in its chunk is padded to keep the size; a larger tag
+// moves the following chunks, and a new chunk is appended to the file.
+func (f *WAVFile) Close() error {
+	defer f.file.Close()
+
+	if !f.Dirty() {
+		return nil
+	}
+
+	if f.chunkOffset > 0 {
+		if grow := f.chunkSize - int64(v2.HeaderSize+f.Size()); grow >= 0 {
+			f.SetPadding(f.Padding() + uint(grow))
+		}
+	}
+
+	data := f.Bytes()
+	size := int64(len(data))
+
+	var delta int64
+	offset := f.chunkOffset
+	if offset > 0 {
+		delta = size + size&1 - f.chunkSize - f.chunkSize&1
+		if delta > 0 {
+			if err := shiftBytesBack(osFile{f.file}, offset+chunkHeaderSize+f.chunkSize+f.chunkSize&1, delta, nil); err != nil {
+				return err
+			}
+		}
+	} else {
+		header := make([]byte, riffHeaderSize)
+		if _, err := f.file.ReadAt(header, 0); err != nil {
+			return err
+		}
+
+		offset = riffHeaderSize - 4 + int64(binary.LittleEndian.Uint32(header[4:]))
+		offset += offset & 1
+		delta = chunkHeaderSize + size + size&1
+	}
+
+	chunk := make([]byte, chunkHeaderSize, chunkHeaderSize+size+1)
+	copy(chunk, wavTagChunkId)
+	binary.LittleEndian.PutUint32(chunk[4:], uint32(size))
+	chunk = append(chunk, data...)
+	if size&1 == 1 {
+		chunk = append(chunk, 0)
+	}
+
+	if _, err := f.file.WriteAt(chunk, offset); err != nil {
+		return err
+	}
+
+	if delta != 0 {
+		riffSize := make([]byte, 4)
+		if _, err := f.file.ReadAt(riffSize, 4); err != nil {
+			return err
+		}
+
+		binary.LittleEndian.PutUint32(riffSize, uint32(int64(binary.LittleEndian.Uint32(riffSize))+delta))
+		if _, err := f.file.WriteAt(riffSize, 4); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
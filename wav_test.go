@@ -0,0 +1,122 @@
+// Copyright 2013 Michael Yang. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+package id3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func riffChunk(id string, data []byte) []byte {
+	chunk := make([]byte, chunkHeaderSize, chunkHeaderSize+len(data)+1)
+	copy(chunk, id)
+	binary.LittleEndian.PutUint32(chunk[4:], uint32(len(data)))
+	chunk = append(chunk, data...)
+	if len(data)&1 == 1 {
+		chunk = append(chunk, 0)
+	}
+
+	return chunk
+}
+
+func riffFile(chunks ...[]byte) []byte {
+	data := []byte("RIFF\x00\x00\x00\x00WAVE")
+	for _, chunk := range chunks {
+		data = append(data, chunk...)
+	}
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(data)-8))
+
+	return data
+}
+
+func checkRIFF(t *testing.T, data []byte, ids ...string) {
+	if size := binary.LittleEndian.Uint32(data[4:]); int(size) != len(data)-8 {
+		t.Errorf("WAV: RIFF size %d, file has %d bytes", size, len(data))
+	}
+
+	var found []string
+	for offset := riffHeaderSize; offset+chunkHeaderSize <= len(data); {
+		size := int(binary.LittleEndian.Uint32(data[offset+4:]))
+		found = append(found, string(data[offset:offset+4]))
+		offset += chunkHeaderSize + size + size&1
+	}
+
+	if strings.Join(found, ",") != strings.Join(ids, ",") {
+		t.Errorf("WAV: expected chunks %v, got %v", ids, found)
+	}
+}
+
+func TestWAV(t *testing.T) {
+	audio := []byte{1, 2, 3, 4, 5}
+	tempfile, err := ioutil.TempFile("", "id3wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tempfile.Name())
+
+	tempfile.Write(riffFile(riffChunk("fmt ", make([]byte, 16)), riffChunk("data", audio)))
+	tempfile.Close()
+
+	file, err := OpenWAV(tempfile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.SetTitle("Nice Life")
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := ioutil.ReadFile(tempfile.Name())
+	checkRIFF(t, data, "fmt ", "data", "id3 ")
+
+	// A chunk after the tag must survive it growing
+	data = append(data, riffChunk("LIST", []byte("INFO"))...)
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(data)-8))
+	ioutil.WriteFile(tempfile.Name(), data, 0666)
+
+	file, err = OpenWAV(tempfile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title := file.Title(); title != "Nice Life" {
+		t.Errorf("WAV: expected title Nice Life, got %q", title)
+	}
+	file.SetArtist(strings.Repeat("Paloalto ", 20))
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ = ioutil.ReadFile(tempfile.Name())
+	checkRIFF(t, data, "fmt ", "data", "id3 ", "LIST")
+	if !bytes.HasSuffix(data, riffChunk("LIST", []byte("INFO"))) {
+		t.Errorf("WAV: chunk after the tag was damaged")
+	}
+
+	file, err = OpenWAV(tempfile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if artist := file.Artist(); artist != strings.Repeat("Paloalto ", 20) {
+		t.Errorf("WAV: expected artist to be saved, got %q", artist)
+	}
+
+	if _, err := ParseWAV(mustOpen(t, testFile)); err != ErrNotWAV {
+		t.Errorf("ParseWAV: expected ErrNotWAV for an MP3, got %v", err)
+	}
+}
+
+func mustOpen(t *testing.T, name string) *os.File {
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+
+	return f
+}